@@ -0,0 +1,150 @@
+// Package asm translates assembly source into bytecode of the Bazo VM and links separately assembled modules into a
+// single contract.
+package asm
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+// Assemble translates assembly source into bytecode.
+// Every token is either an opcode name, a byte (decimal or hex with prefix 0x), a label definition ending with a colon,
+// a label reference, which is replaced by the 2 byte address of the label, or a constant expression without spaces,
+// which is folded into a byte. Comments start with a semicolon.
+//
+// Constants are declared on separate lines with .const and .enum, they may refer to constants declared before them.
+// Enum members are numbered from 0 or from an explicit value and are referenced as <enum>.<member>.
+//
+//	.const LIMIT = 2 * 3
+//	.enum Kind Transfer Vote=10 Close
+//	start:
+//	    pushint 1 0 LIMIT+1  ; push 7
+//	    pushint 1 0 Kind.Close
+//	    jmp start
+func Assemble(source string) ([]byte, error) {
+	module, err := assemble(source, "")
+	if err != nil {
+		return nil, err
+	}
+	return module.Code, nil
+}
+
+// assemble translates the source into a module. Label references of named modules are recorded for the linker,
+// references to labels of other modules are written as <module>.<label>.
+func assemble(source string, name string) (*Module, error) {
+	var tokens []string
+	c := make(constants)
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, ";"); i != -1 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, ".") {
+			if err := c.declare(line); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		tokens = append(tokens, strings.Fields(line)...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// The first pass determines the addresses of the labels
+	labels := make(map[string]int)
+	size := 0
+	for _, token := range tokens {
+		if strings.HasSuffix(token, ":") {
+			name := strings.TrimSuffix(token, ":")
+			if _, ok := labels[name]; ok {
+				return nil, fmt.Errorf("duplicate label %v", name)
+			}
+			if _, ok := c[name]; ok {
+				return nil, fmt.Errorf("duplicate label %v", name)
+			}
+			labels[name] = size
+			continue
+		}
+
+		if _, err := assembleToken(token); err == nil || c.isExpression(token) {
+			size++
+		} else {
+			size += 2
+		}
+	}
+
+	module := &Module{Name: name, Code: make([]byte, 0, size), Labels: labels}
+	for i, token := range tokens {
+		if strings.HasSuffix(token, ":") {
+			continue
+		}
+
+		// The length of a PushAddr immediate is verified when it is assembled, so a mistyped address fails early
+		isAddressLength := i > 0 && tokens[i-1] == vm.OpCodes[vm.PushAddr].Name
+
+		if element, err := assembleToken(token); err == nil {
+			if isAddressLength {
+				if err := checkAddressLength(int(element)); err != nil {
+					return nil, err
+				}
+			}
+			module.Code = append(module.Code, element)
+			continue
+		}
+
+		if c.isExpression(token) {
+			element, err := c.byteValue(token)
+			if err != nil {
+				return nil, err
+			}
+			if isAddressLength {
+				if err := checkAddressLength(int(element)); err != nil {
+					return nil, err
+				}
+			}
+			module.Code = append(module.Code, element)
+			continue
+		}
+
+		reference := Reference{Offset: len(module.Code), Module: name, Label: token}
+		address, ok := labels[token]
+		if i := strings.Index(token, "."); !ok && name != "" && i > 0 {
+			reference.Module, reference.Label = token[:i], token[i+1:]
+		} else if !ok {
+			return nil, fmt.Errorf("unknown label %v", token)
+		}
+
+		if name != "" {
+			module.References = append(module.References, reference)
+		}
+		module.Code = append(module.Code, vm.UInt16ToByteArray(uint16(address))...)
+	}
+	return module, nil
+}
+
+// assembleToken returns the byte of an opcode name or a number
+func assembleToken(token string) (byte, error) {
+	if opCode, ok := vm.LookupOpcode(token); ok {
+		return opCode.Code, nil
+	}
+
+	value, err := strconv.ParseUint(token, 0, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte %v", token)
+	}
+	return byte(value), nil
+}
+
+// checkAddressLength verifies the length of the immediate of PushAddr like vm.VerifyCode
+func checkAddressLength(length int) error {
+	if length != 32 && length != 64 {
+		return fmt.Errorf("address must have 32 or 64 bytes, but has %v", length)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package asm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+func TestAssembler_Assemble(t *testing.T) {
+	source := `
+		; Count down from 2
+		pushint 1 0 2
+	loop:
+		pushint 1 1 0x01 ; -1
+		add
+		dup
+		pushint 0
+		gt
+		jmptrue loop
+		halt
+	`
+
+	code, err := Assemble(source)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, code, []byte{
+		vm.PushInt, 1, 0, 2,
+		vm.PushInt, 1, 1, 1,
+		vm.Add,
+		vm.Dup,
+		vm.PushInt, 0,
+		vm.Gt,
+		vm.JmpTrue, 0, 4,
+		vm.Halt,
+	})
+
+	machine := vm.NewVM(vm.NewMockContext(code))
+	assert.Assert(t, machine.Exec(false), machine.GetErrorMsg())
+	assert.DeepEqual(t, machine.PeekEvalStack(), [][]byte{{0}})
+}
+
+func TestAssembler_Assemble_ForwardLabel(t *testing.T) {
+	code, err := Assemble("jmp end pushint 0 end: halt")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, code, []byte{vm.Jmp, 0, 5, vm.PushInt, 0, vm.Halt})
+}
+
+func TestAssembler_Assemble_Errors(t *testing.T) {
+	_, err := Assemble("jmp nowhere")
+	assert.Error(t, err, "unknown label nowhere")
+
+	_, err = Assemble("a: a: halt")
+	assert.Error(t, err, "duplicate label a")
+
+	_, err = Assemble("pushaddr 31 halt")
+	assert.Error(t, err, "address must have 32 or 64 bytes, but has 31")
+
+	_, err = Assemble(".const WIDTH = 16\npushaddr WIDTH*2+1 halt")
+	assert.Error(t, err, "address must have 32 or 64 bytes, but has 33")
+}
+
+func TestAssembler_Assemble_Constants(t *testing.T) {
+	source := `
+		.const FEE = 10*2       ; folded at compile time
+		.const LIMIT = (FEE + 4) / 3 % 5
+		.enum Kind Transfer Vote=0x10 Close
+		pushint 1 0 FEE
+		pushint 1 0 LIMIT
+		pushint 1 0 Kind.Close
+		pushint 1 0 Kind.Vote-FEE/2
+		jmp end
+	end:
+		halt
+	`
+
+	code, err := Assemble(source)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, code, []byte{
+		vm.PushInt, 1, 0, 20,
+		vm.PushInt, 1, 0, 3,
+		vm.PushInt, 1, 0, 17,
+		vm.PushInt, 1, 0, 6,
+		vm.Jmp, 0, 19,
+		vm.Halt,
+	})
+}
+
+func TestAssembler_Assemble_ConstantErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		err    string
+	}{
+		{".const A = 1\n.const A = 2", "duplicate constant A"},
+		{".const add = 1", "duplicate constant add"},
+		{".const 1A = 1", "invalid directive 1A"},
+		{".const A", "invalid directive .const A"},
+		{".enum Kind", "invalid directive .enum Kind"},
+		{".macro A", "invalid directive .macro A"},
+		{".const A = 1/(2-2)", "invalid expression 1/(2-2): division by zero"},
+		{".const A = (1", "invalid expression (1: missing )"},
+		{".const A = B+1", `invalid expression B+1: unknown operand "B"`},
+		{".const A = 1 2", "invalid expression 1 2: unexpected 2"},
+		{".const A = 200\npushint 1 0 A+A", "A+A evaluates to 400, which is not a byte"},
+		{"pushint 1 1 -1", "-1 evaluates to -1, which is not a byte"},
+		{".const A = 1\nA: halt", "duplicate label A"},
+	}
+
+	for _, test := range tests {
+		_, err := Assemble(test.source)
+		assert.Error(t, err, test.err, test.source)
+	}
+}
+
+func TestAssembler_Disassemble_RoundTrip(t *testing.T) {
+	code := []byte{vm.PushInt, 2, 1, 1, 0, vm.StoreSt, 0, vm.CallLocals, 0, 8, 1, 1, 0, vm.Halt}
+
+	instructions, err := vm.Disassemble(code)
+	assert.NilError(t, err)
+
+	lines := make([]string, len(instructions))
+	for i, instruction := range instructions {
+		lines[i] = instruction.String()
+	}
+
+	reassembled, err := Assemble(strings.Join(lines, "\n"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reassembled, code)
+}
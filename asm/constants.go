@@ -0,0 +1,200 @@
+package asm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+// constants are the named values declared with .const and .enum, enum members are named <enum>.<member>
+type constants map[string]int64
+
+// declare parses a .const or .enum directive and adds its constants
+//
+//	.const FEE = 10 * 2
+//	.enum Color Red Green=5 Blue      ; Color.Red = 0, Color.Green = 5, Color.Blue = 6
+func (c constants) declare(line string) error {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case ".const":
+		parts := strings.SplitN(strings.TrimPrefix(line, ".const"), "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid directive %v", line)
+		}
+		value, err := c.evaluate(parts[1])
+		if err != nil {
+			return err
+		}
+		return c.add(strings.TrimSpace(parts[0]), value)
+
+	case ".enum":
+		if len(fields) < 3 || !isIdentifier(fields[1]) {
+			return fmt.Errorf("invalid directive %v", line)
+		}
+		var value int64
+		for _, member := range fields[2:] {
+			if i := strings.Index(member, "="); i != -1 {
+				var err error
+				if value, err = c.evaluate(member[i+1:]); err != nil {
+					return err
+				}
+				member = member[:i]
+			}
+			if !isIdentifier(member) {
+				return fmt.Errorf("invalid directive %v", line)
+			}
+			if err := c.add(fields[1]+"."+member, value); err != nil {
+				return err
+			}
+			value++
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid directive %v", line)
+}
+
+func (c constants) add(name string, value int64) error {
+	if !isIdentifier(strings.Replace(name, ".", "", 1)) {
+		return fmt.Errorf("invalid directive %v", name)
+	}
+	if _, ok := vm.LookupOpcode(name); ok {
+		return fmt.Errorf("duplicate constant %v", name)
+	}
+	if _, ok := c[name]; ok {
+		return fmt.Errorf("duplicate constant %v", name)
+	}
+	c[name] = value
+	return nil
+}
+
+// isExpression returns true, if the token is a constant or an expression instead of a label reference
+func (c constants) isExpression(token string) bool {
+	_, ok := c[token]
+	return ok || strings.ContainsAny(token, "+-*/%()")
+}
+
+// byteValue evaluates a constant expression, whose value has to fit into a byte
+func (c constants) byteValue(token string) (byte, error) {
+	value, err := c.evaluate(token)
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 || value > 255 {
+		return 0, fmt.Errorf("%v evaluates to %v, which is not a byte", token, value)
+	}
+	return byte(value), nil
+}
+
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// evaluate folds an expression of numbers, constants, parentheses and the operators + - * / % into its value
+func (c constants) evaluate(expression string) (int64, error) {
+	p := &expressionParser{input: strings.TrimSpace(expression), constants: c}
+	value, err := p.sum()
+	if err == nil && p.peek() != 0 {
+		err = p.fail("unexpected " + p.input[p.offset:])
+	}
+	return value, err
+}
+
+type expressionParser struct {
+	input     string
+	offset    int
+	constants constants
+}
+
+func (p *expressionParser) fail(reason string) error {
+	return fmt.Errorf("invalid expression %v: %v", p.input, reason)
+}
+
+// peek skips spaces and returns the next character, 0 at the end of the input
+func (p *expressionParser) peek() byte {
+	for p.offset < len(p.input) && (p.input[p.offset] == ' ' || p.input[p.offset] == '\t') {
+		p.offset++
+	}
+	if p.offset < len(p.input) {
+		return p.input[p.offset]
+	}
+	return 0
+}
+
+// sum parses terms separated by + and -
+func (p *expressionParser) sum() (int64, error) {
+	value, err := p.product()
+	for err == nil && (p.peek() == '+' || p.peek() == '-') {
+		operator := p.peek()
+		p.offset++
+		var right int64
+		if right, err = p.product(); operator == '+' {
+			value += right
+		} else {
+			value -= right
+		}
+	}
+	return value, err
+}
+
+// product parses factors separated by *, / and %
+func (p *expressionParser) product() (int64, error) {
+	value, err := p.factor()
+	for err == nil && (p.peek() == '*' || p.peek() == '/' || p.peek() == '%') {
+		operator := p.peek()
+		p.offset++
+		var right int64
+		if right, err = p.factor(); err != nil {
+			break
+		}
+		switch {
+		case operator == '*':
+			value *= right
+		case right == 0:
+			err = p.fail("division by zero")
+		case operator == '/':
+			value /= right
+		default:
+			value %= right
+		}
+	}
+	return value, err
+}
+
+// factor parses a number, a constant, a negation or an expression in parentheses
+func (p *expressionParser) factor() (int64, error) {
+	switch p.peek() {
+	case '-':
+		p.offset++
+		value, err := p.factor()
+		return -value, err
+	case '(':
+		p.offset++
+		value, err := p.sum()
+		if err == nil && p.peek() != ')' {
+			return 0, p.fail("missing )")
+		}
+		p.offset++
+		return value, err
+	}
+
+	start := p.offset
+	for p.offset < len(p.input) && !strings.ContainsRune("+-*/%() \t", rune(p.input[p.offset])) {
+		p.offset++
+	}
+	token := p.input[start:p.offset]
+	if value, ok := p.constants[token]; ok {
+		return value, nil
+	}
+	value, err := strconv.ParseInt(token, 0, 64)
+	if err != nil {
+		return 0, p.fail("unknown operand " + strconv.Quote(token))
+	}
+	return value, nil
+}
@@ -0,0 +1,88 @@
+package asm
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+// Module is assembled code, whose label references are resolved by Link, so it can be placed at any address.
+type Module struct {
+	Name       string
+	Code       []byte
+	Labels     map[string]int // Addresses of the labels relative to the start of the module
+	References []Reference
+}
+
+// Reference is a 2 byte label address in the code of a module.
+type Reference struct {
+	Offset int    // Offset of the address in the code of the module
+	Module string // Module defining the label
+	Label  string
+}
+
+// AssembleModule translates the source into a module. Labels of other modules are referenced as <module>.<label>,
+// e.g. "call math.pow 2 1 2", and are resolved by Link.
+func AssembleModule(name string, source string) (*Module, error) {
+	if !isIdentifier(name) {
+		return nil, fmt.Errorf("invalid module name %v", name)
+	}
+	return assemble(source, name)
+}
+
+// Link combines the modules into a single contract. The first module is the entry point at address 0, it is followed
+// by the modules it references directly or indirectly in the order of their first reference. Modules, which are not
+// referenced, are left out, and modules passed several times, e.g. a library shared by other libraries, are included
+// once. Modules with the same name must be identical.
+func Link(modules ...*Module) ([]byte, error) {
+	if len(modules) == 0 {
+		return nil, nil
+	}
+
+	byName := make(map[string]*Module, len(modules))
+	for _, module := range modules {
+		if other, ok := byName[module.Name]; ok && !reflect.DeepEqual(other, module) {
+			return nil, fmt.Errorf("duplicate module %v", module.Name)
+		}
+		byName[module.Name] = module
+	}
+
+	// Lay out the modules reachable from the entry point
+	bases := map[string]int{modules[0].Name: 0}
+	layout := []*Module{modules[0]}
+	size := len(modules[0].Code)
+	for i := 0; i < len(layout); i++ {
+		for _, reference := range layout[i].References {
+			module, ok := byName[reference.Module]
+			if !ok {
+				return nil, fmt.Errorf("unknown label %v", reference.Module+"."+reference.Label)
+			}
+			if _, ok := bases[module.Name]; !ok {
+				bases[module.Name] = size
+				layout = append(layout, module)
+				size += len(module.Code)
+			}
+		}
+	}
+
+	code := make([]byte, 0, size)
+	for _, module := range layout {
+		base := len(code)
+		code = append(code, module.Code...)
+
+		for _, reference := range module.References {
+			label, ok := byName[reference.Module].Labels[reference.Label]
+			if !ok {
+				return nil, fmt.Errorf("unknown label %v", reference.Module+"."+reference.Label)
+			}
+
+			address := bases[reference.Module] + label
+			if address > 0xffff {
+				return nil, fmt.Errorf("address of %v exceeds 65535", reference.Module+"."+reference.Label)
+			}
+			copy(code[base+reference.Offset:], vm.UInt16ToByteArray(uint16(address)))
+		}
+	}
+	return code, nil
+}
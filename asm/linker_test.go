@@ -0,0 +1,82 @@
+package asm
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+func assembleModule(t *testing.T, name string, source string) *Module {
+	t.Helper()
+	module, err := AssembleModule(name, source)
+	assert.NilError(t, err)
+	return module
+}
+
+func TestLinker_Link(t *testing.T) {
+	main := assembleModule(t, "main", `
+		pushint 1 0 3
+		calllocals math.square 1 1 1
+		calllocals math.double 1 1 1
+		jmp end
+	end:
+		halt
+	`)
+	math := assembleModule(t, "math", `
+	square:
+		loadloc 0
+		loadloc 0
+		calllocals util.copy 1 1 1
+		mult
+		ret
+	double:
+		loadloc 0
+		loadloc 0
+		add
+		ret
+	`)
+	util := assembleModule(t, "util", `
+	copy:
+		loadloc 0
+		ret
+	`)
+	unused := assembleModule(t, "unused", "halt")
+
+	code, err := Link(main, util, math, unused, util)
+	assert.NilError(t, err)
+
+	// The modules follow the entry point in the order of their first reference
+	assert.Equal(t, len(code), len(main.Code)+len(math.Code)+len(util.Code))
+	assert.DeepEqual(t, code[5:7], vm.UInt16ToByteArray(uint16(len(main.Code))))
+	assert.DeepEqual(t, code[11:13], vm.UInt16ToByteArray(uint16(len(main.Code)+math.Labels["double"])))
+	assert.DeepEqual(t, code[17:19], vm.UInt16ToByteArray(uint16(len(main.Code)-1)))
+	assert.DeepEqual(t, code[len(main.Code)+5:len(main.Code)+7], vm.UInt16ToByteArray(uint16(len(main.Code)+len(math.Code))))
+
+	machine := vm.NewVM(vm.NewMockContext(code))
+	assert.Assert(t, machine.Exec(false), machine.GetErrorMsg())
+	assert.DeepEqual(t, machine.PeekEvalStack(), [][]byte{{0, 18}})
+}
+
+func TestLinker_Link_Errors(t *testing.T) {
+	main := assembleModule(t, "main", "calllocals lib.f 0 0 0 halt")
+	lib := assembleModule(t, "lib", "g: ret")
+	_, err := Link(main, lib)
+	assert.Error(t, err, "unknown label lib.f")
+
+	_, err = Link(main)
+	assert.Error(t, err, "unknown label lib.f")
+
+	_, err = Link(main, assembleModule(t, "lib", "f: ret"), assembleModule(t, "lib", "f: halt ret"))
+	assert.Error(t, err, "duplicate module lib")
+
+	_, err = AssembleModule("main", "jmp missing")
+	assert.Error(t, err, "unknown label missing")
+
+	_, err = AssembleModule("a.b", "halt")
+	assert.Error(t, err, "invalid module name a.b")
+
+	// Code of unlinked modules has no addresses
+	assert.DeepEqual(t, main.Code, []byte{vm.CallLocals, 0, 0, 0, 0, 0, vm.Halt})
+	assert.DeepEqual(t, main.References, []Reference{{Offset: 1, Module: "lib", Label: "f"}})
+}
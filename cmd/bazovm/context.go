@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bazo-blockchain/bazo-vm/asm"
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+// contextConfig describes the mock context of an execution. Byte values are hex encoded.
+type contextConfig struct {
+	Fee       uint64   `json:"fee"`
+	Amount    uint64   `json:"amount"`
+	Balance   uint64   `json:"balance"`
+	Sender    string   `json:"sender"`
+	Issuer    string   `json:"issuer"`
+	Address   string   `json:"address"`
+	CallData  string   `json:"callData"`
+	Variables []string `json:"variables"` // Contract variables
+}
+
+// contextFlags registers the flags of the commands, which execute code
+type contextFlags struct {
+	set         *flag.FlagSet
+	format      *string
+	version     *uint
+	config      contextConfig
+	contextFile *string
+	sourceMap   *string
+	engine      *string
+}
+
+func newContextFlags(set *flag.FlagSet) *contextFlags {
+	f := &contextFlags{set: set}
+	f.format = set.String("format", "", "format of the code file: asm, hex or bin (default: derived from the extension)")
+	f.version = set.Uint("version", uint(vm.DefaultBytecodeVersion), "bytecode version")
+	f.contextFile = set.String("context", "", "JSON file describing the mock context")
+	f.sourceMap = set.String("sourcemap", "", "JSON source map linking code offsets to source locations")
+	f.engine = set.String("engine", string(vm.EngineInterpreter), "execution engine: interpreter or compiled")
+	set.Uint64Var(&f.config.Fee, "fee", 100000, "fee of the transaction")
+	set.Uint64Var(&f.config.Amount, "amount", 0, "amount of the transaction")
+	set.Uint64Var(&f.config.Balance, "balance", 0, "balance of the contract account")
+	set.StringVar(&f.config.CallData, "calldata", "", "hex encoded transaction data")
+	return f
+}
+
+// context builds the mock context. Flags, which are set explicitly, override the context file.
+func (f *contextFlags) context(code []byte) (*vm.MockContext, error) {
+	config := f.config
+	if *f.contextFile != "" {
+		data, err := ioutil.ReadFile(*f.contextFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("%v: %v", *f.contextFile, err)
+		}
+
+		f.set.Visit(func(set *flag.Flag) {
+			switch set.Name {
+			case "fee":
+				config.Fee = f.config.Fee
+			case "amount":
+				config.Amount = f.config.Amount
+			case "balance":
+				config.Balance = f.config.Balance
+			case "calldata":
+				config.CallData = f.config.CallData
+			}
+		})
+	}
+
+	mc := vm.NewMockContext(code)
+	mc.Fee = config.Fee
+	mc.Amount = config.Amount
+	mc.Balance = config.Balance
+
+	var err error
+	if mc.Data, err = hex.DecodeString(config.CallData); err != nil {
+		return nil, fmt.Errorf("call data: %v", err)
+	}
+	if err := decodeFixed(config.Sender, mc.From[:]); err != nil {
+		return nil, fmt.Errorf("sender: %v", err)
+	}
+	if err := decodeFixed(config.Issuer, mc.Issuer[:]); err != nil {
+		return nil, fmt.Errorf("issuer: %v", err)
+	}
+	if err := decodeFixed(config.Address, mc.Address[:]); err != nil {
+		return nil, fmt.Errorf("address: %v", err)
+	}
+
+	mc.ContractVariables = make([][]byte, len(config.Variables))
+	for i, variable := range config.Variables {
+		if mc.ContractVariables[i], err = hex.DecodeString(variable); err != nil {
+			return nil, fmt.Errorf("variable %v: %v", i, err)
+		}
+	}
+	return mc, nil
+}
+
+// newVM creates a VM, which executes the code of the first argument of the command
+func (f *contextFlags) newVM() (*vm.VM, *vm.MockContext, error) {
+	if f.set.NArg() != 1 {
+		return nil, nil, fmt.Errorf("expected exactly one code file")
+	}
+
+	code, err := readCode(f.set.Arg(0), *f.format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mc, err := f.context(code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	engine, err := parseEngine(*f.engine)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	machine := vm.NewVMWithConfig(mc, vm.VMConfig{BytecodeVersion: byte(*f.version), Engine: engine})
+
+	if *f.sourceMap != "" {
+		file, err := os.Open(*f.sourceMap)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+
+		sourceMap, err := vm.LoadSourceMap(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v: %v", *f.sourceMap, err)
+		}
+		machine.SetSourceMap(sourceMap)
+	}
+	return &machine, mc, nil
+}
+
+// readCode reads a code file in assembly, hex or binary format
+func readCode(file string, format string) ([]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(file), ".")
+	}
+
+	switch format {
+	case "asm":
+		return asm.Assemble(string(data))
+	case "hex":
+		return hex.DecodeString(strings.TrimSpace(string(data)))
+	default:
+		return data, nil
+	}
+}
+
+// decodeFixed decodes a hex value into a fixed size array, an empty value leaves the array unchanged
+func decodeFixed(value string, target []byte) error {
+	if value == "" {
+		return nil
+	}
+
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	if len(decoded) != len(target) {
+		return fmt.Errorf("expected %v bytes, but got %v", len(target), len(decoded))
+	}
+	copy(target, decoded)
+	return nil
+}
+
+// parseEngine returns the engine with the name
+func parseEngine(name string) (vm.Engine, error) {
+	for _, engine := range vm.Engines {
+		if string(engine) == name {
+			return engine, nil
+		}
+	}
+	return "", fmt.Errorf("unknown engine %v", name)
+}
@@ -0,0 +1,321 @@
+// Command bazovm executes and inspects Bazo bytecode locally.
+//
+// Usage:
+//
+//	bazovm run [flags] <code>           execute the code and print the result as JSON
+//	bazovm trace [flags] <code>         execute the code and print a JSON trace step per instruction
+//	bazovm estimate-gas [flags] <code>  print the gas used by the execution
+//	bazovm asm [-o file] <source>       assemble the source and print the code as hex or write it to a file
+//	bazovm disasm [-format f] <code>    print the instructions of the code
+//	bazovm render [-format f] <trace>   render a JSON trace as HTML timeline or DOT graph of the stacks
+//	bazovm repl [flags]                 execute assembly interactively
+//	bazovm serve [-addr address]        serve the JSON-RPC debug server
+//
+// Code files are read as assembly (.asm), hex (.hex) or binary (other extensions), unless -format is given.
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/bazo-blockchain/bazo-vm/debugserver"
+	"github.com/bazo-blockchain/bazo-vm/stackviz"
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+var commands = map[string]func(args []string, out io.Writer) error{
+	"run":          run,
+	"trace":        trace,
+	"estimate-gas": estimateGas,
+	"asm":          assemble,
+	"disasm":       disasm,
+	"render":       render,
+	"repl":         repl,
+	"serve":        serve,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+
+	if err := command(os.Args[2:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "bazovm %v: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: bazovm run|trace|estimate-gas|asm|disasm|render|repl|serve [flags] <file>")
+	os.Exit(2)
+}
+
+func run(args []string, out io.Writer) error {
+	set := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags := newContextFlags(set)
+	dump := set.Bool("dump", false, "add the state dump of the VM to the result")
+	profile := set.String("profile", "", "write the gas used per call stack in the folded stack format to the file")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	machine, mc, err := flags.newVM()
+	if err != nil {
+		return err
+	}
+
+	var profiler *vm.Profiler
+	if *profile != "" {
+		profiler = vm.NewProfiler()
+		machine.SetProfiler(profiler)
+	}
+
+	var result struct {
+		vm.TestVectorResult
+		Source string          `json:"source,omitempty"` // Source location of the error
+		Loops  map[int]int     `json:"loops,omitempty"`  // Iterations of the loops by address of the loop header
+		State  json.RawMessage `json:"state,omitempty"`
+
+		// Function calls, which led to the error, innermost call first
+		Backtrace []vm.BacktraceFrame `json:"backtrace,omitempty"`
+		// Address of the failed instruction, -1 if the execution failed before its first instruction
+		PC *int `json:"pc,omitempty"`
+	}
+	result.Success = machine.Exec(false)
+	result.Gas = machine.GetRemainingFee()
+	if failure := machine.GetError(); failure != nil {
+		result.Error = failure.Message
+		result.PC = &failure.PC
+		if failure.Source != nil {
+			result.Source = failure.Source.String()
+		}
+		result.Backtrace = failure.Backtrace
+	}
+
+	if loops := machine.LoopIterations(); len(loops) > 0 {
+		result.Loops = loops
+	}
+
+	if profiler != nil {
+		if err := writeProfile(*profile, profiler); err != nil {
+			return err
+		}
+	}
+
+	if *dump {
+		if result.State, err = machine.DumpState(); err != nil {
+			return err
+		}
+	}
+
+	stack := machine.PeekEvalStack()
+	for i := len(stack) - 1; i >= 0; i-- {
+		result.Stack = append(result.Stack, hex.EncodeToString(stack[i]))
+	}
+	for i := range mc.ContractVariables {
+		variable, _ := mc.GetContractVariable(i)
+		result.Storage = append(result.Storage, hex.EncodeToString(variable))
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+// writeProfile writes the folded stacks of the profiler to the file
+func writeProfile(file string, profiler *vm.Profiler) error {
+	var folded bytes.Buffer
+	if err := profiler.WriteFolded(&folded); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, folded.Bytes(), 0644)
+}
+
+func trace(args []string, out io.Writer) error {
+	set := flag.NewFlagSet("trace", flag.ContinueOnError)
+	flags := newContextFlags(set)
+	stackSize := set.Int("stack", 4, "number of traced stack elements")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	machine, _, err := flags.newVM()
+	if err != nil {
+		return err
+	}
+
+	tracer := vm.NewJSONTracer(out, *stackSize)
+	machine.SetTracer(tracer)
+	machine.Exec(false)
+	return tracer.Err()
+}
+
+func estimateGas(args []string, out io.Writer) error {
+	set := flag.NewFlagSet("estimate-gas", flag.ContinueOnError)
+	flags := newContextFlags(set)
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	machine, mc, err := flags.newVM()
+	if err != nil {
+		return err
+	}
+
+	if !machine.Exec(false) {
+		return fmt.Errorf("execution failed: %v", machine.GetError())
+	}
+
+	_, err = fmt.Fprintln(out, mc.Fee-machine.GetRemainingFee())
+	return err
+}
+
+func assemble(args []string, out io.Writer) error {
+	set := flag.NewFlagSet("asm", flag.ContinueOnError)
+	output := set.String("o", "", "binary output file (default: hex to stdout)")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if set.NArg() != 1 {
+		return fmt.Errorf("expected exactly one source file")
+	}
+
+	code, err := readCode(set.Arg(0), "asm")
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		return ioutil.WriteFile(*output, code, 0644)
+	}
+	_, err = fmt.Fprintln(out, hex.EncodeToString(code))
+	return err
+}
+
+func render(args []string, out io.Writer) error {
+	set := flag.NewFlagSet("render", flag.ContinueOnError)
+	format := set.String("format", "html", "output format: html or dot")
+	title := set.String("title", "", "title of the rendering (default: the name of the trace file)")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if set.NArg() != 1 {
+		return fmt.Errorf("expected exactly one trace file")
+	}
+
+	file, err := os.Open(set.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	steps, err := stackviz.ReadTrace(file)
+	if err != nil {
+		return fmt.Errorf("%v: %v", set.Arg(0), err)
+	}
+	if *title == "" {
+		*title = filepath.Base(set.Arg(0))
+	}
+
+	switch *format {
+	case "html":
+		return stackviz.WriteHTML(out, *title, steps)
+	case "dot":
+		return stackviz.WriteDOT(out, *title, steps)
+	default:
+		return fmt.Errorf("unknown format %v", *format)
+	}
+}
+
+func disasm(args []string, out io.Writer) error {
+	set := flag.NewFlagSet("disasm", flag.ContinueOnError)
+	format := set.String("format", "", "format of the code file: asm, hex or bin (default: derived from the extension)")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+	if set.NArg() != 1 {
+		return fmt.Errorf("expected exactly one code file")
+	}
+
+	contract, err := readCode(set.Arg(0), *format)
+	if err != nil {
+		return err
+	}
+
+	sections, err := vm.ParseSections(contract)
+	if err != nil {
+		return err
+	}
+
+	for index, declaration := range sections.Schema {
+		fmt.Fprintf(out, "; variable %v: %v", index, declaration.Type)
+		if declaration.Size > 0 {
+			fmt.Fprintf(out, ", at most %v bytes", declaration.Size)
+		}
+		if declaration.Immutable {
+			fmt.Fprint(out, ", immutable")
+		}
+		fmt.Fprintln(out)
+	}
+
+	if sections.Constructor >= 0 {
+		fmt.Fprintf(out, "; constructor at %04d\n", sections.Constructor)
+	}
+
+	if metadata := sections.Metadata; metadata != nil {
+		fmt.Fprintf(out, "; metadata: name %q, version %q, author %q, compiler %q, license %q\n",
+			metadata.Name, metadata.Version, metadata.Author, metadata.Compiler, metadata.License)
+		for i, layout := range metadata.Structs {
+			fmt.Fprintf(out, "; struct %v: max field sizes %v\n", i, layout.Fields)
+		}
+	}
+
+	for _, function := range sections.Functions {
+		fmt.Fprintf(out, "; function %x at %04d: %v args, %v return types, %v locals\n",
+			function.Hash, function.Address, function.NrOfArgs, function.NrOfReturnTypes, function.NrOfLocals)
+	}
+
+	code := sections.Code
+	loops, err := vm.FindLoops(code)
+	if err != nil {
+		return err
+	}
+	for _, loop := range loops {
+		fmt.Fprintf(out, "; loop at %04d, jumps back from %04d\n", loop.Header, loop.End)
+	}
+
+	instructions, err := vm.Disassemble(code)
+	if err != nil {
+		return err
+	}
+	for _, instruction := range instructions {
+		if _, err := fmt.Fprintln(out, instruction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func serve(args []string, out io.Writer) error {
+	set := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := set.String("addr", "localhost:8545", "listen address")
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "debug server listening on %v\n", *addr)
+	return http.ListenAndServe(*addr, debugserver.NewServer().Handler())
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+const source = `
+	pushint 1 0 2
+	pushint 1 0 3
+	add
+	storest 0
+	loadst 0
+	halt
+`
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "bazovm")
+	assert.NilError(t, err)
+	return dir
+}
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	file := filepath.Join(dir, name)
+	assert.NilError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	return file
+}
+
+func TestBazoVM_Run(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "add.asm", source)
+	context := writeFile(t, dir, "context.json", `{"fee": 2000, "variables": ["00"]}`)
+
+	var out bytes.Buffer
+	assert.NilError(t, run([]string{"-context", context, file}, &out))
+	assert.Assert(t, strings.Contains(out.String(), `"success": true`))
+	assert.Assert(t, strings.Contains(out.String(), `"stack": [
+    "0005"
+  ]`))
+	assert.Assert(t, strings.Contains(out.String(), `"storage": [
+    "0005"
+  ]`))
+	assert.Assert(t, strings.Contains(out.String(), `"gas": 973`))
+}
+
+func TestBazoVM_Run_FlagOverridesContext(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "add.asm", source)
+	context := writeFile(t, dir, "context.json", `{"fee": 2000, "variables": ["00"]}`)
+
+	var out bytes.Buffer
+	assert.NilError(t, run([]string{"-context", context, "-fee", "20", file}, &out))
+	assert.Assert(t, strings.Contains(out.String(), `"error": "vm.exec(): out of gas"`))
+}
+
+func TestBazoVM_Run_Profile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "add.asm", source)
+	context := writeFile(t, dir, "context.json", `{"fee": 2000, "variables": ["00"]}`)
+	profile := filepath.Join(dir, "profile.folded")
+
+	var out bytes.Buffer
+	assert.NilError(t, run([]string{"-context", context, "-profile", profile, file}, &out))
+
+	folded, err := ioutil.ReadFile(profile)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(folded), "main;add "), string(folded))
+	assert.Assert(t, strings.Contains(string(folded), "main;storest "), string(folded))
+}
+
+func TestBazoVM_Run_CompiledEngine(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "add.asm", source)
+	context := writeFile(t, dir, "context.json", `{"fee": 2000, "variables": ["00"]}`)
+
+	var out bytes.Buffer
+	assert.NilError(t, run([]string{"-context", context, "-engine", "compiled", file}, &out))
+	assert.Assert(t, strings.Contains(out.String(), `"success": true`))
+	assert.Assert(t, strings.Contains(out.String(), `"gas": 973`))
+
+	err := run([]string{"-engine", "jit", file}, &out)
+	assert.Error(t, err, "unknown engine jit")
+}
+
+func TestBazoVM_Run_Dump(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "add.asm", source)
+	context := writeFile(t, dir, "context.json", `{"fee": 2000, "variables": ["00"]}`)
+
+	var out bytes.Buffer
+	assert.NilError(t, run([]string{"-context", context, "-dump", file}, &out))
+	assert.Assert(t, strings.Contains(out.String(), `"state": {`))
+	assert.Assert(t, strings.Contains(out.String(), `"storage": [
+      {
+        "index": 0,
+        "value": "0005"
+      }
+    ]`), out.String())
+}
+
+func TestBazoVM_EstimateGas(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "add.asm", source)
+	context := writeFile(t, dir, "context.json", `{"variables": ["00"]}`)
+
+	var out bytes.Buffer
+	assert.NilError(t, estimateGas([]string{"-context", context, file}, &out))
+	assert.Equal(t, out.String(), "1027\n")
+}
+
+func TestBazoVM_AsmDisasm(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "add.asm", source)
+
+	var hexCode bytes.Buffer
+	assert.NilError(t, assemble([]string{file}, &hexCode))
+
+	hexFile := writeFile(t, dir, "add.hex", hexCode.String())
+	var out bytes.Buffer
+	assert.NilError(t, disasm([]string{hexFile}, &out))
+	assert.Equal(t, out.String(), "0000: pushint 1 0 2\n"+
+		"0004: pushint 1 0 3\n"+
+		"0008: add\n"+
+		"0009: storest 0\n"+
+		"0011: loadst 0\n"+
+		"0013: halt\n")
+}
+
+func TestBazoVM_Loops(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "loop.asm", "pushint 1 0 2\nloop: pushint 1 0 1\nsub\ndup\npushint 1 0 0\ngt\njmptrue loop\nhalt")
+
+	var out bytes.Buffer
+	assert.NilError(t, disasm([]string{file}, &out))
+	assert.Assert(t, strings.HasPrefix(out.String(), "; loop at 0004, jumps back from 0015\n"))
+
+	out.Reset()
+	assert.NilError(t, run([]string{"-fee", "2000", file}, &out))
+	assert.Assert(t, strings.Contains(out.String(), `"loops": {
+    "4": 1
+  }`))
+}
+
+func TestBazoVM_DisasmStateSchema(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	hexFile := writeFile(t, dir, "schema.hex", fmt.Sprintf("fe0003010000060020840000%02x", vm.Halt))
+	var out bytes.Buffer
+	assert.NilError(t, disasm([]string{hexFile}, &out))
+	assert.Equal(t, out.String(), "; variable 0: int\n"+
+		"; variable 1: map, at most 32 bytes\n"+
+		"; variable 2: address, immutable\n"+
+		"0000: halt\n")
+}
+
+func TestBazoVM_DisasmConstructor(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	hexFile := writeFile(t, dir, "constructor.hex", fmt.Sprintf("fd0001%02x%02x", vm.Halt, vm.Halt))
+	var out bytes.Buffer
+	assert.NilError(t, disasm([]string{hexFile}, &out))
+	assert.Equal(t, out.String(), "; constructor at 0001\n"+
+		"0000: halt\n"+
+		"0001: halt\n")
+}
+
+func TestBazoVM_DisasmMetadata(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	section, err := vm.NewMetadataSection(vm.Metadata{
+		Name:    "token",
+		Version: "1.0",
+		Structs: []vm.StructLayout{{Fields: []uint16{32, 0}}},
+	})
+	assert.NilError(t, err)
+	hexFile := writeFile(t, dir, "metadata.hex", fmt.Sprintf("%x%02x", section, vm.Halt))
+
+	var out bytes.Buffer
+	assert.NilError(t, disasm([]string{hexFile}, &out))
+	assert.Equal(t, out.String(), `; metadata: name "token", version "1.0", author "", compiler "", license ""`+"\n"+
+		"; struct 0: max field sizes [32 0]\n"+
+		"0000: halt\n")
+}
+
+func TestBazoVM_Trace(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "add.asm", source)
+
+	context := writeFile(t, dir, "context.json", `{"fee": 2000, "variables": ["00"]}`)
+
+	var out bytes.Buffer
+	assert.NilError(t, trace([]string{"-context", context, "-stack", "1", file}, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, len(lines), 6)
+	assert.Assert(t, strings.Contains(lines[2], `"opcode":"add"`))
+}
+
+func TestBazoVM_Render(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "add.asm", source)
+	context := writeFile(t, dir, "context.json", `{"fee": 2000, "variables": ["00"]}`)
+
+	var traced bytes.Buffer
+	assert.NilError(t, trace([]string{"-context", context, file}, &traced))
+	traceFile := writeFile(t, dir, "add.jsonl", traced.String())
+
+	var out bytes.Buffer
+	assert.NilError(t, render([]string{traceFile}, &out))
+	assert.Assert(t, strings.Contains(out.String(), "<title>add.jsonl</title>"))
+	assert.Assert(t, strings.Contains(out.String(), `<span class="element changed">0005</span>`))
+
+	out.Reset()
+	assert.NilError(t, render([]string{"-format", "dot", "-title", "add", traceFile}, &out))
+	assert.Assert(t, strings.Contains(out.String(), `digraph "add" {`))
+	assert.Assert(t, strings.Contains(out.String(), `s2 [label="{2: 0008 add (5 gas)|main|*0005}"];`), out.String())
+
+	err := render([]string{"-format", "svg", traceFile}, &out)
+	assert.Error(t, err, "unknown format svg")
+}
+
+func TestBazoVM_InvalidCallData(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "add.asm", source)
+
+	var out bytes.Buffer
+	err := run([]string{"-calldata", "zz", file}, &out)
+	assert.ErrorContains(t, err, "call data")
+}
+
+func TestBazoVM_Run_SourceMap(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := writeFile(t, dir, "div.asm", "pushint 1 0 2 pushint 0 div halt")
+	sourceMap := writeFile(t, dir, "div.map.json", `[
+		{"offset": 0, "file": "div.lazo", "line": 1, "col": 1},
+		{"offset": 6, "file": "div.lazo", "line": 1, "col": 3}
+	]`)
+
+	var out bytes.Buffer
+	assert.NilError(t, run([]string{"-sourcemap", sourceMap, file}, &out))
+	assert.Assert(t, strings.Contains(out.String(), `"error": "div: division by zero"`))
+	assert.Assert(t, strings.Contains(out.String(), `"source": "div.lazo:1:3"`))
+	assert.Assert(t, strings.Contains(out.String(), `"pc": 6`))
+
+	err := estimateGas([]string{"-sourcemap", sourceMap, file}, &out)
+	assert.Error(t, err, "execution failed: pc=0006 (div.lazo:1:3): div: division by zero")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bazo-blockchain/bazo-vm/asm"
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+const replHelp = `Enter assembly, e.g. "pushint 1 0 7". Labels can be referenced after their definition.
+  .code     print the instructions entered so far
+  .storage  print the contract variables
+  .reset    remove all instructions
+  .help     print this help
+  .exit     quit`
+
+// stepRecorder collects all steps of an execution
+type stepRecorder struct {
+	steps []vm.TraceStep
+}
+
+func (r *stepRecorder) CaptureStep(step vm.TraceStep) {
+	r.steps = append(r.steps, step)
+}
+
+// session is the state of a REPL. Every input is appended to the source, which is then executed from the start
+// on a new VM with the configured context. Therefore earlier instructions, including their storage changes, persist.
+type session struct {
+	flags  *contextFlags
+	source string
+	steps  int // Number of steps executed by the previous input
+	mc     *vm.MockContext
+}
+
+func repl(args []string, out io.Writer) error {
+	set := flag.NewFlagSet("repl", flag.ContinueOnError)
+	flags := newContextFlags(set)
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	s := &session{flags: flags}
+	if _, err := s.flags.context(nil); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, replHelp)
+	scanner := bufio.NewScanner(os.Stdin)
+	for fmt.Fprint(out, "> "); scanner.Scan(); fmt.Fprint(out, "> ") {
+		if strings.TrimSpace(scanner.Text()) == ".exit" {
+			return nil
+		}
+		s.eval(scanner.Text(), out)
+	}
+	return scanner.Err()
+}
+
+// eval executes the input and prints the stack after every new instruction.
+// Inputs, which cannot be assembled or fail at execution, are discarded.
+func (s *session) eval(input string, out io.Writer) {
+	switch strings.TrimSpace(input) {
+	case "":
+		return
+	case ".help":
+		fmt.Fprintln(out, replHelp)
+		return
+	case ".reset":
+		s.source, s.steps, s.mc = "", 0, nil
+		return
+	case ".code":
+		s.printCode(out)
+		return
+	case ".storage":
+		s.printStorage(out)
+		return
+	}
+
+	source := s.source + input + "\n"
+	code, err := asm.Assemble(source)
+	if err != nil {
+		fmt.Fprintln(out, "error:", err)
+		return
+	}
+
+	mc, err := s.flags.context(append(code, vm.Halt))
+	if err != nil {
+		fmt.Fprintln(out, "error:", err)
+		return
+	}
+
+	recorder := &stepRecorder{}
+	machine := vm.NewVMWithConfig(mc, vm.VMConfig{BytecodeVersion: byte(*s.flags.version), Tracer: recorder})
+
+	if !machine.Exec(false) {
+		fmt.Fprintln(out, "error:", machine.GetErrorMsg())
+		return
+	}
+
+	// The final step is the appended halt
+	steps := recorder.steps[:len(recorder.steps)-1]
+	for _, step := range steps[s.steps:] {
+		fmt.Fprintf(out, "%04d: %-10s %v\n", step.PC, step.OpCode, formatStack(step.Stack))
+	}
+	fmt.Fprintf(out, "gas: %v\n", machine.GetRemainingFee())
+
+	s.source, s.steps, s.mc = source, len(steps), mc
+}
+
+func (s *session) printCode(out io.Writer) {
+	code, err := asm.Assemble(s.source)
+	if err != nil {
+		fmt.Fprintln(out, "error:", err)
+		return
+	}
+
+	instructions, err := vm.Disassemble(code)
+	if err != nil {
+		fmt.Fprintln(out, "error:", err)
+		return
+	}
+	for _, instruction := range instructions {
+		fmt.Fprintln(out, instruction)
+	}
+}
+
+func (s *session) printStorage(out io.Writer) {
+	mc := s.mc
+	if mc == nil {
+		var err error
+		if mc, err = s.flags.context(nil); err != nil {
+			fmt.Fprintln(out, "error:", err)
+			return
+		}
+	}
+
+	for i := range mc.ContractVariables {
+		variable, _ := mc.GetContractVariable(i)
+		fmt.Fprintf(out, "%v: %v\n", i, hex.EncodeToString(variable))
+	}
+}
+
+// formatStack formats the stack hex encoded, top first
+func formatStack(stack [][]byte) string {
+	elements := make([]string, len(stack))
+	for i, element := range stack {
+		elements[i] = hex.EncodeToString(element)
+	}
+	return "[" + strings.Join(elements, " ") + "]"
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func newSession(t *testing.T, args ...string) *session {
+	set := flag.NewFlagSet("repl", flag.ContinueOnError)
+	flags := newContextFlags(set)
+	assert.NilError(t, set.Parse(args))
+	return &session{flags: flags}
+}
+
+func TestRepl_Eval(t *testing.T) {
+	s := newSession(t, "-fee", "100")
+
+	var out bytes.Buffer
+	s.eval("pushint 1 0 2 pushint 1 0 3", &out)
+	assert.Equal(t, out.String(), "0000: pushint    [0002]\n0004: pushint    [0003 0002]\ngas: 90\n")
+
+	out.Reset()
+	s.eval("add", &out)
+	assert.Equal(t, out.String(), "0008: add        [0005]\ngas: 85\n")
+}
+
+func TestRepl_Eval_ErrorDiscardsInput(t *testing.T) {
+	s := newSession(t)
+
+	var out bytes.Buffer
+	s.eval("pushint 1 0 2", &out)
+
+	out.Reset()
+	s.eval("add", &out)
+	assert.Equal(t, out.String(), "error: add: stack underflow at pc=4\n")
+
+	out.Reset()
+	s.eval("unknown", &out)
+	assert.Equal(t, out.String(), "error: unknown label unknown\n")
+
+	out.Reset()
+	s.eval(".code", &out)
+	assert.Equal(t, out.String(), "0000: pushint 1 0 2\n")
+}
+
+func TestRepl_Eval_Storage(t *testing.T) {
+	s := newSession(t)
+	s.flags.config.Variables = []string{"00"}
+
+	var out bytes.Buffer
+	s.eval("pushint 1 0 2 storest 0", &out)
+
+	out.Reset()
+	s.eval(".storage", &out)
+	assert.Equal(t, out.String(), "0: 0002\n")
+
+	s.eval(".reset", &out)
+	out.Reset()
+	s.eval(".storage", &out)
+	assert.Equal(t, out.String(), "0: 00\n")
+}
@@ -0,0 +1,48 @@
+// Command gascalib runs the opcode benchmarks and prints a proposed gas schedule as JSON.
+//
+// Usage:
+//
+//	gascalib [-baseline add] [-iterations 200] [-repetitions 100] [-measurements]
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bazo-blockchain/bazo-vm/gascalib"
+)
+
+func main() {
+	config := gascalib.DefaultConfig()
+	baseline := flag.String("baseline", gascalib.DefaultBaseline, "opcode whose current gas price is kept")
+	flag.IntVar(&config.Iterations, "iterations", config.Iterations, "executions of every benchmark program")
+	flag.IntVar(&config.Repetitions, "repetitions", config.Repetitions, "instructions per benchmark program")
+	printMeasurements := flag.Bool("measurements", false, "print the measurements instead of the gas schedule")
+	flag.Parse()
+
+	measurements, err := gascalib.Run(gascalib.Benchmarks, config)
+	if err != nil {
+		fail(err)
+	}
+
+	var output interface{} = measurements
+	if !*printMeasurements {
+		output, err = gascalib.Propose(measurements, *baseline)
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "gascalib:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,295 @@
+// Package convert maps Go values to the encoding of values on the evaluation stack of the VM and back, so clients can
+// construct call data and decode the results of contracts without encoding the bytes by hand.
+//
+// Integers are encoded as signed integers like PushInt, booleans like PushBool and strings as their bytes. Byte slices
+// and byte arrays are passed unchanged. Other slices and arrays are encoded as arrays, maps as maps and structs as
+// structs of their exported fields in the order of their declaration.
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+var bigIntType = reflect.TypeOf(big.Int{})
+
+// Encode returns the stack encoding of the value.
+func Encode(value interface{}) ([]byte, error) {
+	if value == nil {
+		return nil, fmt.Errorf("cannot encode nil")
+	}
+	return encode(reflect.ValueOf(value))
+}
+
+// CallData encodes the arguments as transaction data, which is pushed onto the stack by the CallData opcode.
+// Every encoded argument must have between 1 and 255 bytes.
+func CallData(args ...interface{}) ([]byte, error) {
+	var data []byte
+	for i, arg := range args {
+		encoded, err := Encode(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %v: %v", i, err)
+		}
+		if len(encoded) == 0 || len(encoded) > 255 {
+			return nil, fmt.Errorf("argument %v has %v bytes, but must have between 1 and 255 bytes", i, len(encoded))
+		}
+
+		data = append(data, byte(len(encoded)))
+		data = append(data, encoded...)
+	}
+	return data, nil
+}
+
+func encode(value reflect.Value) ([]byte, error) {
+	if value.Type() == bigIntType {
+		bigInt := value.Interface().(big.Int)
+		return vm.SignedByteArrayConversion(bigInt), nil
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil, fmt.Errorf("cannot encode nil %v", value.Type())
+		}
+		return encode(value.Elem())
+
+	case reflect.Bool:
+		return vm.BoolToByteArray(value.Bool()), nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return vm.SignedByteArrayConversion(*big.NewInt(value.Int())), nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return vm.SignedByteArrayConversion(*new(big.Int).SetUint64(value.Uint())), nil
+
+	case reflect.String:
+		return []byte(value.String()), nil
+
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			encoded := make([]byte, value.Len())
+			reflect.Copy(reflect.ValueOf(encoded), value)
+			return encoded, nil
+		}
+
+		elements, err := encodeElements(value.Len(), value.Index)
+		if err != nil {
+			return nil, err
+		}
+		return vm.MarshalArray(elements)
+
+	case reflect.Map:
+		return encodeMap(value)
+
+	case reflect.Struct:
+		fields := exportedFields(value.Type())
+		elements, err := encodeElements(len(fields), func(i int) reflect.Value {
+			return value.Field(fields[i])
+		})
+		if err != nil {
+			return nil, err
+		}
+		return vm.MarshalStruct(elements)
+	}
+	return nil, fmt.Errorf("cannot encode %v", value.Type())
+}
+
+func encodeElements(n int, element func(i int) reflect.Value) ([][]byte, error) {
+	elements := make([][]byte, n)
+	for i := range elements {
+		encoded, err := encode(element(i))
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = encoded
+	}
+	return elements, nil
+}
+
+// encodeMap encodes the entries sorted by their encoded keys, so the encoding does not depend on the order of Go maps
+func encodeMap(value reflect.Value) ([]byte, error) {
+	entries := make([]vm.MapEntry, 0, value.Len())
+	for _, key := range value.MapKeys() {
+		k, err := encode(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := encode(value.MapIndex(key))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, vm.MapEntry{Key: k, Value: v})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Key, entries[j].Key) < 0
+	})
+	return vm.MarshalMap(entries)
+}
+
+// Decode decodes the stack encoding into the value, to which target points.
+func Decode(data []byte, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("target must be a non-nil pointer")
+	}
+	return decode(data, value.Elem())
+}
+
+func decode(data []byte, value reflect.Value) error {
+	if value.Type() == bigIntType {
+		bigInt, err := decodeInt(data)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(*bigInt))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return decode(data, value.Elem())
+
+	case reflect.Bool:
+		if len(data) != 1 || data[0] > 1 {
+			return fmt.Errorf("invalid bool %x", data)
+		}
+		value.SetBool(data[0] == 1)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bigInt, err := decodeInt(data)
+		if err != nil {
+			return err
+		}
+		if !bigInt.IsInt64() || value.OverflowInt(bigInt.Int64()) {
+			return fmt.Errorf("integer %v overflows %v", bigInt, value.Type())
+		}
+		value.SetInt(bigInt.Int64())
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		bigInt, err := decodeInt(data)
+		if err != nil {
+			return err
+		}
+		if bigInt.Sign() < 0 || !bigInt.IsUint64() || value.OverflowUint(bigInt.Uint64()) {
+			return fmt.Errorf("integer %v overflows %v", bigInt, value.Type())
+		}
+		value.SetUint(bigInt.Uint64())
+		return nil
+
+	case reflect.String:
+		value.SetString(string(data))
+		return nil
+
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			value.SetBytes(append([]byte{}, data...))
+			return nil
+		}
+
+		elements, err := vm.UnmarshalArray(data)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.MakeSlice(value.Type(), len(elements), len(elements)))
+		return decodeElements(elements, value.Index)
+
+	case reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			if len(data) != value.Len() {
+				return fmt.Errorf("%v bytes do not fit into %v", len(data), value.Type())
+			}
+			reflect.Copy(value, reflect.ValueOf(data))
+			return nil
+		}
+
+		elements, err := vm.UnmarshalArray(data)
+		if err != nil {
+			return err
+		}
+		if len(elements) != value.Len() {
+			return fmt.Errorf("array of %v elements does not fit into %v", len(elements), value.Type())
+		}
+		return decodeElements(elements, value.Index)
+
+	case reflect.Map:
+		return decodeMap(data, value)
+
+	case reflect.Struct:
+		elements, err := vm.UnmarshalStruct(data)
+		if err != nil {
+			return err
+		}
+		fields := exportedFields(value.Type())
+		if len(elements) != len(fields) {
+			return fmt.Errorf("struct of %v fields does not fit into %v", len(elements), value.Type())
+		}
+		return decodeElements(elements, func(i int) reflect.Value {
+			return value.Field(fields[i])
+		})
+	}
+	return fmt.Errorf("cannot decode into %v", value.Type())
+}
+
+func decodeInt(data []byte) (*big.Int, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("invalid integer")
+	}
+
+	bigInt, err := vm.SignedBigIntConversion(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &bigInt, nil
+}
+
+func decodeElements(elements [][]byte, element func(i int) reflect.Value) error {
+	for i, data := range elements {
+		if err := decode(data, element(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeMap(data []byte, value reflect.Value) error {
+	entries, err := vm.UnmarshalMap(data)
+	if err != nil {
+		return err
+	}
+
+	value.Set(reflect.MakeMapWithSize(value.Type(), len(entries)))
+	for _, entry := range entries {
+		key := reflect.New(value.Type().Key()).Elem()
+		if err := decode(entry.Key, key); err != nil {
+			return err
+		}
+		element := reflect.New(value.Type().Elem()).Elem()
+		if err := decode(entry.Value, element); err != nil {
+			return err
+		}
+		value.SetMapIndex(key, element)
+	}
+	return nil
+}
+
+// exportedFields returns the indexes of the exported fields of the struct type
+func exportedFields(structType reflect.Type) []int {
+	var fields []int
+	for i := 0; i < structType.NumField(); i++ {
+		if structType.Field(i).PkgPath == "" {
+			fields = append(fields, i)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,117 @@
+package convert
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+type account struct {
+	Owner   [4]byte
+	Balance uint64
+	Frozen  bool
+	Tags    []string
+	note    string
+}
+
+func TestConvert_Encode(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected []byte
+	}{
+		{int64(-5), []byte{1, 5}},
+		{0, []byte{0}},
+		{uint64(258), []byte{0, 1, 2}},
+		{*big.NewInt(-256), []byte{1, 1, 0}},
+		{big.NewInt(7), []byte{0, 7}},
+		{true, []byte{1}},
+		{"hi", []byte("hi")},
+		{[]byte{1, 2}, []byte{1, 2}},
+		{[2]byte{3, 4}, []byte{3, 4}},
+		{[]int{1, -1}, []byte{2, 0, 2, 0, 2, 0, 1, 0, 2, 1, 1}},
+		{map[string]bool{"b": false, "a": true}, []byte{1, 0, 2, 0, 1, 'a', 0, 1, 1, 0, 1, 'b', 0, 1, 0}},
+	}
+
+	for _, test := range tests {
+		encoded, err := Encode(test.value)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, encoded, test.expected)
+	}
+}
+
+func TestConvert_RoundTrip(t *testing.T) {
+	original := account{Owner: [4]byte{1, 2, 3, 4}, Balance: 1000, Frozen: true, Tags: []string{"a", "bc"}, note: "x"}
+	encoded, err := Encode(original)
+	assert.NilError(t, err)
+
+	var decoded account
+	assert.NilError(t, Decode(encoded, &decoded))
+	assert.Equal(t, decoded.Owner, original.Owner)
+	assert.Equal(t, decoded.Balance, original.Balance)
+	assert.Equal(t, decoded.Frozen, original.Frozen)
+	assert.DeepEqual(t, decoded.Tags, original.Tags)
+	assert.Equal(t, decoded.note, "")
+
+	balances := map[int64]*big.Int{1: big.NewInt(-3), 2: big.NewInt(0)}
+	encoded, err = Encode(balances)
+	assert.NilError(t, err)
+
+	var decodedBalances map[int64]*big.Int
+	assert.NilError(t, Decode(encoded, &decodedBalances))
+	assert.Equal(t, len(decodedBalances), 2)
+	assert.Equal(t, decodedBalances[1].Int64(), int64(-3))
+	assert.Equal(t, decodedBalances[2].Int64(), int64(0))
+}
+
+func TestConvert_DecodeErrors(t *testing.T) {
+	var small int8
+	assert.Error(t, Decode([]byte{0, 1, 0}, &small), "integer 256 overflows int8")
+
+	var unsigned uint
+	assert.Error(t, Decode([]byte{1, 1}, &unsigned), "integer -1 overflows uint")
+
+	var flag bool
+	assert.Error(t, Decode([]byte{2}, &flag), "invalid bool 02")
+
+	var address [4]byte
+	assert.Error(t, Decode([]byte{1, 2}, &address), "2 bytes do not fit into [4]uint8")
+
+	var list []int
+	assert.Error(t, Decode([]byte{1, 0, 0}, &list), "not a valid array")
+
+	assert.Error(t, Decode([]byte{0}, small), "target must be a non-nil pointer")
+
+	var channel chan int
+	assert.Error(t, Decode([]byte{0}, &channel), "cannot decode into chan int")
+}
+
+func TestConvert_CallData(t *testing.T) {
+	data, err := CallData(int64(7), "hi", true)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, data, []byte{2, 0, 7, 2, 'h', 'i', 1, 1})
+
+	_, err = CallData("")
+	assert.Error(t, err, "argument 0 has 0 bytes, but must have between 1 and 255 bytes")
+
+	_, err = CallData(1, make(chan int))
+	assert.Error(t, err, "argument 1: cannot encode chan int")
+}
+
+func TestConvert_Contract(t *testing.T) {
+	data, err := CallData([]int64{4, 5}, int64(1))
+	assert.NilError(t, err)
+
+	mc := vm.NewMockContext([]byte{vm.CallData, vm.Swap, vm.ArrAt, vm.Halt})
+	mc.Data = data
+	machine := vm.NewVM(mc)
+	assert.Assert(t, machine.Exec(false), machine.GetErrorMsg())
+
+	result, err := machine.PeekResult()
+	assert.NilError(t, err)
+
+	var element int64
+	assert.NilError(t, Decode(result, &element))
+	assert.Equal(t, element, int64(5))
+}
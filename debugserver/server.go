@@ -0,0 +1,298 @@
+// Package debugserver exposes the execution of contracts over HTTP, so debuggers can be built outside of Go.
+//
+// Executions are requested with JSON-RPC 2.0 at /rpc:
+//
+//	vm_execute  executes the code and returns the result including all trace steps
+//	vm_trace    prepares an execution and returns its id
+//
+// The steps of a prepared execution are streamed over a WebSocket at /stream?id=<id>.
+// Every message contains either a step or, as last message, the result.
+package debugserver
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+)
+
+// ExecuteParams describe the code and the mock context of an execution. Byte values are hex encoded.
+type ExecuteParams struct {
+	Code      string              `json:"code"`
+	Fee       uint64              `json:"fee"`
+	Amount    uint64              `json:"amount"`
+	Balance   uint64              `json:"balance"`
+	CallData  string              `json:"callData"`
+	Variables []string            `json:"variables"` // Contract variables
+	StackSize int                 `json:"stackSize"` // Number of traced stack elements, 0 traces the whole stack
+	SourceMap []vm.SourceMapEntry `json:"sourceMap"`
+	Dump      bool                `json:"dump"` // Adds the state dump of the VM to the result
+}
+
+// Result is the result of an execution.
+type Result struct {
+	vm.TestVectorResult
+	Source string        `json:"source,omitempty"` // Source location of the error, if a source map is given
+	State  *vm.StateDump `json:"state,omitempty"`  // State dump, if requested
+
+	// Function calls, which led to the error, innermost call first
+	Backtrace []vm.BacktraceFrame `json:"backtrace,omitempty"`
+	// Address of the failed instruction, -1 if the execution failed before its first instruction
+	PC *int `json:"pc,omitempty"`
+}
+
+// ExecuteResult is the result of vm_execute.
+type ExecuteResult struct {
+	Result
+	Steps []vm.TraceStep `json:"steps"`
+}
+
+// TraceResult is the result of vm_trace.
+type TraceResult struct {
+	ID string `json:"id"`
+}
+
+// StreamMessage is a message of the stream of an execution.
+type StreamMessage struct {
+	Step   *vm.TraceStep `json:"step,omitempty"`
+	Result *Result       `json:"result,omitempty"`
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server handles the JSON-RPC requests and the streams of prepared executions.
+type Server struct {
+	mutex   sync.Mutex
+	nextID  int
+	pending map[string]ExecuteParams
+}
+
+// NewServer creates a server without prepared executions.
+func NewServer() *Server {
+	return &Server{pending: make(map[string]ExecuteParams)}
+}
+
+// Handler returns the HTTP handler serving /rpc and /stream.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rpc", s.handleRPC)
+	mux.HandleFunc("/stream", s.handleStream)
+	return mux
+}
+
+func (s *Server) handleRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request rpcRequest
+	response := rpcResponse{JSONRPC: "2.0"}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		response.Error = &rpcError{Code: ParseError, Message: err.Error()}
+	} else {
+		response.ID = request.ID
+		response.Result, response.Error = s.call(request)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) call(request rpcRequest) (interface{}, *rpcError) {
+	if request.JSONRPC != "2.0" {
+		return nil, &rpcError{Code: InvalidRequest, Message: "jsonrpc must be 2.0"}
+	}
+
+	var params ExecuteParams
+	if err := json.Unmarshal(request.Params, &params); err != nil {
+		return nil, &rpcError{Code: InvalidParams, Message: err.Error()}
+	}
+
+	switch request.Method {
+	case "vm_execute":
+		recorder := &stepRecorder{stackSize: params.StackSize}
+		result, err := execute(params, recorder)
+		if err != nil {
+			return nil, &rpcError{Code: InvalidParams, Message: err.Error()}
+		}
+		return ExecuteResult{Result: result, Steps: recorder.steps}, nil
+
+	case "vm_trace":
+		// The parameters are validated now, so the stream only fails on connection errors
+		if _, err := newContext(params); err != nil {
+			return nil, &rpcError{Code: InvalidParams, Message: err.Error()}
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.nextID++
+		id := strconv.Itoa(s.nextID)
+		s.pending[id] = params
+		return TraceResult{ID: id}, nil
+
+	default:
+		return nil, &rpcError{Code: MethodNotFound, Message: "method not found: " + request.Method}
+	}
+}
+
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+
+	s.mutex.Lock()
+	params, ok := s.pending[id]
+	delete(s.pending, id)
+	s.mutex.Unlock()
+
+	if !ok {
+		http.Error(w, "unknown execution "+id, http.StatusNotFound)
+		return
+	}
+
+	ws, err := upgrade(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer ws.close()
+
+	streamer := &stepStreamer{ws: ws, stackSize: params.StackSize}
+	result, err := execute(params, streamer)
+	if err != nil || streamer.err != nil {
+		return
+	}
+
+	message, _ := json.Marshal(StreamMessage{Result: &result})
+	ws.writeText(message)
+}
+
+// execute runs the code on a new VM with the tracer
+func execute(params ExecuteParams, tracer vm.Tracer) (Result, error) {
+	mc, err := newContext(params)
+	if err != nil {
+		return Result{}, err
+	}
+
+	machine := vm.NewVMWithConfig(mc, vm.VMConfig{Tracer: tracer})
+	if params.SourceMap != nil {
+		machine.SetSourceMap(vm.NewSourceMap(params.SourceMap))
+	}
+
+	var result Result
+	result.Success = machine.Exec(false)
+	result.Gas = machine.GetRemainingFee()
+	if failure := machine.GetError(); failure != nil {
+		result.Error = failure.Message
+		result.PC = &failure.PC
+		if failure.Source != nil {
+			result.Source = failure.Source.String()
+		}
+		result.Backtrace = failure.Backtrace
+	}
+
+	stack := machine.PeekEvalStack()
+	for i := len(stack) - 1; i >= 0; i-- {
+		result.Stack = append(result.Stack, hex.EncodeToString(stack[i]))
+	}
+	for i := range mc.ContractVariables {
+		variable, _ := mc.GetContractVariable(i)
+		result.Storage = append(result.Storage, hex.EncodeToString(variable))
+	}
+
+	if params.Dump {
+		state := machine.State()
+		result.State = &state
+	}
+	return result, nil
+}
+
+func newContext(params ExecuteParams) (*vm.MockContext, error) {
+	code, err := hex.DecodeString(params.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	mc := vm.NewMockContext(code)
+	mc.Fee = params.Fee
+	mc.Amount = params.Amount
+	mc.Balance = params.Balance
+
+	if mc.Data, err = hex.DecodeString(params.CallData); err != nil {
+		return nil, err
+	}
+
+	mc.ContractVariables = make([][]byte, len(params.Variables))
+	for i, variable := range params.Variables {
+		if mc.ContractVariables[i], err = hex.DecodeString(variable); err != nil {
+			return nil, err
+		}
+	}
+	return mc, nil
+}
+
+// stepRecorder collects the steps of an execution
+type stepRecorder struct {
+	stackSize int
+	steps     []vm.TraceStep
+}
+
+func (r *stepRecorder) CaptureStep(step vm.TraceStep) {
+	r.steps = append(r.steps, truncateStack(step, r.stackSize))
+}
+
+// stepStreamer sends every step as message over the WebSocket. After the first error, all further steps are dropped.
+type stepStreamer struct {
+	ws        *websocket
+	stackSize int
+	err       error
+}
+
+func (s *stepStreamer) CaptureStep(step vm.TraceStep) {
+	if s.err != nil {
+		return
+	}
+
+	step = truncateStack(step, s.stackSize)
+	message, err := json.Marshal(StreamMessage{Step: &step})
+	if err != nil {
+		s.err = err
+		return
+	}
+	s.err = s.ws.writeText(message)
+}
+
+func truncateStack(step vm.TraceStep, stackSize int) vm.TraceStep {
+	if stackSize > 0 && len(step.Stack) > stackSize {
+		step.Stack = step.Stack[:stackSize]
+	}
+	return step
+}
@@ -0,0 +1,187 @@
+package debugserver
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+var code = hex.EncodeToString([]byte{
+	vm.PushInt, 1, 0, 2,
+	vm.PushInt, 1, 0, 3,
+	vm.Add,
+	vm.Halt,
+})
+
+func call(t *testing.T, server *httptest.Server, method string, params string, result interface{}) *rpcError {
+	body := `{"jsonrpc": "2.0", "id": 1, "method": "` + method + `", "params": ` + params + `}`
+	response, err := http.Post(server.URL+"/rpc", "application/json", strings.NewReader(body))
+	assert.NilError(t, err)
+	defer response.Body.Close()
+
+	var rpcResponse struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	assert.NilError(t, json.NewDecoder(response.Body).Decode(&rpcResponse))
+	assert.Equal(t, rpcResponse.ID, 1)
+
+	if rpcResponse.Error == nil {
+		assert.NilError(t, json.Unmarshal(rpcResponse.Result, result))
+	}
+	return rpcResponse.Error
+}
+
+func TestServer_Execute(t *testing.T) {
+	server := httptest.NewServer(NewServer().Handler())
+	defer server.Close()
+
+	var result ExecuteResult
+	err := call(t, server, "vm_execute", `{"code": "`+code+`", "fee": 50, "stackSize": 1}`, &result)
+	assert.Assert(t, err == nil)
+	assert.Assert(t, result.Success)
+	assert.DeepEqual(t, result.Stack, []string{"0005"})
+	assert.Equal(t, result.Gas, uint64(35))
+	assert.Equal(t, len(result.Steps), 4)
+	assert.Equal(t, result.Steps[2].OpCode, "add")
+}
+
+func TestServer_Execute_Dump(t *testing.T) {
+	server := httptest.NewServer(NewServer().Handler())
+	defer server.Close()
+
+	var result ExecuteResult
+	err := call(t, server, "vm_execute", `{"code": "`+code+`", "fee": 50, "dump": true}`, &result)
+	assert.Assert(t, err == nil)
+	assert.Assert(t, result.State != nil)
+	assert.DeepEqual(t, result.State.Stack, []string{"0005"})
+	assert.Equal(t, result.State.Gas.Used, uint64(15))
+	assert.Equal(t, result.State.Gas.Remaining, uint64(35))
+}
+
+func TestServer_Execute_SourceMap(t *testing.T) {
+	server := httptest.NewServer(NewServer().Handler())
+	defer server.Close()
+
+	var result ExecuteResult
+	sourceMap := `[{"offset": 8, "file": "add.lazo", "line": 2, "col": 7}]`
+	err := call(t, server, "vm_execute", `{"code": "`+code+`", "fee": 12, "sourceMap": `+sourceMap+`}`, &result)
+	assert.Assert(t, err == nil)
+	assert.Assert(t, !result.Success)
+	assert.Equal(t, result.Source, "add.lazo:2:7")
+	assert.Equal(t, result.Steps[2].Source, "add.lazo:2:7")
+}
+
+func TestServer_Execute_Backtrace(t *testing.T) {
+	server := httptest.NewServer(NewServer().Handler())
+	defer server.Close()
+
+	// Calls the function at 9 with one argument, which fails on the stack underflow
+	backtraceCode := hex.EncodeToString([]byte{vm.PushInt, 0, vm.CallLocals, 0, 9, 1, 0, 1, vm.Halt, vm.Add})
+
+	var result ExecuteResult
+	err := call(t, server, "vm_execute", `{"code": "`+backtraceCode+`", "fee": 100}`, &result)
+	assert.Assert(t, err == nil)
+	assert.Assert(t, !result.Success)
+	assert.DeepEqual(t, result.Backtrace, []vm.BacktraceFrame{{Function: 9, CallAddress: 2, ReturnAddress: 8, NrOfArgs: 1}})
+}
+
+func TestServer_Errors(t *testing.T) {
+	server := httptest.NewServer(NewServer().Handler())
+	defer server.Close()
+
+	var result ExecuteResult
+	err := call(t, server, "vm_unknown", `{}`, &result)
+	assert.Equal(t, err.Code, MethodNotFound)
+
+	err = call(t, server, "vm_execute", `{"code": "zz"}`, &result)
+	assert.Equal(t, err.Code, InvalidParams)
+}
+
+func TestServer_Stream(t *testing.T) {
+	server := httptest.NewServer(NewServer().Handler())
+	defer server.Close()
+
+	var trace TraceResult
+	err := call(t, server, "vm_trace", `{"code": "`+code+`", "fee": 50}`, &trace)
+	assert.Assert(t, err == nil)
+
+	conn, dialErr := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	assert.NilError(t, dialErr)
+	defer conn.Close()
+
+	io.WriteString(conn, "GET /stream?id="+trace.ID+" HTTP/1.1\r\n"+
+		"Host: localhost\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n")
+
+	reader := bufio.NewReader(conn)
+	response, readErr := http.ReadResponse(reader, nil)
+	assert.NilError(t, readErr)
+	assert.Equal(t, response.StatusCode, http.StatusSwitchingProtocols)
+	assert.Equal(t, response.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+
+	var messages []StreamMessage
+	for {
+		opcode, payload := readFrame(t, reader)
+		if opcode == closeFrame {
+			break
+		}
+
+		var message StreamMessage
+		assert.NilError(t, json.Unmarshal(payload, &message))
+		messages = append(messages, message)
+	}
+
+	assert.Equal(t, len(messages), 5)
+	assert.Equal(t, messages[0].Step.OpCode, "pushint")
+	assert.Equal(t, messages[3].Step.OpCode, "halt")
+	assert.Assert(t, messages[4].Result.Success)
+	assert.DeepEqual(t, messages[4].Result.Stack, []string{"0005"})
+}
+
+func TestServer_Stream_UnknownID(t *testing.T) {
+	server := httptest.NewServer(NewServer().Handler())
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/stream?id=1")
+	assert.NilError(t, err)
+	assert.Equal(t, response.StatusCode, http.StatusNotFound)
+}
+
+func readFrame(t *testing.T, reader *bufio.Reader) (byte, []byte) {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(reader, header)
+	assert.NilError(t, err)
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(reader, extended)
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		_, err = io.ReadFull(reader, extended)
+		length = binary.BigEndian.Uint64(extended)
+	}
+	assert.NilError(t, err)
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(reader, payload)
+	assert.NilError(t, err)
+	return header[0] & 0x0F, payload
+}
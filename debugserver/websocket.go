@@ -0,0 +1,96 @@
+package debugserver
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// GUID of the WebSocket protocol (RFC 6455), which is appended to the key of the handshake
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket frame opcodes
+const (
+	textFrame  = 0x1
+	closeFrame = 0x8
+)
+
+// websocket is the server side of a WebSocket connection, which only sends messages
+type websocket struct {
+	conn   net.Conn
+	writer *bufio.Writer
+}
+
+// upgrade performs the opening handshake of the WebSocket protocol
+func upgrade(w http.ResponseWriter, r *http.Request) (*websocket, error) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		!strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") {
+		return nil, errors.New("not a websocket handshake")
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, errors.New("missing Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("connection cannot be upgraded")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	accept := sha1.Sum([]byte(key + websocketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &websocket{conn: conn, writer: rw.Writer}, nil
+}
+
+// writeText sends the message in a single unmasked text frame
+func (ws *websocket) writeText(message []byte) error {
+	return ws.writeFrame(textFrame, message)
+}
+
+// close sends a close frame and closes the connection
+func (ws *websocket) close() error {
+	ws.writeFrame(closeFrame, nil)
+	return ws.conn.Close()
+}
+
+func (ws *websocket) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+
+	switch length := len(payload); {
+	case length <= 125:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+
+	if _, err := ws.writer.Write(header); err != nil {
+		return err
+	}
+	if _, err := ws.writer.Write(payload); err != nil {
+		return err
+	}
+	return ws.writer.Flush()
+}
@@ -0,0 +1,76 @@
+package evm
+
+import "fmt"
+
+// Opcodes of the EVM instructions known to the translator
+const (
+	opStop     = 0x00
+	opAdd      = 0x01
+	opMul      = 0x02
+	opSub      = 0x03
+	opDiv      = 0x04
+	opSDiv     = 0x05
+	opMod      = 0x06
+	opSMod     = 0x07
+	opAddMod   = 0x08
+	opMulMod   = 0x09
+	opExp      = 0x0a
+	opLt       = 0x10
+	opGt       = 0x11
+	opSLt      = 0x12
+	opSGt      = 0x13
+	opEq       = 0x14
+	opIsZero   = 0x15
+	opAnd      = 0x16
+	opOr       = 0x17
+	opXor      = 0x18
+	opNot      = 0x19
+	opByte     = 0x1a
+	opShl      = 0x1b
+	opShr      = 0x1c
+	opSar      = 0x1d
+	opSHA3     = 0x20
+	opPop      = 0x50
+	opMLoad    = 0x51
+	opMStore   = 0x52
+	opSLoad    = 0x54
+	opSStore   = 0x55
+	opJump     = 0x56
+	opJumpI    = 0x57
+	opJumpDest = 0x5b
+	opPush0    = 0x5f
+	opPush1    = 0x60
+	opPush32   = 0x7f
+	opDup1     = 0x80
+	opDup16    = 0x8f
+	opSwap1    = 0x90
+	opSwap16   = 0x9f
+	opReturn   = 0xf3
+	opRevert   = 0xfd
+	opInvalid  = 0xfe
+)
+
+var opNames = map[byte]string{
+	opStop: "STOP", opAdd: "ADD", opMul: "MUL", opSub: "SUB", opDiv: "DIV", opSDiv: "SDIV", opMod: "MOD",
+	opSMod: "SMOD", opAddMod: "ADDMOD", opMulMod: "MULMOD", opExp: "EXP", opLt: "LT", opGt: "GT", opSLt: "SLT",
+	opSGt: "SGT", opEq: "EQ", opIsZero: "ISZERO", opAnd: "AND", opOr: "OR", opXor: "XOR", opNot: "NOT",
+	opByte: "BYTE", opShl: "SHL", opShr: "SHR", opSar: "SAR", opSHA3: "SHA3", opPop: "POP", opMLoad: "MLOAD",
+	opMStore: "MSTORE", opSLoad: "SLOAD", opSStore: "SSTORE", opJump: "JUMP", opJumpI: "JUMPI",
+	opJumpDest: "JUMPDEST", opPush0: "PUSH0", opReturn: "RETURN", opRevert: "REVERT", opInvalid: "INVALID",
+}
+
+// opName returns the mnemonic of an EVM opcode, unknown opcodes are printed in hex
+func opName(op byte) string {
+	switch {
+	case op >= opPush1 && op <= opPush32:
+		return fmt.Sprintf("PUSH%v", op-opPush1+1)
+	case op >= opDup1 && op <= opDup16:
+		return fmt.Sprintf("DUP%v", op-opDup1+1)
+	case op >= opSwap1 && op <= opSwap16:
+		return fmt.Sprintf("SWAP%v", op-opSwap1+1)
+	}
+	if name, ok := opNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", op)
+}
@@ -0,0 +1,234 @@
+// Package evm translates a subset of EVM bytecode to Bazo bytecode, so that contracts and tooling targeting the
+// EVM can be deployed on Bazo. The translation happens once at deploy time, the translated contract is executed by
+// the Bazo VM like any other contract.
+//
+// EVM words are represented as non-negative Bazo integers, arithmetic which can leave the range of a word is reduced
+// modulo 2^256. Bazo contracts have no memory and address their storage by constant indexes, hence storage accesses,
+// jumps and SHA3 are only translated, if their operands are constants pushed directly before them.
+package evm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+// MaxCodeSize is the maximum size of translated code, because Bazo jump targets have two bytes
+const MaxCodeSize = 0xffff
+
+// wordModulus pushes 2^256 to reduce results to the range of EVM words
+var wordModulus = append([]byte{vm.PushInt, 33, 0, 1}, make([]byte, 32)...)
+
+// wrap reduces the result of the instructions modulo 2^256
+func wrap(instructions ...byte) []byte {
+	result := append(instructions, wordModulus...)
+	return append(result, vm.Mod)
+}
+
+// translations of the instructions, which do not depend on their operands. EVM pops the left operand first, Bazo
+// the right one, so non-commutative operations swap their operands.
+var translations = map[byte][]byte{
+	opStop:    {vm.Halt},
+	opAdd:     wrap(vm.Add),
+	opMul:     wrap(vm.Mul),
+	opSub:     wrap(vm.Swap, vm.Sub),
+	opDiv:     {vm.Swap, vm.Div},
+	opMod:     {vm.Swap, vm.Mod},
+	opExp:     wrap(vm.Exp),
+	opLt:      {vm.Swap, vm.Lt, vm.BoolToInt},
+	opGt:      {vm.Swap, vm.Gt, vm.BoolToInt},
+	opEq:      {vm.Eq, vm.BoolToInt},
+	opIsZero:  {vm.PushInt, 0, vm.Eq, vm.BoolToInt},
+	opAnd:     {vm.BitwiseAnd},
+	opOr:      {vm.BitwiseOr},
+	opXor:     {vm.BitwiseXor},
+	opNot:     wrap(vm.BitwiseNot),
+	opShl:     wrap(vm.ShiftL),
+	opShr:     {vm.ShiftR},
+	opPop:     {vm.Pop},
+	opPush0:   {vm.PushInt, 0},
+	opInvalid: {vm.ErrHalt},
+}
+
+// VerifierError is returned for EVM bytecode, which cannot be translated.
+type VerifierError struct {
+	Offset int
+	OpCode byte
+	Reason string
+}
+
+func (e *VerifierError) Error() string {
+	return fmt.Sprintf("byte %v: %v %v", e.Offset, opName(e.OpCode), e.Reason)
+}
+
+// instruction is a decoded EVM instruction
+type instruction struct {
+	offset int
+	op     byte
+	value  *big.Int // Immediate value of a push
+}
+
+func (ins instruction) isPush(value ...int64) bool {
+	if ins.value == nil {
+		return false
+	}
+	for _, v := range value {
+		if ins.value.Cmp(big.NewInt(v)) == 0 {
+			return true
+		}
+	}
+	return len(value) == 0
+}
+
+// jump is a jump instruction, whose target is resolved after all jump destinations are translated
+type jump struct {
+	address int         // Address of the label argument
+	ins     instruction // The jump with the destination as value
+}
+
+type translator struct {
+	code      []byte
+	jumpDests map[int]int // Bazo addresses of the jump destinations by their EVM offset
+	jumps     []jump
+}
+
+// Translate translates EVM bytecode to Bazo bytecode. Unsupported instructions are rejected with a VerifierError.
+//
+// Supported are the stack instructions, the arithmetic and bitwise instructions on unsigned words, storage accesses
+// with constant slots below 256, jumps to constant destinations and the following patterns:
+//
+//	PUSH1 0 MSTORE PUSH1 32 PUSH1 0 SHA3   hashes the word on top of the stack with SHA3-256 (not Keccak-256)
+//	PUSH1 0 PUSH1 0 REVERT                 reverts without data
+//
+// Division and modulo by zero fail instead of yielding 0, and intermediate results of EXP and SHL exceeding the
+// maximum integer size of the VM fail as well.
+func Translate(code []byte) ([]byte, error) {
+	instructions, err := decode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &translator{jumpDests: make(map[int]int)}
+	for i := 0; i < len(instructions); i++ {
+		consumed, err := t.translate(instructions[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += consumed - 1
+	}
+	t.code = append(t.code, vm.Halt)
+
+	if len(t.code) > MaxCodeSize {
+		return nil, fmt.Errorf("translated code exceeds %v bytes", MaxCodeSize)
+	}
+
+	for _, j := range t.jumps {
+		address, ok := t.jumpDests[int(j.ins.value.Int64())]
+		if !ok || !j.ins.value.IsInt64() {
+			return nil, &VerifierError{j.ins.offset, j.ins.op, fmt.Sprintf("jumps to %v, which is no JUMPDEST", j.ins.value)}
+		}
+		t.code[j.address] = byte(address >> 8)
+		t.code[j.address+1] = byte(address)
+	}
+	return t.code, nil
+}
+
+// decode splits the code into instructions
+func decode(code []byte) ([]instruction, error) {
+	var instructions []instruction
+	for offset := 0; offset < len(code); offset++ {
+		ins := instruction{offset: offset, op: code[offset]}
+		if ins.op >= opPush1 && ins.op <= opPush32 {
+			size := int(ins.op-opPush1) + 1
+			if offset+size >= len(code) {
+				return nil, &VerifierError{offset, ins.op, "is truncated"}
+			}
+			ins.value = new(big.Int).SetBytes(code[offset+1 : offset+1+size])
+			offset += size
+		} else if ins.op == opPush0 {
+			ins.value = new(big.Int)
+		}
+		instructions = append(instructions, ins)
+	}
+	return instructions, nil
+}
+
+// translate translates the first instruction or pattern of the instructions and returns the number of consumed
+// instructions
+func (t *translator) translate(instructions []instruction) (int, error) {
+	ins := instructions[0]
+	next := func(i int) instruction {
+		if i < len(instructions) {
+			return instructions[i]
+		}
+		return instruction{op: opStop}
+	}
+
+	if ins.isPush() {
+		switch op := next(1).op; {
+		case op == opSLoad || op == opSStore:
+			if !ins.value.IsInt64() || ins.value.Int64() > 255 {
+				return 0, &VerifierError{next(1).offset, op, fmt.Sprintf("accesses slot %v, which exceeds 255", ins.value)}
+			}
+			if op == opSLoad {
+				t.code = append(t.code, vm.LoadSt, byte(ins.value.Int64()))
+			} else {
+				t.code = append(t.code, vm.StoreSt, byte(ins.value.Int64()))
+			}
+			return 2, nil
+		case op == opJump || op == opJumpI:
+			if op == opJumpI {
+				t.code = append(t.code, vm.PushInt, 0, vm.Gt, vm.JmpTrue, 0, 0)
+			} else {
+				t.code = append(t.code, vm.Jmp, 0, 0)
+			}
+			target := instruction{offset: next(1).offset, op: op, value: ins.value}
+			t.jumps = append(t.jumps, jump{address: len(t.code) - 2, ins: target})
+			return 2, nil
+		case op == opMStore && ins.isPush(0) && next(2).isPush(32) && next(3).isPush(0) && next(4).op == opSHA3:
+			t.code = append(t.code, vm.SHA3, vm.BytesToInt)
+			return 5, nil
+		case ins.isPush(0) && next(1).isPush(0) && next(2).op == opRevert:
+			t.code = append(t.code, vm.ErrHalt)
+			return 3, nil
+		}
+
+		value := ins.value.Bytes()
+		if len(value) == 0 {
+			t.code = append(t.code, vm.PushInt, 0)
+		} else {
+			t.code = append(append(t.code, vm.PushInt, byte(len(value)), 0), value...)
+		}
+		return 1, nil
+	}
+
+	switch op := ins.op; {
+	case op == opJumpDest:
+		t.jumpDests[ins.offset] = len(t.code)
+	case op == opDup1:
+		t.code = append(t.code, vm.Dup)
+	case op > opDup1 && op <= opDup16:
+		t.code = append(t.code, vm.Pick, op-opDup1)
+	case op == opSwap1:
+		t.code = append(t.code, vm.Swap)
+	case op > opSwap1 && op <= opSwap16:
+		// Roll n-1 moves the nth element to the top, applying it n times moves the top to the nth element
+		n := op - opSwap1 + 1
+		t.code = append(t.code, vm.Roll, n-1, vm.Swap)
+		for i := byte(0); i < n; i++ {
+			t.code = append(t.code, vm.Roll, n-1)
+		}
+	case op == opSLoad || op == opSStore || op == opMStore || op == opSHA3 || op == opRevert:
+		return 0, &VerifierError{ins.offset, op, "requires constant operands"}
+	case op == opJump || op == opJumpI:
+		return 0, &VerifierError{ins.offset, op, "requires a constant destination"}
+	default:
+		translation, ok := translations[op]
+		if !ok {
+			return 0, &VerifierError{ins.offset, op, "is not supported"}
+		}
+		t.code = append(t.code, translation...)
+	}
+	return 1, nil
+}
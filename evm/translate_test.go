@@ -0,0 +1,152 @@
+package evm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/convert"
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"golang.org/x/crypto/sha3"
+	"gotest.tools/assert"
+)
+
+func execTranslated(t *testing.T, code []byte) (vm.VM, *vm.MockContext) {
+	t.Helper()
+	translated, err := Translate(code)
+	assert.NilError(t, err)
+
+	mc := vm.NewMockContext(translated)
+	mc.Fee = 100000
+	mc.ContractVariables = make([][]byte, 2)
+	machine := vm.NewVMWithConfig(mc, vm.VMConfig{CheckIntegrity: true})
+	assert.Assert(t, machine.Exec(false), machine.GetErrorMsg())
+	return machine, mc
+}
+
+func word(value string) []byte {
+	result, _ := new(big.Int).SetString(value, 0)
+	encoded, _ := convert.Encode(result)
+	return encoded
+}
+
+func TestTranslate_Arithmetic(t *testing.T) {
+	maxWord := "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+	tests := []struct {
+		name     string
+		code     []byte
+		expected []byte
+	}{
+		{"add", []byte{0x60, 3, 0x60, 4, opAdd}, word("7")},
+		{"sub", []byte{0x60, 3, 0x60, 10, opSub}, word("7")},
+		{"sub underflow", []byte{0x60, 1, 0x60, 0, opSub}, word(maxWord)},
+		{"add overflow", []byte{0x60, 2, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+			0xff, opAdd}, word("1")},
+		{"mul", []byte{0x60, 6, 0x60, 7, opMul}, word("42")},
+		{"div", []byte{0x60, 3, 0x60, 10, opDiv}, word("3")},
+		{"mod", []byte{0x60, 3, 0x60, 10, opMod}, word("1")},
+		{"exp", []byte{0x60, 10, 0x60, 2, opExp}, word("1024")},
+		{"lt", []byte{0x60, 2, 0x60, 1, opLt}, word("1")},
+		{"gt", []byte{0x60, 2, 0x60, 1, opGt}, word("0")},
+		{"eq", []byte{0x61, 0, 5, 0x60, 5, opEq}, word("1")},
+		{"iszero", []byte{opPush0, opIsZero}, word("1")},
+		{"and", []byte{0x60, 12, 0x60, 10, opAnd}, word("8")},
+		{"not", []byte{opPush0, opNot}, word(maxWord)},
+		{"shl", []byte{0x60, 1, 0x60, 4, opShl}, word("16")},
+		{"shl overflow", []byte{0x60, 1, 0x61, 1, 0, opShl}, word("0")},
+		{"shr", []byte{0x60, 16, 0x60, 4, opShr}, word("1")},
+		{"pop", []byte{0x60, 1, 0x60, 2, opPop}, word("1")},
+		{"dup3", []byte{0x60, 1, 0x60, 2, 0x60, 3, 0x82}, word("1")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			machine, _ := execTranslated(t, test.code)
+			stack := machine.PeekEvalStack()
+			assert.DeepEqual(t, stack[len(stack)-1], test.expected)
+		})
+	}
+}
+
+func TestTranslate_Swap(t *testing.T) {
+	for n := 1; n <= 16; n++ {
+		var code []byte
+		for i := 0; i <= n; i++ {
+			code = append(code, 0x60, byte(i))
+		}
+		code = append(code, byte(opSwap1+n-1))
+
+		machine, _ := execTranslated(t, code)
+		stack := machine.PeekEvalStack()
+		assert.Equal(t, len(stack), n+1)
+		for i := 0; i <= n; i++ {
+			expected := i
+			if i == 0 {
+				expected = n
+			} else if i == n {
+				expected = 0
+			}
+			assert.DeepEqual(t, stack[i], word(big.NewInt(int64(expected)).String()))
+		}
+	}
+}
+
+func TestTranslate_LoopWithStorage(t *testing.T) {
+	// Sums up 1 to 10 in slot 0, the counter is kept on the stack and the loop starts at byte 6
+	code := []byte{
+		0x60, 10, opPush0, 0x60, 0, opSStore,
+		opJumpDest,
+		0x80, 0x60, 0, opSLoad, opAdd, 0x60, 0, opSStore,
+		0x60, 1, 0x90, opSub,
+		0x80, 0x60, 6, opJumpI,
+		opStop,
+	}
+
+	_, mc := execTranslated(t, code)
+	sum, err := mc.GetContractVariable(0)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sum, word("55"))
+}
+
+func TestTranslate_SHA3(t *testing.T) {
+	code := []byte{0x60, 42, 0x60, 0, opMStore, 0x60, 32, 0x60, 0, opSHA3}
+	machine, _ := execTranslated(t, code)
+
+	hasher := sha3.New256()
+	hasher.Write(word("42"))
+	expected, err := convert.Encode(new(big.Int).SetBytes(hasher.Sum(nil)))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, machine.PeekEvalStack(), [][]byte{expected})
+}
+
+func TestTranslate_Revert(t *testing.T) {
+	translated, err := Translate([]byte{0x60, 0, 0x60, 0, opRevert})
+	assert.NilError(t, err)
+
+	machine := vm.NewTestVM(translated)
+	assert.Assert(t, !machine.Exec(false))
+}
+
+func TestTranslate_VerifierErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		code []byte
+		err  string
+	}{
+		{"unsupported opcode", []byte{0x60, 1, 0x33}, "byte 2: 0x33 is not supported"},
+		{"memory", []byte{0x60, 1, 0x60, 1, opMStore}, "byte 4: MSTORE requires constant operands"},
+		{"dynamic jump", []byte{0x60, 1, 0x80, opJump}, "byte 3: JUMP requires a constant destination"},
+		{"jump to no jumpdest", []byte{0x60, 0, opJump}, "byte 2: JUMP jumps to 0, which is no JUMPDEST"},
+		{"slot exceeds 255", []byte{0x61, 1, 0, opSLoad}, "byte 3: SLOAD accesses slot 256, which exceeds 255"},
+		{"truncated push", []byte{0x60, 1, 0x62, 0}, "byte 2: PUSH3 is truncated"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Translate(test.code)
+			assert.Error(t, err, test.err)
+			_, ok := err.(*VerifierError)
+			assert.Assert(t, ok)
+		})
+	}
+}
@@ -0,0 +1,260 @@
+// Package gascalib derives gas prices from measurements of the opcodes.
+// Every opcode of the benchmark suite is executed repeatedly, its time and allocated memory per execution is measured
+// and normalized to a baseline opcode. The result is a proposed gas schedule, which replaces guessed gas prices.
+package gascalib
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+// DefaultBaseline is the opcode, whose gas price is the unit of the proposed gas schedule
+const DefaultBaseline = "add"
+
+// Benchmark measures one instruction. The setup pushes the operands of the instruction.
+type Benchmark struct {
+	Instruction []byte // Opcode and its arguments
+	Setup       []byte
+}
+
+// Config defines how often the benchmarks are executed.
+type Config struct {
+	Iterations  int // Number of executions of every benchmark program
+	Repetitions int // Number of instructions in a benchmark program
+}
+
+// DefaultConfig returns a configuration, which takes a few seconds for the whole suite.
+func DefaultConfig() Config {
+	return Config{Iterations: 200, Repetitions: 100}
+}
+
+// Measurement is the average cost of a single execution of an opcode.
+type Measurement struct {
+	OpCode     string  `json:"opCode"`
+	NsPerOp    float64 `json:"nsPerOp"`
+	BytesPerOp float64 `json:"bytesPerOp"`
+}
+
+// Operands used by the benchmark suite
+var (
+	integer     = []byte{vm.PushInt, 1, 0, 7}
+	shift       = []byte{vm.PushInt, 1, 0, 3}
+	boolean     = []byte{vm.PushBool, 1}
+	char        = []byte{vm.PushChar, 65}
+	str         = []byte{vm.PushStr, 5, 'h', 'e', 'l', 'l', 'o'}
+	byteArray   = []byte{vm.Push, 2, 1, 2}
+	address     = append([]byte{vm.PushAddr, 32}, make([]byte, 32)...)
+	twoIntegers = concat(integer, shift)
+	twoBooleans = concat(boolean, boolean)
+	twoStrings  = concat(str, str)
+)
+
+// Benchmarks is the benchmark suite. It contains the opcodes, which can be repeated without control flow.
+var Benchmarks = []Benchmark{
+	{Instruction: integer},
+	{Instruction: boolean},
+	{Instruction: char},
+	{Instruction: str},
+	{Instruction: byteArray},
+	{Instruction: address},
+	{Instruction: []byte{vm.Dup}, Setup: integer},
+	{Instruction: []byte{vm.Roll, 0}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Swap}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Pop}, Setup: integer},
+	{Instruction: []byte{vm.Pick, 0}, Setup: integer},
+	{Instruction: []byte{vm.Tuck}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Add}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Sub}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Mul}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Div}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Mod}, Setup: twoIntegers},
+	{Instruction: []byte{vm.DivTrunc}, Setup: twoIntegers},
+	{Instruction: []byte{vm.ModTrunc}, Setup: twoIntegers},
+	{Instruction: []byte{vm.DivFloor}, Setup: twoIntegers},
+	{Instruction: []byte{vm.ModFloor}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Exp}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Min}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Max}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Abs}, Setup: integer},
+	{Instruction: []byte{vm.Sign}, Setup: integer},
+	{Instruction: []byte{vm.Sqrt}, Setup: integer},
+	{Instruction: []byte{vm.Log2}, Setup: integer},
+	{Instruction: []byte{vm.Neg}, Setup: boolean},
+	{Instruction: []byte{vm.Not}, Setup: boolean},
+	{Instruction: []byte{vm.And}, Setup: twoBooleans},
+	{Instruction: []byte{vm.Or}, Setup: twoBooleans},
+	{Instruction: []byte{vm.Xor}, Setup: twoBooleans},
+	{Instruction: []byte{vm.Eq}, Setup: twoIntegers},
+	{Instruction: []byte{vm.NotEq}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Lt}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Gt}, Setup: twoIntegers},
+	{Instruction: []byte{vm.LtEq}, Setup: twoIntegers},
+	{Instruction: []byte{vm.GtEq}, Setup: twoIntegers},
+	{Instruction: []byte{vm.ULt}, Setup: twoStrings},
+	{Instruction: []byte{vm.UGt}, Setup: twoStrings},
+	{Instruction: []byte{vm.ULtEq}, Setup: twoStrings},
+	{Instruction: []byte{vm.UGtEq}, Setup: twoStrings},
+	{Instruction: []byte{vm.StrLt}, Setup: twoStrings},
+	{Instruction: []byte{vm.StrGt}, Setup: twoStrings},
+	{Instruction: []byte{vm.StrCmp}, Setup: twoStrings},
+	{Instruction: []byte{vm.HexEncode}, Setup: str},
+	{Instruction: []byte{vm.HexDecode}, Setup: []byte{vm.PushStr, 4, '0', '0', 'f', 'f'}},
+	{Instruction: []byte{vm.Base64Encode}, Setup: str},
+	{Instruction: []byte{vm.Base64Decode}, Setup: []byte{vm.PushStr, 8, 'a', 'G', 'V', 's', 'b', 'G', '8', '='}},
+	{Instruction: []byte{vm.ShiftL}, Setup: twoIntegers},
+	{Instruction: []byte{vm.ShiftR}, Setup: twoIntegers},
+	{Instruction: []byte{vm.BitwiseAnd}, Setup: twoIntegers},
+	{Instruction: []byte{vm.BitwiseOr}, Setup: twoIntegers},
+	{Instruction: []byte{vm.BitwiseXor}, Setup: twoIntegers},
+	{Instruction: []byte{vm.BitwiseNot}, Setup: integer},
+	{Instruction: []byte{vm.IntToBytes}, Setup: integer},
+	{Instruction: []byte{vm.BytesToInt}, Setup: byteArray},
+	{Instruction: []byte{vm.BytesToAddress}, Setup: byteArray},
+	{Instruction: []byte{vm.BytesToPubKey}, Setup: byteArray},
+	{Instruction: []byte{vm.BoolToInt}, Setup: boolean},
+	{Instruction: []byte{vm.CharToInt}, Setup: char},
+	{Instruction: []byte{vm.Uint64ToInt}, Setup: []byte{vm.Balance}},
+	{Instruction: []byte{vm.IntToUint64}, Setup: integer},
+	{Instruction: []byte{vm.Add64}, Setup: []byte{vm.Balance, vm.CallVal}},
+	{Instruction: []byte{vm.Sub64}, Setup: []byte{vm.Balance, vm.CallVal}},
+	{Instruction: []byte{vm.Cmp64}, Setup: []byte{vm.Balance, vm.CallVal}},
+	{Instruction: []byte{vm.AddAmount}, Setup: []byte{vm.Balance, vm.CallVal}},
+	{Instruction: []byte{vm.SubAmount}, Setup: []byte{vm.Balance, vm.CallVal}},
+	{Instruction: []byte{vm.AddDuration}, Setup: twoIntegers},
+	{Instruction: []byte{vm.SubDuration}, Setup: twoIntegers},
+	{Instruction: []byte{vm.BlocksToSeconds}, Setup: integer},
+	{Instruction: []byte{vm.SecondsToBlocks}, Setup: integer},
+	{Instruction: []byte{vm.NoOp}},
+	{Instruction: []byte{vm.CallDepth}},
+	{Instruction: []byte{vm.StoreSt, 0}, Setup: integer},
+	{Instruction: []byte{vm.LoadSt, 0}},
+	{Instruction: []byte{vm.TStore}, Setup: twoIntegers},
+	{Instruction: []byte{vm.Address}},
+	{Instruction: []byte{vm.Issuer}},
+	{Instruction: []byte{vm.RequireIssuer}},
+	{Instruction: []byte{vm.RequireBefore}, Setup: integer},
+	{Instruction: []byte{vm.MetaField, vm.MetadataVersion}},
+	{Instruction: []byte{vm.Balance}},
+	{Instruction: []byte{vm.BalanceOf}, Setup: []byte{vm.Address}},
+	{Instruction: []byte{vm.Caller}},
+	{Instruction: []byte{vm.Origin}},
+	{Instruction: []byte{vm.CallVal}},
+	{Instruction: []byte{vm.NewMap}},
+	{Instruction: []byte{vm.NewMapFrom, 1}, Setup: twoIntegers},
+	{Instruction: []byte{vm.MapMerge}, Setup: []byte{vm.NewMap, vm.NewMap}},
+	{Instruction: []byte{vm.MapSetMany, 1}, Setup: concat(twoIntegers, []byte{vm.NewMap})},
+	{Instruction: []byte{vm.NewArrFrom, 2}, Setup: twoIntegers},
+	{Instruction: []byte{vm.ArrFill}, Setup: concat(byteArray, shift, []byte{vm.NewArr})},
+	{Instruction: []byte{vm.ArrResize}, Setup: concat(integer, shift, []byte{vm.NewArr})},
+	{Instruction: []byte{vm.ArrFieldAt, 0, 0}, Setup: []byte{vm.PushInt, 0, vm.NewStr, 0, 1, vm.NewArrFrom, 1}},
+	{Instruction: []byte{vm.SHA3}, Setup: str},
+}
+
+// Run executes the benchmarks and returns a measurement per benchmark.
+func Run(benchmarks []Benchmark, config Config) ([]Measurement, error) {
+	measurements := make([]Measurement, len(benchmarks))
+
+	for i, benchmark := range benchmarks {
+		name := vm.OpCodes[benchmark.Instruction[0]].Name
+
+		// The setup is measured separately and subtracted from the measurement of the whole program
+		ns, allocated, err := measure(program(benchmark.Setup, benchmark.Instruction, config.Repetitions), config.Iterations)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", name, err)
+		}
+
+		setupNs, setupAllocated, err := measure(program(benchmark.Setup, nil, config.Repetitions), config.Iterations)
+		if err != nil {
+			return nil, fmt.Errorf("%v setup: %v", name, err)
+		}
+
+		executions := float64(config.Iterations * config.Repetitions)
+		measurements[i] = Measurement{
+			OpCode:     name,
+			NsPerOp:    math.Max(0, ns-setupNs) / executions,
+			BytesPerOp: math.Max(0, allocated-setupAllocated) / executions,
+		}
+	}
+	return measurements, nil
+}
+
+// Propose normalizes the measurements to the baseline opcode and scales them with its current gas price.
+// The more expensive dimension, time or memory, determines the price of an opcode.
+// Gas factors and the prices of opcodes without measurement are taken from the default gas schedule.
+func Propose(measurements []Measurement, baseline string) (vm.GasSchedule, error) {
+	schedule := vm.DefaultGasSchedule()
+
+	baseCost, ok := schedule[baseline]
+	if !ok {
+		return nil, fmt.Errorf("unknown baseline opcode %v", baseline)
+	}
+
+	var base *Measurement
+	for i := range measurements {
+		if measurements[i].OpCode == baseline {
+			base = &measurements[i]
+		}
+	}
+	if base == nil || base.NsPerOp == 0 {
+		return nil, fmt.Errorf("no measurement of baseline opcode %v", baseline)
+	}
+
+	for _, measurement := range measurements {
+		ratio := measurement.NsPerOp / base.NsPerOp
+		if base.BytesPerOp > 0 {
+			ratio = math.Max(ratio, measurement.BytesPerOp/base.BytesPerOp)
+		}
+
+		cost := schedule[measurement.OpCode]
+		cost.Price = uint64(math.Max(1, math.Round(ratio*float64(baseCost.Price))))
+		schedule[measurement.OpCode] = cost
+	}
+	return schedule, nil
+}
+
+// measure returns the total time in nanoseconds and the total allocated bytes of all iterations
+func measure(code []byte, iterations int) (float64, float64, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	machine := vm.NewVM(nil)
+	for i := 0; i < iterations; i++ {
+		mc := vm.NewMockContext(code)
+		mc.Fee = math.MaxUint64 / 2
+		mc.ContractVariables = make([][]byte, 1)
+		mc.BlockInterval = 10
+		machine.Reset(mc)
+
+		if !machine.Exec(false) {
+			return 0, 0, fmt.Errorf("%v", machine.GetErrorMsg())
+		}
+	}
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return float64(elapsed.Nanoseconds()), float64(after.TotalAlloc - before.TotalAlloc), nil
+}
+
+// program repeats the setup and the instruction and halts at the end
+func program(setup []byte, instruction []byte, repetitions int) []byte {
+	var code []byte
+	for i := 0; i < repetitions; i++ {
+		code = append(code, setup...)
+		code = append(code, instruction...)
+	}
+	return append(code, vm.Halt)
+}
+
+func concat(slices ...[]byte) []byte {
+	var result []byte
+	for _, slice := range slices {
+		result = append(result, slice...)
+	}
+	return result
+}
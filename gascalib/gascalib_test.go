@@ -0,0 +1,45 @@
+package gascalib
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+func TestGasCalib_Run(t *testing.T) {
+	measurements, err := Run(Benchmarks, Config{Iterations: 1, Repetitions: 2})
+	assert.NilError(t, err)
+	assert.Equal(t, len(measurements), len(Benchmarks))
+	assert.Equal(t, measurements[0].OpCode, "pushint")
+}
+
+func TestGasCalib_Run_Error(t *testing.T) {
+	_, err := Run([]Benchmark{{Instruction: []byte{vm.Add}}}, Config{Iterations: 1, Repetitions: 1})
+	assert.Error(t, err, "add: add: stack underflow at pc=0")
+}
+
+func TestGasCalib_Propose(t *testing.T) {
+	measurements := []Measurement{
+		{OpCode: "add", NsPerOp: 10, BytesPerOp: 8},
+		{OpCode: "mult", NsPerOp: 25, BytesPerOp: 8},
+		{OpCode: "sha3", NsPerOp: 20, BytesPerOp: 64},
+		{OpCode: "nop", NsPerOp: 1},
+	}
+
+	schedule, err := Propose(measurements, "add")
+	assert.NilError(t, err)
+	assert.Equal(t, schedule["add"], vm.GasCost{Price: 1, Factor: 2})
+	assert.Equal(t, schedule["mult"].Price, uint64(3))
+	assert.Equal(t, schedule["sha3"].Price, uint64(8))
+	assert.Equal(t, schedule["nop"].Price, uint64(1))
+	assert.Equal(t, schedule["storest"].Price, uint64(1000))
+}
+
+func TestGasCalib_Propose_MissingBaseline(t *testing.T) {
+	_, err := Propose([]Measurement{{OpCode: "add", NsPerOp: 10}}, "sub")
+	assert.Error(t, err, "no measurement of baseline opcode sub")
+
+	_, err = Propose([]Measurement{{OpCode: "add", NsPerOp: 10}}, "unknown")
+	assert.Error(t, err, "unknown baseline opcode unknown")
+}
@@ -0,0 +1,177 @@
+// Package codegen generates random programs for fuzz and differential tests of the VM.
+// The programs are structurally valid: every instruction finds enough operands on the stack and jumps land on
+// instruction boundaries within the code, so the execution reaches deeper paths than random bytes.
+package codegen
+
+import (
+	"math/rand"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+// Effect is the number of elements an opcode pops from and pushes onto the evaluation stack.
+type Effect struct {
+	Pops   int
+	Pushes int
+}
+
+// DefaultEffects contains the opcodes without arguments, which accept integers of any size as operands.
+var DefaultEffects = Effects(
+	vm.Dup, vm.Swap, vm.Pop, vm.Tuck,
+	vm.Add, vm.Sub, vm.Mul, vm.Div, vm.Mod, vm.Min, vm.Max, vm.Abs, vm.Sign, vm.Neg,
+	vm.DivTrunc, vm.ModTrunc, vm.DivFloor, vm.ModFloor,
+	vm.Eq, vm.NotEq, vm.Lt, vm.Gt, vm.LtEq, vm.GtEq,
+	vm.BitwiseAnd, vm.BitwiseOr, vm.BitwiseXor,
+)
+
+// Effects returns the stack effects of the opcodes as declared in the opcode table.
+// The opcodes must not have a variable stack effect.
+func Effects(ops ...byte) map[byte]Effect {
+	effects := make(map[byte]Effect, len(ops))
+	for _, op := range ops {
+		effects[op] = Effect{Pops: vm.OpCodes[op].Pops, Pushes: vm.OpCodes[op].Pushes}
+	}
+	return effects
+}
+
+// Config controls the generated programs.
+type Config struct {
+	Effects         map[byte]Effect // Opcodes to choose from, DefaultEffects if nil
+	MaxLength       int             // Maximum number of instructions without the final Halt
+	MaxIntegerSize  int             // Maximum number of bytes of pushed integers without the sign byte
+	Jumps           bool            // Emit forward jumps and conditional jumps
+	UnderflowChance int             // One in UnderflowChance opcodes is emitted without enough operands, never if 0
+}
+
+// DefaultConfig returns the configuration of valid programs with up to 30 instructions, including jumps.
+func DefaultConfig() Config {
+	return Config{Effects: DefaultEffects, MaxLength: 30, MaxIntegerSize: 3, Jumps: true}
+}
+
+type jump struct {
+	argument int // Offset of the jump address in the code
+	depth    int // Stack depth after the jump popped its condition
+}
+
+type generator struct {
+	r       *rand.Rand
+	config  Config
+	ops     []byte
+	code    []byte
+	depth   int
+	offsets []int // Offsets of the instructions
+	depths  []int // Minimal stack depths before the instructions
+	jumps   []jump
+}
+
+// Program generates a random program, which ends with Halt.
+func Program(r *rand.Rand, config Config) []byte {
+	if config.Effects == nil {
+		config.Effects = DefaultEffects
+	}
+
+	g := generator{r: r, config: config}
+	for op := 0; op < 256; op++ {
+		if _, ok := config.Effects[byte(op)]; ok {
+			g.ops = append(g.ops, byte(op))
+		}
+	}
+
+	length := 0
+	if config.MaxLength > 0 {
+		length = r.Intn(config.MaxLength + 1)
+	}
+
+	for i := 0; i < length; i++ {
+		switch {
+		case config.Jumps && r.Intn(10) == 0:
+			g.emitJump()
+		case len(g.ops) == 0 || r.Intn(5) == 0:
+			g.emitPush()
+		default:
+			g.emitOp(g.ops[r.Intn(len(g.ops))])
+		}
+	}
+
+	g.mark()
+	g.code = append(g.code, vm.Halt)
+	g.resolveJumps()
+	return g.code
+}
+
+// mark records the offset and the stack depth of the next instruction
+func (g *generator) mark() {
+	g.offsets = append(g.offsets, len(g.code))
+	g.depths = append(g.depths, g.depth)
+}
+
+func (g *generator) emitPush() {
+	g.mark()
+	size := 0
+	if g.config.MaxIntegerSize > 0 {
+		size = g.r.Intn(g.config.MaxIntegerSize + 1)
+	}
+
+	g.code = append(g.code, vm.PushInt, byte(size))
+	if size > 0 {
+		g.code = append(g.code, byte(g.r.Intn(2)))
+		for i := 0; i < size; i++ {
+			g.code = append(g.code, byte(g.r.Intn(256)))
+		}
+	}
+	g.depth++
+}
+
+// emitOp pushes operands first, unless an underflow is chosen
+func (g *generator) emitOp(op byte) {
+	effect := g.config.Effects[op]
+	underflow := g.config.UnderflowChance > 0 && g.r.Intn(g.config.UnderflowChance) == 0
+	if underflow && g.depth < effect.Pops {
+		// The execution fails here, the remaining depths are never reached. The instruction is marked with its
+		// actual depth, so jumps emitted before can still land on it.
+		g.mark()
+		g.code = append(g.code, op)
+		g.depth = effect.Pushes
+		return
+	}
+	for g.depth < effect.Pops {
+		g.emitPush()
+	}
+
+	g.mark()
+	g.code = append(g.code, op)
+	g.depth += effect.Pushes - effect.Pops
+}
+
+// emitJump emits a jump with a placeholder address, which is resolved when the code is complete
+func (g *generator) emitJump() {
+	ops := []byte{vm.Jmp, vm.JmpTrue, vm.JmpFalse}
+	op := ops[g.r.Intn(len(ops))]
+	if op != vm.Jmp && g.depth == 0 {
+		g.emitPush()
+	}
+
+	g.mark()
+	if op != vm.Jmp {
+		g.depth--
+	}
+	g.code = append(g.code, op, 0, 0)
+	g.jumps = append(g.jumps, jump{argument: len(g.code) - 2, depth: g.depth})
+}
+
+// resolveJumps lets every jump land on a later instruction, which requires at most the stack depth at the jump.
+// Jumping only forward guarantees termination, the instruction directly after the jump is always a candidate.
+func (g *generator) resolveJumps() {
+	for _, j := range g.jumps {
+		var candidates []int
+		for i, offset := range g.offsets {
+			if offset > j.argument && g.depths[i] <= j.depth {
+				candidates = append(candidates, offset)
+			}
+		}
+
+		target := candidates[g.r.Intn(len(candidates))]
+		g.code[j.argument] = byte(target >> 8)
+		g.code[j.argument+1] = byte(target)
+	}
+}
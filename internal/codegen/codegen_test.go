@@ -0,0 +1,99 @@
+package codegen
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+// Errors, which structurally valid programs may cause depending on the pushed values
+var runtimeErrors = []string{"out of gas", "division by zero", "integer overflow"}
+
+func exec(code []byte) (bool, string) {
+	mc := vm.NewMockContext(code)
+	mc.Fee = 100000
+	machine := vm.NewVM(mc)
+	machine.SetConfig(vm.VMConfig{CheckIntegrity: true})
+
+	if machine.Exec(false) {
+		return true, ""
+	}
+	return false, machine.GetErrorMsg()
+}
+
+func isRuntimeError(msg string) bool {
+	for _, err := range runtimeErrors {
+		if strings.Contains(msg, err) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProgram_Valid(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 2000; i++ {
+		code := Program(r, DefaultConfig())
+		if ok, msg := exec(code); !ok && !isRuntimeError(msg) {
+			t.Fatalf("Code %v failed with '%v'", code, msg)
+		}
+	}
+}
+
+func TestProgram_EndsWithHalt(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+
+	for i := 0; i < 100; i++ {
+		code := Program(r, DefaultConfig())
+		assert.Equal(t, code[len(code)-1], byte(vm.Halt))
+	}
+}
+
+func TestProgram_Deterministic(t *testing.T) {
+	first := Program(rand.New(rand.NewSource(3)), DefaultConfig())
+	second := Program(rand.New(rand.NewSource(3)), DefaultConfig())
+	assert.DeepEqual(t, first, second)
+}
+
+func TestProgram_Jumps(t *testing.T) {
+	config := Config{Effects: map[byte]Effect{}, MaxLength: 50, Jumps: true}
+	code := Program(rand.New(rand.NewSource(4)), config)
+
+	jumps := 0
+	for pc := 0; pc < len(code); {
+		switch code[pc] {
+		case vm.PushInt:
+			size := int(code[pc+1])
+			if size > 0 {
+				size++
+			}
+			pc += 2 + size
+		case vm.Jmp, vm.JmpTrue, vm.JmpFalse:
+			target := int(code[pc+1])<<8 | int(code[pc+2])
+			assert.Assert(t, target > pc && target < len(code))
+			jumps++
+			pc += 3
+		default:
+			assert.Equal(t, code[pc], byte(vm.Halt))
+			pc++
+		}
+	}
+	assert.Assert(t, jumps > 0)
+}
+
+func TestProgram_Underflow(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	config := Config{Effects: map[byte]Effect{vm.Add: {2, 1}}, MaxLength: 10, UnderflowChance: 1}
+
+	underflows := 0
+	for i := 0; i < 100; i++ {
+		if ok, msg := exec(Program(r, config)); !ok && strings.Contains(msg, "stack underflow") {
+			underflows++
+		}
+	}
+	assert.Assert(t, underflows > 0)
+}
@@ -0,0 +1,382 @@
+// Package reference contains a naive interpreter for a subset of the Bazo bytecode.
+// It is written to be obviously correct rather than fast and serves as reference for differential tests of the VM.
+// Every value is decoded and encoded again for each instruction, nothing is shared or cached.
+package reference
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+const maxIntegerSize = vm.DefaultMaxIntegerSize
+
+// Result is the outcome of an execution.
+type Result struct {
+	Success bool
+	Error   string   // Error message, if the execution failed
+	Stack   [][]byte // Top of stack first
+	Fee     uint64   // Remaining fee
+}
+
+// Supported lists the opcodes, which are implemented by the reference interpreter.
+// Halt is free, the price of all other opcodes is 1. The growth of the stack beyond its peak is charged with the
+// default memory price per started 64 bytes.
+var Supported = []byte{
+	vm.PushInt, vm.Dup, vm.Swap, vm.Pop,
+	vm.Add, vm.Sub, vm.Mul, vm.Div, vm.Mod, vm.Min, vm.Max, vm.Abs,
+	vm.DivTrunc, vm.ModTrunc, vm.DivFloor, vm.ModFloor,
+	vm.Eq, vm.Lt, vm.Gt,
+	vm.Halt,
+}
+
+// Gas factors per started 64 bytes of the popped elements, if not 2.
+// The gas factor of PushInt is charged per byte of its immediate integer instead.
+var gasFactors = map[byte]uint64{
+	vm.PushInt: 1,
+	vm.Pop:     1,
+}
+
+type interpreter struct {
+	code  []byte
+	pc    int
+	fee   uint64
+	stack [][]byte
+	peak  int // Maximum size of the stack in bytes
+}
+
+type failure string
+
+// Exec interprets the code. Unsupported opcodes are reported as failure.
+func Exec(code []byte, fee uint64) (result Result) {
+	in := &interpreter{code: code, fee: fee}
+
+	defer func() {
+		if r := recover(); r != nil {
+			f, ok := r.(failure)
+			if !ok {
+				panic(r)
+			}
+			result = Result{Error: string(f), Fee: in.fee}
+		}
+	}()
+
+	in.run()
+	return Result{Success: true, Stack: in.topFirst(), Fee: in.fee}
+}
+
+func (in *interpreter) run() {
+	intrinsicGas := uint64((len(in.code) + 63) / 64)
+	if in.fee < intrinsicGas {
+		panic(failure("vm.exec(): out of gas"))
+	}
+	in.fee -= intrinsicGas
+
+	for {
+		if in.pc >= len(in.code) {
+			panic(failure("vm.exec(): instruction set out of bounds"))
+		}
+		op := in.code[in.pc]
+		in.pc++
+
+		if !isSupported(op) {
+			panic(failure("reference: unsupported opcode"))
+		}
+
+		if op == vm.Halt {
+			return
+		}
+
+		if in.fee < 1 {
+			panic(failure("vm.exec(): out of gas"))
+		}
+		in.fee--
+
+		// The stack depth is checked against the declared stack effect before the instruction is executed
+		if len(in.stack) < vm.OpCodes[op].Pops {
+			panic(failure(fmt.Sprintf("%v: stack underflow at pc=%v", vm.OpCodes[op].Name, in.pc-1)))
+		}
+
+		in.step(op, vm.OpCodes[op].Name)
+		in.chargeMemory(vm.OpCodes[op].Name)
+	}
+}
+
+// chargeMemory charges the words, by which the size of the stack exceeds its peak
+func (in *interpreter) chargeMemory(name string) {
+	size := 0
+	for _, value := range in.stack {
+		size += len(value)
+	}
+	if size <= in.peak {
+		return
+	}
+
+	cost := vm.DefaultMemoryGasPrice * uint64((size+63)/64-(in.peak+63)/64)
+	in.peak = size
+	if in.fee < cost {
+		in.fail(name, "out of gas")
+	}
+	in.fee -= cost
+}
+
+func (in *interpreter) step(op byte, name string) {
+	factor := gasFactor(op)
+
+	switch op {
+	case vm.PushInt:
+		if in.pc >= len(in.code) {
+			in.fail(name, "instruction set out of bounds")
+		}
+		length := int(in.code[in.pc])
+		in.pc++
+
+		if length == 0 {
+			in.push([]byte{0})
+			return
+		}
+
+		// Sign byte and length bytes, at least one more byte has to follow
+		if len(in.code)-in.pc <= length+1 {
+			in.fail(name, "instruction set out of bounds")
+		}
+
+		cost := factor * uint64(length+1)
+		if in.fee < cost {
+			in.fail(name, "out of gas")
+		}
+		in.fee -= cost
+
+		in.push(in.code[in.pc : in.pc+length+1])
+		in.pc += length + 1
+
+	case vm.Dup:
+		value, err := in.pop(factor)
+		in.check(name, err)
+		in.push(value)
+		in.push(value)
+
+	case vm.Swap:
+		last, err1 := in.pop(0)
+		secondLast, err2 := in.pop(0)
+		in.check(name, err1, err2)
+		in.push(last)
+		in.push(secondLast)
+
+	case vm.Pop:
+		_, err := in.pop(factor)
+		in.check(name, err)
+
+	case vm.Add, vm.Sub, vm.Mul, vm.Div, vm.Mod, vm.Min, vm.Max, vm.DivTrunc, vm.ModTrunc, vm.DivFloor, vm.ModFloor:
+		right, err1 := in.popInt(factor)
+		left, err2 := in.popInt(factor)
+		in.check(name, err1, err2)
+		in.push(encode(in.arithmetic(op, name, left, right)))
+
+	case vm.Abs:
+		value, err := in.popInt(factor)
+		in.check(name, err)
+		in.push(encode(new(big.Int).Abs(value)))
+
+	case vm.Eq:
+		right, err1 := in.pop(factor)
+		left, err2 := in.pop(factor)
+		in.check(name, err1, err2)
+		in.push(boolean(bytes.Equal(left, right)))
+
+	case vm.Lt, vm.Gt:
+		right, err1 := in.pop(factor)
+		left, err2 := in.pop(factor)
+		in.check(name, err1, err2)
+
+		var result int
+		if len(left) == 1 && len(right) == 1 {
+			result = bytes.Compare(left, right)
+		} else {
+			leftInt, err1 := decode(left)
+			rightInt, err2 := decode(right)
+			in.check(name, err2, err1)
+			result = leftInt.Cmp(rightInt)
+		}
+
+		if op == vm.Lt {
+			in.push(boolean(result == -1))
+		} else {
+			in.push(boolean(result == 1))
+		}
+	}
+}
+
+func (in *interpreter) arithmetic(op byte, name string, left *big.Int, right *big.Int) *big.Int {
+	result := new(big.Int)
+
+	switch op {
+	case vm.Add:
+		result.Add(left, right)
+	case vm.Sub:
+		result.Sub(left, right)
+	case vm.Mul:
+		cost := gasFactor(op) * words(left) * words(right)
+		if in.fee < cost {
+			in.fail(name, "out of gas")
+		}
+		in.fee -= cost
+		result.Mul(left, right)
+	case vm.Div, vm.Mod:
+		if right.Sign() == 0 {
+			in.fail(name, "division by zero")
+		}
+		// Euclidean division, the remainder is never negative
+		if op == vm.Div {
+			result.Div(left, right)
+		} else {
+			result.Mod(left, right)
+		}
+		return result
+	case vm.DivTrunc, vm.ModTrunc:
+		if right.Sign() == 0 {
+			in.fail(name, "division by zero")
+		}
+		// Truncated division, the remainder has the sign of the dividend
+		if op == vm.DivTrunc {
+			result.Quo(left, right)
+		} else {
+			result.Rem(left, right)
+		}
+		return result
+	case vm.DivFloor, vm.ModFloor:
+		if right.Sign() == 0 {
+			in.fail(name, "division by zero")
+		}
+		// Floored division, the remainder has the sign of the divisor
+		quotient, remainder := new(big.Int).QuoRem(left, right, new(big.Int))
+		if remainder.Sign() != 0 && remainder.Sign() != right.Sign() {
+			quotient.Sub(quotient, big.NewInt(1))
+			remainder.Add(remainder, right)
+		}
+		if op == vm.DivFloor {
+			return quotient
+		}
+		return remainder
+	case vm.Min:
+		result.Set(left)
+		if right.Cmp(left) < 0 {
+			result.Set(right)
+		}
+	case vm.Max:
+		result.Set(left)
+		if right.Cmp(left) > 0 {
+			result.Set(right)
+		}
+	}
+
+	if len(result.Bytes()) > maxIntegerSize {
+		in.fail(name, "integer overflow, result exceeds 256 bytes")
+	}
+	return result
+}
+
+func (in *interpreter) push(value []byte) {
+	in.stack = append(in.stack, append([]byte{}, value...))
+}
+
+// pop removes the top element and charges the gas factor for every started 64 bytes
+func (in *interpreter) pop(factor uint64) ([]byte, error) {
+	if len(in.stack) == 0 {
+		return nil, failure("pop() on empty stack")
+	}
+
+	value := in.stack[len(in.stack)-1]
+	in.stack = in.stack[:len(in.stack)-1]
+
+	cost := factor * uint64((len(value)+63)/64)
+	if in.fee < cost {
+		return nil, failure("out of gas")
+	}
+	in.fee -= cost
+	return value, nil
+}
+
+func (in *interpreter) popInt(factor uint64) (*big.Int, error) {
+	value, err := in.pop(factor)
+	if err != nil {
+		return nil, err
+	}
+	return decode(value)
+}
+
+// check fails with the first error, after all operands have been popped
+func (in *interpreter) check(name string, errs ...error) {
+	for _, err := range errs {
+		if err != nil {
+			in.fail(name, err.Error())
+		}
+	}
+}
+
+func (in *interpreter) fail(name string, message string) {
+	panic(failure(name + ": " + message))
+}
+
+func (in *interpreter) topFirst() [][]byte {
+	result := make([][]byte, len(in.stack))
+	for i := range in.stack {
+		result[i] = in.stack[len(in.stack)-1-i]
+	}
+	return result
+}
+
+func (f failure) Error() string {
+	return string(f)
+}
+
+func isSupported(op byte) bool {
+	return bytes.IndexByte(Supported, op) != -1
+}
+
+func gasFactor(op byte) uint64 {
+	if factor, ok := gasFactors[op]; ok {
+		return factor
+	}
+	return 2
+}
+
+// words returns the number of started 64 byte words, but at least 1
+func words(value *big.Int) uint64 {
+	size := len(value.Bytes())
+	if size == 0 {
+		return 1
+	}
+	return uint64((size + 63) / 64)
+}
+
+// decode converts a sign byte followed by the big-endian magnitude into an integer
+func decode(value []byte) (*big.Int, error) {
+	if len(value) == 0 || value[0] > 1 {
+		return nil, failure("invalid signing bit")
+	}
+
+	result := new(big.Int).SetBytes(value[1:])
+	if value[0] == 1 {
+		result.Neg(result)
+	}
+	return result, nil
+}
+
+// encode converts an integer into a sign byte followed by the big-endian magnitude
+func encode(value *big.Int) []byte {
+	sign := byte(0)
+	if value.Sign() == -1 {
+		sign = 1
+	}
+	return append([]byte{sign}, new(big.Int).Abs(value).Bytes()...)
+}
+
+func boolean(value bool) []byte {
+	if value {
+		return []byte{1}
+	}
+	return []byte{0}
+}
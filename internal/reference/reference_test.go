@@ -0,0 +1,107 @@
+package reference
+
+import (
+	"bytes"
+	"flag"
+	"math/rand"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/internal/codegen"
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+var iterations = flag.Int("reference.iterations", 2000, "number of random programs executed by the differential test")
+
+type lastStep struct {
+	step *vm.TraceStep
+}
+
+func (l *lastStep) CaptureStep(step vm.TraceStep) {
+	l.step = &step
+}
+
+// supportedEffects returns the stack effects of the supported opcodes without PushInt and Halt
+func supportedEffects() map[byte]codegen.Effect {
+	effects := make(map[byte]codegen.Effect)
+	for _, op := range Supported {
+		if effect, ok := codegen.DefaultEffects[op]; ok {
+			effects[op] = effect
+		}
+	}
+	return effects
+}
+
+// randomProgram generates a program of supported opcodes, which mostly keeps enough operands on the stack
+func randomProgram(r *rand.Rand) []byte {
+	return codegen.Program(r, codegen.Config{Effects: supportedEffects(), MaxLength: 30, MaxIntegerSize: 3, UnderflowChance: 10})
+}
+
+func execVM(code []byte, fee uint64) Result {
+	mc := vm.NewMockContext(code)
+	mc.Fee = fee
+	machine := vm.NewVM(mc)
+
+	tracer := &lastStep{}
+	machine.SetTracer(tracer)
+	if !machine.Exec(false) {
+		return Result{Error: machine.GetErrorMsg()}
+	}
+	return Result{Success: true, Stack: tracer.step.Stack, Fee: tracer.step.GasAfter}
+}
+
+func equalStacks(a [][]byte, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReference_Differential(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < *iterations; i++ {
+		code := randomProgram(r)
+		fee := uint64(5 + r.Intn(120))
+
+		expected := Exec(code, fee)
+		actual := execVM(code, fee)
+
+		if expected.Success != actual.Success || expected.Error != actual.Error {
+			t.Fatalf("Code %v with fee %v: expected '%v' (%v), but VM returned '%v' (%v)",
+				code, fee, expected.Error, expected.Success, actual.Error, actual.Success)
+		}
+
+		if expected.Success && (!equalStacks(expected.Stack, actual.Stack) || expected.Fee != actual.Fee) {
+			t.Fatalf("Code %v with fee %v: expected stack %v and fee %v, but VM returned %v and %v",
+				code, fee, expected.Stack, expected.Fee, actual.Stack, actual.Fee)
+		}
+	}
+}
+
+func TestReference_Exec(t *testing.T) {
+	code := []byte{
+		vm.PushInt, 1, 0, 7,
+		vm.PushInt, 1, 1, 2,
+		vm.Div,
+		vm.Halt,
+	}
+
+	result := Exec(code, 50)
+	assert.Assert(t, result.Success)
+	assert.Equal(t, len(result.Stack), 1)
+	assert.DeepEqual(t, result.Stack[0], []byte{1, 3})
+	assert.Equal(t, result.Fee, uint64(50-1-3-2*2-4-vm.DefaultMemoryGasPrice))
+}
+
+func TestReference_Exec_Error(t *testing.T) {
+	result := Exec([]byte{vm.PushInt, 1, 0, 7, vm.Add, vm.Halt}, 50)
+	assert.Assert(t, !result.Success)
+	assert.Equal(t, result.Error, "add: stack underflow at pc=4")
+}
@@ -0,0 +1,152 @@
+// Package stackviz renders the evolution of the evaluation stack and the call stack of an execution, which is easier
+// to follow than the textual trace, e.g. in workshops and when triaging bugs.
+//
+// The input is the structured trace written by vm.JSONTracer, e.g. by `bazovm trace`. WriteHTML renders it as a
+// timeline with a row per step, WriteDOT as Graphviz graph with a node per step.
+package stackviz
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+// ReadTrace reads the steps of a trace written by vm.JSONTracer.
+func ReadTrace(r io.Reader) ([]vm.TraceStep, error) {
+	var steps []vm.TraceStep
+	decoder := json.NewDecoder(r)
+	for decoder.More() {
+		var step vm.TraceStep
+		if err := decoder.Decode(&step); err != nil {
+			return nil, fmt.Errorf("step %v: %v", len(steps), err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// element is an element of the evaluation stack in a rendered step
+type element struct {
+	Value   string
+	Changed bool // The element was pushed or modified by the step
+}
+
+// row is a step prepared for rendering
+type row struct {
+	vm.TraceStep
+	Gas      uint64
+	Frames   []string
+	Elements []element // Top of stack first
+}
+
+// rows prepares the steps for rendering. An element is changed, if the element at the same depth from the bottom of
+// the stack differs from the previous step.
+func rows(steps []vm.TraceStep) []row {
+	rows := make([]row, len(steps))
+	var previous [][]byte
+	for i, step := range steps {
+		r := row{TraceStep: step, Frames: []string{"main"}}
+		if step.GasBefore > step.GasAfter {
+			r.Gas = step.GasBefore - step.GasAfter
+		}
+		for _, function := range step.CallStack {
+			r.Frames = append(r.Frames, fmt.Sprintf("fn@%04d", function))
+		}
+
+		for depth, value := range step.Stack {
+			fromBottom := len(step.Stack) - 1 - depth
+			previousDepth := len(previous) - 1 - fromBottom
+			changed := previousDepth < 0 || !bytes.Equal(previous[previousDepth], value)
+			r.Elements = append(r.Elements, element{Value: hex.EncodeToString(value), Changed: changed})
+		}
+		previous = step.Stack
+		rows[i] = r
+	}
+	return rows
+}
+
+var htmlTemplate = template.Must(template.New("timeline").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; text-align: left; vertical-align: top; }
+td.code, span.element { font-family: monospace; }
+span.element { display: inline-block; margin: 1px; padding: 1px 4px; background: #eef; }
+span.changed { background: #fd8; }
+span.frame { margin-right: 4px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><th>Step</th><th>PC</th><th>Opcode</th><th>Gas</th><th>Call stack</th><th>Evaluation stack (top first)</th><th>Source</th></tr>
+{{range .Rows}}<tr>
+<td>{{.Step}}</td>
+<td class="code">{{printf "%04d" .PC}}</td>
+<td class="code">{{.OpCode}}</td>
+<td>{{.Gas}}</td>
+<td>{{range $i, $f := .Frames}}{{if $i}} &gt; {{end}}<span class="frame">{{$f}}</span>{{end}}</td>
+<td>{{range .Elements}}<span class="element{{if .Changed}} changed{{end}}">{{.Value}}</span>{{end}}</td>
+<td>{{.Source}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTML writes the steps as HTML timeline. Elements pushed or modified by a step are highlighted.
+func WriteHTML(w io.Writer, title string, steps []vm.TraceStep) error {
+	return htmlTemplate.Execute(w, struct {
+		Title string
+		Rows  []row
+	}{title, rows(steps)})
+}
+
+// WriteDOT writes the steps as Graphviz graph, in which every step is a node with its call stack and its evaluation
+// stack, connected in the order of the execution. Elements pushed or modified by a step are marked with an asterisk.
+func WriteDOT(w io.Writer, title string, steps []vm.TraceStep) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", title)
+	b.WriteString("\tnode [shape=record, fontname=monospace];\n")
+
+	for i, r := range rows(steps) {
+		var elements []string
+		for _, e := range r.Elements {
+			if e.Changed {
+				elements = append(elements, "*"+e.Value)
+			} else {
+				elements = append(elements, e.Value)
+			}
+		}
+		if len(elements) == 0 {
+			elements = []string{"(empty)"}
+		}
+
+		label := fmt.Sprintf("{%v: %04d %v (%v gas)|%v|%v}", r.Step, r.PC, r.OpCode, r.Gas,
+			escapeRecord(strings.Join(r.Frames, " > ")), strings.Join(elements, "|"))
+		fmt.Fprintf(&b, "\ts%v [label=\"%v\"];\n", i, label)
+		if i > 0 {
+			fmt.Fprintf(&b, "\ts%v -> s%v;\n", i-1, i)
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeRecord escapes the characters with a special meaning in labels of record nodes
+func escapeRecord(label string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`)
+	return replacer.Replace(label)
+}
@@ -0,0 +1,67 @@
+package stackviz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+// trace executes the code and returns its steps read back from the JSON trace
+func trace(t *testing.T, code []byte) []vm.TraceStep {
+	var buffer bytes.Buffer
+	machine := vm.NewVM(vm.NewMockContext(code))
+	tracer := vm.NewJSONTracer(&buffer, 8)
+	machine.SetTracer(tracer)
+	assert.Assert(t, machine.Exec(false), machine.GetErrorMsg())
+	assert.NilError(t, tracer.Err())
+
+	steps, err := ReadTrace(&buffer)
+	assert.NilError(t, err)
+	return steps
+}
+
+var callCode = []byte{
+	vm.PushInt, 1, 0, 2,
+	vm.CallLocals, 0, 11, 0, 0, 0,
+	vm.Halt,
+	vm.PushInt, 1, 0, 3, // Begin of function at address 11
+	vm.Pop,
+	vm.Ret,
+}
+
+func TestStackviz_ReadTrace(t *testing.T) {
+	steps := trace(t, callCode)
+	assert.Equal(t, len(steps), 6)
+	assert.DeepEqual(t, steps[2].CallStack, []int{11})
+	assert.DeepEqual(t, steps[2].Stack, [][]byte{{0, 3}, {0, 2}})
+	assert.Equal(t, len(steps[4].CallStack), 0)
+
+	_, err := ReadTrace(strings.NewReader(`{"step": 0}{"step": "x"}`))
+	assert.ErrorContains(t, err, "step 1: ")
+}
+
+func TestStackviz_WriteHTML(t *testing.T) {
+	var out bytes.Buffer
+	assert.NilError(t, WriteHTML(&out, "call <test>", trace(t, callCode)))
+
+	html := out.String()
+	assert.Assert(t, strings.Contains(html, "<title>call &lt;test&gt;</title>"))
+	assert.Assert(t, strings.Contains(html, `<span class="frame">main</span> &gt; <span class="frame">fn@0011</span>`))
+	assert.Assert(t, strings.Contains(html,
+		`<span class="element changed">0003</span><span class="element">0002</span>`))
+}
+
+func TestStackviz_WriteDOT(t *testing.T) {
+	var out bytes.Buffer
+	assert.NilError(t, WriteDOT(&out, "call", trace(t, callCode)))
+
+	dot := out.String()
+	assert.Assert(t, strings.HasPrefix(dot, "digraph \"call\" {\n"))
+	assert.Assert(t, strings.Contains(dot, `s0 [label="{0: 0000 pushint (6 gas)|main|*0002}"];`), dot)
+	assert.Assert(t, strings.Contains(dot, `s2 [label="{2: 0011 pushint (3 gas)|main \> fn@0011|*0003|0002}"];`), dot)
+	assert.Assert(t, strings.Contains(dot, `s1 -> s2;`))
+	assert.Assert(t, strings.HasSuffix(dot, "}\n"))
+}
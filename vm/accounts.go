@@ -0,0 +1,158 @@
+package vm
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"golang.org/x/crypto/sha3"
+)
+
+// AccountContext is implemented by contexts, which provide the state of other accounts.
+// Contexts without this interface only provide the executing contract.
+type AccountContext interface {
+	// GetAccountCode returns the contract code of the account, which is empty for accounts without contract.
+	// It returns false, if the account does not exist.
+	GetAccountCode(address [32]byte) ([]byte, bool)
+
+	// AccountExists returns true, if the account exists.
+	AccountExists(address [32]byte) bool
+}
+
+// BalanceOfContext is implemented by contexts, which provide the balances of other accounts.
+// Contexts without this interface only provide the balance of the executing contract.
+type BalanceOfContext interface {
+	// GetAccountBalance returns the balance of the account. It returns false, if the account does not exist.
+	GetAccountBalance(address [64]byte) (uint64, bool)
+}
+
+// popBalanceOf pops a 64 byte address and returns the balance of the account as 8 byte little endian value.
+// The balance of the executing contract is resolved without the BalanceOfContext.
+func (vm *VM) popBalanceOf(opCode OpCode) ([]byte, error) {
+	address, err := vm.PopBytes(opCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(address) != 64 {
+		return nil, newError(ErrInvalidAddress)
+	}
+	var account [64]byte
+	copy(account[:], address)
+
+	var balance uint64
+	if account == vm.context.GetAddress() {
+		balance = vm.context.GetBalance()
+	} else {
+		balanceContext, ok := vm.context.(BalanceOfContext)
+		if !ok {
+			return nil, newError(ErrUnsupportedContext, opCode.Name)
+		}
+		balance, _ = balanceContext.GetAccountBalance(account)
+	}
+
+	value := uint64Bytes(balance)
+	vm.witness.record(WitnessBalance, protocol.SerializeHashContent(account), 0, value)
+	return value, nil
+}
+
+// ExtStateContext is implemented by contexts, which provide the contract variables of other accounts.
+// Contexts without this interface only provide the variables of the executing contract.
+type ExtStateContext interface {
+	// GetAccountVariable returns the contract variable of the account. It returns false, if the account does not
+	// exist or has no variable with the index.
+	GetAccountVariable(address [32]byte, index int) ([]byte, bool)
+}
+
+// popExtVariable pops an index and a 32 byte address and returns the contract variable of the account.
+// The variables of the executing contract are resolved without the ExtStateContext, including unpersisted writes.
+func (vm *VM) popExtVariable(opCode OpCode) ([]byte, error) {
+	i, err := vm.PopUnsignedBigInt(opCode)
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := vm.PopBytes(opCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(address) != 32 {
+		return nil, newError(ErrInvalidAddress)
+	}
+	var account [32]byte
+	copy(account[:], address)
+
+	// Variables are addressed by a byte like LoadSt
+	if !i.IsUint64() || i.Uint64() > 255 {
+		return nil, newError(ErrNoVariable, address, i.String())
+	}
+	index := int(i.Uint64())
+
+	if vm.isSelf(address) {
+		return vm.loadVariable(index)
+	}
+
+	stateContext, ok := vm.context.(ExtStateContext)
+	if !ok {
+		return nil, newError(ErrUnsupportedContext, opCode.Name)
+	}
+
+	value, exists := stateContext.GetAccountVariable(account, index)
+	if !exists {
+		return nil, newError(ErrNoVariable, address, index)
+	}
+	vm.witness.record(WitnessVariable, account, index, value)
+	return append([]byte{}, value...), nil
+}
+
+// popAccount pops a 32 byte address. It returns true as second value, if it is the address of the executing contract.
+// Otherwise the context must provide other accounts.
+func (vm *VM) popAccount(opCode OpCode) ([32]byte, bool, AccountContext, error) {
+	var account [32]byte
+	address, err := vm.PopBytes(opCode)
+	if err != nil {
+		return account, false, nil, err
+	}
+
+	if len(address) != 32 {
+		return account, false, nil, newError(ErrInvalidAddress)
+	}
+	copy(account[:], address)
+
+	if vm.isSelf(address) {
+		return account, true, nil, nil
+	}
+
+	accountContext, ok := vm.context.(AccountContext)
+	if !ok {
+		return account, false, nil, newError(ErrUnsupportedContext, opCode.Name)
+	}
+	return account, false, accountContext, nil
+}
+
+// popAccountCode pops a 32 byte address and returns the code of the account.
+// The code of the executing contract is resolved without the context.
+func (vm *VM) popAccountCode(opCode OpCode) ([]byte, bool, error) {
+	account, isSelf, accountContext, err := vm.popAccount(opCode)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if isSelf {
+		return vm.contract, true, nil
+	}
+
+	code, exists := accountContext.GetAccountCode(account)
+	vm.witness.record(WitnessAccount, account, 0, BoolToByteArray(exists))
+	vm.witness.record(WitnessCode, account, 0, code)
+	return code, exists, nil
+}
+
+// codeHash returns the SHA3 hash of the code, the hash of a nonexistent account consists of zeros
+func codeHash(code []byte, exists bool) []byte {
+	if !exists {
+		return make([]byte, 32)
+	}
+
+	hasher := sha3.New256()
+	hasher.Write(code)
+	return hasher.Sum(nil)
+}
@@ -0,0 +1,237 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"golang.org/x/crypto/sha3"
+	"gotest.tools/assert"
+)
+
+// plainContext only provides the methods of Context
+type plainContext struct {
+	Context
+}
+
+func pushAddress(code []byte, address [32]byte) []byte {
+	return push(code, Push, address[:])
+}
+
+func TestAccounts_CodeSize(t *testing.T) {
+	vm, isSuccess := execCode([]byte{CodeSize, Halt})
+	assert.Assert(t, isSuccess)
+
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0, 2)
+}
+
+func TestAccounts_CodeHash(t *testing.T) {
+	code := []byte{CodeHash, Halt}
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+
+	expected := sha3.Sum256(code)
+	tos, _ := vm.evaluationStack.Pop()
+	assert.DeepEqual(t, tos, expected[:])
+}
+
+func TestAccounts_ExtCode(t *testing.T) {
+	other := [32]byte{7}
+	otherCode := []byte{PushInt, 0, Halt}
+	missing := [32]byte{8}
+
+	code := pushAddress(nil, other)
+	code = append(code, ExtCodeSize)
+	code = pushAddress(code, other)
+	code = append(code, ExtCodeHash)
+	code = pushAddress(code, missing)
+	code = append(code, ExtCodeSize)
+	code = pushAddress(code, missing)
+	code = append(code, ExtCodeHash)
+	code = pushAddress(code, protocol.SerializeHashContent(selfAccount))
+	code = append(code, ExtCodeSize, Halt)
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Address = selfAccount
+	mc.Accounts = map[[32]byte][]byte{other: otherCode}
+	mc.Fee = 1000
+	vm.context = mc
+	assert.Assert(t, vm.Exec(false))
+
+	stack := vm.PeekEvalStack()
+	assert.Equal(t, len(stack), 5)
+	assertBytes(t, stack[0], 0, 3)
+	expected := sha3.Sum256(otherCode)
+	assert.DeepEqual(t, stack[1], expected[:])
+	assertBytes(t, stack[2], 0)
+	assert.DeepEqual(t, stack[3], make([]byte, 32))
+	assertBytes(t, stack[4], 0, byte(len(code)))
+}
+
+func TestAccounts_ExtCodeSize_UnsupportedContext(t *testing.T) {
+	vm := NewTestVM([]byte{})
+	vm.context = plainContext{NewMockContext(append(pushAddress(nil, [32]byte{7}), ExtCodeSize, Halt))}
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "extcodesize: context does not support extcodesize")
+}
+
+func TestAccounts_ExtCodeSize_InvalidAddress(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushInt, 1, 0, 2, ExtCodeSize, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "extcodesize: not a valid address")
+}
+
+func TestAccounts_AccountExists(t *testing.T) {
+	contract := [32]byte{7}
+	wallet := [32]byte{8}
+	missing := [32]byte{9}
+
+	var code []byte
+	for _, address := range [][32]byte{contract, wallet, missing, protocol.SerializeHashContent(selfAccount)} {
+		code = pushAddress(code, address)
+		code = append(code, AccountExists)
+		code = pushAddress(code, address)
+		code = append(code, IsContract)
+	}
+	code = append(code, Halt)
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Address = selfAccount
+	mc.Accounts = map[[32]byte][]byte{contract: {Halt}, wallet: nil}
+	mc.Fee = 1000
+	vm.context = mc
+	assert.Assert(t, vm.Exec(false))
+
+	stack := vm.PeekEvalStack()
+	expected := []byte{
+		1, 1, // Contract
+		1, 0, // Wallet
+		0, 0, // Missing
+		1, 1, // Self
+	}
+	assert.Equal(t, len(stack), len(expected))
+	for i, value := range expected {
+		assertBytes(t, stack[i], value)
+	}
+}
+
+func TestAccounts_AccountExists_UnsupportedContext(t *testing.T) {
+	vm := NewTestVM([]byte{})
+	vm.context = plainContext{NewMockContext(append(pushAddress(nil, [32]byte{7}), AccountExists, Halt))}
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "accountexists: context does not support accountexists")
+}
+
+func pushAccountAddress(code []byte, address [64]byte) []byte {
+	return push(code, Push, address[:])
+}
+
+func TestAccounts_BalanceOf(t *testing.T) {
+	other := [64]byte{7}
+	missing := [64]byte{8}
+
+	code := pushAccountAddress(nil, other)
+	code = append(code, BalanceOf)
+	code = pushAccountAddress(code, missing)
+	code = append(code, BalanceOf)
+	code = pushAccountAddress(code, selfAccount)
+	code = append(code, BalanceOf, Halt)
+
+	mc := NewMockContext(code)
+	mc.Address = selfAccount
+	mc.Balance = 300
+	mc.Balances = map[[64]byte]uint64{other: 1000}
+	mc.Fee = 1000
+	vm := NewVM(mc)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{
+		vm.uint64Value(uint64Bytes(1000)),
+		vm.uint64Value(uint64Bytes(0)),
+		vm.uint64Value(uint64Bytes(300)),
+	})
+
+	entries := vm.GetWitness().Entries()
+	assert.Equal(t, len(entries), 4)
+}
+
+func TestAccounts_BalanceOf_Errors(t *testing.T) {
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(append(pushAccountAddress(nil, [64]byte{7}), BalanceOf, Halt))
+	mc.Fee = 1000
+	vm.context = plainContext{mc}
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "balanceof: context does not support balanceof")
+
+	invalid, isSuccess := execCode(append(pushAddress(nil, [32]byte{7}), BalanceOf, Halt))
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, invalid.GetErrorMsg(), "balanceof: not a valid address")
+}
+
+func TestAccounts_ExtLoadSt(t *testing.T) {
+	registry := [32]byte{7}
+
+	code := pushAddress(nil, registry)
+	code = append(code, PushInt, 1, 0, 1, ExtLoadSt)
+	code = append(code, PushInt, 1, 0, 4, StoreSt, 0)
+	code = pushAddress(code, protocol.SerializeHashContent(selfAccount))
+	code = append(code, PushInt, 0, ExtLoadSt, Halt)
+
+	mc := NewMockContext(code)
+	mc.Address = selfAccount
+	mc.ContractVariables = [][]byte{{0, 9}}
+	mc.Variables = map[[32]byte][][]byte{registry: {{0, 5}, {1, 2, 3}}}
+	mc.Fee = 100000
+	vm := NewVM(mc)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+
+	// The variable of the executing contract includes the unpersisted write
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{1, 2, 3}, {0, 4}})
+
+	// The witness contains the variable of the registry besides the one overwritten by StoreSt
+	found := false
+	for _, entry := range vm.GetWitness().Entries() {
+		if entry.Account == registry {
+			assert.DeepEqual(t, entry, WitnessEntry{Kind: WitnessVariable, Account: registry, Index: 1, Value: []byte{1, 2, 3}})
+			found = true
+		}
+	}
+	assert.Assert(t, found)
+}
+
+func TestAccounts_ExtLoadSt_Errors(t *testing.T) {
+	registry := [32]byte{7}
+
+	tests := []struct {
+		code []byte
+		err  string
+	}{
+		{append(pushAddress(nil, registry), PushInt, 1, 0, 2, ExtLoadSt, Halt),
+			"extloadst: account 0700000000000000000000000000000000000000000000000000000000000000 has no variable 2"},
+		{append(pushAddress(nil, [32]byte{8}), PushInt, 0, ExtLoadSt, Halt),
+			"extloadst: account 0800000000000000000000000000000000000000000000000000000000000000 has no variable 0"},
+		{append(pushAddress(nil, registry), PushInt, 2, 0, 1, 0, ExtLoadSt, Halt),
+			"extloadst: account 0700000000000000000000000000000000000000000000000000000000000000 has no variable 256"},
+		{[]byte{PushInt, 1, 0, 7, PushInt, 0, ExtLoadSt, Halt}, "extloadst: not a valid address"},
+	}
+
+	for _, test := range tests {
+		mc := NewMockContext(test.code)
+		mc.Variables = map[[32]byte][][]byte{registry: {{0, 5}}}
+		mc.Fee = 1000
+		vm := NewVM(mc)
+		assert.Assert(t, !vm.Exec(false))
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(append(pushAddress(nil, registry), PushInt, 0, ExtLoadSt, Halt))
+	mc.Fee = 1000
+	vm.context = plainContext{mc}
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "extloadst: context does not support extloadst")
+}
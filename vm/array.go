@@ -1,36 +1,30 @@
 package vm
 
-import (
-	"errors"
-)
-
 type action func(array *Array, index uint16, elementSize uint16) ([]byte, error)
 type Array []byte
 
 func NewArray() Array {
-	ba := []byte{0x02}
-	size := []byte{0x00, 0x00}
-	return append(ba, size...)
+	return ArrayHeader{}.Marshal()
 }
 
 func ArrayFromByteArray(arr []byte) (Array, error) {
-	if len(arr) == 0 {
-		return Array{}, errors.New("not a valid array")
+	var header ArrayHeader
+	if err := header.Unmarshal(arr); err != nil {
+		return Array{}, err
 	}
-
-	if arr[0] != 0x02 {
-		return Array{}, errors.New("not a valid array")
+	if err := validateContainer(arr, 1); err != nil {
+		return Array{}, err
 	}
 	return Array(arr), nil
 }
 
 func (a *Array) GetSize() (uint16, error) {
 	if len(*a) < 3 {
-		return 0, errors.New("not a valid array")
+		return 0, newError(ErrInvalidArray)
 	}
 	value, err := ByteArrayToUI16((*a)[1:3])
 	if err != nil {
-		return 0, errors.New("cannot get size of array")
+		return 0, newError(ErrArraySize)
 	}
 	return value, nil
 }
@@ -45,7 +39,7 @@ func (a *Array) setSize(ba []byte) {
 func (a *Array) IncrementSize() error {
 	s, err := a.GetSize()
 	if err != nil {
-		return errors.New("could not increase size")
+		return newError(ErrArrayIncreaseSize)
 	}
 	s++
 	a.setSize(UInt16ToByteArray(s))
@@ -59,7 +53,7 @@ func (a *Array) DecrementSize() error {
 	}
 
 	if s <= 0 {
-		return errors.New("Array size is already 0")
+		return newError(ErrArrayEmpty)
 	}
 	s--
 	a.setSize(UInt16ToByteArray(s))
@@ -74,7 +68,7 @@ func (a *Array) At(index uint16) ([]byte, error) {
 	return result, err
 }
 
-// Insert sets an element at a certain index of the array
+// Insert sets an element at a certain index of the array. The array is modified in place.
 func (a *Array) Insert(index uint16, element []byte) error {
 	// First remove the current element at the index
 	err := a.Remove(index)
@@ -94,11 +88,16 @@ func (a *Array) Insert(index uint16, element []byte) error {
 
 	} else {
 		var f action = func(array *Array, i uint16, s uint16) ([]byte, error) {
-			tmp := Array{}
-			tmp = append(tmp, (*a)[:i]...)
-			err := tmp.Append(element)
-			*a = append(tmp, (*a)[i:]...)
-			return []byte{}, err
+			if len(element) > int(UINT16_MAX) {
+				return []byte{}, newError(ErrElementSizeOverflow)
+			}
+
+			// The elements after the index are moved in place to make room for the element
+			entry := append(UInt16ToByteArray(uint16(len(element))), element...)
+			*a = append(*a, entry...)
+			copy((*a)[int(i)+len(entry):], (*a)[i:len(*a)-len(entry)])
+			copy((*a)[i:], entry)
+			return []byte{}, a.IncrementSize()
 		}
 		_, err = a.goToIndex(index, f)
 	}
@@ -110,7 +109,7 @@ func (a *Array) Append(ba []byte) error {
 	length := len(ba)
 
 	if length > int(UINT16_MAX) {
-		return errors.New("Element Size overflow")
+		return newError(ErrElementSizeOverflow)
 	}
 
 	sb := UInt16ToByteArray(uint16(len(ba)))
@@ -119,13 +118,11 @@ func (a *Array) Append(ba []byte) error {
 	return err
 }
 
-// Remove removes the element with the given index from the array
+// Remove removes the element with the given index from the array. The array is modified in place.
 func (a *Array) Remove(index uint16) error {
 	// This function actually removes the element at the given index from the array
 	var f action = func(array *Array, k uint16, s uint16) ([]byte, error) {
-		tmp := Array{}
-		tmp = append(tmp, (*a)[:k]...)
-		*a = append(tmp, (*a)[k+2+s:]...)
+		*a = append((*a)[:k], (*a)[k+2+s:]...)
 		return []byte{}, nil
 	}
 	_, err := a.goToIndex(index, f)
@@ -146,7 +143,7 @@ func (a *Array) goToIndex(index uint16, f action) ([]byte, error) {
 	}
 
 	if size < index {
-		return []byte{}, errors.New("array index out of bounds")
+		return []byte{}, newError(ErrArrayIndexOutOfBounds)
 	}
 
 	var currentElement uint16 = 0
@@ -167,5 +164,57 @@ func (a *Array) goToIndex(index uint16, f action) ([]byte, error) {
 		indexOnByteArray += 2 + elementSize
 	}
 
-	return []byte{}, errors.New("array internals error")
+	return []byte{}, newError(ErrArrayInternals)
+}
+
+// Fill replaces every element of the array with the element, the size of the array is not changed
+func (a *Array) Fill(element []byte) error {
+	if len(element) > int(UINT16_MAX) {
+		return newError(ErrElementSizeOverflow)
+	}
+
+	size, err := a.GetSize()
+	if err != nil {
+		return err
+	}
+
+	filled := make(Array, 3, 3+int(size)*(2+len(element)))
+	copy(filled, (*a)[:3])
+	for i := 0; i < int(size); i++ {
+		filled = append(filled, UInt16ToByteArray(uint16(len(element)))...)
+		filled = append(filled, element...)
+	}
+	*a = filled
+	return nil
+}
+
+// Resize truncates the array to the size or appends elements initialized with one byte like NewArr
+func (a *Array) Resize(size uint16) error {
+	current, err := a.GetSize()
+	if err != nil {
+		return err
+	}
+
+	if size >= current {
+		for i := current; i < size; i++ {
+			*a = append(*a, 0, 1, 0)
+		}
+		a.setSize(UInt16ToByteArray(size))
+		return nil
+	}
+
+	offset := 3
+	for i := uint16(0); i < size; i++ {
+		if offset+2 > len(*a) {
+			return newError(ErrArrayInternals)
+		}
+		offset += 2 + (int((*a)[offset])<<8 | int((*a)[offset+1]))
+	}
+	if offset > len(*a) {
+		return newError(ErrArrayInternals)
+	}
+
+	*a = (*a)[:offset]
+	a.setSize(UInt16ToByteArray(size))
+	return nil
 }
@@ -159,3 +159,38 @@ func TestArray_Remove(t *testing.T) {
 		t.Errorf("Invalid Array Size, Expected 1 after appending 2 elements and removing one but got %v", size)
 	}
 }
+
+func TestArray_Fill(t *testing.T) {
+	a := NewArray()
+	a.Append([]byte{1})
+	a.Append([]byte{2, 3})
+
+	err := a.Fill([]byte{7, 7})
+	expected := []byte{0x02, 0x00, 0x02, 0x00, 0x02, 7, 7, 0x00, 0x02, 7, 7}
+	if err != nil || !bytes.Equal(a, expected) {
+		t.Errorf("Expected %v after fill but got %v (%v)", expected, a, err)
+	}
+}
+
+func TestArray_Resize(t *testing.T) {
+	a := NewArray()
+	a.Append([]byte{1})
+	a.Append([]byte{2, 3})
+
+	err := a.Resize(4)
+	expected := []byte{0x02, 0x00, 0x04, 0x00, 0x01, 1, 0x00, 0x02, 2, 3, 0x00, 0x01, 0, 0x00, 0x01, 0}
+	if err != nil || !bytes.Equal(a, expected) {
+		t.Errorf("Expected %v after growing but got %v (%v)", expected, a, err)
+	}
+
+	err = a.Resize(1)
+	expected = []byte{0x02, 0x00, 0x01, 0x00, 0x01, 1}
+	if err != nil || !bytes.Equal(a, expected) {
+		t.Errorf("Expected %v after shrinking but got %v (%v)", expected, a, err)
+	}
+
+	err = a.Resize(0)
+	if err != nil || !bytes.Equal(a, NewArray()) {
+		t.Errorf("Expected empty array but got %v (%v)", a, err)
+	}
+}
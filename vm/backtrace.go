@@ -0,0 +1,35 @@
+package vm
+
+// BacktraceFrame is a call frame of the function path, which led to the failed instruction.
+type BacktraceFrame struct {
+	Function      int    `json:"function"`         // Address of the called function
+	CallAddress   int    `json:"callAddress"`      // Address of the call instruction
+	ReturnAddress int    `json:"returnAddress"`    // Address, at which the caller continues
+	NrOfArgs      int    `json:"nrOfArgs"`         // Number of arguments declared by the call
+	Source        string `json:"source,omitempty"` // Source location of the call, if a source map is set
+}
+
+// GetErrorBacktrace returns the frames of the call stack, the innermost call first. After a failed execution they
+// show the path of function calls to the failed instruction, which is resolved by GetErrorLocation.
+// It is empty, if the instruction failed outside of a function.
+func (vm *VM) GetErrorBacktrace() []BacktraceFrame {
+	if vm.callStack == nil {
+		return nil
+	}
+
+	var backtrace []BacktraceFrame
+	for i := len(vm.callStack.values) - 1; i >= 0; i-- {
+		frame := vm.callStack.values[i]
+		entry := BacktraceFrame{
+			Function:      frame.function,
+			CallAddress:   frame.callAddress,
+			ReturnAddress: frame.returnAddress,
+			NrOfArgs:      frame.nrOfArgs,
+		}
+		if location, ok := vm.sourceLocation(frame.callAddress); ok {
+			entry.Source = location.String()
+		}
+		backtrace = append(backtrace, entry)
+	}
+	return backtrace
+}
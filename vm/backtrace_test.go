@@ -0,0 +1,70 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// backtraceCode calls f(7) at 4, which calls g(a, 0) at 15, which fails dividing by zero at 26
+var backtraceCode = []byte{
+	PushInt, 1, 0, 7,
+	CallLocals, 0, 11, 1, 0, 1,
+	Halt,
+	// f
+	LoadLoc, 0,
+	PushInt, 0,
+	CallLocals, 0, 22, 2, 0, 2,
+	Ret,
+	// g
+	LoadLoc, 0,
+	LoadLoc, 1,
+	Div,
+	Ret,
+}
+
+func TestBacktrace_NestedCalls(t *testing.T) {
+	for _, engine := range Engines {
+		mc := NewMockContext(backtraceCode)
+		mc.Fee = 1000
+		vm := NewVMWithConfig(mc, VMConfig{Engine: engine})
+		vm.SetSourceMap(NewSourceMap([]SourceMapEntry{
+			{Offset: 4, SourceLocation: SourceLocation{File: "a.lazo", Line: 2, Column: 1}},
+			{Offset: 15, SourceLocation: SourceLocation{File: "a.lazo", Line: 5, Column: 3}},
+			{Offset: 21, SourceLocation: SourceLocation{File: "a.lazo", Line: 6, Column: 1}},
+		}))
+		assert.Assert(t, !vm.Exec(false))
+
+		assert.DeepEqual(t, vm.GetErrorBacktrace(), []BacktraceFrame{
+			{Function: 22, CallAddress: 15, ReturnAddress: 21, NrOfArgs: 2, Source: "a.lazo:5:3"},
+			{Function: 11, CallAddress: 4, ReturnAddress: 10, NrOfArgs: 1, Source: "a.lazo:2:1"},
+		})
+	}
+}
+
+func TestBacktrace_CallFnAndTailCall(t *testing.T) {
+	// main calls f by CallFn at 0, f tail calls g(1) at 11, which fails on the stack underflow at 17.
+	// The tail call replaces the frame of f, the call site stays the one of f.
+	f := Function{Hash: FunctionHash("f"), Address: 7, NrOfArgs: 0, NrOfReturnTypes: 0, NrOfLocals: 0}
+	code := append([]byte{CallFn}, f.Hash[:]...)
+	code = append(code, 0,
+		Halt,
+		// f
+		PushInt, 1, 0, 1,
+		TailCall, 0, 17, 1, 0, 1,
+		// g
+		Add,
+	)
+
+	vm, isSuccess := execCode(append(NewFunctionTable([]Function{f}), code...))
+	assert.Assert(t, !isSuccess)
+	assert.DeepEqual(t, vm.GetErrorBacktrace(), []BacktraceFrame{
+		{Function: 17, CallAddress: 0, ReturnAddress: 6, NrOfArgs: 1},
+	})
+}
+
+func TestBacktrace_OutsideOfFunctions(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushInt, 1, 0, 1, Add, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, len(vm.GetErrorBacktrace()), 0)
+}
@@ -0,0 +1,109 @@
+package vm
+
+import (
+	"math"
+	"math/big"
+)
+
+// BlockContext is implemented by contexts, which provide the block, in which the transaction is executed.
+// Contexts without this interface do not support opcodes depending on the block.
+type BlockContext interface {
+	// GetBlockTime returns the timestamp of the block in seconds since the Unix epoch.
+	GetBlockTime() uint64
+
+	// GetBlockInterval returns the target time between two blocks in seconds, which is a parameter of the chain.
+	GetBlockInterval() uint64
+}
+
+// requireBefore pops a deadline as block time and fails, if the block time is past the deadline
+func (vm *VM) requireBefore(opCode OpCode) error {
+	deadline, err := vm.PopSignedBigInt(opCode)
+	if err != nil {
+		return err
+	}
+
+	blockContext, ok := vm.context.(BlockContext)
+	if !ok {
+		return newError(ErrUnsupportedContext, opCode.Name)
+	}
+
+	blockTime := blockContext.GetBlockTime()
+	if new(big.Int).SetUint64(blockTime).Cmp(&deadline) > 0 {
+		return newError(ErrDeadlinePassed, deadline.String(), blockTime)
+	}
+	return nil
+}
+
+// popTime pops a block height or a duration, which must fit into an uint64
+func (vm *VM) popTime(opCode OpCode) (uint64, error) {
+	bigInt, err := vm.PopSignedBigInt(opCode)
+	if err != nil {
+		return 0, err
+	}
+
+	if bigInt.Sign() < 0 || !bigInt.IsUint64() {
+		return 0, newError(ErrInvalidTime)
+	}
+	return bigInt.Uint64(), nil
+}
+
+func (vm *VM) pushTime(value uint64) error {
+	return vm.evaluationStack.Push(SignedByteArrayConversion(*new(big.Int).SetUint64(value)))
+}
+
+// timeArithmetic pops two block heights or durations and pushes their sum or difference
+func (vm *VM) timeArithmetic(opCode OpCode) error {
+	right, rerr := vm.popTime(opCode)
+	left, lerr := vm.popTime(opCode)
+	if rerr != nil {
+		return rerr
+	}
+	if lerr != nil {
+		return lerr
+	}
+
+	switch {
+	case opCode.Code == AddDuration && left > math.MaxUint64-right:
+		return newError(ErrTimeOverflow)
+	case opCode.Code == AddDuration:
+		return vm.pushTime(left + right)
+	case left < right:
+		return newError(ErrTimeUnderflow)
+	default:
+		return vm.pushTime(left - right)
+	}
+}
+
+// convertDuration pops a duration and converts it between blocks and seconds with the block interval of the chain.
+// Seconds are rounded up to whole blocks, so a duration in blocks never ends before the duration in seconds.
+func (vm *VM) convertDuration(opCode OpCode) error {
+	duration, err := vm.popTime(opCode)
+	if err != nil {
+		return err
+	}
+
+	blockContext, ok := vm.context.(BlockContext)
+	if !ok {
+		return newError(ErrUnsupportedContext, opCode.Name)
+	}
+
+	interval := blockContext.GetBlockInterval()
+	if interval == 0 {
+		return newError(ErrInvalidBlockInterval)
+	}
+
+	if opCode.Code == SecondsToBlocks {
+		return vm.pushTime(duration/interval + boolToUint64(duration%interval != 0))
+	}
+	if duration > math.MaxUint64/interval {
+		return newError(ErrTimeOverflow)
+	}
+	return vm.pushTime(duration * interval)
+}
+
+func boolToUint64(value bool) uint64 {
+	if value {
+		return 1
+	}
+	return 0
+}
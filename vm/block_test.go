@@ -0,0 +1,98 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestBlock_RequireBefore(t *testing.T) {
+	tests := []struct {
+		deadline  []byte
+		isSuccess bool
+	}{
+		{[]byte{PushInt, 2, 0, 0x03, 0xe9}, true},
+		{[]byte{PushInt, 2, 0, 0x03, 0xe8}, true},
+		{[]byte{PushInt, 2, 0, 0x03, 0xe7}, false},
+		{[]byte{PushInt, 1, 1, 1}, false},
+		{append([]byte{PushInt, 9, 0, 1}, make([]byte, 8)...), true},
+	}
+
+	for _, test := range tests {
+		mc := NewMockContext(append(append([]byte{}, test.deadline...), RequireBefore, Halt))
+		mc.BlockTime = 1000
+
+		vm := NewVM(mc)
+		assert.Equal(t, vm.Exec(false), test.isSuccess, vm.GetErrorMsg())
+	}
+}
+
+func TestBlock_RequireBefore_Errors(t *testing.T) {
+	mc := NewMockContext([]byte{PushInt, 1, 0, 5, RequireBefore, Halt})
+	mc.BlockTime = 6
+
+	vm := NewVM(mc)
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "requirebefore: deadline 5 passed at block time 6")
+
+	vm = NewTestVM([]byte{})
+	vm.context = plainContext{NewMockContext([]byte{PushInt, 1, 0, 5, RequireBefore, Halt})}
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "requirebefore: context does not support requirebefore")
+}
+
+func TestBlock_DurationArithmetic(t *testing.T) {
+	tests := []struct {
+		code     []byte
+		expected []byte
+	}{
+		{[]byte{PushInt, 1, 0, 200, PushInt, 1, 0, 100, AddDuration}, []byte{0, 1, 44}},
+		{[]byte{PushInt, 1, 0, 200, PushInt, 1, 0, 100, SubDuration}, []byte{0, 100}},
+		{[]byte{PushInt, 1, 0, 100, PushInt, 1, 0, 100, SubDuration}, []byte{0}},
+		{[]byte{PushInt, 1, 0, 3, BlocksToSeconds}, []byte{0, 30}},
+		{[]byte{PushInt, 1, 0, 30, SecondsToBlocks}, []byte{0, 3}},
+		{[]byte{PushInt, 1, 0, 31, SecondsToBlocks}, []byte{0, 4}},
+		{[]byte{PushInt, 0, SecondsToBlocks}, []byte{0}},
+	}
+
+	for _, test := range tests {
+		mc := NewMockContext(append(append([]byte{}, test.code...), Halt))
+		mc.BlockInterval = 10
+
+		vm := NewVM(mc)
+		assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.expected...)
+	}
+}
+
+func TestBlock_DurationArithmetic_Errors(t *testing.T) {
+	maxUint64 := []byte{PushInt, 8, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	tests := []struct {
+		code     []byte
+		interval uint64
+		err      string
+	}{
+		{append(append([]byte{}, maxUint64...), PushInt, 1, 0, 1, AddDuration), 10,
+			"addduration: block height or duration overflow"},
+		{[]byte{PushInt, 1, 0, 1, PushInt, 1, 0, 2, SubDuration}, 10, "subduration: block height or duration underflow"},
+		{[]byte{PushInt, 1, 0, 1, PushInt, 1, 1, 2, AddDuration}, 10,
+			"addduration: block height or duration must be between 0 and 2^64-1"},
+		{append(append([]byte{}, maxUint64...), BlocksToSeconds), 10, "blockstoseconds: block height or duration overflow"},
+		{[]byte{PushInt, 1, 0, 1, SecondsToBlocks}, 0, "secondstoblocks: block interval must be positive"},
+	}
+
+	for _, test := range tests {
+		mc := NewMockContext(append(append([]byte{}, test.code...), Halt))
+		mc.BlockInterval = test.interval
+
+		vm := NewVM(mc)
+		assert.Assert(t, !vm.Exec(false))
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+	}
+
+	vm := NewTestVM([]byte{})
+	vm.context = plainContext{NewMockContext([]byte{PushInt, 1, 0, 5, BlocksToSeconds, Halt})}
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "blockstoseconds: context does not support blockstoseconds")
+}
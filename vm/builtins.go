@@ -0,0 +1,140 @@
+package vm
+
+import (
+	"math/big"
+)
+
+// Built-in library routines, which are called with CallBuiltin and the index of the routine.
+// The arguments are signed integers and are popped in reverse order, i.e. the first argument is pushed first.
+// All divisions round toward zero.
+const (
+	// BuiltinPercentOf (value, basisPoints) computes value * basisPoints / 10000.
+	BuiltinPercentOf = iota
+	// BuiltinMulDiv (a, b, c) computes a * b / c without limiting the size of the intermediate product.
+	BuiltinMulDiv
+	// BuiltinCompoundInterest (principal, rateBasisPoints, periods) accrues the interest of every period and
+	// rounds down after each period. The arguments must not be negative.
+	BuiltinCompoundInterest
+	// BuiltinSaturatingAdd (a, b) computes a + b and clamps the result to the maximum integer size.
+	BuiltinSaturatingAdd
+	// BuiltinSaturatingSub (a, b) computes a - b and clamps the result to the maximum integer size.
+	BuiltinSaturatingSub
+	// BuiltinSaturatingMul (a, b) computes a * b and clamps the result to the maximum integer size.
+	BuiltinSaturatingMul
+)
+
+// Basis points of 100 percent
+var basisPoints = big.NewInt(10000)
+
+// Builtin contains the name, number of arguments and gas price of a built-in routine.
+// The gas price is charged in addition to the gas of CallBuiltin.
+type Builtin struct {
+	Name     string
+	Nargs    int
+	gasPrice uint64
+	exec     func(vm *VM, args []*big.Int) (*big.Int, error)
+}
+
+// Builtins contains all built-in routines
+var Builtins = []Builtin{
+	{"percentof", 2, 2, percentOf},
+	{"muldiv", 3, 2, mulDiv},
+	{"compoundinterest", 3, 2, compoundInterest},
+	{"saturatingadd", 2, 1, saturatingAdd},
+	{"saturatingsub", 2, 1, saturatingSub},
+	{"saturatingmul", 2, 2, saturatingMul},
+}
+
+// callBuiltin charges the gas of the routine, pops its arguments and executes it
+func (vm *VM) callBuiltin(opCode OpCode, builtin Builtin) (*big.Int, error) {
+	if vm.fee < builtin.gasPrice {
+		return nil, newError(ErrOutOfGas)
+	}
+	vm.fee -= builtin.gasPrice
+
+	args := make([]*big.Int, builtin.Nargs)
+	for i := builtin.Nargs - 1; i >= 0; i-- {
+		arg, err := vm.PopSignedBigInt(opCode)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = &arg
+	}
+
+	result, err := builtin.exec(vm, args)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := vm.checkIntegerSize(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func percentOf(vm *VM, args []*big.Int) (*big.Int, error) {
+	result := new(big.Int).Mul(args[0], args[1])
+	return result.Quo(result, basisPoints), nil
+}
+
+func mulDiv(vm *VM, args []*big.Int) (*big.Int, error) {
+	if args[2].Sign() == 0 {
+		return nil, newError(ErrDivisionByZero)
+	}
+
+	result := new(big.Int).Mul(args[0], args[1])
+	return result.Quo(result, args[2]), nil
+}
+
+func compoundInterest(vm *VM, args []*big.Int) (*big.Int, error) {
+	principal, rate, periods := args[0], args[1], args[2]
+	if principal.Sign() == -1 || rate.Sign() == -1 || periods.Sign() == -1 {
+		return nil, newError(ErrNegativeOperand)
+	}
+
+	// Every period costs 1 gas, which bounds the number of iterations
+	if !periods.IsUint64() || vm.fee < periods.Uint64() {
+		return nil, newError(ErrOutOfGas)
+	}
+	vm.fee -= periods.Uint64()
+
+	result := new(big.Int).Set(principal)
+	interest := new(big.Int)
+	for i := uint64(0); i < periods.Uint64(); i++ {
+		interest.Mul(result, rate)
+		interest.Quo(interest, basisPoints)
+		result.Add(result, interest)
+
+		if err := vm.checkIntegerSize(result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func saturatingAdd(vm *VM, args []*big.Int) (*big.Int, error) {
+	return vm.saturate(new(big.Int).Add(args[0], args[1])), nil
+}
+
+func saturatingSub(vm *VM, args []*big.Int) (*big.Int, error) {
+	return vm.saturate(new(big.Int).Sub(args[0], args[1])), nil
+}
+
+func saturatingMul(vm *VM, args []*big.Int) (*big.Int, error) {
+	return vm.saturate(new(big.Int).Mul(args[0], args[1])), nil
+}
+
+// saturate clamps the value to the largest magnitude, which fits into the maximum integer size
+func (vm *VM) saturate(value *big.Int) *big.Int {
+	max := new(big.Int).Lsh(big.NewInt(1), uint(8*vm.maxIntegerSize))
+	max.Sub(max, big.NewInt(1))
+
+	if value.CmpAbs(max) <= 0 {
+		return value
+	}
+
+	if value.Sign() == -1 {
+		return max.Neg(max)
+	}
+	return max
+}
@@ -0,0 +1,135 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func execBuiltin(t *testing.T, builtin byte, args ...int64) (*VM, bool) {
+	var code []byte
+	for _, arg := range args {
+		value := SignedByteArrayConversion(*big.NewInt(arg))
+		if arg == 0 {
+			code = append(code, PushInt, 0)
+			continue
+		}
+		code = append(code, PushInt, byte(len(value)-1))
+		code = append(code, value...)
+	}
+	code = append(code, CallBuiltin, builtin, Halt)
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Fee = 1000
+	vm.context = mc
+	return &vm, vm.Exec(false)
+}
+
+func assertBuiltinResult(t *testing.T, vm *VM, expected int64) {
+	result, err := vm.PopSignedBigInt(OpCodes[CallBuiltin])
+	assert.NilError(t, err)
+	assert.Equal(t, result.Int64(), expected)
+}
+
+func TestBuiltins_PercentOf(t *testing.T) {
+	vm, isSuccess := execBuiltin(t, BuiltinPercentOf, 1999, 250)
+	assert.Assert(t, isSuccess)
+	assertBuiltinResult(t, vm, 49)
+
+	vm, isSuccess = execBuiltin(t, BuiltinPercentOf, -1999, 250)
+	assert.Assert(t, isSuccess)
+	assertBuiltinResult(t, vm, -49)
+}
+
+func TestBuiltins_MulDiv(t *testing.T) {
+	vm, isSuccess := execBuiltin(t, BuiltinMulDiv, 7, 5, 3)
+	assert.Assert(t, isSuccess)
+	assertBuiltinResult(t, vm, 11)
+}
+
+func TestBuiltins_MulDiv_IntermediateOverflow(t *testing.T) {
+	large := new(big.Int).Lsh(big.NewInt(1), 2000)
+	value := SignedByteArrayConversion(*large)
+
+	code := []byte{PushInt, byte(len(value) - 1)}
+	code = append(code, value...)
+	code = append(code, PushInt, byte(len(value)-1))
+	code = append(code, value...)
+	code = append(code, PushInt, byte(len(value)-1))
+	code = append(code, value...)
+	code = append(code, CallBuiltin, BuiltinMulDiv, Halt)
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Fee = 1000
+	vm.context = mc
+	assert.Assert(t, vm.Exec(false))
+
+	result, err := vm.PopSignedBigInt(OpCodes[CallBuiltin])
+	assert.NilError(t, err)
+	assert.Equal(t, result.Cmp(large), 0)
+}
+
+func TestBuiltins_MulDiv_DivisionByZero(t *testing.T) {
+	vm, isSuccess := execBuiltin(t, BuiltinMulDiv, 7, 5, 0)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "callbuiltin: division by zero")
+}
+
+func TestBuiltins_CompoundInterest(t *testing.T) {
+	// 1000 at 5% for 3 periods: 1050, 1102, 1157
+	vm, isSuccess := execBuiltin(t, BuiltinCompoundInterest, 1000, 500, 3)
+	assert.Assert(t, isSuccess)
+	assertBuiltinResult(t, vm, 1157)
+}
+
+func TestBuiltins_CompoundInterest_Negative(t *testing.T) {
+	vm, isSuccess := execBuiltin(t, BuiltinCompoundInterest, 1000, -500, 3)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "callbuiltin: negative operands are not allowed")
+}
+
+func TestBuiltins_CompoundInterest_OutOfGas(t *testing.T) {
+	vm, isSuccess := execBuiltin(t, BuiltinCompoundInterest, 1000, 500, 5000)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "callbuiltin: out of gas")
+}
+
+func TestBuiltins_Saturating(t *testing.T) {
+	vm, isSuccess := execBuiltin(t, BuiltinSaturatingAdd, 2, 3)
+	assert.Assert(t, isSuccess)
+	assertBuiltinResult(t, vm, 5)
+
+	vm, isSuccess = execBuiltin(t, BuiltinSaturatingSub, 2, 3)
+	assert.Assert(t, isSuccess)
+	assertBuiltinResult(t, vm, -1)
+
+	vm = &VM{maxIntegerSize: 1}
+	assert.Equal(t, vm.saturate(big.NewInt(300)).Int64(), int64(255))
+	assert.Equal(t, vm.saturate(big.NewInt(-300)).Int64(), int64(-255))
+	assert.Equal(t, vm.saturate(big.NewInt(-20)).Int64(), int64(-20))
+}
+
+func TestBuiltins_SaturatingMul(t *testing.T) {
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext([]byte{
+		PushInt, 1, 0, 200,
+		PushInt, 1, 1, 200,
+		CallBuiltin, BuiltinSaturatingMul,
+		Halt,
+	})
+	vm.context = mc
+	vm.SetMaxIntegerSize(1)
+	assert.Assert(t, vm.Exec(false))
+
+	result, _ := vm.PopSignedBigInt(OpCodes[CallBuiltin])
+	assert.Equal(t, result.Int64(), int64(-255))
+}
+
+func TestBuiltins_Unknown(t *testing.T) {
+	vm, isSuccess := execCode([]byte{CallBuiltin, 200, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "callbuiltin: unknown built-in 200")
+}
@@ -1,14 +1,70 @@
 package vm
 
 import (
-	"errors"
+	"math/big"
 )
 
+// CallByReference is set in the number of arguments of Call, CallTrue, CallLocals and CallTrueLocals to pass the
+// arguments by reference. They stay on the evaluation stack below the frame of the callee, which reads them through its
+// locals without copying or charging them, and are removed when the callee returns. TailCall rejects the flag, since
+// it replaces the frame.
+const CallByReference = 0x80
+
 type Frame struct {
-	variables       map[int][]byte
+	variables       [][]byte
 	nrOfReturnTypes int
 	returnAddress   int
 	evalStackOffset int
+	references      []bool // Arguments passed by reference, true while the local aliases its stack slot
+	function        int    // Address of the called function
+	callAddress     int    // Address of the call instruction
+	nrOfArgs        int
+	undeclared      bool // The call did not declare the locals, they are created when they are stored
+}
+
+// LocalIndexError is returned if a local variable outside of the declared locals of a frame is accessed.
+type LocalIndexError struct {
+	Index      int
+	NrOfLocals int
+}
+
+func (e *LocalIndexError) Error() string {
+	return newError(ErrLocalIndexOutOfBounds, e.Index, e.NrOfLocals).Error()
+}
+
+// isDeclared returns true, if the local can be accessed. Frames of calls, which do not declare their locals, accept
+// every index.
+func (f *Frame) isDeclared(index int) bool {
+	return index >= 0 && (index < len(f.variables) || f.undeclared)
+}
+
+func (f *Frame) getVariable(index int) ([]byte, error) {
+	if !f.isDeclared(index) {
+		return nil, &LocalIndexError{Index: index, NrOfLocals: len(f.variables)}
+	}
+
+	if index >= len(f.variables) || f.variables[index] == nil {
+		return nil, newError(ErrLocalNotInitialized, index)
+	}
+	return f.variables[index], nil
+}
+
+// setVariable sets the local and returns its previous value
+func (f *Frame) setVariable(index int, value []byte) ([]byte, error) {
+	if !f.isDeclared(index) {
+		return nil, &LocalIndexError{Index: index, NrOfLocals: len(f.variables)}
+	}
+
+	if index >= len(f.variables) {
+		f.variables = append(f.variables, make([][]byte, index+1-len(f.variables))...)
+	}
+
+	previous := f.variables[index]
+	f.variables[index] = value
+	if index < len(f.references) {
+		f.references[index] = false
+	}
+	return previous, nil
 }
 
 type CallStack struct {
@@ -33,12 +89,131 @@ func (cs *CallStack) Pop() (frame *Frame, err error) {
 		cs.values = cs.values[:cs.GetLength()-1]
 		return element, nil
 	}
-	return nil, errors.New("pop() on empty callStack")
+	return nil, newError(ErrPopOnEmptyCallStack)
 }
 
 func (cs *CallStack) Peek() (frame *Frame, err error) {
 	if (*cs).GetLength() > 0 {
 		return (*cs).values[cs.GetLength()-1], nil
 	}
-	return nil, errors.New("peek() on empty callStack")
+	return nil, newError(ErrPeekOnEmptyCallStack)
+}
+
+// PeekAt returns the frame at the given depth without removing it, depth 0 is the current frame.
+func (cs *CallStack) PeekAt(depth int) (frame *Frame, err error) {
+	if depth < 0 || depth >= (*cs).GetLength() {
+		return nil, newError(ErrIndexOutOfBounds)
+	}
+	return (*cs).values[cs.GetLength()-1-depth], nil
+}
+
+// memoryUsage returns the size of the local variables of all frames in bytes. Locals aliasing arguments passed by
+// reference are counted on the evaluation stack.
+func (cs *CallStack) memoryUsage() int {
+	size := 0
+	for _, frame := range cs.values {
+		for i, variable := range frame.variables {
+			if i >= len(frame.references) || !frame.references[i] {
+				size += len(variable)
+			}
+		}
+	}
+	return size
+}
+
+// loadArguments pops the arguments of a call into the locals of the frame. Arguments passed by reference stay on the
+// evaluation stack and are aliased by the locals, so a container passed by reference is copied, when it is modified.
+func (vm *VM) loadArguments(opCode OpCode, frame *Frame, argsToLoad byte) error {
+	if argsToLoad&CallByReference == 0 {
+		for i := int(argsToLoad) - 1; i >= 0; i-- {
+			value, err := vm.popValue(opCode)
+			if err != nil {
+				return err
+			}
+			frame.variables[i] = value
+		}
+		frame.evalStackOffset = len(vm.evaluationStack.Stack)
+		return nil
+	}
+
+	nrOfArgs := int(argsToLoad &^ CallByReference)
+	stack := vm.evaluationStack
+	if stack.GetLength() < nrOfArgs {
+		return newError(ErrPopOnEmptyStack)
+	}
+
+	frame.references = make([]bool, nrOfArgs)
+	for i := 0; i < nrOfArgs; i++ {
+		value := stack.Stack[stack.GetLength()-nrOfArgs+i]
+		vm.objects.retain(value)
+		frame.variables[i] = value
+		frame.references[i] = true
+	}
+	frame.evalStackOffset = stack.GetLength()
+	return nil
+}
+
+// call executes Call, CallTrue, CallLocals and CallTrueLocals. The conditional calls pop the condition after the
+// arguments of the instruction are fetched. Call and CallTrue do not declare the locals of the callee, they are created
+// when they are stored, like in the initial bytecode format.
+func (vm *VM) call(opCode OpCode) bool {
+	returnAddressBytes, errArg1 := vm.fetchMany(opCode.Name, 2) // Shows where to jump after executing
+	argsToLoad, errArg2 := vm.fetch(opCode.Name)                // Shows how many elements have to be popped from evaluationStack
+	nrOfReturnTypesByte, errArg3 := vm.fetch(opCode.Name)
+
+	declaresLocals := opCode.Code == CallLocals || opCode.Code == CallTrueLocals
+	var nrOfLocalsByte byte
+	var errArg4 error
+	if declaresLocals {
+		nrOfLocalsByte, errArg4 = vm.fetch(opCode.Name) // Shows how many local variables (including arguments) the function declares
+	}
+
+	if !vm.checkErrors(opCode.Name, errArg1, errArg2, errArg3, errArg4) {
+		return false
+	}
+
+	if opCode.Code == CallTrue || opCode.Code == CallTrueLocals {
+		right, err := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return false
+		}
+		if !ByteArrayToBool(right) {
+			return true
+		}
+	}
+
+	var returnAddress big.Int
+	returnAddress.SetBytes(returnAddressBytes)
+
+	if int(returnAddress.Int64()) == 0 || int(returnAddress.Int64()) > len(vm.code) {
+		vm.pushError(opCode, newError(ErrReturnAddressOutOfBounds))
+		return false
+	}
+
+	nrOfArgs := argsToLoad &^ CallByReference
+	if !declaresLocals {
+		nrOfLocalsByte = nrOfArgs
+	} else if nrOfLocalsByte < nrOfArgs {
+		vm.pushError(opCode, newError(ErrLessLocalsThanArguments))
+		return false
+	}
+
+	frame := &Frame{
+		returnAddress:   vm.pc,
+		variables:       make([][]byte, nrOfLocalsByte),
+		nrOfReturnTypes: int(nrOfReturnTypesByte),
+		function:        int(returnAddress.Int64()),
+		callAddress:     vm.instructionPC,
+		nrOfArgs:        int(nrOfArgs),
+		undeclared:      !declaresLocals,
+	}
+
+	if err := vm.loadArguments(opCode, frame, argsToLoad); err != nil {
+		vm.pushError(opCode, err)
+		return false
+	}
+
+	vm.callStack.Push(frame)
+	vm.pc = int(returnAddress.Int64())
+	return true
 }
@@ -4,6 +4,8 @@ import (
 	"math/big"
 	"reflect"
 	"testing"
+
+	"gotest.tools/assert"
 )
 
 func TestCallStack_NewCallStack(t *testing.T) {
@@ -16,10 +18,10 @@ func TestCallStack_NewCallStack(t *testing.T) {
 func TestCallStack_Push(t *testing.T) {
 	cs := NewCallStack()
 
-	variables := map[int][]byte{
-		0: SignedByteArrayConversion(*big.NewInt(int64(4))),
-		1: SignedByteArrayConversion(*big.NewInt(int64(5))),
-		2: SignedByteArrayConversion(*big.NewInt(int64(6))),
+	variables := [][]byte{
+		SignedByteArrayConversion(*big.NewInt(int64(4))),
+		SignedByteArrayConversion(*big.NewInt(int64(5))),
+		SignedByteArrayConversion(*big.NewInt(int64(6))),
 	}
 
 	cs.Push(&Frame{variables: variables, returnAddress: 3})
@@ -37,19 +39,19 @@ func TestCallStack_Push(t *testing.T) {
 func TestCallStack_MultiplePushPop(t *testing.T) {
 	cs := NewCallStack()
 
-	variables1 := map[int][]byte{
-		0: SignedByteArrayConversion(*big.NewInt(int64(4))),
+	variables1 := [][]byte{
+		SignedByteArrayConversion(*big.NewInt(int64(4))),
 	}
 
-	variables2 := map[int][]byte{
-		0: SignedByteArrayConversion(*big.NewInt(int64(4))),
-		1: SignedByteArrayConversion(*big.NewInt(int64(5))),
+	variables2 := [][]byte{
+		SignedByteArrayConversion(*big.NewInt(int64(4))),
+		SignedByteArrayConversion(*big.NewInt(int64(5))),
 	}
 
-	variables3 := map[int][]byte{
-		0: SignedByteArrayConversion(*big.NewInt(int64(4))),
-		1: SignedByteArrayConversion(*big.NewInt(int64(5))),
-		2: SignedByteArrayConversion(*big.NewInt(int64(6))),
+	variables3 := [][]byte{
+		SignedByteArrayConversion(*big.NewInt(int64(4))),
+		SignedByteArrayConversion(*big.NewInt(int64(5))),
+		SignedByteArrayConversion(*big.NewInt(int64(6))),
 	}
 
 	cs.Push(&Frame{variables: variables1, returnAddress: 0})
@@ -73,3 +75,127 @@ func TestCallStack_MultiplePushPop(t *testing.T) {
 		t.Errorf("Expected variables popped to be %v but got %v", variables1, topOfStack)
 	}
 }
+
+func TestFrame_GetVariable_OutOfBounds(t *testing.T) {
+	frame := &Frame{variables: make([][]byte, 2)}
+
+	_, err := frame.getVariable(2)
+	if _, ok := err.(*LocalIndexError); !ok {
+		t.Errorf("Expected LocalIndexError but got %v", err)
+	}
+}
+
+func TestFrame_GetVariable_NotInitialized(t *testing.T) {
+	frame := &Frame{variables: make([][]byte, 2)}
+
+	_, err := frame.getVariable(1)
+	if err == nil || err.Error() != "local variable 1 is not initialized" {
+		t.Errorf("Expected uninitialized error but got %v", err)
+	}
+}
+
+func TestFrame_SetVariable_OutOfBounds(t *testing.T) {
+	frame := &Frame{variables: make([][]byte, 2)}
+
+	_, err := frame.setVariable(5, []byte{0, 1})
+	if _, ok := err.(*LocalIndexError); !ok {
+		t.Errorf("Expected LocalIndexError but got %v", err)
+	}
+}
+
+func TestCallStack_PeekAt(t *testing.T) {
+	cs := NewCallStack()
+	cs.Push(&Frame{returnAddress: 3})
+	cs.Push(&Frame{returnAddress: 7})
+
+	frame, err := cs.PeekAt(1)
+	if err != nil || frame.returnAddress != 3 {
+		t.Errorf("Expected frame with return address 3 but got %v (%v)", frame, err)
+	}
+
+	if cs.GetLength() != 2 {
+		t.Errorf("Expected call stack size to be 2 but got %v", cs.GetLength())
+	}
+
+	_, err = cs.PeekAt(2)
+	if err == nil {
+		t.Errorf("Expected index out of bounds error")
+	}
+}
+
+func newCallTestVM(code []byte) VM {
+	mc := NewMockContext(code)
+	mc.Fee = 100000
+	return NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+}
+
+// callWithArray pushes a 200 byte array and calls a function with 5 and 9, which returns its second argument
+func callWithArray(args byte) []byte {
+	code := pushBytes(200)
+	code = append(code, PushInt, 1, 0, 5, PushInt, 1, 0, 9)
+	code = append(code, CallLocals, 0, 0, args, 1, 2, Halt)
+	function := len(code)
+	code = append(code, LoadLoc, 1, Ret)
+	code[function-5] = byte(function)
+	return code
+}
+
+func TestCall_ByReference(t *testing.T) {
+	byValue := newCallTestVM(callWithArray(2))
+	assert.Assert(t, byValue.Exec(false), byValue.GetErrorMsg())
+	assert.DeepEqual(t, byValue.PeekEvalStack()[1:], [][]byte{{0, 9}})
+
+	// The arguments are removed from the stack, when the function returns
+	byReference := newCallTestVM(callWithArray(2 | CallByReference))
+	assert.Assert(t, byReference.Exec(false), byReference.GetErrorMsg())
+	assert.DeepEqual(t, byReference.PeekEvalStack(), byValue.PeekEvalStack())
+
+	// The array is neither popped nor charged per byte
+	assert.Assert(t, byReference.GasUsed() < byValue.GasUsed())
+}
+
+func TestCall_ByReference_LiveMemory(t *testing.T) {
+	vm := NewTestVM(nil)
+	vm.evaluationStack.Push(make([]byte, 200))
+	frame := &Frame{variables: make([][]byte, 2)}
+	assert.NilError(t, vm.loadArguments(OpCodes[Call], frame, 1|CallByReference))
+	vm.callStack.Push(frame)
+
+	// The aliased argument is only counted on the stack, until the local is overwritten
+	assert.Equal(t, vm.LiveMemory(), 200)
+	_, err := frame.setVariable(0, []byte{1})
+	assert.NilError(t, err)
+	assert.Equal(t, vm.LiveMemory(), 201)
+}
+
+func TestCall_ByReference_TooFewArguments(t *testing.T) {
+	code := []byte{PushInt, 1, 0, 5, CallLocals, 0, 10, 2 | CallByReference, 0, 2, Halt, Ret}
+	vm := NewTestVM(code)
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "calllocals: pop() on empty stack")
+}
+
+func TestTailCall_ByReference(t *testing.T) {
+	code := callWithArray(2 | CallByReference)
+	function := len(code) - 3
+	// The function tail calls a function returning its first local, which is the new argument
+	code = append(code[:function], PushInt, 1, 0, 7, TailCall, 0, 0, 1, 1, 1, LoadLoc, 0, Ret)
+	code[function+6] = byte(function + 10)
+
+	vm := newCallTestVM(code)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack()[1:], [][]byte{{0, 7}})
+}
+
+func TestTailCall_ByReferenceFlag(t *testing.T) {
+	code := []byte{
+		CallLocals, 0, 7, 0, 0, 0,
+		Halt,
+		PushInt, 1, 0, 7, // Begin of function at address 7
+		TailCall, 0, 7, 1 | CallByReference, 0, 1,
+	}
+
+	vm := newCallTestVM(code)
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "tailcall: tail calls cannot pass arguments by reference")
+}
@@ -0,0 +1,19 @@
+package vm
+
+// checkCancelled returns ErrCancelled, if the Cancel channel of the config is closed.
+//
+// The execution loop checks it before every instruction. Native operations, whose duration is not bounded by a
+// single gas check, e.g. Exp, call it after every step of their loop as cancellation point, so a deadline or a
+// cancellation is honored within the instruction and not only after it.
+func (vm *VM) checkCancelled() error {
+	if vm.config.Cancel == nil {
+		return nil
+	}
+
+	select {
+	case <-vm.config.Cancel:
+		return newError(ErrCancelled)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCancellation_BeforeExecution(t *testing.T) {
+	cancel := make(chan struct{})
+	close(cancel)
+
+	mc := NewMockContext([]byte{PushInt, 1, 0, 1, Halt})
+	vm := NewVMWithConfig(mc, VMConfig{Cancel: cancel})
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "vm.exec(): execution cancelled")
+}
+
+func TestCancellation_BetweenInstructions(t *testing.T) {
+	cancel := make(chan struct{})
+	executed := 0
+	hook := func(pc int, opCode byte, state StateView) error {
+		executed++
+		if pc == 4 {
+			close(cancel)
+		}
+		return nil
+	}
+
+	mc := NewMockContext([]byte{PushInt, 1, 0, 1, PushInt, 1, 0, 2, PushInt, 1, 0, 3, Halt})
+	vm := NewVMWithConfig(mc, VMConfig{Cancel: cancel, PostOpHook: hook})
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "vm.exec(): execution cancelled")
+	assert.Equal(t, executed, 2)
+}
+
+func TestCancellation_InsideExp(t *testing.T) {
+	for _, engine := range Engines {
+		cancel := make(chan struct{})
+		hook := func(pc int, opCode byte, state StateView) error {
+			if opCode == Exp {
+				close(cancel)
+			}
+			return nil
+		}
+
+		mc := NewMockContext([]byte{PushInt, 1, 0, 20, PushInt, 1, 0, 3, Exp, Halt})
+		mc.Fee = 1000
+		vm := NewVMWithConfig(mc, VMConfig{Cancel: cancel, PreOpHook: hook, Engine: engine})
+		assert.Assert(t, !vm.Exec(false))
+		assert.Equal(t, vm.GetErrorMsg(), "exp: execution cancelled", engine)
+	}
+}
+
+func TestCancellation_ExpResults(t *testing.T) {
+	vm := NewTestVM([]byte{})
+	for _, base := range []int64{-3, -1, 0, 1, 2, 7, 255} {
+		for exponent := int64(0); exponent <= 20; exponent++ {
+			expected := new(big.Int).Exp(big.NewInt(base), big.NewInt(exponent), nil)
+			actual, err := vm.exp(big.NewInt(base), big.NewInt(exponent))
+			assert.NilError(t, err)
+			assert.Equal(t, actual.Cmp(expected), 0, "%v ** %v", base, exponent)
+		}
+	}
+}
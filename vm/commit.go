@@ -0,0 +1,82 @@
+package vm
+
+// commit writes the changes of a successful execution to the context: the contract variables, the delegation of the
+// code, the nonce of the caller and the execution of the constructor. The previous values are read before anything is
+// written, and if a write fails, the changes written before it are restored, so the context receives all changes or
+// none. The constructor is recorded last, because InitContext cannot revoke it.
+func (vm *VM) commit() error {
+	previous, err := vm.previousVariables()
+	if err != nil {
+		return err
+	}
+	previousDelegate, hasDelegate := vm.previousDelegate()
+	previousNonce := vm.previousNonce()
+
+	steps := []struct {
+		write   func() error
+		restore func()
+	}{
+		{vm.commitVariables, func() { _ = vm.writeVariables(previous) }},
+		{vm.commitDelegate, func() { vm.restoreDelegate(previousDelegate, hasDelegate) }},
+		{vm.commitNonce, func() { vm.restoreNonce(previousNonce) }},
+		{vm.commitInit, func() {}},
+	}
+	for i, step := range steps {
+		if err := step.write(); err != nil {
+			// A failed step may have been written partially, e.g. the variables of a context without batches
+			for j := i; j >= 0; j-- {
+				steps[j].restore()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// previousVariables reads the values of the written contract variables, which are restored if the commit fails
+func (vm *VM) previousVariables() (map[int][]byte, error) {
+	previous := make(map[int][]byte, len(vm.dirty))
+	for _, index := range vm.DirtyVariables() {
+		value, err := vm.context.GetContractVariable(index)
+		if err != nil {
+			return nil, err
+		}
+		previous[index] = value
+	}
+	return previous, nil
+}
+
+// previousDelegate returns the delegate, which is restored if the commit fails, false if the code is not delegated
+func (vm *VM) previousDelegate() ([32]byte, bool) {
+	if vm.delegate == nil {
+		return [32]byte{}, false
+	}
+	return vm.context.(DelegationContext).GetCodeDelegate()
+}
+
+// previousNonce returns the nonce of the caller, which is restored if the commit fails
+func (vm *VM) previousNonce() uint64 {
+	if vm.nonce == nil {
+		return 0
+	}
+	return vm.context.(NonceContext).GetNonce(vm.caller())
+}
+
+// restoreDelegate restores the delegate, if the execution delegated the code. Delegating to the contract itself
+// removes a delegation, which did not exist before.
+func (vm *VM) restoreDelegate(delegate [32]byte, ok bool) {
+	if vm.delegate == nil {
+		return
+	}
+	if !ok {
+		delegate = vm.selfAddress()
+	}
+	_ = vm.context.(DelegationContext).SetCodeDelegate(delegate)
+}
+
+// restoreNonce restores the nonce of the caller, if the execution bumped it
+func (vm *VM) restoreNonce(nonce uint64) {
+	if vm.nonce != nil {
+		_ = vm.context.(NonceContext).SetNonce(vm.caller(), nonce)
+	}
+}
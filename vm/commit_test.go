@@ -0,0 +1,39 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestCommit_NonceFault(t *testing.T) {
+	code := pushNonce([]byte{PushInt, 1, 0, 1, StoreSt, 0, PushInt, 1, 0, 2, StoreSt, 2}, 0)
+	mc := newTestContext(append(code, CheckAndBumpNonce, Halt), withVariables, withSender)
+	mc.Faults.SetNonce = true
+	vm := NewVM(mc)
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "halt: injected fault: SetNonce")
+
+	mc.PersistChanges()
+	assert.DeepEqual(t, mc.ContractVariables, [][]byte{{0}, {0}, {0}})
+	assert.Equal(t, mc.GetNonce(mc.From), uint64(0))
+}
+
+func TestCommit_InitFault(t *testing.T) {
+	// The constructor at address 3 writes variable 0 and bumps the nonce, both are restored
+	code := pushNonce([]byte{LoadSt, 0, Halt, PushInt, 1, 0, 5, StoreSt, 0}, 4)
+	contract := append(NewConstructorSection(3), append(code, CheckAndBumpNonce, Halt)...)
+	mc := newTestContext(contract, withVariables, withSender)
+	mc.Nonces = map[[32]byte]uint64{{9}: 4}
+	mc.Faults.SetInitialized = true
+	vm := NewVM(mc)
+
+	assert.Assert(t, !vm.ExecInit())
+	assert.Equal(t, vm.GetErrorMsg(), "halt: injected fault: SetInitialized")
+	assert.Assert(t, !mc.Initialized)
+
+	mc.PersistChanges()
+	assert.DeepEqual(t, mc.ContractVariables, [][]byte{{0}, {0}, {0}})
+	assert.Equal(t, mc.GetNonce(mc.From), uint64(4))
+}
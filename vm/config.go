@@ -0,0 +1,126 @@
+package vm
+
+import "time"
+
+// VMConfig contains optional settings of the VM. Zero values select the defaults.
+type VMConfig struct {
+	// PreOpHook is invoked before every instruction, before its gas is charged.
+	PreOpHook OpHook
+	// PostOpHook is invoked after every instruction, which succeeded and did not end the execution.
+	PostOpHook OpHook
+	// CheckIntegrity verifies the evaluation stack and the call stack after every instruction and fails the
+	// execution, if an instruction corrupted them or the evaluation stack is modified between instructions.
+	// It is expensive and intended for tests.
+	CheckIntegrity bool
+	// Watchdog is the maximum duration of a single instruction, 0 disables the watchdog. A watchdog goroutine panics
+	// with the stacks of all goroutines, if a handler exceeds it, so accidental infinite loops in the native code
+	// of an opcode fail fast. It is intended for tests, NewTestVM enables it with TestWatchdog.
+	Watchdog time.Duration
+	// GasSchedule overrides the gas costs of the opcodes by name, opcodes missing in it keep their default costs.
+	// The price of the LoopGasName entry is charged for every backward jump and the price of the MemoryGasName entry for
+	// every 64 bytes, by which the live memory exceeds its peak, DefaultMemoryGasPrice if the entry is missing.
+	// The prices of the CodeGasName and CallDataGasName entries are charged for every started 64 bytes of the code and
+	// the transaction data before the execution, CodeGasFactor and CallDataGasFactor if the entries are missing.
+	GasSchedule GasSchedule
+	// MaxIntegerSize is the maximum size in bytes of integer results, DefaultMaxIntegerSize if 0.
+	MaxIntegerSize int
+	// MaxCallDataParams is the maximum number of parameters pushed by CallData, DefaultMaxCallDataParams if 0.
+	MaxCallDataParams int
+	// MaxCallDataParamSize is the maximum size of a CallData parameter, DefaultMaxCallDataParamSize if 0.
+	MaxCallDataParamSize int
+	// BytecodeVersion selects the semantics of deprecated opcodes, DefaultBytecodeVersion if 0.
+	BytecodeVersion byte
+	// Tracer receives every executed instruction, if set.
+	Tracer Tracer
+	// Engine selects how the instructions are executed, EngineInterpreter if empty.
+	Engine Engine
+	// DisabledOpcodes are rejected with a DisabledOpCodeError, e.g. CallExt on permissioned chains.
+	DisabledOpcodes []byte
+	// Cancel aborts the execution, once it is closed, e.g. the Done channel of a context with a deadline. It is
+	// checked before every instruction and at the cancellation points of long native operations, see checkCancelled.
+	Cancel <-chan struct{}
+	// Profiler receives the gas used by every instruction and its call stack, if set, see WriteFolded.
+	Profiler *Profiler
+	// OpStats counts the executed opcodes and their gas, if set. It can be shared by the VMs of a replay.
+	OpStats *OpStats
+	// Strict rejects deprecated behaviors, which old contracts rely on, so new deployments get clean semantics: Neg on
+	// booleans of bytecode version 1 fails with ErrDeprecatedBehavior, Roll with an index one below the bottom of the
+	// stack with ErrIndexOutOfBounds and LoadLoc of an uninitialized local with ErrLocalNotInitialized. Otherwise Roll
+	// ignores the index and LoadLoc pushes an empty value.
+	Strict bool
+}
+
+// DisabledOpCodeError is returned if an opcode disabled by the config is executed.
+type DisabledOpCodeError struct {
+	OpCode byte
+}
+
+func (e *DisabledOpCodeError) Error() string {
+	return newError(ErrOpCodeDisabled).Error()
+}
+
+// NewVMWithConfig creates a new Bazo virtual machine with the context and the settings of the config.
+func NewVMWithConfig(context Context, config VMConfig) VM {
+	vm := VM{
+		code:            []byte{},
+		pc:              0,
+		fee:             0,
+		evaluationStack: NewStack(),
+		callStack:       NewCallStack(),
+		context:         context,
+		selfCallPolicy:  DefaultSelfCallPolicy,
+		witness:         NewWitness(),
+	}
+	vm.SetConfig(config)
+	return vm
+}
+
+// SetConfig applies the settings of the config and replaces all settings applied before, including the tracer.
+func (vm *VM) SetConfig(config VMConfig) {
+	vm.config = config
+	vm.maxIntegerSize = orDefault(config.MaxIntegerSize, DefaultMaxIntegerSize)
+	vm.callDataLimits = callDataLimits{
+		maxParams:    orDefault(config.MaxCallDataParams, DefaultMaxCallDataParams),
+		maxParamSize: orDefault(config.MaxCallDataParamSize, DefaultMaxCallDataParamSize),
+	}
+	vm.bytecodeVersion = config.BytecodeVersion
+	if vm.bytecodeVersion == 0 {
+		vm.bytecodeVersion = DefaultBytecodeVersion
+	}
+	vm.tracer = config.Tracer
+}
+
+// gasPrice returns the price of an entry of the configured gas schedule, which is not an opcode, or its default
+func (vm *VM) gasPrice(name string, defaultPrice uint64) uint64 {
+	if cost, ok := vm.config.GasSchedule[name]; ok {
+		return cost.Price
+	}
+	return defaultPrice
+}
+
+// opCode returns the definition of the opcode with the gas costs of the configured gas schedule
+func (vm *VM) opCode(code byte) OpCode {
+	opCode := OpCodes[code]
+	if cost, ok := vm.config.GasSchedule[opCode.Name]; ok {
+		opCode.GasPrice = cost.Price
+		opCode.GasFactor = cost.Factor
+	}
+	return opCode
+}
+
+// checkDisabled rejects the opcodes disabled by the config
+func (vm *VM) checkDisabled(opCode OpCode) error {
+	for _, code := range vm.config.DisabledOpcodes {
+		if code == opCode.Code {
+			return &DisabledOpCodeError{OpCode: code}
+		}
+	}
+	return nil
+}
+
+func orDefault(value int, defaultValue int) int {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,116 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestConfig_Defaults(t *testing.T) {
+	vm := NewVMWithConfig(NewMockContext([]byte{Halt}), VMConfig{})
+
+	assert.Equal(t, vm.maxIntegerSize, DefaultMaxIntegerSize)
+	assert.Equal(t, vm.bytecodeVersion, DefaultBytecodeVersion)
+	assert.Equal(t, vm.selfCallPolicy, DefaultSelfCallPolicy)
+	assert.Equal(t, vm.callDataLimits, callDataLimits{DefaultMaxCallDataParams, DefaultMaxCallDataParamSize})
+	assert.Assert(t, vm.tracer == nil)
+}
+
+func TestConfig_Settings(t *testing.T) {
+	recorder := &StepRecorder{}
+	vm := NewVMWithConfig(NewMockContext([]byte{Halt}), VMConfig{
+		MaxIntegerSize:       8,
+		MaxCallDataParams:    2,
+		MaxCallDataParamSize: 16,
+		BytecodeVersion:      BytecodeVersion3,
+		Tracer:               recorder,
+	})
+
+	assert.Equal(t, vm.maxIntegerSize, 8)
+	assert.Equal(t, vm.bytecodeVersion, BytecodeVersion3)
+	assert.Equal(t, vm.callDataLimits, callDataLimits{2, 16})
+
+	assert.Assert(t, vm.Exec(false))
+	assert.Equal(t, len(recorder.Steps), 1)
+}
+
+func TestConfig_GasSchedule(t *testing.T) {
+	code := []byte{PushInt, 1, 0, 5, PushInt, 1, 0, 6, Add, Halt}
+	schedule := GasSchedule{"add": {Price: 10, Factor: 0}}
+	vm := NewVMWithConfig(NewMockContext(code), VMConfig{GasSchedule: schedule})
+
+	assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+	assert.Equal(t, vm.GasUsed(), IntrinsicGas(len(code), 0)+3+3+10+DefaultMemoryGasPrice)
+}
+
+func TestConfig_DisabledOpcodes(t *testing.T) {
+	code := []byte{PushInt, 1, 0, 5, StoreSt, 0, Halt}
+	config := VMConfig{DisabledOpcodes: []byte{CallExt, StoreSt}}
+
+	for _, engine := range Engines {
+		config.Engine = engine
+		mc := NewMockContext(code)
+		mc.ContractVariables = make([][]byte, 1)
+		vm := NewVMWithConfig(mc, config)
+
+		assert.Assert(t, !vm.ExecUnlimited())
+		assert.Equal(t, vm.GetErrorMsg(), "storest: opcode is disabled")
+		assert.Equal(t, vm.instructionPC, 4)
+	}
+
+	vm := NewVMWithConfig(NewMockContext([]byte{PushInt, 1, 0, 5, Halt}), config)
+	assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+}
+
+func TestConfig_DisabledOpCodeError(t *testing.T) {
+	vm := NewVMWithConfig(nil, VMConfig{DisabledOpcodes: []byte{CallExt}})
+
+	err := vm.checkDisabled(OpCodes[CallExt])
+	disabled, ok := err.(*DisabledOpCodeError)
+	assert.Assert(t, ok)
+	assert.Equal(t, disabled.OpCode, byte(CallExt))
+	assert.NilError(t, vm.checkDisabled(OpCodes[Add]))
+}
+
+func TestConfig_Strict(t *testing.T) {
+	code := []byte{PushBool, 1, Neg, Halt}
+
+	for _, engine := range Engines {
+		vm := NewVMWithConfig(NewMockContext(code), VMConfig{Engine: engine})
+		assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+
+		vm = NewVMWithConfig(NewMockContext(code), VMConfig{Engine: engine, Strict: true})
+		assert.Assert(t, !vm.ExecUnlimited())
+		assert.Equal(t, vm.GetErrorMsg(), "neg: boolean negation with neg is deprecated in strict mode")
+	}
+
+	// Neg of signed integers is not deprecated
+	code = []byte{PushInt, 1, 0, 5, Neg, Halt}
+	vm := NewVMWithConfig(NewMockContext(code), VMConfig{BytecodeVersion: BytecodeVersion2, Strict: true})
+	assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+
+	// Roll one element below the bottom of the stack
+	code = []byte{PushInt, 1, 0, 3, PushInt, 1, 0, 4, Roll, 1, Halt}
+	vm = NewVMWithConfig(NewMockContext(code), VMConfig{})
+	assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{0, 3}, {0, 4}})
+
+	vm = NewVMWithConfig(NewMockContext(code), VMConfig{Strict: true})
+	assert.Assert(t, !vm.ExecUnlimited())
+	assert.Equal(t, vm.GetErrorMsg(), "roll: index out of bounds")
+
+	// Rolling further below the bottom of the stack fails in every mode
+	vm = NewVMWithConfig(NewMockContext([]byte{PushInt, 0, Roll, 1, Halt}), VMConfig{})
+	assert.Assert(t, !vm.ExecUnlimited())
+	assert.Equal(t, vm.GetErrorMsg(), "roll: index out of bounds")
+
+	// LoadLoc of an uninitialized local
+	code = []byte{CallLocals, 0, 7, 0, 1, 1, Halt, LoadLoc, 0, Ret}
+	vm = NewVMWithConfig(NewMockContext(code), VMConfig{})
+	assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{}})
+
+	vm = NewVMWithConfig(NewMockContext(code), VMConfig{Strict: true})
+	assert.Assert(t, !vm.ExecUnlimited())
+	assert.Equal(t, vm.GetErrorMsg(), "loadloc: local variable 0 is not initialized")
+}
@@ -0,0 +1,143 @@
+package vm
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// The conformance tests are generated from the OpCodes table, so every opcode is tested for the behavior shared by
+// all instructions without writing a test for it.
+
+const conformanceFee = 100000
+
+// conformanceArgs returns zero arguments of the opcode, BYTES arguments are empty
+func conformanceArgs(opCode OpCode) []byte {
+	var args []byte
+	for _, argType := range opCode.ArgTypes {
+		if argType == BYTES {
+			args = append(args, 0)
+			continue
+		}
+		args = append(args, make([]byte, ArgWidth(argType))...)
+	}
+	return args
+}
+
+// execConformance executes the code with every engine and reports panics as test failures
+func execConformance(t *testing.T, code []byte, config VMConfig, check func(t *testing.T, vm *VM, success bool)) {
+	for _, engine := range Engines {
+		t.Run(string(engine), func(t *testing.T) {
+			mc := NewMockContext(code)
+			mc.Fee = conformanceFee
+			mc.ContractVariables = [][]byte{{0}, {0}}
+			config.Engine = engine
+			vm := NewVMWithConfig(mc, config)
+
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("panic: %v", r)
+				}
+			}()
+			success := vm.Exec(false)
+			check(t, &vm, success)
+		})
+	}
+}
+
+func TestConformance_EmptyStack(t *testing.T) {
+	for _, opCode := range OpCodes {
+		if opCode.Pops <= 0 {
+			continue
+		}
+		opCode := opCode
+		t.Run(opCode.Name, func(t *testing.T) {
+			code := append(append([]byte{opCode.Code}, conformanceArgs(opCode)...), Halt)
+			execConformance(t, code, VMConfig{}, func(t *testing.T, vm *VM, success bool) {
+				assert.Assert(t, !success)
+				assert.Equal(t, vm.GetErrorMsg(), opCode.Name+": stack underflow at pc=0")
+			})
+		})
+	}
+}
+
+func TestConformance_TruncatedImmediates(t *testing.T) {
+	for _, opCode := range OpCodes {
+		args := conformanceArgs(opCode)
+		if len(args) == 0 {
+			continue
+		}
+		opCode := opCode
+		t.Run(opCode.Name, func(t *testing.T) {
+			// Operands are provided, so the instruction fails because of the missing argument bytes
+			var code []byte
+			for i := 0; i < opCode.Pops; i++ {
+				code = append(code, PushInt, 1, 0, 1)
+			}
+			code = append(code, opCode.Code)
+			code = append(code, args[:len(args)-1]...)
+
+			execConformance(t, code, VMConfig{}, func(t *testing.T, vm *VM, success bool) {
+				assert.Assert(t, !success)
+				assert.Assert(t, vm.GetErrorMsg() != "")
+			})
+		})
+	}
+}
+
+func TestConformance_OversizedOperands(t *testing.T) {
+	// Operands of 200 bytes exceed the maximum integer size of 8 bytes
+	operand := append([]byte{Push, 200, 0}, make([]byte, 199)...)
+	operand[3] = 0x7f
+
+	for _, opCode := range OpCodes {
+		if opCode.Pops <= 0 {
+			continue
+		}
+		opCode := opCode
+		t.Run(opCode.Name, func(t *testing.T) {
+			var code []byte
+			for i := 0; i < opCode.Pops; i++ {
+				code = append(code, operand...)
+			}
+			code = append(code, opCode.Code)
+			code = append(code, conformanceArgs(opCode)...)
+			code = append(code, Halt)
+
+			execConformance(t, code, VMConfig{MaxIntegerSize: 8}, func(t *testing.T, vm *VM, success bool) {
+				if !success {
+					assert.Assert(t, vm.GetErrorMsg() != "")
+				}
+				assert.Assert(t, vm.GasUsed() <= conformanceFee)
+			})
+		})
+	}
+}
+
+func TestConformance_GasPrice(t *testing.T) {
+	for _, opCode := range OpCodes {
+		opCode := opCode
+		code := append([]byte{opCode.Code}, conformanceArgs(opCode)...)
+
+		t.Run(opCode.Name, func(t *testing.T) {
+			// The price is charged before the instruction is executed, an instruction failing on the empty stack
+			// costs exactly its price in addition to the intrinsic gas of the code
+			intrinsicGas := IntrinsicGas(len(code), 0)
+			if opCode.Pops > 0 {
+				execConformance(t, code, VMConfig{}, func(t *testing.T, vm *VM, success bool) {
+					assert.Equal(t, vm.GasUsed(), intrinsicGas+opCode.GasPrice)
+				})
+			}
+
+			if opCode.GasPrice == 0 {
+				return
+			}
+			mc := NewMockContext(code)
+			mc.Fee = intrinsicGas + opCode.GasPrice - 1
+			vm := NewVM(mc)
+			assert.Assert(t, !vm.Exec(false))
+			assert.Equal(t, vm.GetErrorMsg(), fmt.Sprintf("vm.exec(): %v", newError(ErrOutOfGas)))
+		})
+	}
+}
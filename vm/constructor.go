@@ -0,0 +1,77 @@
+package vm
+
+// ConstructorMarker is the first byte of a contract section, which declares the constructor. It is never a valid
+// opcode. The section follows the state schema and precedes the function table.
+const ConstructorMarker = 0xFD
+
+// InitContext is implemented by contexts, which record whether the constructor of the contract was executed, so it
+// runs exactly once. Contexts without this interface rely on the node to call ExecInit only at the deployment.
+type InitContext interface {
+	// IsInitialized returns true, if the constructor was executed successfully.
+	IsInitialized() bool
+
+	// SetInitialized records the successful execution of the constructor.
+	SetInitialized() error
+}
+
+// NewConstructorSection declares the constructor at the address, which is relative to the code following the
+// function table. The code from the address to the end is the constructor, it is only executed by ExecInit.
+func NewConstructorSection(address uint16) []byte {
+	return append([]byte{ConstructorMarker}, UInt16ToByteArray(address)...)
+}
+
+// ParseConstructorSection splits the contract into the address of the constructor and the remaining contract.
+// Contracts without a constructor section are returned unchanged with the address -1.
+func ParseConstructorSection(contract []byte) (address int, rest []byte, err error) {
+	if len(contract) == 0 || contract[0] != ConstructorMarker {
+		return -1, contract, nil
+	}
+
+	if len(contract) < 3 {
+		return -1, nil, newError(ErrConstructorOutOfBounds)
+	}
+
+	value, _ := ByteArrayToUI16(contract[1:3])
+	return int(value), contract[3:], nil
+}
+
+// ExecInit executes the constructor of the contract at its deployment, e.g. to write the initial contract variables.
+// It fails, if the contract has no constructor or the context reports, that the constructor was executed before.
+func (vm *VM) ExecInit() bool {
+	vm.initializing = true
+	defer func() { vm.initializing = false }()
+	return vm.exec(vm.context.GetFee(), false)
+}
+
+// enterCode sets the code of the execution. ExecInit starts at the constructor, other executions cannot reach it.
+func (vm *VM) enterCode(constructor int, code []byte) error {
+	if constructor > len(code) {
+		return newError(ErrConstructorOutOfBounds)
+	}
+
+	if !vm.initializing {
+		if constructor >= 0 {
+			code = code[:constructor]
+		}
+		vm.code = code
+		return nil
+	}
+
+	if constructor < 0 {
+		return newError(ErrNoConstructor)
+	}
+	if initContext, ok := vm.context.(InitContext); ok && initContext.IsInitialized() {
+		return newError(ErrAlreadyInitialized)
+	}
+	vm.code = code
+	vm.pc = constructor
+	return nil
+}
+
+// commitInit records the execution of the constructor, if the execution ran it
+func (vm *VM) commitInit() error {
+	if initContext, ok := vm.context.(InitContext); ok && vm.initializing {
+		return initContext.SetInitialized()
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// newConstructorContract returns a contract, whose constructor writes 5 to variable 0 and whose regular code
+// pushes variable 0
+func newConstructorContract() []byte {
+	code := []byte{
+		LoadSt, 0,
+		Halt,
+		// Constructor at address 3
+		PushInt, 1, 0, 5,
+		StoreSt, 0,
+		Halt,
+	}
+	return append(NewConstructorSection(3), code...)
+}
+
+func TestConstructor_ExecInit(t *testing.T) {
+	mc := newTestContext(newConstructorContract(), withVariables)
+	vm := NewVM(mc)
+
+	assert.Assert(t, vm.ExecInit(), vm.GetErrorMsg())
+	assert.Assert(t, mc.Initialized)
+	mc.PersistChanges()
+	assert.DeepEqual(t, mc.ContractVariables[0], []byte{0, 5})
+
+	vm = NewVM(mc)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{0, 5}})
+}
+
+func TestConstructor_ExactlyOnce(t *testing.T) {
+	mc := newTestContext(newConstructorContract(), withVariables)
+	vm := NewVM(mc)
+	assert.Assert(t, vm.ExecInit(), vm.GetErrorMsg())
+
+	vm = NewVM(mc)
+	assert.Assert(t, !vm.ExecInit())
+	assert.Equal(t, vm.GetErrorMsg(), "vm.exec(): contract is already initialized")
+}
+
+func TestConstructor_FailedInit(t *testing.T) {
+	contract := append(NewConstructorSection(1), Halt, PushInt, 1, 0, 1, PushInt, 0, Div, Halt)
+	mc := newTestContext(contract, withVariables)
+	vm := NewVM(mc)
+
+	assert.Assert(t, !vm.ExecInit())
+	assert.Assert(t, !mc.Initialized)
+}
+
+func TestConstructor_UnreachableByExec(t *testing.T) {
+	// The regular code jumps to the constructor, which is cut off
+	contract := append(NewConstructorSection(3), Jmp, 0, 3, PushInt, 1, 0, 5, StoreSt, 0, Halt)
+	mc := newTestContext(contract, withVariables)
+	vm := NewVM(mc)
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, len(vm.dirty), 0)
+}
+
+func TestConstructor_WithFunctionTable(t *testing.T) {
+	functions := []Function{{Hash: FunctionHash("get"), Address: 0, NrOfReturnTypes: 1}}
+	contract := append(NewConstructorSection(3), NewFunctionTable(functions)...)
+	contract = append(contract, LoadSt, 0, Ret, PushInt, 1, 0, 7, StoreSt, 0, Halt)
+	mc := newTestContext(contract, withVariables)
+	vm := NewVM(mc)
+
+	assert.Assert(t, vm.ExecInit(), vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.dirty[0], []byte{0, 7})
+
+	declared, err := vm.Functions()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, declared, functions)
+}
+
+func TestConstructor_Errors(t *testing.T) {
+	vm := NewVM(newTestContext([]byte{Halt}, withVariables))
+	assert.Assert(t, !vm.ExecInit())
+	assert.Equal(t, vm.GetErrorMsg(), "vm.exec(): contract has no constructor")
+
+	vm = NewVM(newTestContext(append(NewConstructorSection(5), Halt), withVariables))
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "vm.exec(): constructor out of bounds")
+
+	_, _, err := ParseConstructorSection([]byte{ConstructorMarker, 0})
+	assert.Error(t, err, "constructor out of bounds")
+}
@@ -0,0 +1,170 @@
+package vm
+
+// Containers are encoded on the evaluation stack and in contract variables as a header of 3 bytes followed by their
+// elements: the type byte, the number of entries (2 bytes, big endian) and every element prefixed by its size
+// (2 bytes, big endian). Maps have 2 elements per entry, the key followed by the value. Structs are encoded as arrays
+// of their fields. The types of this file are the canonical implementation of the format for clients.
+
+// Type bytes of the containers
+const (
+	MapType   byte = 0x01
+	ArrayType byte = 0x02
+)
+
+// containerHeaderSize is the size of the header of a container in bytes
+const containerHeaderSize = 3
+
+// ArrayHeader is the header of an array, which declares its number of elements.
+type ArrayHeader struct {
+	Length uint16
+}
+
+// Marshal encodes the header.
+func (h ArrayHeader) Marshal() []byte {
+	return append([]byte{ArrayType}, UInt16ToByteArray(h.Length)...)
+}
+
+// Unmarshal decodes the header at the beginning of an array.
+func (h *ArrayHeader) Unmarshal(data []byte) error {
+	if len(data) == 0 || data[0] != ArrayType {
+		return newError(ErrInvalidArray)
+	}
+	if len(data) < containerHeaderSize {
+		return newError(ErrMalformedContainer, 0)
+	}
+
+	h.Length, _ = ByteArrayToUI16(data[1:containerHeaderSize])
+	return nil
+}
+
+// MapHeader is the header of a map, which declares its number of entries.
+type MapHeader struct {
+	Entries uint16
+}
+
+// Marshal encodes the header.
+func (h MapHeader) Marshal() []byte {
+	return append([]byte{MapType}, UInt16ToByteArray(h.Entries)...)
+}
+
+// Unmarshal decodes the header at the beginning of a map.
+func (h *MapHeader) Unmarshal(data []byte) error {
+	if len(data) == 0 {
+		return newError(ErrEmptyMap)
+	}
+	if data[0] != MapType {
+		return newError(ErrInvalidMap)
+	}
+	if len(data) < containerHeaderSize {
+		return newError(ErrMalformedContainer, 0)
+	}
+
+	h.Entries, _ = ByteArrayToUI16(data[1:containerHeaderSize])
+	return nil
+}
+
+// StructHeader is the header of a struct, which declares its number of fields. Structs share the header of arrays.
+type StructHeader struct {
+	Fields uint16
+}
+
+// Marshal encodes the header.
+func (h StructHeader) Marshal() []byte {
+	return ArrayHeader{Length: h.Fields}.Marshal()
+}
+
+// Unmarshal decodes the header at the beginning of a struct.
+func (h *StructHeader) Unmarshal(data []byte) error {
+	var header ArrayHeader
+	if err := header.Unmarshal(data); err != nil {
+		return err
+	}
+
+	h.Fields = header.Length
+	return nil
+}
+
+// MapEntry is a key and its value in a map.
+type MapEntry struct {
+	Key   []byte
+	Value []byte
+}
+
+// MarshalArray encodes the elements as array.
+func MarshalArray(elements [][]byte) ([]byte, error) {
+	if len(elements) > int(UINT16_MAX) {
+		return nil, newError(ErrArraySizeOverflow)
+	}
+
+	a := NewArray()
+	for _, element := range elements {
+		if err := a.Append(element); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// UnmarshalArray decodes the elements of an array.
+func UnmarshalArray(data []byte) ([][]byte, error) {
+	a, err := ArrayFromByteArray(data)
+	if err != nil {
+		return nil, err
+	}
+	return a.elements(), nil
+}
+
+// MarshalMap encodes the entries as map. A later entry replaces an earlier entry with the same key.
+func MarshalMap(entries []MapEntry) ([]byte, error) {
+	m := CreateMap()
+	for _, entry := range entries {
+		if err := m.Put(entry.Key, entry.Value); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// UnmarshalMap decodes the entries of a map in the order of their encoding.
+func UnmarshalMap(data []byte) ([]MapEntry, error) {
+	m, err := MapFromByteArray(data)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, values, err := m.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]MapEntry, len(keys))
+	for i := range keys {
+		entries[i] = MapEntry{Key: keys[i], Value: values[i]}
+	}
+	return entries, nil
+}
+
+// MarshalStruct encodes the fields as struct.
+func MarshalStruct(fields [][]byte) ([]byte, error) {
+	return MarshalArray(fields)
+}
+
+// UnmarshalStruct decodes the fields of a struct.
+func UnmarshalStruct(data []byte) ([][]byte, error) {
+	s, err := structFromByteArray(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.toArray().elements(), nil
+}
+
+// elements returns the elements of a validated array
+func (a *Array) elements() [][]byte {
+	var elements [][]byte
+	for offset := containerHeaderSize; offset+2 <= len(*a); {
+		size := int((*a)[offset])<<8 | int((*a)[offset+1])
+		elements = append(elements, (*a)[offset+2:offset+2+size])
+		offset += 2 + size
+	}
+	return elements
+}
@@ -0,0 +1,74 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestContainerFormat_Headers(t *testing.T) {
+	assertBytes(t, ArrayHeader{Length: 258}.Marshal(), ArrayType, 1, 2)
+	assertBytes(t, MapHeader{Entries: 3}.Marshal(), MapType, 0, 3)
+	assertBytes(t, StructHeader{Fields: 2}.Marshal(), ArrayType, 0, 2)
+
+	var array ArrayHeader
+	assert.NilError(t, array.Unmarshal([]byte{ArrayType, 1, 2, 0xff}))
+	assert.Equal(t, array.Length, uint16(258))
+
+	var m MapHeader
+	assert.NilError(t, m.Unmarshal([]byte{MapType, 0, 3}))
+	assert.Equal(t, m.Entries, uint16(3))
+
+	var s StructHeader
+	assert.NilError(t, s.Unmarshal([]byte{ArrayType, 0, 2}))
+	assert.Equal(t, s.Fields, uint16(2))
+}
+
+func TestContainerFormat_HeaderErrors(t *testing.T) {
+	var array ArrayHeader
+	assert.Error(t, array.Unmarshal(nil), "not a valid array")
+	assert.Error(t, array.Unmarshal([]byte{MapType, 0, 0}), "not a valid array")
+	assert.Error(t, array.Unmarshal([]byte{ArrayType, 0}), "container element at byte 0 exceeds the container")
+
+	var m MapHeader
+	assert.Error(t, m.Unmarshal(nil), "empty map")
+	assert.Error(t, m.Unmarshal([]byte{ArrayType, 0, 0}), "invalid datatype supplied")
+	assert.Error(t, m.Unmarshal([]byte{MapType}), "container element at byte 0 exceeds the container")
+}
+
+func TestContainerFormat_RoundTrip(t *testing.T) {
+	elements := [][]byte{{7}, {}, {8, 9}}
+	array, err := MarshalArray(elements)
+	assert.NilError(t, err)
+	decoded, err := UnmarshalArray(array)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, decoded, elements)
+
+	s, err := MarshalStruct(elements)
+	assert.NilError(t, err)
+	assertBytes(t, s, array...)
+	decoded, err = UnmarshalStruct(s)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, decoded, elements)
+
+	entries := []MapEntry{{Key: []byte{1}, Value: []byte{10}}, {Key: []byte{2}, Value: []byte{}}}
+	m, err := MarshalMap(entries)
+	assert.NilError(t, err)
+	decodedEntries, err := UnmarshalMap(m)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, decodedEntries, entries)
+
+	_, err = UnmarshalArray([]byte{ArrayType, 0, 2, 0, 1, 7})
+	assert.Error(t, err, "container declares 2 elements but contains 1")
+}
+
+func TestContainerFormat_MatchesOpCodes(t *testing.T) {
+	vm, isSuccess := execCode([]byte{Push, 1, 7, Push, 2, 8, 9, NewArrFrom, 2, Push, 1, 10, Push, 1, 1, NewMapFrom, 1, Halt})
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+	array, err := MarshalArray([][]byte{{7}, {8, 9}})
+	assert.NilError(t, err)
+	m, err := MarshalMap([]MapEntry{{Key: []byte{1}, Value: []byte{10}}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{array, m})
+}
@@ -0,0 +1,292 @@
+package vm
+
+// Containers (maps, arrays and structs) live in the object table of the VM and are referenced by handles, see
+// objectTable. A container opcode modifies the container in place, if its handle is the only reference, and a copy
+// otherwise. Instructions consuming a container as bytes take its encoding, which is written to the state unchanged.
+
+// MaxContainerSize is the maximum size of a map or an array in bytes, which is decoded from the evaluation stack
+const MaxContainerSize = 1 << 20
+
+// validateContainer verifies the layout of a container, before it is decoded: The type byte and the number of
+// elements are followed by the elements, each prefixed with its size. Maps have 2 elements per entry, key and value.
+// The elements are walked iteratively and never decoded as nested containers, so adversarial input is bounded by the
+// size of the container.
+func validateContainer(container []byte, elementsPerEntry int) error {
+	if len(container) > MaxContainerSize {
+		return newError(ErrContainerTooLarge, MaxContainerSize)
+	}
+	if len(container) < 3 {
+		return newError(ErrMalformedContainer, 0)
+	}
+
+	entries := int(container[1])<<8 | int(container[2])
+	elements := 0
+	for offset := 3; offset < len(container); elements++ {
+		if offset+2 > len(container) {
+			return newError(ErrMalformedContainer, offset)
+		}
+		size := int(container[offset])<<8 | int(container[offset+1])
+		if offset+2+size > len(container) {
+			return newError(ErrMalformedContainer, offset)
+		}
+		offset += 2 + size
+	}
+
+	if elements != entries*elementsPerEntry {
+		return newError(ErrContainerElementCount, entries*elementsPerEntry, elements)
+	}
+	return nil
+}
+
+// popContainer pops a container, which may be modified in place, and charges the gas factor like PopBytes. A container
+// referenced elsewhere and an element, which is not a handle, are copied.
+func (vm *VM) popContainer(opCode OpCode) ([]byte, error) {
+	element, err := vm.popValue(opCode)
+	if err != nil {
+		return nil, err
+	}
+
+	container, owned := vm.objects.take(element)
+	if !owned {
+		container = append(make([]byte, 0, len(container)), container...)
+	}
+	return container, nil
+}
+
+// popContainerView pops a container, which is only read, without copying it. The container must not be modified and
+// elements of it must be copied, before they are pushed.
+func (vm *VM) popContainerView(opCode OpCode) ([]byte, error) {
+	element, err := vm.popValue(opCode)
+	if err != nil {
+		return nil, err
+	}
+
+	container := vm.objects.resolve(element)
+	vm.objects.release(element)
+	return container, nil
+}
+
+// pushContainer adds a container, which is not referenced anywhere else, to the object table and pushes its handle
+func (vm *VM) pushContainer(container []byte) error {
+	handle := vm.objects.add(container)
+	if err := vm.evaluationStack.Push(handle); err != nil {
+		vm.objects.release(handle)
+		return err
+	}
+	return nil
+}
+
+// popElements pops n elements and returns them in the order, in which they were pushed
+func (vm *VM) popElements(opCode OpCode, n int) ([][]byte, error) {
+	if n > vm.evaluationStack.GetLength() {
+		return nil, newError(ErrIndexOutOfBounds)
+	}
+
+	elements := make([][]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		element, err := vm.PopBytes(opCode)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = element
+	}
+	return elements, nil
+}
+
+// newArrayFrom pops n elements and pushes an array of them. The first pushed element is the first element of the array.
+func (vm *VM) newArrayFrom(opCode OpCode, n int) error {
+	elements, err := vm.popElements(opCode, n)
+	if err != nil {
+		return err
+	}
+
+	a := NewArray()
+	for _, element := range elements {
+		if err := a.Append(element); err != nil {
+			return err
+		}
+	}
+
+	if err := vm.chargeSizeGas(opCode, len(a)); err != nil {
+		return err
+	}
+	return vm.pushContainer(a)
+}
+
+// newMapFrom pops n entries and pushes a map of them. Every entry is pushed as value and key like the operands of
+// MapSetVal, a later entry replaces an earlier entry with the same key.
+func (vm *VM) newMapFrom(opCode OpCode, n int) error {
+	elements, err := vm.popElements(opCode, 2*n)
+	if err != nil {
+		return err
+	}
+
+	m := CreateMap()
+	for i := 0; i < len(elements); i += 2 {
+		if err := m.Put(elements[i+1], elements[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := vm.chargeSizeGas(opCode, len(m)); err != nil {
+		return err
+	}
+	return vm.pushContainer(m)
+}
+
+// arrFill pops an array and a value and pushes the array, whose elements are all set to the value
+func (vm *VM) arrFill(opCode OpCode) error {
+	a, aerr := vm.popContainer(opCode)
+	value, verr := vm.PopBytes(opCode)
+	if aerr != nil {
+		return aerr
+	}
+	if verr != nil {
+		return verr
+	}
+
+	arr, err := ArrayFromByteArray(a)
+	if err != nil {
+		return err
+	}
+	size, err := arr.GetSize()
+	if err != nil {
+		return err
+	}
+
+	// The gas is charged before the array is built, as it may be much larger than the popped operands
+	filledSize := 3 + int(size)*(2+len(value))
+	if filledSize > MaxContainerSize {
+		return newError(ErrContainerTooLarge, MaxContainerSize)
+	}
+	if err := vm.chargeSizeGas(opCode, filledSize); err != nil {
+		return err
+	}
+
+	if err := arr.Fill(value); err != nil {
+		return err
+	}
+	return vm.pushContainer(arr)
+}
+
+// arrResize pops an array and a size and pushes the array truncated or extended to the size
+func (vm *VM) arrResize(opCode OpCode) error {
+	a, aerr := vm.popContainer(opCode)
+	size, serr := vm.PopUnsignedBigInt(opCode)
+	if aerr != nil {
+		return aerr
+	}
+	if serr != nil {
+		return serr
+	}
+
+	if !size.IsUint64() || size.Uint64() > uint64(UINT16_MAX) {
+		return newError(ErrArraySizeOverflow)
+	}
+
+	arr, err := ArrayFromByteArray(a)
+	if err != nil {
+		return err
+	}
+	current, err := arr.GetSize()
+	if err != nil {
+		return err
+	}
+
+	// Like NewArr, every appended element is charged with one byte and its size of two bytes
+	if added := int(size.Uint64()) - int(current); added > 0 {
+		if err := vm.chargeSizeGas(opCode, 3*added); err != nil {
+			return err
+		}
+	}
+
+	if err := arr.Resize(uint16(size.Uint64())); err != nil {
+		return err
+	}
+	return vm.pushContainer(arr)
+}
+
+// chargeEntryGas charges the gas factor per entry, which a bulk opcode writes into a container
+func (vm *VM) chargeEntryGas(opCode OpCode, entries int) error {
+	gasCost := opCode.GasFactor * uint64(entries)
+	if vm.fee < gasCost {
+		return newError(ErrOutOfGas)
+	}
+
+	vm.fee -= gasCost
+	return nil
+}
+
+// mapMerge pops two maps and pushes the left map updated with the entries of the right map, so the values of the right
+// map replace the values of the left map for keys in both maps
+func (vm *VM) mapMerge(opCode OpCode) error {
+	r, rerr := vm.popContainer(opCode)
+	l, lerr := vm.popContainer(opCode)
+	if rerr != nil {
+		return rerr
+	}
+	if lerr != nil {
+		return lerr
+	}
+
+	right, err := MapFromByteArray(r)
+	if err != nil {
+		return err
+	}
+	left, err := MapFromByteArray(l)
+	if err != nil {
+		return err
+	}
+
+	keys, values, err := right.Entries()
+	if err != nil {
+		return err
+	}
+	if err := vm.chargeEntryGas(opCode, len(keys)); err != nil {
+		return err
+	}
+
+	for i, key := range keys {
+		if err := left.Put(key, values[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := vm.chargeSizeGas(opCode, len(left)); err != nil {
+		return err
+	}
+	return vm.pushContainer(left)
+}
+
+// mapSetMany pops a map and n entries, each pushed as value and key like the operands of MapSetVal, and pushes the map
+// with the entries set in the order, in which they were pushed
+func (vm *VM) mapSetMany(opCode OpCode, n int) error {
+	a, err := vm.popContainer(opCode)
+	if err != nil {
+		return err
+	}
+
+	m, err := MapFromByteArray(a)
+	if err != nil {
+		return err
+	}
+
+	elements, err := vm.popElements(opCode, 2*n)
+	if err != nil {
+		return err
+	}
+	if err := vm.chargeEntryGas(opCode, n); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(elements); i += 2 {
+		if err := m.Put(elements[i+1], elements[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := vm.chargeSizeGas(opCode, len(m)); err != nil {
+		return err
+	}
+	return vm.pushContainer(m)
+}
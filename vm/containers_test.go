@@ -0,0 +1,338 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestContainers_ModifiedInPlace(t *testing.T) {
+	code := []byte{
+		Push, 1, 7,
+		PushInt, 1, 0, 1,
+		NewArr,
+		ArrAppend,
+		Push, 1, 8,
+		Swap,
+		ArrAppend,
+		Halt,
+	}
+
+	// The array is the only object in the table until the execution ends
+	vm := NewVMWithConfig(newTestContext(code), VMConfig{CheckIntegrity: true})
+	assert.Equal(t, vm.ExecSteps(7), StatusPaused, vm.GetErrorMsg())
+	assert.Equal(t, len(vm.objects.objects), 1)
+	assert.Equal(t, vm.objects.size, 12)
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{2, 0, 3, 0, 1, 0, 0, 1, 7, 0, 1, 8}})
+
+	assert.Equal(t, vm.ExecSteps(1), StatusDone)
+	assert.Equal(t, len(vm.objects.objects), 0)
+	assert.DeepEqual(t, vm.evaluationStack.Stack, [][]byte{{2, 0, 3, 0, 1, 0, 0, 1, 7, 0, 1, 8}})
+}
+
+func TestContainers_CopyOnWrite(t *testing.T) {
+	tests := []struct {
+		name      string
+		duplicate []byte
+	}{
+		{"dup", []byte{Dup}},
+		{"pick", []byte{Pick, 0}},
+		{"storeloc", []byte{Dup, StoreLoc, 0, LoadLoc, 0}},
+	}
+
+	for _, test := range tests {
+		code := []byte{
+			CallLocals, 0, 7, 0, 2, 1,
+			Halt,
+			PushInt, 1, 0, 1,
+			NewArr,
+		}
+		code = append(code, test.duplicate...)
+		code = append(code,
+			PushInt, 1, 0, 0,
+			Swap,
+			ArrRemove,
+			Push, 1, 8,
+			Swap,
+			ArrAppend,
+			Ret,
+		)
+
+		vm, isSuccess := execCode(code)
+		assert.Assert(t, isSuccess, "%v: %v", test.name, vm.GetErrorMsg())
+
+		stack := vm.PeekEvalStack()
+		assert.DeepEqual(t, stack[len(stack)-2], []byte{2, 0, 1, 0, 1, 0})
+		assert.DeepEqual(t, stack[len(stack)-1], []byte{2, 0, 1, 0, 1, 8})
+	}
+}
+
+func TestContainers_CopyOnWriteByReference(t *testing.T) {
+	// The array passed by reference is aliased by the local, modifying it leaves the local unchanged
+	code := []byte{
+		PushInt, 1, 0, 1,
+		NewArr,
+		CallLocals, 0, 12, 1 | CallByReference, 2, 1,
+		Halt,
+		Push, 1, 8, // Begin of function at address 12
+		LoadLoc, 0,
+		ArrAppend,
+		LoadLoc, 0,
+		Ret,
+	}
+
+	vm := newCallTestVM(code)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{2, 0, 2, 0, 1, 0, 0, 1, 8}, {2, 0, 1, 0, 1, 0}})
+}
+
+// stackRecorder keeps the stacks of all steps
+type stackRecorder struct {
+	stacks [][][]byte
+}
+
+func (r *stackRecorder) CaptureStep(step TraceStep) {
+	r.stacks = append(r.stacks, step.Stack)
+}
+
+func TestContainers_TracedStackUnchanged(t *testing.T) {
+	recorder := &stackRecorder{}
+	vm := NewTestVM([]byte{PushInt, 1, 0, 1, NewArr, Push, 1, 8, Swap, ArrAppend, Halt})
+	vm.SetTracer(recorder)
+
+	assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+	assert.DeepEqual(t, recorder.stacks[1], [][]byte{{2, 0, 1, 0, 1, 0}})
+}
+
+func BenchmarkContainers_ArrAppend(b *testing.B) {
+	code := []byte{PushInt, 0, NewArr}
+	for i := 0; i < 1000; i++ {
+		code = append(code, Push, 8, 1, 2, 3, 4, 5, 6, 7, 8, Swap, ArrAppend)
+	}
+	code = append(code, Halt)
+	benchmarkContainers(b, code)
+}
+
+func BenchmarkContainers_MapSetVal(b *testing.B) {
+	code := []byte{NewMap}
+	for i := 0; i < 250; i++ {
+		code = append(code, Push, 8, 1, 2, 3, 4, 5, 6, 7, 8, Push, 2, 0, byte(i), Roll, 1, MapSetVal)
+	}
+	code = append(code, Halt)
+	benchmarkContainers(b, code)
+}
+
+func benchmarkContainers(b *testing.B, code []byte) {
+	b.ReportAllocs()
+	vm := NewVM(nil)
+	for i := 0; i < b.N; i++ {
+		vm.Reset(NewMockContext(code))
+		if !vm.ExecUnlimited() {
+			b.Fatal(vm.GetErrorMsg())
+		}
+	}
+}
+
+func TestContainers_Decode(t *testing.T) {
+	tests := []struct {
+		name      string
+		container []byte
+		err       string
+	}{
+		{"array", []byte{2, 0, 2, 0, 1, 7, 0, 0}, ""},
+		{"map", []byte{1, 0, 1, 0, 1, 7, 0, 1, 8}, ""},
+		{"truncated header", []byte{2, 0}, "container element at byte 0 exceeds the container"},
+		{"truncated size", []byte{2, 0, 1, 0}, "container element at byte 3 exceeds the container"},
+		{"truncated element", []byte{2, 0, 2, 0, 1, 7, 0xff, 0xff, 1}, "container element at byte 6 exceeds the container"},
+		{"missing element", []byte{2, 0, 2, 0, 1, 7}, "container declares 2 elements but contains 1"},
+		{"missing value", []byte{1, 0, 1, 0, 1, 7}, "container declares 2 elements but contains 1"},
+		{"too large", append([]byte{2, 0, 1, 0xff, 0xff}, make([]byte, MaxContainerSize)...), "container exceeds 1048576 bytes"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var err error
+			if test.container[0] == 1 {
+				_, err = MapFromByteArray(test.container)
+			} else {
+				_, err = ArrayFromByteArray(test.container)
+			}
+
+			if test.err == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.Error(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestContainers_DecodeMalformedOperand(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 0,
+		Push, 6, 2, 0, 2, 0, 1, 7,
+		ArrAt,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "arrat: container declares 2 elements but contains 1")
+}
+
+func TestContainers_NewArrFrom(t *testing.T) {
+	chain, isSuccess := execCode([]byte{
+		PushInt, 0, NewArr,
+		Push, 1, 7, Swap, ArrAppend,
+		Push, 2, 8, 9, Swap, ArrAppend,
+		Push, 0, Swap, ArrAppend,
+		Halt,
+	})
+	assert.Assert(t, isSuccess, chain.GetErrorMsg())
+
+	literal, isSuccess := execCode([]byte{Push, 1, 7, Push, 2, 8, 9, Push, 0, NewArrFrom, 3, Halt})
+	assert.Assert(t, isSuccess, literal.GetErrorMsg())
+	assert.DeepEqual(t, literal.PeekEvalStack(), chain.PeekEvalStack())
+	assert.Assert(t, literal.fee > chain.fee)
+
+	empty, isSuccess := execCode([]byte{NewArrFrom, 0, Halt})
+	assert.Assert(t, isSuccess, empty.GetErrorMsg())
+	assert.DeepEqual(t, empty.PeekEvalStack(), [][]byte{NewArray()})
+}
+
+func TestContainers_NewMapFrom(t *testing.T) {
+	chain, isSuccess := execCode([]byte{
+		Push, 1, 20, Push, 1, 2,
+		Push, 1, 10, Push, 1, 1,
+		NewMap, MapSetVal, MapSetVal,
+		Halt,
+	})
+	assert.Assert(t, isSuccess, chain.GetErrorMsg())
+
+	literal, isSuccess := execCode([]byte{Push, 1, 10, Push, 1, 1, Push, 1, 20, Push, 1, 2, NewMapFrom, 2, Halt})
+	assert.Assert(t, isSuccess, literal.GetErrorMsg())
+	assert.DeepEqual(t, literal.PeekEvalStack(), chain.PeekEvalStack())
+
+	duplicate, isSuccess := execCode([]byte{Push, 1, 10, Push, 1, 1, Push, 1, 20, Push, 1, 1, NewMapFrom, 2, Halt})
+	assert.Assert(t, isSuccess, duplicate.GetErrorMsg())
+	assert.DeepEqual(t, duplicate.PeekEvalStack(), [][]byte{{1, 0, 1, 0, 1, 1, 0, 1, 20}})
+}
+
+func TestContainers_LiteralErrors(t *testing.T) {
+	tests := []struct {
+		code []byte
+		err  string
+	}{
+		{[]byte{Push, 1, 7, NewArrFrom, 2, Halt}, "newarrfrom: index out of bounds"},
+		{[]byte{Push, 1, 7, Push, 1, 1, Push, 1, 8, NewMapFrom, 2, Halt}, "newmapfrom: index out of bounds"},
+		{[]byte{NewArrFrom}, "newarrfrom: instruction set out of bounds"},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode(test.code)
+		assert.Assert(t, !isSuccess)
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+	}
+}
+
+func TestContainers_ArrFillResize(t *testing.T) {
+	vm, isSuccess := execCode([]byte{
+		Push, 1, 9,
+		PushInt, 1, 0, 3,
+		PushInt, 1, 0, 2,
+		NewArr,
+		ArrResize,
+		ArrFill,
+		Halt,
+	})
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{2, 0, 3, 0, 1, 9, 0, 1, 9, 0, 1, 9}})
+
+	vm, isSuccess = execCode([]byte{Push, 1, 7, Push, 1, 8, NewArrFrom, 2, PushInt, 1, 0, 1, Swap, ArrResize, Halt})
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{2, 0, 1, 0, 1, 7}})
+}
+
+func TestContainers_ArrFillResizeErrors(t *testing.T) {
+	tests := []struct {
+		code []byte
+		err  string
+	}{
+		{[]byte{PushInt, 3, 0, 1, 0, 0, PushInt, 0, NewArr, ArrResize, Halt}, "arrresize: array size overflow"},
+		{[]byte{Push, 1, 7, NewMap, ArrFill, Halt}, "arrfill: not a valid array"},
+		{[]byte{Push, 1, 7, PushInt, 1, 0, 1, NewMap, ArrResize, Halt}, "arrresize: not a valid array"},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode(test.code)
+		assert.Assert(t, !isSuccess)
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+	}
+}
+
+func TestContainers_MapMerge(t *testing.T) {
+	vm, isSuccess := execCode([]byte{
+		Push, 1, 10, Push, 1, 1, Push, 1, 20, Push, 1, 2, NewMapFrom, 2,
+		Push, 1, 21, Push, 1, 2, Push, 1, 30, Push, 1, 3, NewMapFrom, 2,
+		MapMerge,
+		Halt,
+	})
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+	expected, isSuccess := execCode([]byte{
+		Push, 1, 10, Push, 1, 1, Push, 1, 21, Push, 1, 2, Push, 1, 30, Push, 1, 3, NewMapFrom, 3, Halt,
+	})
+	assert.Assert(t, isSuccess, expected.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), expected.PeekEvalStack())
+}
+
+func TestContainers_MapSetMany(t *testing.T) {
+	vm, isSuccess := execCode([]byte{
+		Push, 1, 10, Push, 1, 1, Push, 1, 20, Push, 1, 2, Push, 1, 11, Push, 1, 1,
+		Push, 1, 30, Push, 1, 3, NewMapFrom, 1,
+		MapSetMany, 3,
+		Halt,
+	})
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+	keys, values := mapEntries(t, vm)
+	assert.DeepEqual(t, keys, [][]byte{{3}, {2}, {1}})
+	assert.DeepEqual(t, values, [][]byte{{30}, {20}, {11}})
+}
+
+func TestContainers_MapBulkGas(t *testing.T) {
+	setMany, isSuccess := execCode([]byte{Push, 1, 10, Push, 1, 1, Push, 1, 20, Push, 1, 2, NewMap, MapSetMany, 2, Halt})
+	assert.Assert(t, isSuccess, setMany.GetErrorMsg())
+
+	setVal, isSuccess := execCode([]byte{Push, 1, 20, Push, 1, 2, Push, 1, 10, Push, 1, 1, NewMap, MapSetVal, MapSetVal, Halt})
+	assert.Assert(t, isSuccess, setVal.GetErrorMsg())
+	assert.DeepEqual(t, setMany.PeekEvalStack(), setVal.PeekEvalStack())
+	assert.Assert(t, setMany.fee > setVal.fee)
+}
+
+func TestContainers_MapBulkErrors(t *testing.T) {
+	tests := []struct {
+		code []byte
+		err  string
+	}{
+		{[]byte{NewMap, PushInt, 0, NewArr, MapMerge, Halt}, "mapmerge: invalid datatype supplied"},
+		{[]byte{Push, 1, 1, NewMap, MapSetMany, 1, Halt}, "mapsetmany: index out of bounds"},
+		{[]byte{Push, 1, 10, Push, 1, 1, PushInt, 0, NewArr, MapSetMany, 1, Halt}, "mapsetmany: invalid datatype supplied"},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode(test.code)
+		assert.Assert(t, !isSuccess)
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+	}
+}
+
+func mapEntries(t *testing.T, vm *VM) ([][]byte, [][]byte) {
+	tos, err := vm.evaluationStack.Pop()
+	assert.NilError(t, err)
+	m, err := MapFromByteArray(tos)
+	assert.NilError(t, err)
+	keys, values, err := m.Entries()
+	assert.NilError(t, err)
+	return keys, values
+}
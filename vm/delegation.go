@@ -0,0 +1,89 @@
+package vm
+
+// DelegationContext is implemented by contexts, which support the proxy pattern: the contract delegates its code to
+// another account, whose code is executed instead. The contract keeps its address, balance and contract variables.
+type DelegationContext interface {
+	AccountContext
+
+	// GetCodeDelegate returns the account, whose code is executed instead of the contract code.
+	// It returns false, if the code is not delegated.
+	GetCodeDelegate() ([32]byte, bool)
+
+	// SetCodeDelegate delegates the code of future executions to the account.
+	// Delegating to the contract itself removes the delegation.
+	SetCodeDelegate(address [32]byte) error
+}
+
+// CodeAddress returns the address of the account, whose code was executed by the last execution.
+// It is the address of the delegate, if the contract delegates its code, otherwise the address of the contract.
+func (vm *VM) CodeAddress() [32]byte {
+	return vm.codeAddress
+}
+
+// ContractAddress returns the address of the executing contract.
+func (vm *VM) ContractAddress() [32]byte {
+	return vm.selfAddress()
+}
+
+// loadCode returns the code to execute and the address of the account it is loaded from
+func (vm *VM) loadCode() ([]byte, [32]byte, error) {
+	self := vm.selfAddress()
+	delegationContext, ok := vm.context.(DelegationContext)
+	if !ok {
+		return vm.context.GetContract(), self, nil
+	}
+
+	delegate, ok := delegationContext.GetCodeDelegate()
+	if !ok || delegate == self {
+		return vm.context.GetContract(), self, nil
+	}
+
+	code, exists := delegationContext.GetAccountCode(delegate)
+	if !exists || len(code) == 0 {
+		return nil, delegate, newError(ErrInvalidDelegate, delegate)
+	}
+	return code, delegate, nil
+}
+
+// delegateCode pops a 32 byte address, to which the code is delegated, when the execution succeeds
+func (vm *VM) delegateCode(opCode OpCode) error {
+	if vm.caller() != vm.context.GetIssuer() {
+		return newError(ErrNotIssuer)
+	}
+
+	delegationContext, ok := vm.context.(DelegationContext)
+	if !ok {
+		return newError(ErrUnsupportedContext, opCode.Name)
+	}
+
+	delegate, isSelf, _, err := vm.popAccount(opCode)
+	if err != nil {
+		return err
+	}
+
+	if !isSelf {
+		code, exists := delegationContext.GetAccountCode(delegate)
+		if !exists || len(code) == 0 {
+			return newError(ErrInvalidDelegate, delegate)
+		}
+	}
+
+	vm.delegate = &delegate
+	return nil
+}
+
+// commitDelegate applies the delegation of the code, if the execution delegated it
+func (vm *VM) commitDelegate() error {
+	if vm.delegate == nil {
+		return nil
+	}
+	return vm.context.(DelegationContext).SetCodeDelegate(*vm.delegate)
+}
+
+// caller returns the immediate caller, which is the transaction signer, if the context does not declare a caller
+func (vm *VM) caller() [32]byte {
+	if callerContext, ok := vm.context.(CallerContext); ok {
+		return callerContext.GetCaller()
+	}
+	return vm.context.GetSender()
+}
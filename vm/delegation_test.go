@@ -0,0 +1,108 @@
+package vm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"gotest.tools/assert"
+)
+
+// withAccounts declares the accounts, which the contract can delegate to
+func withAccounts(accounts map[[32]byte][]byte) func(mc *MockContext) {
+	return func(mc *MockContext) {
+		mc.Address = selfAccount
+		mc.Accounts = accounts
+	}
+}
+
+func TestDelegation_Exec(t *testing.T) {
+	delegate := [32]byte{7}
+	mc := newTestContext([]byte{PushInt, 1, 0, 1, Halt},
+		withAccounts(map[[32]byte][]byte{delegate: {PushInt, 1, 0, 2, Halt}}))
+	mc.Delegate = &delegate
+
+	vm := NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+	assert.Assert(t, vm.Exec(false))
+
+	tos, _ := vm.PeekResult()
+	assert.DeepEqual(t, tos, []byte{0, 2})
+	assert.Equal(t, vm.CodeAddress(), delegate)
+	assert.Equal(t, vm.ContractAddress(), protocol.SerializeHashContent(selfAccount))
+}
+
+func TestDelegation_Exec_NotDelegated(t *testing.T) {
+	mc := newTestContext([]byte{PushInt, 1, 0, 1, Halt}, withAccounts(nil))
+
+	vm := NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+	assert.Assert(t, vm.Exec(false))
+	assert.Equal(t, vm.CodeAddress(), vm.ContractAddress())
+}
+
+func TestDelegation_Exec_InvalidDelegate(t *testing.T) {
+	delegate := [32]byte{7}
+	mc := newTestContext([]byte{Halt}, withAccounts(map[[32]byte][]byte{delegate: nil}))
+	mc.Delegate = &delegate
+
+	vm := NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), fmt.Sprintf("vm.exec(): code delegate %x is not a contract", delegate))
+}
+
+func TestDelegation_DelegateCode(t *testing.T) {
+	delegate := [32]byte{7}
+	mc := newTestContext(append(pushAddress(nil, delegate), DelegateCode, Halt),
+		withAccounts(map[[32]byte][]byte{delegate: {Halt}}))
+
+	vm := NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.DeepEqual(t, mc.Delegate, &delegate)
+
+	// Delegating to the contract itself removes the delegation
+	mc.Contract = append(pushAddress(nil, protocol.SerializeHashContent(selfAccount)), DelegateCode, Halt)
+	mc.Accounts[delegate] = mc.Contract
+	vm.Reset(mc)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.Assert(t, mc.Delegate == nil)
+}
+
+func TestDelegation_DelegateCode_Failed(t *testing.T) {
+	delegate := [32]byte{7}
+	missing := [32]byte{8}
+
+	tests := []struct {
+		code   []byte
+		issuer [32]byte
+		err    string
+	}{
+		{append(pushAddress(nil, delegate), DelegateCode, Halt), [32]byte{9},
+			"delegatecode: caller is not the issuer"},
+		{append(pushAddress(nil, missing), DelegateCode, Halt), [32]byte{},
+			fmt.Sprintf("delegatecode: code delegate %x is not a contract", missing)},
+		{[]byte{PushInt, 1, 0, 2, DelegateCode, Halt}, [32]byte{},
+			"delegatecode: not a valid address"},
+		{append(pushAddress(nil, delegate), DelegateCode, ErrHalt), [32]byte{}, ""},
+	}
+
+	for _, test := range tests {
+		mc := newTestContext(test.code, withAccounts(map[[32]byte][]byte{delegate: {Halt}}))
+		mc.Issuer = test.issuer
+
+		vm := NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+		assert.Assert(t, !vm.Exec(false))
+		if test.err != "" {
+			assert.Equal(t, vm.GetErrorMsg(), test.err)
+		}
+		assert.Assert(t, mc.Delegate == nil)
+	}
+}
+
+func TestDelegation_DelegateCode_UnsupportedContext(t *testing.T) {
+	mc := NewMockContext(append(pushAddress(nil, [32]byte{7}), DelegateCode, Halt))
+	mc.Fee = 5000
+	vm := NewTestVM([]byte{})
+	vm.context = plainContext{mc}
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "delegatecode: context does not support delegatecode")
+}
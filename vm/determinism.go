@@ -0,0 +1,48 @@
+package vm
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Nodes must agree on the result of every execution, so handlers must not depend on the wall clock, randomness or
+// the iteration order of Go maps. Nondeterministic values are only obtained through the helpers of this file. Built
+// with the tag determinism, the helpers panic if they are called by a function, which is not whitelisted.
+
+// determinismWhitelist contains the functions, which may use nondeterministic values, because they do not affect the
+// result of an execution, or iterate maps in an order independent way
+var determinismWhitelist = map[string]bool{
+	"(*watchdog).begin":                   true, // Development aid, the timeout does not change the result
+	"(*VM).DirtyVariables":                true, // Sorts the indexes
+	"(*VM).writeVariables":                true, // Sorts the indexes
+	"(*VM).LoopIterations":                true, // Copies the map
+	"(*VM).transientMemoryUsage":          true, // Sums up the sizes
+	"(*MockContext).SetContractVariables": true, // Sorts the indexes
+	"(*MockContext).Save":                 true, // Copies the map, which is encoded with sorted keys
+	"LoadMockContext":                     true, // Copies the map
+}
+
+// auditDeterminism panics in the determinism audit mode, if the caller of the helper is not whitelisted
+func auditDeterminism(helper string) {
+	if !determinismAudit {
+		return
+	}
+
+	caller := "unknown"
+	if pc, _, _, ok := runtime.Caller(2); ok {
+		caller = runtime.FuncForPC(pc).Name()
+		caller = caller[strings.LastIndex(caller, "/")+1:]
+		caller = strings.TrimPrefix(caller, "vm.")
+	}
+	if !determinismWhitelist[caller] {
+		panic(fmt.Sprintf("determinism audit: %v calls %v", caller, helper))
+	}
+}
+
+// now returns the wall clock time
+func now() time.Time {
+	auditDeterminism("now")
+	return time.Now()
+}
@@ -0,0 +1,7 @@
+//go:build determinism
+// +build determinism
+
+package vm
+
+// determinismAudit enables the audit of the nondeterministic helpers
+const determinismAudit = true
@@ -0,0 +1,146 @@
+package vm
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+// nondeterministicImports are only imported by the files, which do not affect the result of an execution
+var nondeterministicImports = map[string][]string{
+	"time":        {"config.go", "determinism.go", "watchdog.go"},
+	"math/rand":   nil,
+	"crypto/rand": nil,
+}
+
+// parsePackage parses the non-test files of the package
+func parsePackage(t *testing.T) (*token.FileSet, map[string]*ast.File) {
+	fileSet := token.NewFileSet()
+	paths, err := filepath.Glob("*.go")
+	assert.NilError(t, err)
+
+	files := make(map[string]*ast.File)
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fileSet, path, nil, 0)
+		assert.NilError(t, err)
+		files[path] = file
+	}
+	return fileSet, files
+}
+
+func TestDeterminism_Imports(t *testing.T) {
+	_, files := parsePackage(t)
+	for path, file := range files {
+		for _, spec := range file.Imports {
+			name, _ := strconv.Unquote(spec.Path.Value)
+			allowed, banned := nondeterministicImports[name]
+			if !banned {
+				continue
+			}
+
+			isAllowed := false
+			for _, allowedPath := range allowed {
+				isAllowed = isAllowed || allowedPath == path
+			}
+			assert.Assert(t, isAllowed, "%v imports %v", path, name)
+		}
+	}
+}
+
+// funcName returns the name of the function as reported by the runtime, e.g. (*VM).Exec
+func funcName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	switch recv := decl.Recv.List[0].Type.(type) {
+	case *ast.StarExpr:
+		return "(*" + recv.X.(*ast.Ident).Name + ")." + decl.Name.Name
+	case *ast.Ident:
+		return recv.Name + "." + decl.Name.Name
+	}
+	return decl.Name.Name
+}
+
+func TestDeterminism_MapIteration(t *testing.T) {
+	fileSet, files := parsePackage(t)
+
+	// Struct fields of a map type, a name declared with other types as well is ambiguous and not tracked
+	fields := make(map[string]bool)
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			if structType, ok := node.(*ast.StructType); ok {
+				for _, field := range structType.Fields.List {
+					_, isMap := field.Type.(*ast.MapType)
+					for _, name := range field.Names {
+						if previous, ok := fields[name.Name]; ok && previous != isMap {
+							isMap = false
+						}
+						fields[name.Name] = isMap
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			function, ok := decl.(*ast.FuncDecl)
+			if !ok || function.Body == nil || determinismWhitelist[funcName(function)] {
+				continue
+			}
+
+			// Parameters of a map type, local variables are not tracked
+			params := make(map[string]bool)
+			for _, param := range function.Type.Params.List {
+				if _, isMap := param.Type.(*ast.MapType); isMap {
+					for _, name := range param.Names {
+						params[name.Name] = true
+					}
+				}
+			}
+
+			ast.Inspect(function.Body, func(node ast.Node) bool {
+				statement, ok := node.(*ast.RangeStmt)
+				if !ok {
+					return true
+				}
+
+				isMap, name := false, ""
+				switch x := statement.X.(type) {
+				case *ast.Ident:
+					isMap, name = params[x.Name], x.Name
+				case *ast.SelectorExpr:
+					isMap, name = fields[x.Sel.Name], x.Sel.Name
+				}
+				assert.Assert(t, !isMap, "%v: %v iterates the map %v in random order",
+					fileSet.Position(statement.Pos()), funcName(function), name)
+				return true
+			})
+		}
+	}
+}
+
+func TestDeterminism_Audit(t *testing.T) {
+	defer func() {
+		r := recover()
+		assert.Equal(t, r != nil, determinismAudit, "%v", r)
+	}()
+	now()
+}
+
+func TestDeterminism_Whitelist(t *testing.T) {
+	w := &watchdog{timeout: time.Second}
+	w.begin(0, OpCodes[Add])
+	assert.Assert(t, !w.started.IsZero())
+}
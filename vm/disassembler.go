@@ -0,0 +1,82 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Instruction is a decoded opcode with its arguments.
+type Instruction struct {
+	Address int
+	OpCode  OpCode
+	Args    []byte
+}
+
+// String formats the instruction as assembly, the address is written as label.
+func (i Instruction) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%04d: %v", i.Address, i.OpCode.Name)
+	for _, arg := range i.Args {
+		fmt.Fprintf(&builder, " %v", arg)
+	}
+	return builder.String()
+}
+
+// Disassemble decodes the code into instructions. The code must not contain a function table.
+func Disassemble(code []byte) ([]Instruction, error) {
+	var instructions []Instruction
+
+	for pc := 0; pc < len(code); {
+		instruction, err := decodeInstruction(code, pc)
+		if err != nil {
+			return nil, err
+		}
+
+		instructions = append(instructions, instruction)
+		pc += 1 + len(instruction.Args)
+	}
+	return instructions, nil
+}
+
+// decodeInstruction decodes the instruction at the address pc
+func decodeInstruction(code []byte, pc int) (Instruction, error) {
+	if int(code[pc]) >= len(OpCodes) {
+		return Instruction{}, newError(ErrInvalidOpCode)
+	}
+	opCode := OpCodes[code[pc]]
+
+	size := 0
+	for _, argType := range opCode.ArgTypes {
+		if argType != BYTES {
+			size += ArgWidth(argType)
+			continue
+		}
+
+		if pc+1+size >= len(code) {
+			return Instruction{}, newError(ErrInstructionSetOutOfBounds)
+		}
+		length := int(code[pc+1+size])
+		size++
+
+		// The length of integers excludes the sign byte
+		if opCode.Code == PushInt && length > 0 {
+			length++
+		}
+		if opCode.Code == PushAddr {
+			if err := checkAddressLength(length); err != nil {
+				return Instruction{}, err
+			}
+		}
+		size += length
+	}
+
+	if pc+1+size > len(code) {
+		return Instruction{}, newError(ErrInstructionSetOutOfBounds)
+	}
+
+	return Instruction{
+		Address: pc,
+		OpCode:  opCode,
+		Args:    code[pc+1 : pc+1+size],
+	}, nil
+}
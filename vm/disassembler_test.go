@@ -0,0 +1,37 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestDisassemble(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 2,
+		PushInt, 0,
+		PushStr, 2, 'h', 'i',
+		Jmp, 0, 13,
+		Halt,
+	}
+
+	instructions, err := Disassemble(code)
+	assert.NilError(t, err)
+	assert.Equal(t, len(instructions), 5)
+	assert.Equal(t, instructions[0].String(), "0000: pushint 1 0 2")
+	assert.Equal(t, instructions[1].String(), "0004: pushint 0")
+	assert.Equal(t, instructions[2].String(), "0006: pushstr 2 104 105")
+	assert.Equal(t, instructions[3].String(), "0010: jmp 0 13")
+	assert.Equal(t, instructions[4].String(), "0013: halt")
+}
+
+func TestDisassemble_Errors(t *testing.T) {
+	_, err := Disassemble([]byte{PushInt, 1, 0})
+	assert.Error(t, err, "instruction set out of bounds")
+
+	_, err = Disassemble([]byte{200})
+	assert.Error(t, err, "not a valid opcode")
+
+	_, err = Disassemble(append([]byte{PushAddr, 20}, make([]byte, 20)...))
+	assert.Error(t, err, "address must have 32 or 64 bytes, but has 20")
+}
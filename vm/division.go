@@ -0,0 +1,29 @@
+package vm
+
+import "math/big"
+
+// Div and Mod divide Euclidean like big.Int.Div and big.Int.Mod: the remainder is never negative, e.g. -7 div 2 = -4
+// and -7 mod 2 = 1. The other pairs round the quotient differently, every pair satisfies
+// left = quotient * right + remainder with |remainder| < |right|:
+//
+//	          -7, 2    7, -2    -7, -2
+//	div/mod   -4, 1    -3, 1     4, 1
+//	divtrunc  -3, -1   -3, 1     3, -1
+//	divfloor  -4, 1    -4, -1    3, -1
+
+// divide returns the quotient of DivTrunc and DivFloor or the remainder of ModTrunc and ModFloor.
+// The divisor must not be zero.
+func divide(code byte, left *big.Int, right *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(left, right, new(big.Int))
+
+	// Truncation rounds toward zero, floor rounds down, if the remainder and the divisor have different signs
+	if (code == DivFloor || code == ModFloor) && remainder.Sign() != 0 && remainder.Sign() != right.Sign() {
+		quotient.Sub(quotient, big.NewInt(1))
+		remainder.Add(remainder, right)
+	}
+
+	if code == DivTrunc || code == DivFloor {
+		return quotient
+	}
+	return remainder
+}
@@ -0,0 +1,53 @@
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestDivision_NegativeOperands(t *testing.T) {
+	tests := []struct {
+		left, right int64
+		div, mod    int64
+		trunc, rem  int64
+		floor, fmod int64
+	}{
+		{7, 2, 3, 1, 3, 1, 3, 1},
+		{-7, 2, -4, 1, -3, -1, -4, 1},
+		{7, -2, -3, 1, -3, 1, -4, -1},
+		{-7, -2, 4, 1, 3, -1, 3, -1},
+		{-6, 2, -3, 0, -3, 0, -3, 0},
+		{0, -5, 0, 0, 0, 0, 0, 0},
+	}
+
+	for _, test := range tests {
+		expected := map[byte]int64{
+			Div: test.div, Mod: test.mod,
+			DivTrunc: test.trunc, ModTrunc: test.rem,
+			DivFloor: test.floor, ModFloor: test.fmod,
+		}
+		for _, op := range []byte{Div, Mod, DivTrunc, ModTrunc, DivFloor, ModFloor} {
+			for _, engine := range Engines {
+				code := push(nil, PushInt, SignedByteArrayConversion(*big.NewInt(test.left)))
+				code = push(code, PushInt, SignedByteArrayConversion(*big.NewInt(test.right)))
+				code = append(code, op, Halt)
+				vm := NewVMWithConfig(NewMockContext(code), VMConfig{Engine: engine})
+				assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+
+				result, err := vm.PopSignedBigInt(OpCodes[op])
+				assert.NilError(t, err)
+				assert.Equal(t, result.Int64(), expected[op], "%v %v %v", test.left, OpCodes[op].Name, test.right)
+			}
+		}
+	}
+}
+
+func TestDivision_ByZero(t *testing.T) {
+	for _, op := range []byte{DivTrunc, ModTrunc, DivFloor, ModFloor} {
+		vm, isSuccess := execCode([]byte{PushInt, 1, 0, 6, PushInt, 0, op, Halt})
+		assert.Assert(t, !isSuccess)
+		assert.Equal(t, vm.GetErrorMsg(), OpCodes[op].Name+": division by zero")
+	}
+}
@@ -0,0 +1,73 @@
+package vm
+
+import (
+	"encoding/hex"
+	"encoding/json"
+)
+
+// StateDump is the state of an execution written by DumpState. Byte values are hex encoded and all collections are
+// ordered, so equal states always yield the same JSON.
+type StateDump struct {
+	PC      int            `json:"pc"`
+	Stack   []string       `json:"stack"`   // Top of stack first
+	Frames  []FrameDump    `json:"frames"`  // Current frame first
+	Storage []StorageEntry `json:"storage"` // Contract variables written by the execution, ordered by index
+	Gas     GasDump        `json:"gas"`
+}
+
+// FrameDump is a frame of the call stack, uninitialized locals are empty.
+type FrameDump struct {
+	ReturnAddress int      `json:"returnAddress"`
+	Locals        []string `json:"locals"`
+}
+
+// StorageEntry is a contract variable of the storage journal.
+type StorageEntry struct {
+	Index int    `json:"index"`
+	Value string `json:"value"`
+}
+
+// GasDump is the gas consumption of an execution.
+type GasDump struct {
+	Used      uint64 `json:"used"`
+	Remaining uint64 `json:"remaining"`
+}
+
+// State returns the current state of the execution.
+func (vm *VM) State() StateDump {
+	state := StateDump{
+		PC:      vm.pc,
+		Stack:   []string{},
+		Frames:  []FrameDump{},
+		Storage: []StorageEntry{},
+		Gas:     GasDump{Used: vm.GasUsed(), Remaining: vm.fee},
+	}
+
+	if vm.evaluationStack != nil {
+		stack := vm.evaluationStack.Stack
+		for i := len(stack) - 1; i >= 0; i-- {
+			state.Stack = append(state.Stack, hex.EncodeToString(vm.objects.resolve(stack[i])))
+		}
+	}
+
+	if vm.callStack != nil {
+		for i := len(vm.callStack.values) - 1; i >= 0; i-- {
+			frame := vm.callStack.values[i]
+			locals := make([]string, len(frame.variables))
+			for j, variable := range frame.variables {
+				locals[j] = hex.EncodeToString(vm.objects.resolve(variable))
+			}
+			state.Frames = append(state.Frames, FrameDump{ReturnAddress: frame.returnAddress, Locals: locals})
+		}
+	}
+
+	for _, index := range vm.DirtyVariables() {
+		state.Storage = append(state.Storage, StorageEntry{Index: index, Value: hex.EncodeToString(vm.dirty[index])})
+	}
+	return state
+}
+
+// DumpState returns the current state of the execution as canonical JSON, i.e. compact and in a stable order.
+func (vm *VM) DumpState() ([]byte, error) {
+	return json.Marshal(vm.State())
+}
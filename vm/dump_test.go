@@ -0,0 +1,42 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestVM_DumpState(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 5,
+		StoreSt, 1,
+		PushInt, 1, 0, 7,
+		PushBool, 1,
+		Halt,
+	}
+	mc := NewMockContext(code)
+	mc.Fee = 5000
+	mc.ContractVariables = make([][]byte, 2)
+	vm := NewVM(mc)
+	assert.Assert(t, vm.Exec(false))
+
+	// A frame left over by a called function
+	vm.callStack.Push(&Frame{variables: [][]byte{{0, 1}, nil}, returnAddress: 7})
+
+	dump, err := vm.DumpState()
+	assert.NilError(t, err)
+	assert.Equal(t, string(dump), `{"pc":13,"stack":["01","0007"],"frames":[{"returnAddress":7,"locals":["0001",""]}],`+
+		`"storage":[{"index":1,"value":"0005"}],"gas":{"used":1013,"remaining":3987}}`)
+
+	again, err := vm.DumpState()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, again, dump)
+}
+
+func TestVM_DumpState_NotExecuted(t *testing.T) {
+	vm := NewVM(NewMockContext(nil))
+
+	dump, err := vm.DumpState()
+	assert.NilError(t, err)
+	assert.Equal(t, string(dump), `{"pc":0,"stack":[],"frames":[],"storage":[],"gas":{"used":0,"remaining":0}}`)
+}
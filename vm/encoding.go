@@ -0,0 +1,34 @@
+package vm
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// transcode converts the value between bytes and their hex or base64 text as selected by the opcode
+func transcode(code byte, value []byte) ([]byte, error) {
+	switch code {
+	case HexEncode:
+		text := make([]byte, hex.EncodedLen(len(value)))
+		hex.Encode(text, value)
+		return text, nil
+	case HexDecode:
+		decoded := make([]byte, hex.DecodedLen(len(value)))
+		if _, err := hex.Decode(decoded, value); err != nil {
+			return nil, newError(ErrInvalidEncoding, "hex")
+		}
+		return decoded, nil
+	case Base64Encode:
+		text := make([]byte, base64.StdEncoding.EncodedLen(len(value)))
+		base64.StdEncoding.Encode(text, value)
+		return text, nil
+	case Base64Decode:
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(value)))
+		n, err := base64.StdEncoding.Decode(decoded, value)
+		if err != nil {
+			return nil, newError(ErrInvalidEncoding, "base64")
+		}
+		return decoded[:n], nil
+	}
+	return nil, newError(ErrInvalidOpCode)
+}
@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestEncoding_Transcode(t *testing.T) {
+	tests := []struct {
+		value    []byte
+		opCode   byte
+		expected string
+	}{
+		{[]byte{Push, 3, 0x00, 0xab, 0xff}, HexEncode, "00abff"},
+		{push(nil, PushStr, []byte("00ABff")), HexDecode, "\x00\xab\xff"},
+		{push(nil, PushStr, []byte("hello")), Base64Encode, "aGVsbG8="},
+		{push(nil, PushStr, []byte("aGVsbG8=")), Base64Decode, "hello"},
+		{push(nil, PushStr, []byte("")), HexEncode, ""},
+		{push(nil, PushStr, []byte("")), Base64Decode, ""},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode(append(append([]byte{}, test.value...), test.opCode, Halt))
+		assert.Assert(t, isSuccess, vm.GetErrorMsg())
+		tos, _ := vm.evaluationStack.Pop()
+		assert.Equal(t, string(tos), test.expected, OpCodes[test.opCode].Name)
+	}
+}
+
+func TestEncoding_RoundTrip(t *testing.T) {
+	hash := make([]byte, 32)
+	for i := range hash {
+		hash[i] = byte(i * 7)
+	}
+	push := append([]byte{Push, 32}, hash...)
+
+	for _, opCodes := range [][]byte{{HexEncode, HexDecode}, {Base64Encode, Base64Decode}} {
+		vm, isSuccess := execCode(append(append([]byte{}, push...), opCodes[0], opCodes[1], Halt))
+		assert.Assert(t, isSuccess, vm.GetErrorMsg())
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, hash...)
+	}
+}
+
+func TestEncoding_InvalidText(t *testing.T) {
+	tests := []struct {
+		value  []byte
+		opCode byte
+		err    string
+	}{
+		{push(nil, PushStr, []byte("abc")), HexDecode, "hexdecode: invalid hex text"},
+		{push(nil, PushStr, []byte("zz")), HexDecode, "hexdecode: invalid hex text"},
+		{push(nil, PushStr, []byte("aGVsbG8")), Base64Decode, "base64decode: invalid base64 text"},
+		{push(nil, PushStr, []byte("a*==")), Base64Decode, "base64decode: invalid base64 text"},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode(append(append([]byte{}, test.value...), test.opCode, Halt))
+		assert.Assert(t, !isSuccess)
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+	}
+}
+
+func TestEncoding_SizeGas(t *testing.T) {
+	gasUsed := func(opCode byte) uint64 {
+		mc := NewMockContext(append(append([]byte{Push, 48}, make([]byte, 48)...), opCode, Halt))
+		mc.Fee = 1000
+		vm := NewVM(mc)
+		assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+		return vm.GasUsed()
+	}
+
+	// Both pop the 48 bytes as 1 word, HexEncode is additionally charged for the 96 hex characters as 2 words and the
+	// word of memory, by which they exceed the 48 bytes
+	pop, hexEncode := OpCodes[Pop], OpCodes[HexEncode]
+	expected := gasUsed(Pop) - pop.GasPrice - pop.GasFactor + hexEncode.GasPrice + 3*hexEncode.GasFactor + DefaultMemoryGasPrice
+	assert.Equal(t, gasUsed(HexEncode), expected)
+}
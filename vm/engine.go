@@ -0,0 +1,164 @@
+package vm
+
+import (
+	"math/big"
+)
+
+// Engine selects how the VM executes the instructions of a contract.
+type Engine string
+
+const (
+	// EngineInterpreter fetches, decodes and dispatches every instruction, when it is executed. It is the default.
+	EngineInterpreter Engine = "interpreter"
+	// EngineCompiled translates the instructions into closures before the execution, so immediate arguments are
+	// decoded once and frequent instructions bypass the dispatch of the interpreter. It is experimental.
+	EngineCompiled Engine = "compiled"
+)
+
+// Engines lists the engines, which can be configured.
+var Engines = []Engine{EngineInterpreter, EngineCompiled}
+
+// instructionFunc executes an instruction, whose opcode has been fetched. It returns true and the result of the
+// execution, if the instruction ended the execution.
+type instructionFunc func(vm *VM, opCode OpCode) (done bool, isSuccess bool)
+
+// compiledInstruction is an instruction of the code translated by the compiled engine
+type compiledInstruction struct {
+	opCode OpCode // Opcode with the costs of the gas schedule
+	run    instructionFunc
+}
+
+// compile translates the instructions of the code into closures by address. Only the addresses reached by decoding
+// the code from the start are compiled. All other addresses, e.g. jumps into arguments or code after a malformed
+// instruction, are left to the interpreter, which also reports their errors.
+func (vm *VM) compile(code []byte) []*compiledInstruction {
+	program := make([]*compiledInstruction, len(code))
+	for pc := 0; pc < len(code); {
+		instruction, err := decodeInstruction(code, pc)
+		if err != nil {
+			break
+		}
+
+		program[pc] = &compiledInstruction{
+			opCode: vm.opCode(code[pc]),
+			run:    compileInstruction(instruction, len(code)),
+		}
+		pc += 1 + len(instruction.Args)
+	}
+	return program
+}
+
+// fetchInstruction fetches the opcode at the program counter and returns the function executing it
+func (vm *VM) fetchInstruction() (OpCode, instructionFunc, error) {
+	if vm.pc < len(vm.program) && vm.program[vm.pc] != nil {
+		instruction := vm.program[vm.pc]
+		vm.pc++
+		return instruction.opCode, instruction.run, nil
+	}
+
+	byteCode, err := vm.fetch("vm.exec()")
+	if err != nil {
+		return OpCode{}, nil, err
+	}
+
+	if len(OpCodes) <= int(byteCode) {
+		return OpCode{}, nil, newError(ErrInvalidOpCode)
+	}
+	return vm.opCode(byteCode), (*VM).execute, nil
+}
+
+// compileInstruction returns a closure, which executes the instruction like the interpreter.
+// The interpreter only fetches arguments followed by further code, other instructions are left to it.
+func compileInstruction(instruction Instruction, codeSize int) instructionFunc {
+	next := instruction.Address + 1 + len(instruction.Args)
+	if next >= codeSize {
+		return (*VM).execute
+	}
+	args := instruction.Args
+
+	switch instruction.OpCode.Code {
+	case Push:
+		value := args[1:]
+		return func(vm *VM, opCode OpCode) (bool, bool) {
+			vm.pc = next
+			if err := vm.chargeImmediateGas(opCode, len(value)); err != nil {
+				vm.pushError(opCode, err)
+				return true, false
+			}
+
+			err := vm.evaluationStack.Push(append([]byte{}, value...))
+			return !vm.checkErrors(opCode.Name, err), false
+		}
+
+	case PushInt:
+		if args[0] == 0 {
+			return (*VM).execute
+		}
+
+		value := args[1:]
+		return func(vm *VM, opCode OpCode) (bool, bool) {
+			vm.pc = next
+			if err := vm.chargeImmediateGas(opCode, len(value)); err != nil {
+				vm.pushError(opCode, err)
+				return true, false
+			}
+
+			if err := vm.evaluationStack.Push(append([]byte{}, value...)); err != nil {
+				vm.pushError(opCode, err)
+				return true, false
+			}
+			return false, false
+		}
+
+	case Pop:
+		return func(vm *VM, opCode OpCode) (bool, bool) {
+			err := vm.discard(opCode)
+			return !vm.checkErrors(opCode.Name, err), false
+		}
+
+	case Add:
+		return func(vm *VM, opCode OpCode) (bool, bool) {
+			return !vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
+				left.Add(left, right)
+			}), false
+		}
+
+	case Sub:
+		return func(vm *VM, opCode OpCode) (bool, bool) {
+			return !vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
+				left.Sub(left, right)
+			}), false
+		}
+
+	case Jmp:
+		target := ByteArrayToInt(args)
+		return func(vm *VM, opCode OpCode) (bool, bool) {
+			vm.pc = next
+			if err := vm.jump(target); err != nil {
+				vm.pushError(opCode, err)
+				return true, false
+			}
+			return false, false
+		}
+
+	case JmpTrue, JmpFalse:
+		target := ByteArrayToInt(args)
+		jumpIf := instruction.OpCode.Code == JmpTrue
+		return func(vm *VM, opCode OpCode) (bool, bool) {
+			vm.pc = next
+			condition, err := vm.PopBytes(opCode)
+			if !vm.checkErrors(opCode.Name, err) {
+				return true, false
+			}
+
+			if ByteArrayToBool(condition) == jumpIf {
+				if err := vm.jump(target); err != nil {
+					vm.pushError(opCode, err)
+					return true, false
+				}
+			}
+			return false, false
+		}
+	}
+	return (*VM).execute
+}
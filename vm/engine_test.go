@@ -0,0 +1,144 @@
+package vm_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/asm"
+	"github.com/bazo-blockchain/bazo-vm/internal/codegen"
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+const engineCountdown = `
+	pushint 1 0 %v
+loop:
+	pushint 1 0 1
+	sub
+	dup
+	pushint 1 0 0
+	gt
+	jmptrue loop
+	halt
+`
+
+// stepRecorder records the steps of an execution as text, because the stacks of the steps are reused
+type stepRecorder struct {
+	steps []string
+}
+
+func (r *stepRecorder) CaptureStep(step vm.TraceStep) {
+	r.steps = append(r.steps, fmt.Sprintf("%+v", step))
+}
+
+type engineResult struct {
+	IsSuccess bool
+	Stack     [][]byte
+	GasUsed   uint64
+	Variables [][]byte
+	Steps     []string
+}
+
+func runEngine(code []byte, fee uint64, engine vm.Engine) engineResult {
+	mc := vm.NewMockContext(code)
+	mc.Fee = fee
+	mc.ContractVariables = make([][]byte, 2)
+	recorder := &stepRecorder{}
+	machine := vm.NewVMWithConfig(mc, vm.VMConfig{Engine: engine, Tracer: recorder, CheckIntegrity: true})
+
+	isSuccess := machine.Exec(false)
+	return engineResult{
+		IsSuccess: isSuccess,
+		Stack:     machine.PeekEvalStack(),
+		GasUsed:   machine.GasUsed(),
+		Variables: mc.ContractVariables,
+		Steps:     recorder.steps,
+	}
+}
+
+func assertSameResults(t *testing.T, code []byte, fee uint64) {
+	t.Helper()
+	interpreted := runEngine(code, fee, vm.EngineInterpreter)
+	compiled := runEngine(code, fee, vm.EngineCompiled)
+	assert.DeepEqual(t, compiled, interpreted)
+}
+
+func TestEngine_Compiled(t *testing.T) {
+	code, err := asm.Assemble(fmt.Sprintf(engineCountdown, 10))
+	assert.NilError(t, err)
+
+	result := runEngine(code, 100000, vm.EngineCompiled)
+	assert.Assert(t, result.IsSuccess)
+	assert.DeepEqual(t, result.Stack, [][]byte{{0}})
+	assertSameResults(t, code, 100000)
+}
+
+func TestEngine_Differential(t *testing.T) {
+	tests := []struct {
+		name string
+		code []byte
+		fee  uint64
+	}{
+		{"out of gas in loop", []byte{vm.PushInt, 1, 0, 100, vm.PushInt, 1, 0, 1, vm.Sub, vm.Dup, vm.JmpTrue, 0, 4, vm.Halt}, 200},
+		{"jump into arguments", []byte{vm.Jmp, 0, 5, vm.PushInt, 1, 0, vm.Halt, vm.Halt}, 1000},
+		{"truncated push", []byte{vm.Push, 3, 1}, 1000},
+		{"push at end", []byte{vm.Push, 1, 1}, 1000},
+		{"jump at end", []byte{vm.PushBool, 0, vm.Jmp, 0, 0}, 1000},
+		{"pop on empty stack", []byte{vm.Pop, vm.Halt}, 1000},
+		{"invalid opcode", []byte{vm.NoOp, 0, 0xff}, 1000},
+		{"store", []byte{vm.PushInt, 1, 0, 7, vm.StoreSt, 1, vm.Halt}, 1000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assertSameResults(t, test.code, test.fee)
+		})
+	}
+}
+
+func TestEngine_DifferentialGeneratedPrograms(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	config := codegen.DefaultConfig()
+	config.MaxLength = 100
+	config.UnderflowChance = 20
+
+	for i := 0; i < *fuzzIterations/10; i++ {
+		code := codegen.Program(r, config)
+		assertSameResults(t, code, uint64(r.Intn(2000)))
+	}
+}
+
+func TestEngine_DifferentialRandomCode(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < *fuzzIterations/10; i++ {
+		code := make([]byte, r.Intn(50)+1)
+		r.Read(code)
+		assertSameResults(t, code, 1000)
+	}
+}
+
+func benchmarkEngine(b *testing.B, engine vm.Engine) {
+	code, err := asm.Assemble(fmt.Sprintf(engineCountdown, 200))
+	assert.NilError(b, err)
+
+	machine := vm.NewVMWithConfig(nil, vm.VMConfig{Engine: engine})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mc := vm.NewMockContext(code)
+		mc.Fee = 1000000
+		machine.Reset(mc)
+		if !machine.Exec(false) {
+			b.Fatal(machine.GetErrorMsg())
+		}
+	}
+}
+
+func BenchmarkEngine_Interpreter(b *testing.B) {
+	benchmarkEngine(b, vm.EngineInterpreter)
+}
+
+func BenchmarkEngine_Compiled(b *testing.B) {
+	benchmarkEngine(b, vm.EngineCompiled)
+}
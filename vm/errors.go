@@ -0,0 +1,248 @@
+package vm
+
+import (
+	"fmt"
+)
+
+// ErrorCode identifies an error of the VM.
+// Error messages end up on the evaluation stack and are therefore part of the consensus.
+// The table is frozen: existing codes and messages must never be changed, new codes are appended only.
+type ErrorCode uint16
+
+// Error codes of the VM
+const (
+	ErrInstructionSetTooBig ErrorCode = iota + 1
+	ErrInvalidOpCode
+	ErrOutOfGas
+	ErrInstructionSetOutOfBounds
+	ErrInvalidBool
+	ErrInvalidASCII
+	ErrIndexOutOfBounds
+	ErrNegativeExponent
+	ErrDivisionByZero
+	ErrUnableToNegate
+	ErrNegativeShift
+	ErrReturnAddressOutOfBounds
+	ErrNegativeReturnTypes
+	ErrLessLocalsThanArguments
+	ErrReturnTypesMismatch
+	ErrFrameNotEmpty
+	ErrArgumentsMismatch
+	ErrReturnedElementsMismatch
+	ErrReentrantCall
+	ErrGuardNotActive
+	ErrArraySizeOverflow
+	ErrInvalidArgumentSize
+	ErrInvalidAddress
+	ErrInvalidHash
+	ErrLocalIndexOutOfBounds
+	ErrLocalNotInitialized
+	ErrPopOnEmptyCallStack
+	ErrPeekOnEmptyCallStack
+	ErrFunctionTableOutOfBounds
+	ErrFunctionNotFound
+	ErrIntegerOverflow
+	ErrInvalidArray
+	ErrArraySize
+	ErrArrayIncreaseSize
+	ErrArrayEmpty
+	ErrElementSizeOverflow
+	ErrArrayIndexOutOfBounds
+	ErrArrayInternals
+	ErrEmptyMap
+	ErrInvalidMap
+	ErrMapSize
+	ErrMapEmpty
+	ErrZeroElementSizes
+	ErrKeyValueSizeOverflow
+	ErrNoElementsInMap
+	ErrKeyNotFound
+	ErrRetrieveElement
+	ErrMapInternals
+	ErrStackOutOfMemory
+	ErrPopOnEmptyStack
+	ErrPeekOnEmptyStack
+	ErrValueExceeds32Bits
+	ErrValueExceedsUInt16
+	ErrInvalidSigningBit
+	ErrNegativeConversion
+	ErrNegativeSqrt
+	ErrNonPositiveLog
+	ErrUnknownBuiltin
+	ErrNegativeOperand
+	ErrSelfCall
+	ErrUnsupportedContext
+	ErrUint64Overflow
+	ErrStateSchemaOutOfBounds
+	ErrUnknownVariableType
+	ErrVariableType
+	ErrVariableSize
+	ErrIntegrity
+	ErrFixedWidthOverflow
+	ErrEmptyCallDataParam
+	ErrCallDataParamSize
+	ErrTooManyCallDataParams
+	ErrStackUnderflow
+	ErrNotIssuer
+	ErrInvalidDelegate
+	ErrPaused
+	ErrContainerTooLarge
+	ErrMalformedContainer
+	ErrContainerElementCount
+	ErrOpCodeDisabled
+	ErrTransientNotSet
+	ErrInvalidLength
+	ErrConstructorOutOfBounds
+	ErrNoConstructor
+	ErrAlreadyInitialized
+	ErrImmutableVariable
+	ErrInvalidAmount
+	ErrAmountOverflow
+	ErrAmountUnderflow
+	ErrNoVariable
+	ErrCancelled
+	ErrInvalidEncoding
+	ErrAddressLength
+	ErrMetadataOutOfBounds
+	ErrMetadataFieldSize
+	ErrUnknownMetadataField
+	ErrNonceMismatch
+	ErrDeadlinePassed
+	ErrInvalidTime
+	ErrTimeOverflow
+	ErrTimeUnderflow
+	ErrInvalidBlockInterval
+	ErrStructLayoutCount
+	ErrStructLayoutSize
+	ErrStructLayout
+	ErrDeprecatedBehavior
+	ErrTailCallByReference
+	ErrTailCallReturnTypes
+
+	// errorCodeEnd marks the end of the table, new codes are added above it
+	errorCodeEnd
+)
+
+var errorMessages = map[ErrorCode]string{
+	ErrInstructionSetTooBig:      "instruction set too big",
+	ErrInvalidOpCode:             "not a valid opcode",
+	ErrOutOfGas:                  "out of gas",
+	ErrInstructionSetOutOfBounds: "instruction set out of bounds",
+	ErrInvalidBool:               "invalid bool value %v",
+	ErrInvalidASCII:              "invalid ASCII code %v",
+	ErrIndexOutOfBounds:          "index out of bounds",
+	ErrNegativeExponent:          "negative exponents are not allowed",
+	ErrDivisionByZero:            "division by zero",
+	ErrUnableToNegate:            "unable to negate %v",
+	ErrNegativeShift:             "negative shift operand is not allowed",
+	ErrReturnAddressOutOfBounds:  "return address out of bounds",
+	ErrNegativeReturnTypes:       "number of return types cannot be negative",
+	ErrLessLocalsThanArguments:   "number of locals cannot be less than number of arguments",
+	ErrReturnTypesMismatch:       "number of return types does not match the current frame",
+	ErrFrameNotEmpty:             "evaluation stack of the current frame is not empty",
+	ErrArgumentsMismatch:         "number of arguments does not match the function declaration",
+	ErrReturnedElementsMismatch:  "number of returned elements does not match",
+	ErrReentrantCall:             "re-entrant call while guard is active",
+	ErrGuardNotActive:            "guard is not active",
+	ErrArraySizeOverflow:         "array size overflow",
+	ErrInvalidArgumentSize:       "invalid argument size",
+	ErrInvalidAddress:            "not a valid address",
+	ErrInvalidHash:               "not a valid hash",
+	ErrLocalIndexOutOfBounds:     "local variable index %v out of bounds, %v locals declared",
+	ErrLocalNotInitialized:       "local variable %v is not initialized",
+	ErrPopOnEmptyCallStack:       "pop() on empty call stack",
+	ErrPeekOnEmptyCallStack:      "peek() on empty call stack",
+	ErrFunctionTableOutOfBounds:  "function table out of bounds",
+	ErrFunctionNotFound:          "function not found",
+	ErrIntegerOverflow:           "integer overflow, result exceeds %v bytes",
+	ErrInvalidArray:              "not a valid array",
+	ErrArraySize:                 "cannot get size of array",
+	ErrArrayIncreaseSize:         "cannot increase size of array",
+	ErrArrayEmpty:                "array size is already 0",
+	ErrElementSizeOverflow:       "element size overflow",
+	ErrArrayIndexOutOfBounds:     "array index out of bounds",
+	ErrArrayInternals:            "array internals error",
+	ErrEmptyMap:                  "empty map",
+	ErrInvalidMap:                "invalid datatype supplied",
+	ErrMapSize:                   "cannot get size of map",
+	ErrMapEmpty:                  "map size is already 0",
+	ErrZeroElementSizes:          "element sizes are 0",
+	ErrKeyValueSizeOverflow:      "key or value size overflows uint16",
+	ErrNoElementsInMap:           "no elements in map",
+	ErrKeyNotFound:               "key not found",
+	ErrRetrieveElement:           "cannot retrieve element",
+	ErrMapInternals:              "map internals error",
+	ErrStackOutOfMemory:          "stack out of memory",
+	ErrPopOnEmptyStack:           "pop() on empty stack",
+	ErrPeekOnEmptyStack:          "peek() on empty stack",
+	ErrValueExceeds32Bits:        "value cannot be greater than 32 bits",
+	ErrValueExceedsUInt16:        "value cannot be greater than %v",
+	ErrInvalidSigningBit:         "invalid signing bit",
+	ErrNegativeConversion:        "negative integers cannot be converted to bytes",
+	ErrNegativeSqrt:              "square root of negative integers is not allowed",
+	ErrNonPositiveLog:            "logarithm of non-positive integers is not allowed",
+	ErrUnknownBuiltin:            "unknown built-in %v",
+	ErrNegativeOperand:           "negative operands are not allowed",
+	ErrSelfCall:                  "call to the executing contract is not allowed",
+	ErrUnsupportedContext:        "context does not support %v",
+	ErrUint64Overflow:            "integer does not fit into uint64",
+	ErrStateSchemaOutOfBounds:    "state schema out of bounds",
+	ErrUnknownVariableType:       "unknown variable type %v",
+	ErrVariableType:              "variable %v must be of type %v",
+	ErrVariableSize:              "variable %v exceeds %v bytes",
+	ErrIntegrity:                 "integrity check failed: %v",
+	ErrFixedWidthOverflow:        "value does not fit into %v bytes",
+	ErrEmptyCallDataParam:        "call data parameter %v is empty",
+	ErrCallDataParamSize:         "call data parameter %v exceeds %v bytes",
+	ErrTooManyCallDataParams:     "call data has more than %v parameters",
+	ErrStackUnderflow:            "stack underflow at pc=%v",
+	ErrNotIssuer:                 "caller is not the issuer",
+	ErrInvalidDelegate:           "code delegate %x is not a contract",
+	ErrPaused:                    "contract is paused",
+	ErrContainerTooLarge:         "container exceeds %v bytes",
+	ErrMalformedContainer:        "container element at byte %v exceeds the container",
+	ErrContainerElementCount:     "container declares %v elements but contains %v",
+	ErrOpCodeDisabled:            "opcode is disabled",
+	ErrTransientNotSet:           "transient key %x is not set",
+	ErrInvalidLength:             "expected %v bytes, but got %v",
+	ErrConstructorOutOfBounds:    "constructor out of bounds",
+	ErrNoConstructor:             "contract has no constructor",
+	ErrAlreadyInitialized:        "contract is already initialized",
+	ErrImmutableVariable:         "variable %v is immutable",
+	ErrInvalidAmount:             "amount must be between 0 and 2^64-1",
+	ErrAmountOverflow:            "amount overflow",
+	ErrAmountUnderflow:           "amount underflow",
+	ErrNoVariable:                "account %x has no variable %v",
+	ErrCancelled:                 "execution cancelled",
+	ErrInvalidEncoding:           "invalid %v text",
+	ErrAddressLength:             "address must have 32 or 64 bytes, but has %v",
+	ErrMetadataOutOfBounds:       "metadata out of bounds",
+	ErrMetadataFieldSize:         "metadata field %v exceeds 255 bytes",
+	ErrUnknownMetadataField:      "unknown metadata field %v",
+	ErrNonceMismatch:             "expected nonce %v, but got %v",
+	ErrDeadlinePassed:            "deadline %v passed at block time %v",
+	ErrInvalidTime:               "block height or duration must be between 0 and 2^64-1",
+	ErrTimeOverflow:              "block height or duration overflow",
+	ErrTimeUnderflow:             "block height or duration underflow",
+	ErrInvalidBlockInterval:      "block interval must be positive",
+	ErrStructLayoutCount:         "metadata declares more than 255 struct layouts",
+	ErrStructLayoutSize:          "struct layout %v exceeds 255 fields",
+	ErrStructLayout:              "struct with %v fields does not match a declared layout",
+	ErrDeprecatedBehavior:        "%v is deprecated in strict mode",
+	ErrTailCallByReference:       "tail calls cannot pass arguments by reference",
+	ErrTailCallReturnTypes:       "tail call at %v to a function with %v return types, but the caller has %v",
+}
+
+// Error is an error of the VM with a code of the error table.
+type Error struct {
+	Code ErrorCode
+	args []interface{}
+}
+
+func newError(code ErrorCode, args ...interface{}) *Error {
+	return &Error{Code: code, args: args}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf(errorMessages[e.Code], e.args...)
+}
@@ -0,0 +1,109 @@
+package vm
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Functions which are allowed to compose the error message pushed onto the evaluation stack.
+var errorPushFunctions = map[string]bool{
+	"pushError":     true,
+	"pushExecError": true,
+	"checkErrors":   true,
+	"trace":         true,
+}
+
+// Files which are not executed by the VM and may therefore return plain errors.
+var plainErrorFiles = map[string]bool{
+	"mock_context.go": true,
+	"tracer.go":       true,
+	"vectors.go":      true,
+}
+
+func TestErrors_UniqueMessages(t *testing.T) {
+	messages := make(map[string]ErrorCode)
+	for code, message := range errorMessages {
+		if other, ok := messages[message]; ok {
+			t.Errorf("Error codes %v and %v share the message '%v'", code, other, message)
+		}
+		messages[message] = code
+	}
+
+	for code := ErrInstructionSetTooBig; code < errorCodeEnd; code++ {
+		if _, ok := errorMessages[code]; !ok {
+			t.Errorf("Error code %v has no message", code)
+		}
+	}
+}
+
+func TestErrors_Error(t *testing.T) {
+	err := newError(ErrUnableToNegate, 3)
+	if err.Error() != "unable to negate 3" {
+		t.Errorf("Expected 'unable to negate 3' but got '%v'", err.Error())
+	}
+}
+
+// Error messages are part of the consensus, therefore every error has to be created from the error table.
+func TestErrors_NoUnknownMessages(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || file == "errors.go" {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+
+			ast.Inspect(fn, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+
+				if pkg, ok := sel.X.(*ast.Ident); ok && !plainErrorFiles[file] {
+					if (pkg.Name == "errors" && (sel.Sel.Name == "New" || sel.Sel.Name == "Wrap" || sel.Sel.Name == "Errorf")) ||
+						(pkg.Name == "fmt" && sel.Sel.Name == "Errorf") {
+						t.Errorf("%v: error created outside of the error table", fset.Position(call.Pos()))
+					}
+				}
+
+				if sel.Sel.Name == "Push" && !errorPushFunctions[fn.Name.Name] && containsStringLiteral(call) {
+					t.Errorf("%v: string literal pushed onto the stack", fset.Position(call.Pos()))
+				}
+				return true
+			})
+		}
+	}
+}
+
+func containsStringLiteral(node ast.Node) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
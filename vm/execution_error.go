@@ -0,0 +1,51 @@
+package vm
+
+import "fmt"
+
+// ExecutionError describes the failure of an execution: the error message, the address of the failed instruction and,
+// if a source map is set, its location in the source code, so compiler users do not have to resolve raw offsets.
+type ExecutionError struct {
+	Message string `json:"message"`
+	// Address of the failed instruction, -1 if the execution failed before its first instruction
+	PC int `json:"pc"`
+	// Name of the failed instruction, if it is a valid opcode
+	OpCode string `json:"opcode,omitempty"`
+	// Source location of the failed instruction, if a source map is set
+	Source *SourceLocation `json:"source,omitempty"`
+	// Function calls, which led to the failed instruction, innermost call first
+	Backtrace []BacktraceFrame `json:"backtrace,omitempty"`
+}
+
+// Error formats the error with its location, e.g. "pc=0012 (token.lazo:7:3): add: integer overflow".
+func (e *ExecutionError) Error() string {
+	if e.PC < 0 {
+		return e.Message
+	}
+	if e.Source != nil {
+		return fmt.Sprintf("pc=%04d (%v): %v", e.PC, e.Source, e.Message)
+	}
+	return fmt.Sprintf("pc=%04d: %v", e.PC, e.Message)
+}
+
+// GetError returns the failure of the last execution, nil if it succeeded or the VM has not been executed.
+// It replaces GetErrorMsg, GetErrorLocation and GetErrorBacktrace, whose results it contains.
+func (vm *VM) GetError() *ExecutionError {
+	return vm.failure
+}
+
+// recordFailure records the failure of the execution at the address, -1 if it failed before its first instruction.
+// The error message is on top of the evaluation stack.
+func (vm *VM) recordFailure(pc int) {
+	failure := &ExecutionError{
+		Message:   vm.GetErrorMsg(),
+		PC:        pc,
+		Backtrace: vm.GetErrorBacktrace(),
+	}
+	if pc >= 0 && pc < len(vm.code) && int(vm.code[pc]) < len(OpCodes) {
+		failure.OpCode = OpCodes[vm.code[pc]].Name
+	}
+	if location, ok := vm.sourceLocation(pc); ok && pc >= 0 {
+		failure.Source = &location
+	}
+	vm.failure = failure
+}
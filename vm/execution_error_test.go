@@ -0,0 +1,69 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestExecutionError_FailedInstruction(t *testing.T) {
+	for _, engine := range Engines {
+		mc := NewMockContext(backtraceCode)
+		mc.Fee = 1000
+		vm := NewVMWithConfig(mc, VMConfig{Engine: engine})
+		vm.SetSourceMap(NewSourceMap([]SourceMapEntry{
+			{Offset: 22, SourceLocation: SourceLocation{File: "a.lazo", Line: 7, Column: 5}},
+		}))
+		assert.Assert(t, !vm.Exec(false))
+
+		failure := vm.GetError()
+		assert.Assert(t, failure != nil)
+		assert.Equal(t, failure.Message, vm.GetErrorMsg())
+		assert.Equal(t, failure.PC, 26)
+		assert.Equal(t, failure.OpCode, "div")
+		assert.DeepEqual(t, *failure.Source, SourceLocation{File: "a.lazo", Line: 7, Column: 5})
+		assert.Equal(t, len(failure.Backtrace), 2)
+		assert.Equal(t, failure.Error(), "pc=0026 (a.lazo:7:5): "+vm.GetErrorMsg())
+	}
+}
+
+func TestExecutionError_WithoutSourceMap(t *testing.T) {
+	vm := NewVM(NewMockContext([]byte{PushInt, 0, Pop, Pop, Halt}))
+	assert.Assert(t, !vm.Exec(false))
+
+	failure := vm.GetError()
+	assert.Equal(t, failure.PC, 3)
+	assert.Assert(t, failure.Source == nil)
+	assert.Equal(t, failure.Error(), "pc=0003: pop: stack underflow at pc=3")
+}
+
+func TestExecutionError_BeforeFirstInstruction(t *testing.T) {
+	mc := NewMockContext([]byte{Halt})
+	mc.Fee = 0
+	vm := NewVM(mc)
+	vm.SetSourceMap(NewSourceMap([]SourceMapEntry{{Offset: 0, SourceLocation: SourceLocation{File: "a.lazo", Line: 1}}}))
+	assert.Assert(t, !vm.Exec(false))
+
+	failure := vm.GetError()
+	assert.Equal(t, failure.PC, -1)
+	assert.Equal(t, failure.OpCode, "")
+	assert.Assert(t, failure.Source == nil)
+	assert.Equal(t, failure.Error(), "vm.exec(): out of gas")
+
+	_, ok := vm.GetErrorLocation()
+	assert.Assert(t, !ok)
+}
+
+func TestExecutionError_Success(t *testing.T) {
+	vm := NewVM(NewMockContext([]byte{Halt}))
+	assert.Assert(t, vm.Exec(false))
+	assert.Assert(t, vm.GetError() == nil)
+}
+
+func TestExecutionError_ExecSteps(t *testing.T) {
+	vm := NewVM(NewMockContext([]byte{PushInt, 0, Pop, Pop, Halt}))
+	assert.Equal(t, vm.ExecSteps(2), StatusPaused)
+	assert.Assert(t, vm.GetError() == nil)
+	assert.Equal(t, vm.ExecSteps(2), StatusFailed)
+	assert.Equal(t, vm.GetError().PC, 3)
+}
@@ -0,0 +1,73 @@
+package vm
+
+import (
+	"bytes"
+	"sort"
+)
+
+// Canonicalize returns the canonical form of a contract, which is equal for all builds of the same program:
+// the sections are re-encoded, the entries of the function table are ordered by their hash and the NoOp padding at
+// the end of the code is removed. NoOps, which are the target of a jump, a call, a function or the constructor, are
+// kept, so the canonical contract executes like the original one.
+func Canonicalize(contract []byte) ([]byte, error) {
+	sections, err := ParseSections(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	instructions, err := Disassemble(sections.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	// Padding starts after the last address targeted by a jump, a call, a function or the constructor
+	end := sections.Constructor + 1
+	for _, f := range sections.Functions {
+		if int(f.Address) >= end {
+			end = int(f.Address) + 1
+		}
+	}
+	for _, instruction := range instructions {
+		offset := 0
+		for _, argType := range instruction.OpCode.ArgTypes {
+			if argType == LABEL {
+				target, _ := ByteArrayToUI16(instruction.Args[offset : offset+2])
+				if int(target) >= end {
+					end = int(target) + 1
+				}
+			}
+			offset += ArgWidth(argType)
+		}
+	}
+
+	size := len(sections.Code)
+	for i := len(instructions) - 1; i >= 0; i-- {
+		if instructions[i].OpCode.Code != NoOp || instructions[i].Address < end {
+			break
+		}
+		size = instructions[i].Address
+	}
+	sections.Code = sections.Code[:size]
+
+	if sections.Functions != nil {
+		sorted := append([]Function{}, sections.Functions...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return bytes.Compare(sorted[i].Hash[:], sorted[j].Hash[:]) < 0
+		})
+		sections.Functions = sorted
+	}
+	return NewContract(sections)
+}
+
+// Fingerprint returns the SHA3-256 hash of the canonical form of the contract, so that reproducible builds can be
+// verified and deployed contracts can be matched to their source. Contracts, which cannot be decoded, are hashed
+// unchanged.
+func Fingerprint(code []byte) [32]byte {
+	if canonical, err := Canonicalize(code); err == nil {
+		code = canonical
+	}
+
+	var fingerprint [32]byte
+	copy(fingerprint[:], codeHash(code, true))
+	return fingerprint
+}
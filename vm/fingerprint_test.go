@@ -0,0 +1,106 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestFingerprint_StripsNoOpPadding(t *testing.T) {
+	code := []byte{PushInt, 1, 0, NoOp, Halt}
+	padded := append(append([]byte{}, code...), NoOp, NoOp, NoOp)
+
+	canonical, err := Canonicalize(padded)
+	assert.NilError(t, err)
+	assertBytes(t, canonical, code...)
+	assert.Equal(t, Fingerprint(padded), Fingerprint(code))
+}
+
+func TestFingerprint_KeepsTargetedNoOps(t *testing.T) {
+	// The NoOp at address 5 is the target of the jump, it has to stay, the one after it is padding
+	code := []byte{PushBool, 1, JmpTrue, 0, 5, NoOp, NoOp}
+
+	canonical, err := Canonicalize(code)
+	assert.NilError(t, err)
+	assertBytes(t, canonical, PushBool, 1, JmpTrue, 0, 5, NoOp)
+}
+
+func TestFingerprint_KeepsNoOpArguments(t *testing.T) {
+	// The last byte is the argument of pushint, which equals the NoOp opcode
+	code := []byte{PushInt, 1, 0, NoOp}
+
+	canonical, err := Canonicalize(code)
+	assert.NilError(t, err)
+	assertBytes(t, canonical, code...)
+}
+
+func TestFingerprint_OrdersFunctionTable(t *testing.T) {
+	add := Function{Hash: FunctionHash("add"), Address: 0, NrOfArgs: 2, NrOfReturnTypes: 1, NrOfLocals: 2}
+	sub := Function{Hash: FunctionHash("sub"), Address: 4, NrOfArgs: 2, NrOfReturnTypes: 1, NrOfLocals: 2}
+	code := []byte{LoadLoc, 0, Add, Ret, LoadLoc, 0, Sub, Ret}
+
+	first := append(NewFunctionTable([]Function{add, sub}), code...)
+	second := append(NewFunctionTable([]Function{sub, add}), append(code, NoOp)...)
+	assert.Equal(t, Fingerprint(first), Fingerprint(second))
+
+	canonical, err := Canonicalize(second)
+	assert.NilError(t, err)
+	functions, actualCode, err := ParseFunctionTable(canonical)
+	assert.NilError(t, err)
+	assert.Equal(t, len(functions), 2)
+	assert.Assert(t, string(functions[0].Hash[:]) < string(functions[1].Hash[:]))
+	assertBytes(t, actualCode, code...)
+}
+
+func TestFingerprint_DistinguishesPrograms(t *testing.T) {
+	assert.Assert(t, Fingerprint([]byte{PushInt, 1, 0, 1, Halt}) != Fingerprint([]byte{PushInt, 1, 0, 2, Halt}))
+}
+
+func TestFingerprint_UndecodableCode(t *testing.T) {
+	code := []byte{PushInt, 5, 0}
+
+	_, err := Canonicalize(code)
+	assert.Error(t, err, "instruction set out of bounds")
+	assert.Equal(t, Fingerprint(code), contractChecksum(code))
+}
+
+func TestFingerprint_KeepsSections(t *testing.T) {
+	metadata, err := NewMetadataSection(Metadata{Name: "counter", Version: "1.0"})
+	assert.NilError(t, err)
+	sections := [][]byte{
+		NewStateSchema(StateSchema{{Type: TypeInt}}),
+		NewConstructorSection(1),
+		metadata,
+	}
+
+	for _, section := range sections {
+		code := append(append([]byte{}, section...), Halt, Halt)
+		padded := append(append([]byte{}, code...), NoOp, NoOp)
+
+		canonical, err := Canonicalize(padded)
+		assert.NilError(t, err)
+		assertBytes(t, canonical, code...)
+		assert.Equal(t, Fingerprint(padded), Fingerprint(code))
+	}
+}
+
+func TestFingerprint_KeepsConstructorNoOps(t *testing.T) {
+	// The constructor at address 1 consists of NoOps only, the one at its address has to stay
+	contract := append(NewConstructorSection(1), Halt, NoOp, NoOp)
+
+	canonical, err := Canonicalize(contract)
+	assert.NilError(t, err)
+	assertBytes(t, canonical, append(NewConstructorSection(1), Halt, NoOp)...)
+}
+
+func TestFingerprint_OrdersFunctionTableAfterSections(t *testing.T) {
+	add := Function{Hash: FunctionHash("add"), Address: 0, NrOfArgs: 2, NrOfReturnTypes: 1, NrOfLocals: 2}
+	sub := Function{Hash: FunctionHash("sub"), Address: 4, NrOfArgs: 2, NrOfReturnTypes: 1, NrOfLocals: 2}
+	code := []byte{LoadLoc, 0, Add, Ret, LoadLoc, 0, Sub, Ret}
+	schema := NewStateSchema(StateSchema{{Type: TypeInt}})
+
+	first := append(append(append([]byte{}, schema...), NewFunctionTable([]Function{add, sub})...), code...)
+	second := append(append(append([]byte{}, schema...), NewFunctionTable([]Function{sub, add})...), code...)
+	assert.Equal(t, Fingerprint(first), Fingerprint(second))
+	assert.Assert(t, Fingerprint(first) != Fingerprint(append(NewFunctionTable([]Function{add, sub}), code...)))
+}
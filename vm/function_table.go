@@ -0,0 +1,87 @@
+package vm
+
+import (
+	"bytes"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// FunctionTableMarker is the first byte of a contract which starts with a function table.
+// It is never a valid opcode, therefore contracts without a function table are not affected.
+const FunctionTableMarker = 0xFF
+
+// Size of a function table entry in bytes: hash (4), address (2), arguments (1), return types (1), locals (1)
+const functionEntrySize = 9
+
+// Function describes an entry point of a contract as declared in the function table.
+type Function struct {
+	Hash            [4]byte
+	Address         uint16
+	NrOfArgs        byte
+	NrOfReturnTypes byte
+	NrOfLocals      byte
+}
+
+// FunctionHash returns the first 4 bytes of the SHA3 hash of the function name,
+// which identifies the function in the function table.
+func FunctionHash(name string) [4]byte {
+	var result [4]byte
+	hasher := sha3.New256()
+	hasher.Write([]byte(name))
+	copy(result[:], hasher.Sum(nil))
+	return result
+}
+
+// NewFunctionTable serializes the functions into a function table, which can be prepended to the contract code.
+// The addresses of the functions are relative to the code following the table.
+func NewFunctionTable(functions []Function) []byte {
+	table := []byte{FunctionTableMarker}
+	table = append(table, UInt16ToByteArray(uint16(len(functions)))...)
+
+	for _, f := range functions {
+		table = append(table, f.Hash[:]...)
+		table = append(table, UInt16ToByteArray(f.Address)...)
+		table = append(table, f.NrOfArgs, f.NrOfReturnTypes, f.NrOfLocals)
+	}
+	return table
+}
+
+// ParseFunctionTable splits the contract into the declared functions and the executable code.
+// Contracts without a function table are returned unchanged.
+func ParseFunctionTable(contract []byte) (functions []Function, code []byte, err error) {
+	if len(contract) == 0 || contract[0] != FunctionTableMarker {
+		return nil, contract, nil
+	}
+
+	if len(contract) < 3 {
+		return nil, nil, newError(ErrFunctionTableOutOfBounds)
+	}
+
+	size, _ := ByteArrayToUI16(contract[1:3])
+	codeStart := 3 + int(size)*functionEntrySize
+	if len(contract) < codeStart {
+		return nil, nil, newError(ErrFunctionTableOutOfBounds)
+	}
+
+	functions = make([]Function, size)
+	for i := range functions {
+		entry := contract[3+i*functionEntrySize : 3+(i+1)*functionEntrySize]
+
+		copy(functions[i].Hash[:], entry[:4])
+		functions[i].Address, _ = ByteArrayToUI16(entry[4:6])
+		functions[i].NrOfArgs = entry[6]
+		functions[i].NrOfReturnTypes = entry[7]
+		functions[i].NrOfLocals = entry[8]
+	}
+
+	return functions, contract[codeStart:], nil
+}
+
+func findFunction(functions []Function, hash []byte) (Function, error) {
+	for _, f := range functions {
+		if bytes.Equal(f.Hash[:], hash) {
+			return f, nil
+		}
+	}
+	return Function{}, newError(ErrFunctionNotFound)
+}
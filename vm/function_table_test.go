@@ -0,0 +1,40 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestFunctionTable_ParseWithoutTable(t *testing.T) {
+	code := []byte{PushInt, 0, Halt}
+
+	functions, actualCode, err := ParseFunctionTable(code)
+	assert.NilError(t, err)
+	assert.Equal(t, len(functions), 0)
+	assertBytes(t, actualCode, code...)
+}
+
+func TestFunctionTable_NewAndParse(t *testing.T) {
+	expected := []Function{
+		{Hash: FunctionHash("add"), Address: 10, NrOfArgs: 2, NrOfReturnTypes: 1, NrOfLocals: 2},
+		{Hash: FunctionHash("sub"), Address: 20, NrOfArgs: 2, NrOfReturnTypes: 1, NrOfLocals: 3},
+	}
+	code := append(NewFunctionTable(expected), Halt)
+
+	functions, actualCode, err := ParseFunctionTable(code)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, functions, expected)
+	assertBytes(t, actualCode, Halt)
+}
+
+func TestFunctionTable_ParseOutOfBounds(t *testing.T) {
+	code := []byte{FunctionTableMarker, 0, 1, 1, 2, 3}
+
+	_, _, err := ParseFunctionTable(code)
+	assert.Error(t, err, "function table out of bounds")
+}
+
+func TestFunctionTable_FunctionHash(t *testing.T) {
+	assert.Assert(t, FunctionHash("add") != FunctionHash("sub"))
+}
@@ -0,0 +1,48 @@
+package vm_test
+
+import (
+	"flag"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/internal/codegen"
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+var fuzzIterations = flag.Int("fuzz.iterations", 1000, "number of generated programs executed by the fuzz test")
+
+// Generated programs are structurally valid, they must not fail because of the stack or the control flow
+var structuralErrors = []string{"empty stack", "out of bounds", "opcode", "integrity check failed"}
+
+func TestFuzz_GeneratedPrograms(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	config := codegen.DefaultConfig()
+	config.MaxLength = 200
+
+	for i := 0; i < *fuzzIterations; i++ {
+		code := codegen.Program(r, config)
+
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					t.Fatalf("Code %v panicked: %v", code, err)
+				}
+			}()
+
+			mc := vm.NewMockContext(code)
+			mc.Fee = 100000
+			machine := vm.NewVM(mc)
+			machine.SetConfig(vm.VMConfig{CheckIntegrity: true})
+
+			if machine.Exec(false) {
+				return
+			}
+			for _, err := range structuralErrors {
+				if msg := machine.GetErrorMsg(); strings.Contains(msg, err) {
+					t.Fatalf("Code %v failed with '%v'", code, msg)
+				}
+			}
+		}()
+	}
+}
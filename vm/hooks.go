@@ -0,0 +1,30 @@
+package vm
+
+// OpHook is invoked with the address and the opcode of an instruction and a read-only view of the VM.
+// If the hook returns an error, the execution fails with this error.
+type OpHook func(pc int, opCode byte, state StateView) error
+
+// StateView gives hooks read-only access to the state of the VM.
+type StateView interface {
+	// GetRemainingFee returns the fee, which has not been used yet.
+	GetRemainingFee() uint64
+	// PeekEvalStack returns a copy of the evaluation stack.
+	PeekEvalStack() [][]byte
+	// CallDepth returns the number of active function calls.
+	CallDepth() int
+	// DirtyVariables returns the indexes of the contract variables written so far.
+	DirtyVariables() []int
+}
+
+// CallDepth returns the number of active function calls.
+func (vm *VM) CallDepth() int {
+	return vm.callStack.GetLength()
+}
+
+// runHook invokes the hook, if it is set
+func (vm *VM) runHook(hook OpHook, pc int, opCode OpCode) error {
+	if hook == nil {
+		return nil
+	}
+	return hook(pc, opCode.Code, vm)
+}
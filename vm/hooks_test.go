@@ -0,0 +1,58 @@
+package vm
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestHooks_Order(t *testing.T) {
+	var calls []string
+	record := func(prefix string) OpHook {
+		return func(pc int, opCode byte, state StateView) error {
+			calls = append(calls, prefix+OpCodes[opCode].Name)
+			return nil
+		}
+	}
+
+	mc := NewMockContext([]byte{PushInt, 1, 0, 1, Pop, Halt})
+	mc.Fee = 100
+	vm := NewVM(mc)
+	vm.SetConfig(VMConfig{PreOpHook: record("pre "), PostOpHook: record("post ")})
+
+	assert.Assert(t, vm.Exec(false))
+	assert.DeepEqual(t, calls, []string{"pre pushint", "post pushint", "pre pop", "post pop", "pre halt"})
+}
+
+func TestHooks_StateView(t *testing.T) {
+	var stackSizes []int
+	mc := NewMockContext([]byte{PushInt, 1, 0, 1, PushInt, 1, 0, 2, Halt})
+	mc.Fee = 100
+	vm := NewVM(mc)
+	vm.SetConfig(VMConfig{PostOpHook: func(pc int, opCode byte, state StateView) error {
+		stackSizes = append(stackSizes, len(state.PeekEvalStack()))
+		assert.Equal(t, state.CallDepth(), 0)
+		return nil
+	}})
+
+	assert.Assert(t, vm.Exec(false))
+	assert.DeepEqual(t, stackSizes, []int{1, 2})
+}
+
+func TestHooks_Policy(t *testing.T) {
+	mc := NewMockContext([]byte{PushInt, 1, 0, 1, StoreSt, 0, Halt})
+	mc.ContractVariables = [][]byte{{0}}
+	mc.Fee = 5000
+	vm := NewVM(mc)
+	vm.SetConfig(VMConfig{PreOpHook: func(pc int, opCode byte, state StateView) error {
+		if opCode == StoreSt {
+			return errors.New("storage is read-only")
+		}
+		return nil
+	}})
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "storest: storage is read-only")
+	assert.Equal(t, mc.Batches, 0)
+}
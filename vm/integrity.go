@@ -0,0 +1,75 @@
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// checkIntegrity verifies the invariants of the evaluation stack and the call stack after an instruction
+func (vm *VM) checkIntegrity() error {
+	if contractChecksum(vm.contract) != vm.codeChecksum {
+		return newError(ErrIntegrity, "contract code modified during the execution")
+	}
+
+	var memoryUsage uint32
+	for i, element := range vm.evaluationStack.Stack {
+		if element == nil {
+			return newError(ErrIntegrity, fmt.Sprintf("nil element at index %v", i))
+		}
+		memoryUsage += uint32(len(element))
+	}
+
+	if memoryUsage != vm.evaluationStack.memoryUsage {
+		return newError(ErrIntegrity,
+			fmt.Sprintf("memory usage is %v bytes, but elements use %v bytes", vm.evaluationStack.memoryUsage, memoryUsage))
+	}
+
+	offset := 0
+	for i, frame := range vm.callStack.values {
+		if frame == nil {
+			return newError(ErrIntegrity, fmt.Sprintf("nil call frame at depth %v", i))
+		}
+		if frame.returnAddress < 0 || frame.returnAddress > len(vm.code) {
+			return newError(ErrIntegrity, fmt.Sprintf("return address %v out of bounds at depth %v", frame.returnAddress, i))
+		}
+		if frame.nrOfReturnTypes < 0 {
+			return newError(ErrIntegrity, fmt.Sprintf("negative number of return types at depth %v", i))
+		}
+		if frame.evalStackOffset-len(frame.references) < offset {
+			return newError(ErrIntegrity, fmt.Sprintf("stack offset %v below the caller at depth %v", frame.evalStackOffset, i))
+		}
+		offset = frame.evalStackOffset
+	}
+	return nil
+}
+
+// contractChecksum hashes the contract code, which must not change during the execution
+func contractChecksum(code []byte) [32]byte {
+	var checksum [32]byte
+	copy(checksum[:], codeHash(code, true))
+	return checksum
+}
+
+// evaluationStackChecksum hashes the evaluation stack, so modifications between instructions are detected
+func (vm *VM) evaluationStackChecksum() [32]byte {
+	var checksum [32]byte
+	hasher := sha3.New256()
+	size := make([]byte, 4)
+	for _, element := range vm.evaluationStack.Stack {
+		binary.BigEndian.PutUint32(size, uint32(len(element)))
+		hasher.Write(size)
+		hasher.Write(element)
+	}
+	copy(checksum[:], hasher.Sum(nil))
+	return checksum
+}
+
+// verifyChecksum compares the evaluation stack with the checksum taken after the previous instruction
+func (vm *VM) verifyChecksum() error {
+	if vm.stackChecksum != nil && *vm.stackChecksum != vm.evaluationStackChecksum() {
+		return newError(ErrIntegrity, "evaluation stack modified between instructions")
+	}
+	return nil
+}
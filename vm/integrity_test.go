@@ -0,0 +1,99 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// corruptingTracer modifies the evaluation stack between instructions
+type corruptingTracer struct {
+	vm *VM
+}
+
+func (c corruptingTracer) CaptureStep(step TraceStep) {
+	if len(c.vm.evaluationStack.Stack) > 0 {
+		c.vm.evaluationStack.Stack[0][0] = 1
+	}
+}
+
+func TestIntegrity_ModifiedBetweenInstructions(t *testing.T) {
+	vm := NewTestVM([]byte{PushInt, 1, 0, 5, PushInt, 1, 0, 6, Halt})
+	vm.SetTracer(corruptingTracer{&vm})
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "vm.exec(): integrity check failed: evaluation stack modified between instructions")
+}
+
+func TestIntegrity_Disabled(t *testing.T) {
+	mc := NewMockContext([]byte{PushInt, 1, 0, 5, PushInt, 1, 0, 6, Halt})
+	vm := NewVM(mc)
+	vm.SetTracer(corruptingTracer{&vm})
+
+	assert.Assert(t, vm.Exec(false))
+}
+
+func TestIntegrity_Invariants(t *testing.T) {
+	tests := []struct {
+		corrupt func(vm *VM)
+		err     string
+	}{
+		{func(vm *VM) { vm.evaluationStack.Stack = append(vm.evaluationStack.Stack, nil) },
+			"integrity check failed: nil element at index 1"},
+		{func(vm *VM) { vm.evaluationStack.memoryUsage++ },
+			"integrity check failed: memory usage is 3 bytes, but elements use 2 bytes"},
+		{func(vm *VM) { vm.callStack.Push(nil) },
+			"integrity check failed: nil call frame at depth 0"},
+		{func(vm *VM) { vm.callStack.Push(&Frame{returnAddress: 100}) },
+			"integrity check failed: return address 100 out of bounds at depth 0"},
+		{func(vm *VM) {
+			vm.callStack.Push(&Frame{evalStackOffset: 1})
+			vm.callStack.Push(&Frame{evalStackOffset: 0})
+		}, "integrity check failed: stack offset 0 below the caller at depth 1"},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode([]byte{PushInt, 1, 0, 5, Halt})
+		assert.Assert(t, isSuccess)
+		assert.NilError(t, vm.checkIntegrity())
+
+		test.corrupt(vm)
+		assert.Error(t, vm.checkIntegrity(), test.err)
+	}
+}
+
+func TestIntegrity_CodeModifiedByContext(t *testing.T) {
+	code := []byte{PushInt, 1, 0, 5, PushInt, 1, 0, 6, Add, Halt}
+	mc := NewMockContext(code)
+	mc.Fee = 100
+	vm := NewVMWithConfig(mc, VMConfig{
+		CheckIntegrity: true,
+		PreOpHook: func(pc int, opCode byte, state StateView) error {
+			code[8] = Sub
+			return nil
+		},
+	})
+
+	assert.Assert(t, vm.Exec(false))
+	result, _ := vm.PeekResult()
+	assert.DeepEqual(t, result, []byte{0, 11})
+}
+
+func TestIntegrity_OperandsDoNotAliasCode(t *testing.T) {
+	code := []byte{PushInt, 1, 0, 5, Neg, Halt}
+	vm, isSuccess := execCode(code)
+
+	assert.Assert(t, isSuccess)
+	assert.DeepEqual(t, vm.contract, code)
+}
+
+func TestIntegrity_CodeModifiedDuringExecution(t *testing.T) {
+	vm := NewTestVM([]byte{PushInt, 1, 0, 5, PushInt, 1, 0, 6, Halt})
+	vm.config.PostOpHook = func(pc int, opCode byte, state StateView) error {
+		vm.contract[0] = Halt
+		return nil
+	}
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "pushint: integrity check failed: contract code modified during the execution")
+}
@@ -0,0 +1,61 @@
+package vm
+
+// LoopGasName is the entry of the gas schedule, whose price is charged in addition to the jump for every backward
+// jump, i.e. for every further iteration of a loop. Loops are not charged extra, if the gas schedule lacks the entry.
+const LoopGasName = "loop"
+
+// Loop is a backward jump of the code. Every iteration after the first jumps from the end back to the header.
+type Loop struct {
+	Header int // Address of the first instruction of the loop
+	End    int // Address of the backward jump
+}
+
+// FindLoops returns the backward jumps of the code in the order of their address.
+// The code must not contain a function table.
+func FindLoops(code []byte) ([]Loop, error) {
+	instructions, err := Disassemble(code)
+	if err != nil {
+		return nil, err
+	}
+
+	var loops []Loop
+	for _, instruction := range instructions {
+		switch instruction.OpCode.Code {
+		case Jmp, JmpTrue, JmpFalse:
+			target := ByteArrayToInt(instruction.Args)
+			if target <= instruction.Address {
+				loops = append(loops, Loop{Header: target, End: instruction.Address})
+			}
+		}
+	}
+	return loops, nil
+}
+
+// LoopIterations returns the number of backward jumps of the last execution by address of the loop header.
+func (vm *VM) LoopIterations() map[int]int {
+	iterations := make(map[int]int, len(vm.loops))
+	for header, count := range vm.loops {
+		iterations[header] = count
+	}
+	return iterations
+}
+
+// jump continues the execution at the target. A backward jump is counted as loop iteration and charged with the
+// loop gas of the gas schedule.
+func (vm *VM) jump(target int) error {
+	if target <= vm.instructionPC {
+		gasCost := vm.config.GasSchedule[LoopGasName].Price
+		if vm.fee < gasCost {
+			return newError(ErrOutOfGas)
+		}
+		vm.fee -= gasCost
+
+		if vm.loops == nil {
+			vm.loops = make(map[int]int)
+		}
+		vm.loops[target]++
+	}
+
+	vm.pc = target
+	return nil
+}
@@ -0,0 +1,59 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// countdown counts down from 3, the loop starts at address 4
+var countdown = []byte{
+	PushInt, 1, 0, 3,
+	PushInt, 1, 0, 1,
+	Sub,
+	Dup,
+	PushInt, 1, 0, 0,
+	Gt,
+	JmpTrue, 0, 4,
+	Halt,
+}
+
+func TestFindLoops(t *testing.T) {
+	loops, err := FindLoops(countdown)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, loops, []Loop{{Header: 4, End: 15}})
+}
+
+func TestFindLoops_ForwardJump(t *testing.T) {
+	loops, err := FindLoops([]byte{Jmp, 0, 7, PushInt, 1, 0, 1, Halt})
+	assert.NilError(t, err)
+	assert.Equal(t, len(loops), 0)
+}
+
+func TestVM_LoopIterations(t *testing.T) {
+	vm, isSuccess := execCode(countdown)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.LoopIterations(), map[int]int{4: 2})
+
+	vm.Reset(NewMockContext([]byte{Halt}))
+	assert.Equal(t, len(vm.LoopIterations()), 0)
+}
+
+func TestVM_LoopGas(t *testing.T) {
+	vm := NewVMWithConfig(NewMockContext(countdown), VMConfig{})
+	assert.Assert(t, vm.ExecUnlimited())
+	gasUsed := vm.GasUsed()
+
+	vm = NewVMWithConfig(NewMockContext(countdown), VMConfig{GasSchedule: GasSchedule{LoopGasName: {Price: 10}}})
+	assert.Assert(t, vm.ExecUnlimited())
+	assert.Equal(t, vm.GasUsed(), gasUsed+2*10)
+}
+
+func TestVM_LoopGas_OutOfGas(t *testing.T) {
+	mc := NewMockContext(countdown)
+	mc.Fee = 1000
+	vm := NewVMWithConfig(mc, VMConfig{GasSchedule: GasSchedule{LoopGasName: {Price: 1000}}})
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "jmptrue: out of gas")
+}
@@ -2,22 +2,22 @@ package vm
 
 import (
 	"bytes"
-	"errors"
 	"log"
 )
 
 type Map []byte
 
 func CreateMap() Map {
-	return []byte{0x01, 0x00, 0x00}
+	return MapHeader{}.Marshal()
 }
 
 func MapFromByteArray(m []byte) (Map, error) {
-	if len(m) <= 0 {
-		return Map{}, errors.New("empty map")
+	var header MapHeader
+	if err := header.Unmarshal(m); err != nil {
+		return Map{}, err
 	}
-	if m[0] != 0x01 {
-		return Map{}, errors.New("invalid datatype supplied")
+	if err := validateContainer(m, 2); err != nil {
+		return Map{}, err
 	}
 	return Map(m), nil
 }
@@ -25,7 +25,7 @@ func MapFromByteArray(m []byte) (Map, error) {
 func (m *Map) getSize() (uint16, error) {
 	value, err := ByteArrayToUI16((*m)[1:3])
 	if err != nil {
-		return 0, errors.New("cannot get size of map")
+		return 0, newError(ErrMapSize)
 	}
 	return value, nil
 }
@@ -51,7 +51,7 @@ func (m *Map) DecrementSize() error {
 	}
 
 	if s <= 0 {
-		return errors.New("Map size already 0")
+		return newError(ErrMapEmpty)
 	}
 	s--
 	m.setSize(UInt16ToByteArray(s))
@@ -81,7 +81,7 @@ func (m *Map) MapContainsKey(key []byte) (bool, error) {
 		valueEndsBefore := nextElementStartsAt(valueStartsAt, sizeOfValue)
 
 		if index == valueEndsBefore {
-			return false, errors.New("element sizes are 0")
+			return false, newError(ErrZeroElementSizes)
 		}
 		index = valueEndsBefore
 	}
@@ -92,7 +92,7 @@ func (m *Map) Append(key []byte, value []byte) error {
 	sk := len(key)
 	sv := len(value)
 	if sk > int(UINT16_MAX) || sv > int(UINT16_MAX) {
-		return errors.New("key or value size overflows uint16")
+		return newError(ErrKeyValueSizeOverflow)
 	}
 
 	tmp := append(*m, UInt16ToByteArray(uint16(sk))...)
@@ -113,13 +113,44 @@ func (m *Map) SetVal(key []byte, value []byte) error {
 	return nil
 }
 
+// Put sets the value of the key and appends the entry, if the map does not contain the key
+func (m *Map) Put(key []byte, value []byte) error {
+	hasKey, err := m.MapContainsKey(key)
+	if err != nil {
+		return err
+	}
+	if hasKey {
+		return m.SetVal(key, value)
+	}
+	return m.Append(key, value)
+}
+
+// Entries returns the keys and the values of the map in the order of its entries
+func (m *Map) Entries() (keys [][]byte, values [][]byte, err error) {
+	for index := 3; index < len(*m); {
+		key, keyEndsBefore, err := getElement(m, index)
+		if err != nil {
+			return nil, nil, err
+		}
+		value, valueEndsBefore, err := getElement(m, keyEndsBefore)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		keys = append(keys, key)
+		values = append(values, value)
+		index = valueEndsBefore
+	}
+	return keys, values, nil
+}
+
 func (m *Map) GetVal(key []byte) ([]byte, error) {
 	offset := 3
 	l := len(*m)
 
 	for index := offset; index < l; {
 		if l == 3 {
-			return []byte{}, errors.New("no elements in map")
+			return []byte{}, newError(ErrNoElementsInMap)
 		}
 
 		k, valueStartsAt, err := getElement(m, index)
@@ -137,12 +168,12 @@ func (m *Map) GetVal(key []byte) ([]byte, error) {
 		}
 
 		if index == nextElementStartsAt {
-			return []byte{}, errors.New("element sizes are 0")
+			return []byte{}, newError(ErrZeroElementSizes)
 		}
 		index = nextElementStartsAt
 	}
 
-	return []byte{}, errors.New("key not found")
+	return []byte{}, newError(ErrKeyNotFound)
 }
 
 func (m *Map) Remove(key []byte) error {
@@ -151,7 +182,7 @@ func (m *Map) Remove(key []byte) error {
 
 	for index := offset; index < l; {
 		if l == 3 {
-			return errors.New("no elements in map")
+			return newError(ErrNoElementsInMap)
 		}
 
 		k, keyEndsBefore, err := getElement(m, index)
@@ -167,18 +198,17 @@ func (m *Map) Remove(key []byte) error {
 		valueStartsAt := keyEndsBefore //Just for better readability
 		valueEndsBefore := nextElementStartsAt(valueStartsAt, sizeOfValue)
 		if bytes.Equal(key, k) {
-			tmp := append([]byte{}, (*m)[:index]...)
-			*m = append(tmp, (*m)[valueEndsBefore:]...)
+			*m = append((*m)[:index], (*m)[valueEndsBefore:]...)
 			m.DecrementSize()
 			return nil
 		}
 
 		if index == valueEndsBefore {
-			return errors.New("element sizes are 0")
+			return newError(ErrZeroElementSizes)
 		}
 		index = valueEndsBefore
 	}
-	return errors.New("key not found")
+	return newError(ErrKeyNotFound)
 }
 
 func getElement(m *Map, startsAt int) (element []byte, endsBefore int, err error) {
@@ -196,12 +226,12 @@ func getElement(m *Map, startsAt int) (element []byte, endsBefore int, err error
 
 func getBytesOfElement(m *Map, startsAt int, endsBefore int) ([]byte, error) {
 	if startsAt >= endsBefore {
-		return []byte{}, errors.New("can't retrieve element")
+		return []byte{}, newError(ErrRetrieveElement)
 	}
 	length := len(*m)
 
 	if length < startsAt+2 || length < endsBefore {
-		return []byte{}, errors.New("map internals error")
+		return []byte{}, newError(ErrMapInternals)
 	}
 
 	return (*m)[startsAt+2 : endsBefore], nil
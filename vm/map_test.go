@@ -187,3 +187,30 @@ func TestMap_Remove(t *testing.T) {
 		t.Errorf("Expected map to be '[%# x]' but was '[%# x]' after element removal", expected, actual)
 	}
 }
+
+func TestMap_Put(t *testing.T) {
+	m := CreateMap()
+	m.Put([]byte{1}, []byte{10})
+	m.Put([]byte{2}, []byte{20})
+	m.Put([]byte{1}, []byte{11})
+
+	expected := []byte{0x01, 0x00, 0x02, 0x00, 0x01, 2, 0x00, 0x01, 20, 0x00, 0x01, 1, 0x00, 0x01, 11}
+	if !bytes.Equal(m, expected) {
+		t.Errorf("Expected %v after put but got %v", expected, m)
+	}
+}
+
+func TestMap_Entries(t *testing.T) {
+	m := CreateMap()
+	m.Append([]byte{1}, []byte{10, 11})
+	m.Append([]byte{2, 3}, []byte{})
+
+	keys, values, err := m.Entries()
+	if err != nil || len(keys) != 2 || len(values) != 2 {
+		t.Fatalf("Expected 2 entries but got %v, %v (%v)", keys, values, err)
+	}
+	if !bytes.Equal(keys[0], []byte{1}) || !bytes.Equal(values[0], []byte{10, 11}) ||
+		!bytes.Equal(keys[1], []byte{2, 3}) || len(values[1]) != 0 {
+		t.Errorf("Unexpected entries %v, %v", keys, values)
+	}
+}
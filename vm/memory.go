@@ -0,0 +1,46 @@
+package vm
+
+// MemoryGasName is the entry of the gas schedule, whose price is charged for every 64 bytes, by which the live memory
+// of the execution exceeds its previous peak. DefaultMemoryGasPrice is charged, if the gas schedule lacks the entry.
+const MemoryGasName = "memory"
+
+// DefaultMemoryGasPrice is the price of 64 bytes of live memory, if the gas schedule does not override it
+const DefaultMemoryGasPrice = 3
+
+// memoryWordSize is the number of bytes charged with the price of the MemoryGasName entry
+const memoryWordSize = 64
+
+// LiveMemory returns the number of bytes referenced by the evaluation stack, the local variables of the call stack
+// and the transient storage. Containers are counted once in the object table, however many handles reference them, and
+// a container is released with its last handle.
+func (vm *VM) LiveMemory() int {
+	return int(vm.evaluationStack.memoryUsage) + vm.callStack.memoryUsage() + vm.transientMemoryUsage() + vm.objects.size
+}
+
+// PeakMemory returns the maximum live memory of the last execution, which was measured between its instructions.
+func (vm *VM) PeakMemory() int {
+	return vm.memoryPeak
+}
+
+// chargeMemory records the peak of the live memory after an instruction has been executed and charges its growth
+func (vm *VM) chargeMemory() error {
+	live := vm.LiveMemory()
+	if live <= vm.memoryPeak {
+		return nil
+	}
+
+	previous := vm.memoryPeak
+	vm.memoryPeak = live
+	price := vm.gasPrice(MemoryGasName, DefaultMemoryGasPrice)
+
+	words := uint64(memoryWords(live) - memoryWords(previous))
+	if words > 0 && vm.fee/words < price {
+		return newError(ErrOutOfGas)
+	}
+	vm.fee -= words * price
+	return nil
+}
+
+func memoryWords(size int) int {
+	return (size + memoryWordSize - 1) / memoryWordSize
+}
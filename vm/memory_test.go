@@ -0,0 +1,92 @@
+package vm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func pushBytes(size int) []byte {
+	return push(nil, Push, bytes.Repeat([]byte{1}, size))
+}
+
+func TestVM_MemoryGas(t *testing.T) {
+	code := append(pushBytes(100), Pop)
+	code = append(code, pushBytes(100)...)
+	code = append(code, Halt)
+
+	vm := NewVMWithConfig(NewMockContext(code), VMConfig{GasSchedule: GasSchedule{MemoryGasName: {Price: 0}}})
+	assert.Assert(t, vm.ExecUnlimited())
+	gasUsed := vm.GasUsed()
+
+	// The second array does not exceed the peak of the first one
+	vm = NewVMWithConfig(NewMockContext(code), VMConfig{GasSchedule: GasSchedule{MemoryGasName: {Price: 10}}})
+	assert.Assert(t, vm.ExecUnlimited())
+	assert.Equal(t, vm.PeakMemory(), 100)
+	assert.Equal(t, vm.GasUsed(), gasUsed+2*10)
+}
+
+func TestVM_MemoryGas_LocalVariables(t *testing.T) {
+	code := []byte{
+		CallLocals, 0, 7, 0, 0, 1,
+		Halt,
+	}
+	code = append(code, pushBytes(64)...)
+	code = append(code, StoreLoc, 0)
+	code = append(code, pushBytes(64)...)
+	code = append(code, Pop, Ret)
+
+	vm := NewVMWithConfig(NewMockContext(code), VMConfig{GasSchedule: GasSchedule{MemoryGasName: {Price: 1}}})
+	assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+	assert.Equal(t, vm.PeakMemory(), 128)
+	assert.Equal(t, vm.LiveMemory(), 0)
+}
+
+func TestVM_MemoryGas_OutOfGas(t *testing.T) {
+	code := append(pushBytes(200), Halt)
+
+	mc := NewMockContext(code)
+	mc.Fee = 1000
+	vm := NewVMWithConfig(mc, VMConfig{GasSchedule: GasSchedule{MemoryGasName: {Price: 300}}})
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "push: out of gas")
+}
+
+func TestVM_MemoryGas_Default(t *testing.T) {
+	vm, isSuccess := execCode(append(pushBytes(100), Halt))
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+	assert.Equal(t, vm.PeakMemory(), 100)
+	assert.Equal(t, vm.GasUsed(),
+		IntrinsicGas(103, 0)+OpCodes[Push].GasPrice+100*OpCodes[Push].GasFactor+2*DefaultMemoryGasPrice)
+}
+
+// arrayLoop returns a loop, which appends 32 bytes to a copy of an array in every iteration and keeps all copies
+func arrayLoop(iterations byte) []byte {
+	code := []byte{NewArrFrom, 0, PushInt, 1, 0, iterations}
+	loop := len(code)
+	code = append(code, Swap, Dup)
+	code = append(code, pushBytes(32)...)
+	return append(code,
+		Swap, ArrAppend, // Appends to the copy, since the array is referenced twice
+		Roll, 1, PushInt, 1, 0, 1, Sub,
+		Dup, PushInt, 0, Gt, JmpTrue, 0, byte(loop),
+		Halt,
+	)
+}
+
+func TestVM_MemoryGas_ArrayLoop(t *testing.T) {
+	mc := NewMockContext(arrayLoop(200))
+	mc.Fee = 70000
+	free := NewVMWithConfig(mc, VMConfig{GasSchedule: GasSchedule{MemoryGasName: {Price: 0}}})
+	assert.Assert(t, free.Exec(false), free.GetErrorMsg())
+
+	// The 200 copies use about 670 KB, which exceed the fee under the default config
+	mc = NewMockContext(arrayLoop(200))
+	mc.Fee = 70000
+	vm := NewVM(mc)
+	assert.Assert(t, !vm.Exec(false))
+	assert.Assert(t, strings.HasSuffix(vm.GetErrorMsg(), "out of gas"), vm.GetErrorMsg())
+}
@@ -0,0 +1,160 @@
+package vm
+
+// MetadataMarker is the first byte of a contract section, which describes the contract for tooling. It is never
+// a valid opcode. The section follows the constructor section and precedes the function table.
+//
+// The section consists of the marker, its size (2 bytes) and the fields in the order of Metadata, each prefixed by
+// its length (1 byte). The struct layouts may follow the fields: their number (1 byte) and for every layout the number
+// of fields (1 byte) followed by the maximum size of every field (2 bytes). Bytes following the known fields are
+// skipped, so later versions can append fields.
+const MetadataMarker = 0xFC
+
+// Fields of the metadata, which are selected by the argument of MetaField
+const (
+	MetadataName = iota
+	MetadataVersion
+	MetadataAuthor
+	MetadataCompiler
+	MetadataLicense
+)
+
+// Metadata describes a deployed contract, e.g. to match it to its source and to check its version.
+type Metadata struct {
+	Name     string `json:"name,omitempty"`
+	Version  string `json:"version,omitempty"`
+	Author   string `json:"author,omitempty"`
+	Compiler string `json:"compiler,omitempty"`
+	License  string `json:"license,omitempty"`
+
+	// Layouts of the structs used by the contract, which are validated, when a struct is accessed
+	Structs []StructLayout `json:"structs,omitempty"`
+}
+
+// StructLayout declares the number of fields of a struct and the maximum size of every field in bytes.
+// A size of 0 does not limit the size of the field.
+type StructLayout struct {
+	Fields []uint16 `json:"fields"`
+}
+
+// fields returns the fields in the order of their encoding
+func (m *Metadata) fields() []*string {
+	return []*string{&m.Name, &m.Version, &m.Author, &m.Compiler, &m.License}
+}
+
+// NewMetadataSection encodes the metadata as contract section. Fields must not exceed 255 bytes.
+func NewMetadataSection(metadata Metadata) ([]byte, error) {
+	var blob []byte
+	for i, field := range metadata.fields() {
+		if len(*field) > 255 {
+			return nil, newError(ErrMetadataFieldSize, i)
+		}
+		blob = append(blob, byte(len(*field)))
+		blob = append(blob, *field...)
+	}
+
+	if len(metadata.Structs) > 0 {
+		if len(metadata.Structs) > 255 {
+			return nil, newError(ErrStructLayoutCount)
+		}
+		blob = append(blob, byte(len(metadata.Structs)))
+		for i, layout := range metadata.Structs {
+			if len(layout.Fields) > 255 {
+				return nil, newError(ErrStructLayoutSize, i)
+			}
+			blob = append(blob, byte(len(layout.Fields)))
+			for _, size := range layout.Fields {
+				blob = append(blob, UInt16ToByteArray(size)...)
+			}
+		}
+	}
+
+	section := append([]byte{MetadataMarker}, UInt16ToByteArray(uint16(len(blob)))...)
+	return append(section, blob...), nil
+}
+
+// ParseMetadataSection splits the contract into the metadata and the remaining contract.
+// Contracts without a metadata section are returned unchanged with nil metadata.
+func ParseMetadataSection(contract []byte) (metadata *Metadata, rest []byte, err error) {
+	if len(contract) == 0 || contract[0] != MetadataMarker {
+		return nil, contract, nil
+	}
+
+	if len(contract) < 3 {
+		return nil, nil, newError(ErrMetadataOutOfBounds)
+	}
+	size, _ := ByteArrayToUI16(contract[1:3])
+	end := 3 + int(size)
+	if end > len(contract) {
+		return nil, nil, newError(ErrMetadataOutOfBounds)
+	}
+
+	blob := contract[3:end]
+	metadata = &Metadata{}
+	for _, field := range metadata.fields() {
+		if len(blob) == 0 || 1+int(blob[0]) > len(blob) {
+			return nil, nil, newError(ErrMetadataOutOfBounds)
+		}
+		*field = string(blob[1 : 1+blob[0]])
+		blob = blob[1+blob[0]:]
+	}
+
+	if metadata.Structs, err = parseStructLayouts(blob); err != nil {
+		return nil, nil, err
+	}
+	return metadata, contract[end:], nil
+}
+
+// parseStructLayouts parses the struct layouts following the fields of the metadata, which are optional
+func parseStructLayouts(blob []byte) ([]StructLayout, error) {
+	if len(blob) == 0 {
+		return nil, nil
+	}
+
+	count := int(blob[0])
+	blob = blob[1:]
+	layouts := make([]StructLayout, count)
+	for i := range layouts {
+		if len(blob) == 0 || 1+2*int(blob[0]) > len(blob) {
+			return nil, newError(ErrMetadataOutOfBounds)
+		}
+
+		layouts[i].Fields = make([]uint16, blob[0])
+		for j := range layouts[i].Fields {
+			layouts[i].Fields[j], _ = ByteArrayToUI16(blob[1+2*j : 3+2*j])
+		}
+		blob = blob[1+2*int(blob[0]):]
+	}
+	return layouts, nil
+}
+
+// structLayouts returns the struct layouts declared by the metadata of the execution
+func (vm *VM) structLayouts() []StructLayout {
+	if vm.metadata == nil {
+		return nil
+	}
+	return vm.metadata.Structs
+}
+
+// Metadata returns the metadata of the contract, nil if the contract has no metadata section.
+// The metadata of a delegating contract is declared by the code of its delegate.
+func (vm *VM) Metadata() (*Metadata, error) {
+	contract, _, err := vm.loadCode()
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err := ParseSections(contract)
+	return sections.Metadata, err
+}
+
+// metadataField returns the field of the metadata of the execution, which is empty, if the contract has no metadata
+func (vm *VM) metadataField(index byte) ([]byte, error) {
+	if int(index) > MetadataLicense {
+		return nil, newError(ErrUnknownMetadataField, index)
+	}
+
+	if vm.metadata == nil {
+		return []byte{}, nil
+	}
+	return []byte(*vm.metadata.fields()[index]), nil
+}
@@ -0,0 +1,106 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+var testMetadata = Metadata{Name: "token", Version: "1.2.0", Author: "bazo", Compiler: "lazo 0.3", License: "MIT"}
+
+// newMetadataContract returns a contract with all sections, whose code pushes the version and the license
+func newMetadataContract(t *testing.T) []byte {
+	metadata, err := NewMetadataSection(testMetadata)
+	assert.NilError(t, err)
+
+	contract := append(NewStateSchema([]VariableDeclaration{{Type: TypeInt}}), NewConstructorSection(5)...)
+	contract = append(contract, metadata...)
+	return append(contract, MetaField, MetadataVersion, MetaField, MetadataLicense, Halt, Halt)
+}
+
+func TestMetadata_Section(t *testing.T) {
+	section, err := NewMetadataSection(testMetadata)
+	assert.NilError(t, err)
+
+	metadata, rest, err := ParseMetadataSection(append(section, Halt))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *metadata, testMetadata)
+	assertBytes(t, rest, Halt)
+
+	metadata, rest, err = ParseMetadataSection([]byte{Halt})
+	assert.NilError(t, err)
+	assert.Assert(t, metadata == nil)
+	assertBytes(t, rest, Halt)
+}
+
+func TestMetadata_SkipsUnknownFields(t *testing.T) {
+	section, err := NewMetadataSection(Metadata{Name: "a", Structs: []StructLayout{{Fields: []uint16{1}}}})
+	assert.NilError(t, err)
+
+	// A later version appends a field of 2 bytes after the struct layouts
+	section = append(section, 2, 'x', 'y')
+	section[2] += 3
+
+	metadata, rest, err := ParseMetadataSection(append(section, Halt))
+	assert.NilError(t, err)
+	assert.Equal(t, metadata.Name, "a")
+	assert.DeepEqual(t, metadata.Structs, []StructLayout{{Fields: []uint16{1}}})
+	assertBytes(t, rest, Halt)
+}
+
+func TestMetadata_Errors(t *testing.T) {
+	_, err := NewMetadataSection(Metadata{License: string(make([]byte, 256))})
+	assert.Error(t, err, "metadata field 4 exceeds 255 bytes")
+
+	for _, section := range [][]byte{
+		{MetadataMarker, 0},
+		{MetadataMarker, 0, 5, 0, 0},
+		{MetadataMarker, 0, 3, 0, 0, 1},
+		{MetadataMarker, 0, 5, 0, 0, 0, 0, 1},
+	} {
+		_, _, err := ParseMetadataSection(section)
+		assert.Error(t, err, "metadata out of bounds")
+	}
+}
+
+func TestMetadata_VM(t *testing.T) {
+	mc := NewMockContext(newMetadataContract(t))
+	vm := NewVM(mc)
+
+	metadata, err := vm.Metadata()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *metadata, testMetadata)
+
+	// The metadata is skipped by the execution, the code starts after it
+	assert.NilError(t, VerifyCode(mc.Contract))
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{[]byte("1.2.0"), []byte("MIT")})
+}
+
+func TestMetadata_MetaField(t *testing.T) {
+	vm, isSuccess := execCode([]byte{MetaField, MetadataName, Halt})
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{}})
+
+	vm, isSuccess = execCode([]byte{MetaField, 5, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "metafield: unknown metadata field 5")
+}
+
+func TestMetadata_StructLayouts(t *testing.T) {
+	metadata := Metadata{Name: "a", Structs: []StructLayout{{Fields: []uint16{32, 0}}, {Fields: []uint16{}}}}
+	section, err := NewMetadataSection(metadata)
+	assert.NilError(t, err)
+
+	parsed, _, err := ParseMetadataSection(section)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *parsed, metadata)
+
+	_, err = NewMetadataSection(Metadata{Structs: []StructLayout{{}, {Fields: make([]uint16, 256)}}})
+	assert.Error(t, err, "struct layout 1 exceeds 255 fields")
+	_, err = NewMetadataSection(Metadata{Structs: make([]StructLayout, 256)})
+	assert.Error(t, err, "metadata declares more than 255 struct layouts")
+
+	_, _, err = ParseMetadataSection([]byte{MetadataMarker, 0, 9, 0, 0, 0, 0, 0, 1, 2, 0, 1})
+	assert.Error(t, err, "metadata out of bounds")
+}
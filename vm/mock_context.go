@@ -1,11 +1,47 @@
 package vm
 
 import (
+	"errors"
+	"sort"
+
 	"github.com/bazo-blockchain/bazo-miner/protocol"
 )
 
 type MockContext struct {
 	protocol.Context
+	Caller    *[32]byte           // Immediate caller, if the contract is called by another contract
+	Accounts  map[[32]byte][]byte // Code of other accounts, which is empty for accounts without contract
+	Balances  map[[64]byte]uint64 // Balances of other accounts
+	Batches   int                 // Number of batch writes of contract variables
+	Persisted []int               // Indexes of the contract variables written by the last PersistChanges
+	Delegate  *[32]byte           // Account, to which the contract delegates its code
+	Paused    bool
+	Faults    Faults // Faults injected into the context access
+	changed   []int
+	reads     int
+
+	// Call data of the transactions, whose changes were persisted
+	CallDataHistory [][]byte
+	// Initialized is set, when the constructor was executed
+	Initialized bool
+	// Contract variables of other accounts
+	Variables map[[32]byte][][]byte
+	// Next expected nonces of the callers for CheckAndBumpNonce
+	Nonces map[[32]byte]uint64
+	// Timestamp of the block, in which the transaction is executed
+	BlockTime uint64
+	// Target time between two blocks in seconds
+	BlockInterval uint64
+}
+
+// Faults are programmable failures of the MockContext, which make the error paths of the VM around context access
+// testable. The zero value injects no faults.
+type Faults struct {
+	// GetContractVariable fails on this call, counted from 1 over the lifetime of the context, 0 never fails
+	GetContractVariableCall int
+	SetContractVariable     bool // SetContractVariable fails
+	SetNonce                bool // SetNonce fails
+	SetInitialized          bool // SetInitialized fails
 }
 
 func NewMockContext(byteCode []byte) *MockContext {
@@ -18,3 +54,161 @@ func NewMockContext(byteCode []byte) *MockContext {
 func (mc *MockContext) SetContract(contract []byte) {
 	mc.Contract = contract
 }
+
+// GetCaller returns the immediate caller or the transaction signer, if no caller is set.
+func (mc *MockContext) GetCaller() [32]byte {
+	if mc.Caller != nil {
+		return *mc.Caller
+	}
+	return mc.From
+}
+
+// GetAccountCode returns the code of another account.
+func (mc *MockContext) GetAccountCode(address [32]byte) ([]byte, bool) {
+	code, ok := mc.Accounts[address]
+	return code, ok
+}
+
+// GetAccountBalance returns the balance of another account declared in Balances.
+func (mc *MockContext) GetAccountBalance(address [64]byte) (uint64, bool) {
+	balance, ok := mc.Balances[address]
+	return balance, ok
+}
+
+// GetAccountVariable returns a contract variable of another account declared in Variables.
+func (mc *MockContext) GetAccountVariable(address [32]byte, index int) ([]byte, bool) {
+	variables, ok := mc.Variables[address]
+	if !ok || index >= len(variables) {
+		return nil, false
+	}
+	return variables[index], true
+}
+
+// GetNonce returns the next expected nonce of the caller declared in Nonces.
+func (mc *MockContext) GetNonce(caller [32]byte) uint64 {
+	return mc.Nonces[caller]
+}
+
+// SetNonce stores the next expected nonce of the caller in Nonces, unless Faults declares it to fail.
+func (mc *MockContext) SetNonce(caller [32]byte, nonce uint64) error {
+	if mc.Faults.SetNonce {
+		return errors.New("injected fault: SetNonce")
+	}
+	if mc.Nonces == nil {
+		mc.Nonces = make(map[[32]byte]uint64)
+	}
+	mc.Nonces[caller] = nonce
+	return nil
+}
+
+// GetBlockTime returns BlockTime.
+func (mc *MockContext) GetBlockTime() uint64 {
+	return mc.BlockTime
+}
+
+// GetBlockInterval returns BlockInterval.
+func (mc *MockContext) GetBlockInterval() uint64 {
+	return mc.BlockInterval
+}
+
+// AccountExists returns true, if the account is declared in Accounts.
+func (mc *MockContext) AccountExists(address [32]byte) bool {
+	_, ok := mc.Accounts[address]
+	return ok
+}
+
+// GetCodeDelegate returns the delegate, if it is set.
+func (mc *MockContext) GetCodeDelegate() ([32]byte, bool) {
+	if mc.Delegate == nil {
+		return [32]byte{}, false
+	}
+	return *mc.Delegate, true
+}
+
+// SetCodeDelegate sets the delegate, delegating to the contract itself removes it.
+func (mc *MockContext) SetCodeDelegate(address [32]byte) error {
+	if address == protocol.SerializeHashContent(mc.Address) {
+		mc.Delegate = nil
+		return nil
+	}
+	mc.Delegate = &address
+	return nil
+}
+
+// IsInitialized returns Initialized.
+func (mc *MockContext) IsInitialized() bool {
+	return mc.Initialized
+}
+
+// SetInitialized sets Initialized, unless Faults declares it to fail.
+func (mc *MockContext) SetInitialized() error {
+	if mc.Faults.SetInitialized {
+		return errors.New("injected fault: SetInitialized")
+	}
+	mc.Initialized = true
+	return nil
+}
+
+// IsPaused returns Paused.
+func (mc *MockContext) IsPaused() bool {
+	return mc.Paused
+}
+
+// SetContractVariables writes all variables as one batch. If an index is out of bounds, no variable is written.
+func (mc *MockContext) SetContractVariables(variables map[int][]byte) error {
+	indexes := make([]int, 0, len(variables))
+	for index := range variables {
+		if index < 0 || index >= len(mc.ContractVariables) {
+			return newError(ErrIndexOutOfBounds)
+		}
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		if err := mc.SetContractVariable(index, variables[index]); err != nil {
+			return err
+		}
+	}
+	mc.Batches++
+	return nil
+}
+
+// GetContractVariable reads a single variable, unless the call is the one declared in Faults.
+func (mc *MockContext) GetContractVariable(index int) ([]byte, error) {
+	mc.reads++
+	if mc.reads == mc.Faults.GetContractVariableCall {
+		return nil, errors.New("injected fault: GetContractVariable")
+	}
+	return mc.Context.GetContractVariable(index)
+}
+
+// SetContractVariable writes a single variable.
+func (mc *MockContext) SetContractVariable(index int, value []byte) error {
+	if mc.Faults.SetContractVariable {
+		return errors.New("injected fault: SetContractVariable")
+	}
+	if err := mc.Context.SetContractVariable(index, value); err != nil {
+		return err
+	}
+	mc.changed = append(mc.changed, index)
+	return nil
+}
+
+// PersistChanges applies the written variables to ContractVariables and records their indexes in Persisted and the
+// call data in CallDataHistory.
+func (mc *MockContext) PersistChanges() {
+	mc.Context.PersistChanges()
+	mc.CallDataHistory = append(mc.CallDataHistory, append([]byte{}, mc.Data...))
+
+	mc.Persisted = nil
+	seen := make(map[int]bool)
+	for _, index := range mc.changed {
+		if !seen[index] {
+			seen[index] = true
+			mc.Persisted = append(mc.Persisted, index)
+		}
+	}
+	sort.Ints(mc.Persisted)
+	mc.changed = nil
+}
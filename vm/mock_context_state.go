@@ -0,0 +1,153 @@
+package vm
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+)
+
+// mockContextState is the persistent state of a MockContext, which outlives a transaction. Byte values are hex encoded.
+type mockContextState struct {
+	Address         string            `json:"address"`
+	Issuer          string            `json:"issuer"`
+	Balance         uint64            `json:"balance"`
+	Contract        string            `json:"contract"`
+	Variables       []string          `json:"variables"`
+	Accounts        map[string]string `json:"accounts,omitempty"` // Code of other accounts by address
+	Balances        map[string]uint64 `json:"balances,omitempty"` // Balances of other accounts by address
+	Delegate        string            `json:"delegate,omitempty"`
+	Paused          bool              `json:"paused,omitempty"`
+	Initialized     bool              `json:"initialized,omitempty"`
+	CallDataHistory []string          `json:"callDataHistory,omitempty"`
+}
+
+// Save writes the state of the context as JSON, so a scenario of several transactions can be resumed by
+// LoadMockContext. The fields of the current transaction, e.g. the fee and the call data, are not saved.
+func (mc *MockContext) Save(w io.Writer) error {
+	state := mockContextState{
+		Address:         hex.EncodeToString(mc.Address[:]),
+		Issuer:          hex.EncodeToString(mc.Issuer[:]),
+		Balance:         mc.Balance,
+		Contract:        hex.EncodeToString(mc.Contract),
+		Variables:       encodeAll(mc.ContractVariables),
+		CallDataHistory: encodeAll(mc.CallDataHistory),
+		Paused:          mc.Paused,
+		Initialized:     mc.Initialized,
+	}
+	if mc.Delegate != nil {
+		state.Delegate = hex.EncodeToString(mc.Delegate[:])
+	}
+	if len(mc.Accounts) > 0 {
+		state.Accounts = make(map[string]string, len(mc.Accounts))
+		for address, code := range mc.Accounts {
+			state.Accounts[hex.EncodeToString(address[:])] = hex.EncodeToString(code)
+		}
+	}
+
+	if len(mc.Balances) > 0 {
+		state.Balances = make(map[string]uint64, len(mc.Balances))
+		for address, balance := range mc.Balances {
+			state.Balances[hex.EncodeToString(address[:])] = balance
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(state)
+}
+
+// LoadMockContext reads a context written by Save. The fee is set to the default of NewMockContext.
+func LoadMockContext(r io.Reader) (*MockContext, error) {
+	var state mockContextState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, err
+	}
+
+	contract, err := hex.DecodeString(state.Contract)
+	if err != nil {
+		return nil, err
+	}
+	mc := NewMockContext(contract)
+	mc.Balance = state.Balance
+	mc.Paused = state.Paused
+	mc.Initialized = state.Initialized
+
+	if err := decodeFixed(state.Address, mc.Address[:]); err != nil {
+		return nil, err
+	}
+	if err := decodeFixed(state.Issuer, mc.Issuer[:]); err != nil {
+		return nil, err
+	}
+	if state.Delegate != "" {
+		mc.Delegate = new([32]byte)
+		if err := decodeFixed(state.Delegate, mc.Delegate[:]); err != nil {
+			return nil, err
+		}
+	}
+
+	if mc.ContractVariables, err = decodeAll(state.Variables); err != nil {
+		return nil, err
+	}
+	if mc.CallDataHistory, err = decodeAll(state.CallDataHistory); err != nil {
+		return nil, err
+	}
+
+	if state.Accounts != nil {
+		mc.Accounts = make(map[[32]byte][]byte, len(state.Accounts))
+		for address, code := range state.Accounts {
+			var account [32]byte
+			if err := decodeFixed(address, account[:]); err != nil {
+				return nil, err
+			}
+			if mc.Accounts[account], err = hex.DecodeString(code); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if state.Balances != nil {
+		mc.Balances = make(map[[64]byte]uint64, len(state.Balances))
+		for address, balance := range state.Balances {
+			var account [64]byte
+			if err := decodeFixed(address, account[:]); err != nil {
+				return nil, err
+			}
+			mc.Balances[account] = balance
+		}
+	}
+	return mc, nil
+}
+
+func encodeAll(values [][]byte) []string {
+	encoded := make([]string, len(values))
+	for i, value := range values {
+		encoded[i] = hex.EncodeToString(value)
+	}
+	return encoded
+}
+
+func decodeAll(values []string) ([][]byte, error) {
+	if values == nil {
+		return nil, nil
+	}
+	decoded := make([][]byte, len(values))
+	for i, value := range values {
+		var err error
+		if decoded[i], err = hex.DecodeString(value); err != nil {
+			return nil, err
+		}
+	}
+	return decoded, nil
+}
+
+// decodeFixed decodes a hex value, which has to fill the target
+func decodeFixed(value string, target []byte) error {
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return err
+	}
+	if len(decoded) != len(target) {
+		return newError(ErrInvalidLength, len(target), len(decoded))
+	}
+	copy(target, decoded)
+	return nil
+}
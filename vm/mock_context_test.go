@@ -0,0 +1,99 @@
+package vm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestMockContext_GetContractVariableFault(t *testing.T) {
+	mc := newTestContext([]byte{
+		LoadSt, 0,
+		LoadSt, 1,
+		Halt,
+	}, withVariables)
+	mc.Faults.GetContractVariableCall = 2
+	vm := NewVM(mc)
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "loadst: injected fault: GetContractVariable")
+	assert.Equal(t, vm.pc, 4)
+
+	// The fault is injected once, later calls succeed
+	value, err := mc.GetContractVariable(1)
+	assert.NilError(t, err)
+	assertBytes(t, value, 0)
+}
+
+func TestMockContext_SetContractVariableFault(t *testing.T) {
+	mc := newTestContext([]byte{
+		PushInt, 1, 0, 1, StoreSt, 0,
+		Halt,
+	}, withVariables)
+	mc.Faults.SetContractVariable = true
+	vm := NewVM(mc)
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "halt: injected fault: SetContractVariable")
+	assert.Equal(t, mc.Batches, 0)
+
+	mc.PersistChanges()
+	assert.DeepEqual(t, mc.ContractVariables, [][]byte{{0}, {0}, {0}})
+}
+
+func TestMockContext_SaveAndLoad(t *testing.T) {
+	// Counter contract, which adds the call data to variable 0
+	code := []byte{
+		LoadSt, 0,
+		CallData,
+		Add,
+		StoreSt, 0,
+		Halt,
+	}
+	mc := newTestContext(code, withVariables)
+	mc.Balance = 300
+	mc.Address[0] = 1
+	mc.Accounts = map[[32]byte][]byte{{3}: {Halt}}
+	mc.Balances = map[[64]byte]uint64{{4}: 1000}
+
+	call := func(mc *MockContext, value byte) {
+		t.Helper()
+		mc.Fee = 5000
+		mc.Data = []byte{2, 0, value}
+		vm := NewVM(mc)
+		assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+		mc.PersistChanges()
+	}
+	call(mc, 2)
+	delegate := [32]byte{2}
+	mc.Delegate = &delegate
+
+	var file bytes.Buffer
+	assert.NilError(t, mc.Save(&file))
+
+	loaded, err := LoadMockContext(&file)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, loaded.Contract, mc.Contract)
+	assert.DeepEqual(t, loaded.ContractVariables, mc.ContractVariables)
+	assert.DeepEqual(t, loaded.Accounts, mc.Accounts)
+	assert.DeepEqual(t, loaded.Balances, mc.Balances)
+	assert.DeepEqual(t, loaded.CallDataHistory, mc.CallDataHistory)
+	assert.Equal(t, loaded.Balance, uint64(300))
+	assert.Equal(t, loaded.Address, mc.Address)
+	assert.Equal(t, *loaded.Delegate, delegate)
+
+	loaded.Delegate = nil
+	call(loaded, 5)
+	assert.DeepEqual(t, loaded.ContractVariables[0], []byte{0, 7})
+	assert.Equal(t, len(loaded.CallDataHistory), 2)
+}
+
+func TestMockContext_LoadInvalid(t *testing.T) {
+	_, err := LoadMockContext(strings.NewReader(`{"address": "01", "contract": ""}`))
+	assert.Error(t, err, "expected 64 bytes, but got 1")
+
+	_, err = LoadMockContext(strings.NewReader(`{"contract": "zz"}`))
+	assert.ErrorContains(t, err, "invalid byte")
+}
@@ -0,0 +1,55 @@
+package vm
+
+import "math"
+
+// NonceContext is implemented by contexts, which store a nonce per caller of the contract for CheckAndBumpNonce.
+// Contexts without this interface do not support replay protection by the VM.
+type NonceContext interface {
+	// GetNonce returns the next expected nonce of the caller, which is 0 for callers without nonce.
+	GetNonce(caller [32]byte) uint64
+
+	// SetNonce stores the next expected nonce of the caller.
+	SetNonce(caller [32]byte, nonce uint64) error
+}
+
+// checkAndBumpNonce pops the nonce expected by the caller and verifies it against the nonce of the caller. The
+// incremented nonce is committed, when the execution succeeds, so a failed execution does not consume the nonce.
+func (vm *VM) checkAndBumpNonce(opCode OpCode) error {
+	bigInt, err := vm.PopSignedBigInt(opCode)
+	if err != nil {
+		return err
+	}
+
+	nonceContext, ok := vm.context.(NonceContext)
+	if !ok {
+		return newError(ErrUnsupportedContext, opCode.Name)
+	}
+
+	caller := vm.caller()
+	var current uint64
+	if vm.nonce != nil {
+		current = *vm.nonce
+	} else {
+		current = nonceContext.GetNonce(caller)
+		vm.witness.record(WitnessNonce, caller, 0, uint64Bytes(current))
+	}
+
+	if bigInt.Sign() < 0 || !bigInt.IsUint64() || bigInt.Uint64() != current {
+		return newError(ErrNonceMismatch, current, bigInt.String())
+	}
+	if current == math.MaxUint64 {
+		return newError(ErrUint64Overflow)
+	}
+
+	next := current + 1
+	vm.nonce = &next
+	return nil
+}
+
+// commitNonce stores the nonce of the caller, if the execution bumped it
+func (vm *VM) commitNonce() error {
+	if vm.nonce == nil {
+		return nil
+	}
+	return vm.context.(NonceContext).SetNonce(vm.caller(), *vm.nonce)
+}
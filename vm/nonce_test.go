@@ -0,0 +1,111 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func pushNonce(code []byte, nonce byte) []byte {
+	return append(code, PushInt, 1, 0, nonce)
+}
+
+// withSender sets the sender of the transaction
+func withSender(mc *MockContext) {
+	mc.From = [32]byte{9}
+}
+
+func TestNonce_CheckAndBump(t *testing.T) {
+	mc := newTestContext(append(pushNonce(nil, 0), CheckAndBumpNonce, Halt), withSender)
+
+	vm := NewVM(mc)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.Equal(t, mc.GetNonce(mc.From), uint64(1))
+
+	vm = NewVM(mc)
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "checkandbumpnonce: expected nonce 1, but got 0")
+	assert.Equal(t, mc.GetNonce(mc.From), uint64(1))
+}
+
+func TestNonce_PerCaller(t *testing.T) {
+	mc := newTestContext(append(pushNonce(nil, 3), CheckAndBumpNonce, Halt), withSender)
+	mc.Nonces = map[[32]byte]uint64{{9}: 3, {8}: 5}
+
+	vm := NewVM(mc)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.DeepEqual(t, mc.Nonces, map[[32]byte]uint64{{9}: 4, {8}: 5})
+}
+
+func TestNonce_Twice(t *testing.T) {
+	code := append(pushNonce(nil, 0), CheckAndBumpNonce)
+	code = append(pushNonce(code, 1), CheckAndBumpNonce, Halt)
+	mc := newTestContext(code, withSender)
+
+	vm := NewVM(mc)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.Equal(t, mc.GetNonce(mc.From), uint64(2))
+
+	entries := vm.GetWitness().Entries()
+	entry := entries[len(entries)-1]
+	assert.Equal(t, entry.Kind, WitnessNonce)
+	assert.Equal(t, entry.Account, mc.From)
+	assertBytes(t, entry.Value, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+func TestNonce_FailedExecution(t *testing.T) {
+	mc := newTestContext(append(pushNonce(nil, 0), CheckAndBumpNonce, PushInt, 1, 0, 1, PushInt, 1, 0, 0, Div, Halt),
+		withSender)
+
+	vm := NewVM(mc)
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, mc.GetNonce(mc.From), uint64(0))
+}
+
+func TestNonce_Errors(t *testing.T) {
+	tests := []struct {
+		code []byte
+		err  string
+	}{
+		{append([]byte{PushInt, 1, 1, 1}, CheckAndBumpNonce, Halt), "checkandbumpnonce: expected nonce 0, but got -1"},
+		{append([]byte{PushInt, 9, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}, CheckAndBumpNonce, Halt),
+			"checkandbumpnonce: expected nonce 0, but got 18446744073709551616"},
+		{[]byte{CheckAndBumpNonce, Halt}, "checkandbumpnonce: stack underflow at pc=0"},
+	}
+
+	for _, test := range tests {
+		mc := newTestContext(test.code, withSender)
+
+		vm := NewVM(mc)
+		assert.Assert(t, !vm.Exec(false))
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+		assert.Equal(t, len(mc.Nonces), 0)
+	}
+}
+
+func TestNonce_Overflow(t *testing.T) {
+	code := append([]byte{PushInt, 8, 0}, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	mc := newTestContext(append(code, CheckAndBumpNonce, Halt), withSender)
+	mc.Nonces = map[[32]byte]uint64{{9}: 1<<64 - 1}
+
+	vm := NewVM(mc)
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "checkandbumpnonce: integer does not fit into uint64")
+}
+
+func TestNonce_UnsupportedContext(t *testing.T) {
+	vm := NewTestVM([]byte{})
+	vm.context = plainContext{newTestContext(append(pushNonce(nil, 0), CheckAndBumpNonce, Halt), withSender)}
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "checkandbumpnonce: context does not support checkandbumpnonce")
+}
+
+func TestNonce_Paused(t *testing.T) {
+	mc := newTestContext(append(pushNonce(nil, 0), CheckAndBumpNonce, Halt), withSender)
+	mc.Paused = true
+
+	vm := NewVM(mc)
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "checkandbumpnonce: contract is paused")
+	assert.Equal(t, mc.GetNonce(mc.From), uint64(0))
+}
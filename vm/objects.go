@@ -0,0 +1,147 @@
+package vm
+
+// object is a container in the object table
+type object struct {
+	data []byte // Encoded container, as written to the state
+	refs int    // Number of stack slots and locals referencing the object
+}
+
+// objectTable holds the containers created by container opcodes. The evaluation stack and the locals reference them by
+// handles, which are one byte elements identified by the address of their byte. So duplicating a container copies its
+// handle, and the container is copied, when it is modified while it is referenced more than once (copy-on-write).
+// Handles never leave the execution: instructions consuming a container as bytes, e.g. StoreSt, take its encoding
+// instead, and the handles left on the evaluation stack are replaced by their containers, when the execution ends.
+type objectTable struct {
+	objects map[*byte]*object // By address of the handle
+	size    int               // Size of all containers in bytes
+}
+
+// add adds a container, which is not referenced anywhere else, and returns its handle
+func (t *objectTable) add(container []byte) []byte {
+	if t.objects == nil {
+		t.objects = make(map[*byte]*object)
+	}
+
+	handle := make([]byte, 1)
+	t.objects[&handle[0]] = &object{data: container, refs: 1}
+	t.size += len(container)
+	return handle
+}
+
+// lookup returns the object of a handle, nil if the element is not a handle
+func (t *objectTable) lookup(element []byte) *object {
+	if len(element) != 1 || len(t.objects) == 0 {
+		return nil
+	}
+	return t.objects[&element[0]]
+}
+
+// retain records another reference to the object of a handle
+func (t *objectTable) retain(element []byte) {
+	if o := t.lookup(element); o != nil {
+		o.refs++
+	}
+}
+
+// release removes a reference to the object of a handle and removes the object with its last reference
+func (t *objectTable) release(element []byte) {
+	o := t.lookup(element)
+	if o == nil {
+		return
+	}
+
+	o.refs--
+	if o.refs == 0 {
+		t.remove(element, o)
+	}
+}
+
+// resolve returns the container of a handle or the element itself. The container must not be modified.
+func (t *objectTable) resolve(element []byte) []byte {
+	if o := t.lookup(element); o != nil {
+		return o.data
+	}
+	return element
+}
+
+// take releases a handle and returns its container, which the caller may modify, and true. A container referenced
+// elsewhere is copied. Elements, which are not handles, are returned with false.
+func (t *objectTable) take(element []byte) ([]byte, bool) {
+	o := t.lookup(element)
+	if o == nil {
+		return element, false
+	}
+
+	if o.refs > 1 {
+		o.refs--
+		return append(make([]byte, 0, len(o.data)), o.data...), true
+	}
+	t.remove(element, o)
+	return o.data, true
+}
+
+func (t *objectTable) remove(handle []byte, o *object) {
+	delete(t.objects, &handle[0])
+	t.size -= len(o.data)
+}
+
+// clear removes all objects
+func (t *objectTable) clear() {
+	t.objects = nil
+	t.size = 0
+}
+
+// popValue pops an element without taking the container of a handle, so it can be moved to another slot or a local.
+// The gas factor is charged for the size of the container like PopBytes.
+func (vm *VM) popValue(opCode OpCode) ([]byte, error) {
+	element, err := vm.evaluationStack.Pop()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := vm.chargePopGas(opCode, len(vm.objects.resolve(element))); err != nil {
+		return nil, err
+	}
+	return element, nil
+}
+
+// discard pops an element and releases the container of a handle
+func (vm *VM) discard(opCode OpCode) error {
+	element, err := vm.popValue(opCode)
+	if err != nil {
+		return err
+	}
+
+	vm.objects.release(element)
+	return nil
+}
+
+// pushCopy pushes another reference to an element, which is kept by a stack slot or a local
+func (vm *VM) pushCopy(element []byte) error {
+	if err := vm.evaluationStack.Push(element); err != nil {
+		return err
+	}
+
+	vm.objects.retain(element)
+	return nil
+}
+
+// releaseLocals releases the containers referenced by the locals of a frame, which is removed or replaced
+func (vm *VM) releaseLocals(frame *Frame) {
+	for _, variable := range frame.variables {
+		vm.objects.release(variable)
+	}
+}
+
+// materialize replaces the handles on the evaluation stack by their containers and clears the object table, so the
+// results of the execution are plain bytes
+func (vm *VM) materialize() {
+	stack := vm.evaluationStack
+	for i, element := range stack.Stack {
+		if o := vm.objects.lookup(element); o != nil {
+			stack.Stack[i] = o.data
+			stack.memoryUsage += uint32(len(o.data) - len(element))
+		}
+	}
+	vm.objects.clear()
+}
@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestObjectTable_CopyOnWrite(t *testing.T) {
+	var table objectTable
+	container := []byte{2, 0, 1, 0, 1, 7}
+	handle := table.add(container)
+	table.retain(handle)
+	assert.Equal(t, table.size, 6)
+
+	// The container is referenced twice, so the first reference takes a copy
+	data, owned := table.take(handle)
+	assert.Assert(t, owned)
+	assert.DeepEqual(t, data, container)
+	data[5] = 8
+	assert.DeepEqual(t, table.resolve(handle), []byte{2, 0, 1, 0, 1, 7})
+
+	// The last reference takes the container itself and removes it from the table
+	data, owned = table.take(handle)
+	assert.Assert(t, owned)
+	assert.Equal(t, &data[0], &container[0])
+	assert.Equal(t, len(table.objects), 0)
+	assert.Equal(t, table.size, 0)
+}
+
+func TestObjectTable_Release(t *testing.T) {
+	var table objectTable
+	handle := table.add([]byte{2, 0, 0})
+	table.retain(handle)
+
+	table.release(handle)
+	assert.Equal(t, table.lookup(handle).refs, 1)
+	table.release(handle)
+	assert.Assert(t, table.lookup(handle) == nil)
+	assert.Equal(t, table.size, 0)
+}
+
+func TestObjectTable_PlainElements(t *testing.T) {
+	var table objectTable
+	table.add([]byte{2, 0, 0})
+
+	// Elements, which are not handles, are neither counted nor copied
+	element := []byte{7}
+	table.retain(element)
+	table.release(element)
+	data, owned := table.take(element)
+	assert.Assert(t, !owned)
+	assert.Equal(t, &data[0], &element[0])
+	assert.Equal(t, len(table.objects), 1)
+}
+
+func TestObjects_ReleasedByInstructions(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    []byte
+		steps   int
+		objects int
+	}{
+		{"pop", []byte{NewMap, Dup, Pop, Pop, Halt}, 4, 0},
+		{"popn", []byte{NewMap, Dup, PopN, 2, Halt}, 3, 0},
+		{"storeloc", []byte{CallLocals, 0, 7, 0, 0, 1, Halt, NewMap, StoreLoc, 0, NewMap, StoreLoc, 0, Ret}, 5, 1},
+		{"ret", []byte{NewMap, CallLocals, 0, 8, 1 | CallByReference, 0, 2, Halt, LoadLoc, 0, StoreLoc, 1, Ret}, 5, 0},
+		{"storest", []byte{NewMap, StoreSt, 0, Halt}, 2, 0},
+	}
+
+	for _, test := range tests {
+		mc := newTestContext(test.code)
+		mc.ContractVariables = [][]byte{{0}}
+		vm := NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+		assert.Equal(t, vm.ExecSteps(test.steps), StatusPaused, "%v: %v", test.name, vm.GetErrorMsg())
+		assert.Equal(t, len(vm.objects.objects), test.objects, test.name)
+		assert.Equal(t, vm.objects.size, 3*test.objects, test.name)
+	}
+}
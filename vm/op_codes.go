@@ -14,13 +14,13 @@ const (
 	Add
 	Sub
 	Mul
-	Div
+	Div // Euclidean division, the remainder of Mod is never negative
 	Mod
 	Exp
 	Neg
 	Eq
 	NotEq
-	Lt
+	Lt // Signed comparison of integers, use ULt for byte strings like hashes and addresses
 	Gt
 	LtEq
 	GtEq
@@ -67,6 +67,89 @@ const (
 	CheckSig
 	ErrHalt
 	Halt
+
+	// Opcodes added after the initial release are appended, so that the codes of deployed contracts never change
+	PushAddr // Push of a 32 byte address or a 64 byte public key, other lengths are rejected by the decoder
+	Pick
+	Tuck
+	PopN
+	DupN
+	DivTrunc // Rounds toward zero, the remainder of ModTrunc has the sign of the dividend
+	ModTrunc
+	DivFloor // Rounds toward negative infinity, the remainder of ModFloor has the sign of the divisor
+	ModFloor
+	Min
+	Max
+	Abs
+	Sign
+	Sqrt
+	Log2
+	Not
+	And
+	Or
+	Xor
+	ULt // Unsigned comparison of byte strings as big-endian magnitudes
+	UGt
+	ULtEq
+	UGtEq
+	StrLt
+	StrGt
+	StrCmp
+	IntToBytes
+	BytesToInt
+	BytesToAddress
+	BytesToPubKey
+	HexEncode // Textual encodings of bytes, e.g. of hashes and addresses in string call data
+	HexDecode
+	Base64Encode
+	Base64Decode
+	BoolToInt
+	CharToInt
+	Uint64ToInt
+	IntToUint64
+	Add64
+	Sub64
+	Cmp64
+	AddAmount // Overflow-checked addition of coin amounts as pushed by Balance and CallVal
+	SubAmount
+	AddDuration
+	SubDuration
+	BlocksToSeconds
+	SecondsToBlocks
+	TailCall
+	CallFn
+	CallBuiltin
+	EnterGuard
+	ExitGuard
+	CallDepth
+	ReturnAddressOf
+	TStore // Transient storage, which is cleared at the end of the execution
+	TLoad
+	BalanceOf
+	Origin
+	IsSelf
+	CodeSize
+	CodeHash
+	MetaField // Field of the metadata section, e.g. the version of the contract
+	ExtCodeSize
+	ExtCodeHash
+	ExtLoadSt // Read-only access to a contract variable of another contract
+	AccountExists
+	IsContract
+	DelegateCode
+	RequireIssuer
+	CheckAndBumpNonce
+	RequireBefore
+	NewMapFrom
+	MapMerge
+	MapSetMany
+	NewArrFrom
+	ArrFill
+	ArrResize
+	ArrFieldAt
+	ArrFieldStore
+	CallLocals // Call declaring the number of locals, accesses beyond them fail
+	CallTrueLocals
 )
 
 // Supported OpCode argument types
@@ -77,81 +160,251 @@ const (
 	ADDR
 )
 
-// OpCode contains the code, name, number of arguments, argument types, gas price and gas factor of the opcode
+// ArgWidth returns the size in bytes of an argument of the type. BYTES arguments have a variable size, which is
+// declared by their first byte, so 0 is returned for them.
+func ArgWidth(argType int) int {
+	switch argType {
+	case BYTE:
+		return 1
+	case LABEL:
+		return 2
+	case ADDR:
+		return 32
+	}
+	return 0
+}
+
+// Intrinsic gas factors, charged per started 64 bytes before the first instruction is executed. They are the default
+// prices of the CodeGasName and CallDataGasName entries of the gas schedule.
+const (
+	CodeGasFactor     uint64 = 1
+	CallDataGasFactor uint64 = 1
+)
+
+// Entries of the gas schedule, whose prices are charged per started 64 bytes of the code and the transaction data
+const (
+	CodeGasName     = "codechunk"
+	CallDataGasName = "calldatachunk"
+)
+
+// CallDataParamGas is charged by CallData per pushed parameter
+const CallDataParamGas uint64 = 1
+
+// IntrinsicGas returns the gas which is charged for the code and the transaction data before the execution starts
+// with the default gas schedule.
+func IntrinsicGas(codeSize int, callDataSize int) uint64 {
+	return intrinsicGas(codeSize, callDataSize, CodeGasFactor, CallDataGasFactor)
+}
+
+func intrinsicGas(codeSize int, callDataSize int, codePrice uint64, callDataPrice uint64) uint64 {
+	codeChunks := uint64((codeSize + 64 - 1) / 64)
+	callDataChunks := uint64((callDataSize + 64 - 1) / 64)
+	return codePrice*codeChunks + callDataPrice*callDataChunks
+}
+
+// VariableStackEffect is declared as number of popped or pushed elements, if it depends on the arguments of the
+// instruction or on the state, e.g. the number of arguments of a function call.
+const VariableStackEffect = -1
+
+// OpCode contains the code, name, number of arguments, argument types, gas price, gas factor and stack effect
+// of the opcode
 type OpCode struct {
-	code      byte
+	Code      byte
 	Name      string
 	Nargs     int
 	ArgTypes  []int
-	gasPrice  uint64
-	gasFactor uint64
+	GasPrice  uint64
+	GasFactor uint64
+	Pops      int // Number of elements popped from the evaluation stack or VariableStackEffect
+	Pushes    int // Number of elements pushed onto the evaluation stack or VariableStackEffect
 }
 
-// OpCodes contains all OpCode definitions
+// OpCodes contains all OpCode definitions.
+// The gas factor is charged per started 64 bytes of the popped elements. Push opcodes with a variable size immediate
+// (pushint, pushstr and push) charge it per immediate byte instead, so large constants cost more than small ones.
 var OpCodes = []OpCode{
-	{PushInt, "pushint", 1, []int{BYTES}, 1, 1},
-	{PushBool, "pushbool", 1, []int{BYTE}, 1, 1},
-	{PushChar, "pushchar", 1, []int{BYTE}, 1, 1},
-	{PushStr, "pushstr", 1, []int{BYTES}, 1, 1},
-	{Push, "push", 1, []int{BYTES}, 1, 1},
-	{Dup, "dup", 0, nil, 1, 2},
-	{Roll, "roll", 1, []int{BYTE}, 1, 2},
-	{Swap, "swap", 0, nil, 1, 2},
-	{Pop, "pop", 0, nil, 1, 1},
-	{Add, "add", 0, nil, 1, 2},
-	{Sub, "sub", 0, nil, 1, 2},
-	{Mul, "mult", 0, nil, 1, 2},
-	{Div, "div", 0, nil, 1, 2},
-	{Mod, "mod", 0, nil, 1, 2},
-	{Exp, "exp", 0, nil, 1, 2},
-	{Neg, "neg", 0, nil, 1, 2},
-	{Eq, "eq", 0, nil, 1, 2},
-	{NotEq, "neq", 0, nil, 1, 2},
-	{Lt, "lt", 0, nil, 1, 2},
-	{Gt, "gt", 0, nil, 1, 2},
-	{LtEq, "lte", 0, nil, 1, 2},
-	{GtEq, "gte", 0, nil, 1, 2},
-	{ShiftL, "shiftl", 0, nil, 1, 2},
-	{ShiftR, "shiftr", 0, nil, 1, 2},
-	{BitwiseAnd, "bitwiseand", 0, nil, 1, 2},
-	{BitwiseOr, "bitwiseor", 0, nil, 1, 2},
-	{BitwiseXor, "bitwisexor", 0, nil, 1, 2},
-	{BitwiseNot, "bitwisenot", 0, nil, 1, 2},
-	{NoOp, "nop", 0, nil, 1, 1},
-	{Jmp, "jmp", 1, []int{LABEL}, 1, 1},
-	{JmpTrue, "jmptrue", 1, []int{LABEL}, 1, 1},
-	{JmpFalse, "jmpfalse", 1, []int{LABEL}, 1, 1},
-	{Call, "call", 2, []int{LABEL, BYTE}, 1, 1},
-	{CallTrue, "callif", 2, []int{LABEL, BYTE}, 1, 1},
-	{CallExt, "callext", 3, []int{ADDR, BYTE, BYTE, BYTE, BYTE, BYTE}, 1000, 2},
-	{Ret, "ret", 0, nil, 1, 1},
-	{Size, "size", 0, nil, 1, 1},
-	{StoreLoc, "storeloc", 1, []int{BYTE}, 1, 2},
-	{StoreSt, "storest", 1, []int{BYTE}, 1000, 2},
-	{LoadLoc, "loadloc", 1, []int{BYTE}, 1, 2},
-	{LoadSt, "loadst", 1, []int{BYTE}, 10, 2},
-	{Address, "address", 0, nil, 1, 1},
-	{Issuer, "issuer", 0, nil, 1, 1},
-	{Balance, "balance", 0, nil, 1, 1},
-	{Caller, "caller", 0, nil, 1, 1},
-	{CallVal, "callval", 0, nil, 1, 1},
-	{CallData, "calldata", 0, nil, 1, 1},
-	{NewMap, "newmap", 0, nil, 1, 2},
-	{MapHasKey, "maphaskey", 0, nil, 1, 2},
-	{MapGetVal, "mapgetval", 0, nil, 1, 2},
-	{MapSetVal, "mapsetval", 0, nil, 1, 2},
-	{MapRemove, "mapremove", 0, nil, 1, 2},
-	{NewArr, "newarr", 0, nil, 1, 2},
-	{ArrAppend, "arrappend", 0, nil, 1, 2},
-	{ArrInsert, "arrinsert", 0, nil, 1, 2},
-	{ArrRemove, "arrremove", 0, nil, 1, 2},
-	{ArrAt, "arrat", 0, nil, 1, 2},
-	{ArrLen, "arrlen", 0, nil, 1, 2},
-	{NewStr, "newstr", 1, []int{BYTE}, 1, 2},
-	{StoreFld, "storefld", 1, []int{BYTE}, 1, 2},
-	{LoadFld, "loadfld", 1, []int{BYTE}, 1, 2},
-	{SHA3, "sha3", 0, nil, 1, 2},
-	{CheckSig, "checksig", 0, nil, 1, 2},
-	{ErrHalt, "errhalt", 0, nil, 0, 1},
-	{Halt, "halt", 0, nil, 0, 1},
+	{PushInt, "pushint", 1, []int{BYTES}, 1, 1, 0, 1},
+	{PushBool, "pushbool", 1, []int{BYTE}, 1, 1, 0, 1},
+	{PushChar, "pushchar", 1, []int{BYTE}, 1, 1, 0, 1},
+	{PushStr, "pushstr", 1, []int{BYTES}, 1, 1, 0, 1},
+	{Push, "push", 1, []int{BYTES}, 1, 1, 0, 1},
+	{Dup, "dup", 0, nil, 1, 2, 1, 2},
+	{Roll, "roll", 1, []int{BYTE}, 1, 2, VariableStackEffect, VariableStackEffect},
+	{Swap, "swap", 0, nil, 1, 2, 2, 2},
+	{Pop, "pop", 0, nil, 1, 1, 1, 0},
+	{Add, "add", 0, nil, 1, 2, 2, 1},
+	{Sub, "sub", 0, nil, 1, 2, 2, 1},
+	{Mul, "mult", 0, nil, 1, 2, 2, 1},
+	{Div, "div", 0, nil, 1, 2, 2, 1},
+	{Mod, "mod", 0, nil, 1, 2, 2, 1},
+	{Exp, "exp", 0, nil, 1, 2, 2, 1},
+	{Neg, "neg", 0, nil, 1, 2, 1, 1},
+	{Eq, "eq", 0, nil, 1, 2, 2, 1},
+	{NotEq, "neq", 0, nil, 1, 2, 2, 1},
+	{Lt, "lt", 0, nil, 1, 2, 2, 1},
+	{Gt, "gt", 0, nil, 1, 2, 2, 1},
+	{LtEq, "lte", 0, nil, 1, 2, 2, 1},
+	{GtEq, "gte", 0, nil, 1, 2, 2, 1},
+	{ShiftL, "shiftl", 0, nil, 1, 2, 2, 1},
+	{ShiftR, "shiftr", 0, nil, 1, 2, 2, 1},
+	{BitwiseAnd, "bitwiseand", 0, nil, 1, 2, 2, 1},
+	{BitwiseOr, "bitwiseor", 0, nil, 1, 2, 2, 1},
+	{BitwiseXor, "bitwisexor", 0, nil, 1, 2, 2, 1},
+	{BitwiseNot, "bitwisenot", 0, nil, 1, 2, 1, 1},
+	{NoOp, "nop", 0, nil, 1, 1, 0, 0},
+	{Jmp, "jmp", 1, []int{LABEL}, 1, 1, 0, 0},
+	{JmpTrue, "jmptrue", 1, []int{LABEL}, 1, 1, 1, 0},
+	{JmpFalse, "jmpfalse", 1, []int{LABEL}, 1, 1, 1, 0},
+	{Call, "call", 2, []int{LABEL, BYTE, BYTE}, 1, 1, VariableStackEffect, VariableStackEffect},
+	{CallTrue, "callif", 2, []int{LABEL, BYTE, BYTE}, 1, 1, VariableStackEffect, VariableStackEffect},
+	{CallExt, "callext", 3, []int{ADDR, BYTE, BYTE, BYTE, BYTE, BYTE}, 1000, 2, VariableStackEffect, VariableStackEffect},
+	{Ret, "ret", 0, nil, 1, 1, 0, 0},
+	{Size, "size", 0, nil, 1, 1, 1, 1},
+	{StoreLoc, "storeloc", 1, []int{BYTE}, 1, 2, 1, 0},
+	{StoreSt, "storest", 1, []int{BYTE}, 1000, 2, 1, 0},
+	{LoadLoc, "loadloc", 1, []int{BYTE}, 1, 2, 0, 1},
+	{LoadSt, "loadst", 1, []int{BYTE}, 10, 2, 0, 1},
+	{Address, "address", 0, nil, 1, 1, 0, 1},
+	{Issuer, "issuer", 0, nil, 1, 1, 0, 1},
+	{Balance, "balance", 0, nil, 1, 1, 0, 1},
+	{Caller, "caller", 0, nil, 1, 1, 0, 1},
+	{CallVal, "callval", 0, nil, 1, 1, 0, 1},
+	{CallData, "calldata", 0, nil, 1, 1, 0, VariableStackEffect},
+	{NewMap, "newmap", 0, nil, 1, 2, 0, 1},
+	{MapHasKey, "maphaskey", 0, nil, 1, 2, 2, 1},
+	{MapGetVal, "mapgetval", 0, nil, 1, 2, 2, 1},
+	{MapSetVal, "mapsetval", 0, nil, 1, 2, 3, 1},
+	{MapRemove, "mapremove", 0, nil, 1, 2, 2, 1},
+	{NewArr, "newarr", 0, nil, 1, 2, 1, 1},
+	{ArrAppend, "arrappend", 0, nil, 1, 2, 2, 1},
+	{ArrInsert, "arrinsert", 0, nil, 1, 2, 3, 1},
+	{ArrRemove, "arrremove", 0, nil, 1, 2, 2, 1},
+	{ArrAt, "arrat", 0, nil, 1, 2, 2, 1},
+	{ArrLen, "arrlen", 0, nil, 1, 2, 1, 1},
+	{NewStr, "newstr", 1, []int{BYTE}, 1, 2, 0, 1},
+	{StoreFld, "storefld", 1, []int{BYTE}, 1, 2, 2, 1},
+	{LoadFld, "loadfld", 1, []int{BYTE}, 1, 2, 1, 1},
+	{SHA3, "sha3", 0, nil, 1, 2, 1, 1},
+	{CheckSig, "checksig", 0, nil, 1, 2, 2, 1},
+	{ErrHalt, "errhalt", 0, nil, 0, 1, 0, 0},
+	{Halt, "halt", 0, nil, 0, 1, 0, 0},
+
+	// Opcodes added after the initial release
+	{PushAddr, "pushaddr", 1, []int{BYTES}, 1, 1, 0, 1},
+	{Pick, "pick", 1, []int{BYTE}, 1, 2, VariableStackEffect, VariableStackEffect},
+	{Tuck, "tuck", 0, nil, 1, 2, 2, 3},
+	{PopN, "popn", 1, []int{BYTE}, 1, 1, VariableStackEffect, 0},
+	{DupN, "dupn", 1, []int{BYTE}, 1, 2, VariableStackEffect, VariableStackEffect},
+	{DivTrunc, "divtrunc", 0, nil, 1, 2, 2, 1},
+	{ModTrunc, "modtrunc", 0, nil, 1, 2, 2, 1},
+	{DivFloor, "divfloor", 0, nil, 1, 2, 2, 1},
+	{ModFloor, "modfloor", 0, nil, 1, 2, 2, 1},
+	{Min, "min", 0, nil, 1, 2, 2, 1},
+	{Max, "max", 0, nil, 1, 2, 2, 1},
+	{Abs, "abs", 0, nil, 1, 2, 1, 1},
+	{Sign, "sign", 0, nil, 1, 2, 1, 1},
+	{Sqrt, "sqrt", 0, nil, 1, 2, 1, 1},
+	{Log2, "log2", 0, nil, 1, 2, 1, 1},
+	{Not, "not", 0, nil, 1, 1, 1, 1},
+	{And, "and", 0, nil, 1, 1, 2, 1},
+	{Or, "or", 0, nil, 1, 1, 2, 1},
+	{Xor, "xor", 0, nil, 1, 1, 2, 1},
+	{ULt, "ult", 0, nil, 1, 2, 2, 1},
+	{UGt, "ugt", 0, nil, 1, 2, 2, 1},
+	{ULtEq, "ulte", 0, nil, 1, 2, 2, 1},
+	{UGtEq, "ugte", 0, nil, 1, 2, 2, 1},
+	{StrLt, "strlt", 0, nil, 1, 2, 2, 1},
+	{StrGt, "strgt", 0, nil, 1, 2, 2, 1},
+	{StrCmp, "strcmp", 0, nil, 1, 2, 2, 1},
+	{IntToBytes, "inttobytes", 0, nil, 1, 2, 1, 1},
+	{BytesToInt, "bytestoint", 0, nil, 1, 2, 1, 1},
+	{BytesToAddress, "bytestoaddress", 0, nil, 1, 2, 1, 1},
+	{BytesToPubKey, "bytestopubkey", 0, nil, 1, 2, 1, 1},
+	{HexEncode, "hexencode", 0, nil, 1, 2, 1, 1},
+	{HexDecode, "hexdecode", 0, nil, 1, 2, 1, 1},
+	{Base64Encode, "base64encode", 0, nil, 1, 2, 1, 1},
+	{Base64Decode, "base64decode", 0, nil, 1, 2, 1, 1},
+	{BoolToInt, "booltoint", 0, nil, 1, 1, 1, 1},
+	{CharToInt, "chartoint", 0, nil, 1, 1, 1, 1},
+	{Uint64ToInt, "uint64toint", 0, nil, 1, 1, 1, 1},
+	{IntToUint64, "inttouint64", 0, nil, 1, 2, 1, 1},
+	{Add64, "add64", 0, nil, 1, 1, 2, 1},
+	{Sub64, "sub64", 0, nil, 1, 1, 2, 1},
+	{Cmp64, "cmp64", 0, nil, 1, 1, 2, 1},
+	{AddAmount, "addamount", 0, nil, 1, 1, 2, 1},
+	{SubAmount, "subamount", 0, nil, 1, 1, 2, 1},
+	{AddDuration, "addduration", 0, nil, 1, 1, 2, 1},
+	{SubDuration, "subduration", 0, nil, 1, 1, 2, 1},
+	{BlocksToSeconds, "blockstoseconds", 0, nil, 1, 1, 1, 1},
+	{SecondsToBlocks, "secondstoblocks", 0, nil, 1, 1, 1, 1},
+	{TailCall, "tailcall", 4, []int{LABEL, BYTE, BYTE, BYTE}, 1, 1, VariableStackEffect, VariableStackEffect},
+	{CallFn, "callfn", 2, []int{BYTE, BYTE, BYTE, BYTE, BYTE}, 1, 1, VariableStackEffect, VariableStackEffect},
+	{CallBuiltin, "callbuiltin", 1, []int{BYTE}, 1, 2, VariableStackEffect, 1},
+	{EnterGuard, "enterguard", 0, nil, 1, 1, 0, 0},
+	{ExitGuard, "exitguard", 0, nil, 1, 1, 0, 0},
+	{CallDepth, "calldepth", 0, nil, 1, 1, 0, 1},
+	{ReturnAddressOf, "returnaddressof", 1, []int{BYTE}, 1, 1, 0, 1},
+	{TStore, "tstore", 0, nil, 5, 2, 2, 0},
+	{TLoad, "tload", 0, nil, 5, 2, 1, 1},
+	{BalanceOf, "balanceof", 0, nil, 10, 1, 1, 1},
+	{Origin, "origin", 0, nil, 1, 1, 0, 1},
+	{IsSelf, "isself", 0, nil, 1, 1, 1, 1},
+	{CodeSize, "codesize", 0, nil, 1, 1, 0, 1},
+	{CodeHash, "codehash", 0, nil, 1, 2, 0, 1},
+	{MetaField, "metafield", 1, []int{BYTE}, 1, 1, 0, 1},
+	{ExtCodeSize, "extcodesize", 0, nil, 10, 1, 1, 1},
+	{ExtCodeHash, "extcodehash", 0, nil, 10, 2, 1, 1},
+	{ExtLoadSt, "extloadst", 0, nil, 50, 2, 2, 1},
+	{AccountExists, "accountexists", 0, nil, 10, 1, 1, 1},
+	{IsContract, "iscontract", 0, nil, 10, 1, 1, 1},
+	{DelegateCode, "delegatecode", 0, nil, 1000, 1, 1, 0},
+	{RequireIssuer, "requireissuer", 0, nil, 1, 1, 0, 0},
+	{CheckAndBumpNonce, "checkandbumpnonce", 0, nil, 1000, 1, 1, 0},
+	{RequireBefore, "requirebefore", 0, nil, 1, 1, 1, 0},
+	{NewMapFrom, "newmapfrom", 1, []int{BYTE}, 1, 2, VariableStackEffect, 1},
+	{MapMerge, "mapmerge", 0, nil, 1, 2, 2, 1},
+	{MapSetMany, "mapsetmany", 1, []int{BYTE}, 1, 2, VariableStackEffect, 1},
+	{NewArrFrom, "newarrfrom", 1, []int{BYTE}, 1, 2, VariableStackEffect, 1},
+	{ArrFill, "arrfill", 0, nil, 1, 2, 2, 1},
+	{ArrResize, "arrresize", 0, nil, 1, 2, 2, 1},
+	{ArrFieldAt, "arrfieldat", 1, []int{BYTE, BYTE}, 1, 2, 2, 1},
+	{ArrFieldStore, "arrfieldstore", 1, []int{BYTE, BYTE}, 1, 2, 3, 1},
+	{CallLocals, "calllocals", 4, []int{LABEL, BYTE, BYTE, BYTE}, 1, 1, VariableStackEffect, VariableStackEffect},
+	{CallTrueLocals, "calliflocals", 4, []int{LABEL, BYTE, BYTE, BYTE}, 1, 1, VariableStackEffect, VariableStackEffect},
+}
+
+var opCodesByName = make(map[string]OpCode, len(OpCodes))
+
+func init() {
+	for _, opCode := range OpCodes {
+		opCodesByName[opCode.Name] = opCode
+	}
+}
+
+// LookupOpcode returns the definition of the opcode with the name, which is used in assembly.
+func LookupOpcode(name string) (OpCode, bool) {
+	opCode, ok := opCodesByName[name]
+	return opCode, ok
+}
+
+// GasCost contains the gas price and the gas factor of an opcode
+type GasCost struct {
+	Price  uint64 `json:"price"`
+	Factor uint64 `json:"factor"`
+}
+
+// GasSchedule maps the opcode names to their gas costs
+type GasSchedule map[string]GasCost
+
+// DefaultGasSchedule returns the gas costs of all OpCode definitions and the default prices of the memory, the code
+// and the transaction data
+func DefaultGasSchedule() GasSchedule {
+	schedule := make(GasSchedule, len(OpCodes)+3)
+	for _, opCode := range OpCodes {
+		schedule[opCode.Name] = GasCost{Price: opCode.GasPrice, Factor: opCode.GasFactor}
+	}
+	schedule[MemoryGasName] = GasCost{Price: DefaultMemoryGasPrice}
+	schedule[CodeGasName] = GasCost{Price: CodeGasFactor}
+	schedule[CallDataGasName] = GasCost{Price: CallDataGasFactor}
+	return schedule
 }
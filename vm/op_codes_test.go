@@ -0,0 +1,134 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestOpCodes_IntrinsicGas(t *testing.T) {
+	assert.Equal(t, IntrinsicGas(0, 0), uint64(0))
+	assert.Equal(t, IntrinsicGas(1, 0), CodeGasFactor)
+	assert.Equal(t, IntrinsicGas(64, 65), CodeGasFactor+2*CallDataGasFactor)
+}
+
+func TestOpCodes_DefaultGasSchedule(t *testing.T) {
+	schedule := DefaultGasSchedule()
+	assert.Equal(t, len(schedule), len(OpCodes)+3)
+	assert.Equal(t, schedule["storest"], GasCost{Price: 1000, Factor: 2})
+	assert.Equal(t, schedule["halt"], GasCost{Price: 0, Factor: 1})
+	assert.Equal(t, schedule[MemoryGasName], GasCost{Price: DefaultMemoryGasPrice})
+	assert.Equal(t, schedule[CodeGasName], GasCost{Price: CodeGasFactor})
+	assert.Equal(t, schedule[CallDataGasName], GasCost{Price: CallDataGasFactor})
+}
+
+func TestOpCodes_Table(t *testing.T) {
+	for i, opCode := range OpCodes {
+		assert.Equal(t, int(opCode.Code), i, opCode.Name)
+		assert.Assert(t, opCode.Pops >= VariableStackEffect, opCode.Name)
+		assert.Assert(t, opCode.Pushes >= VariableStackEffect, opCode.Name)
+	}
+}
+
+// TestOpCodes_BaselineValues pins the codes of the opcodes of the initial release. Deployed contracts are decoded with
+// them, so new opcodes have to be appended to the table.
+func TestOpCodes_BaselineValues(t *testing.T) {
+	baseline := []struct {
+		opCode int
+		code   byte
+		name   string
+	}{
+		{PushInt, 0, "pushint"},
+		{PushBool, 1, "pushbool"},
+		{PushChar, 2, "pushchar"},
+		{PushStr, 3, "pushstr"},
+		{Push, 4, "push"},
+		{Dup, 5, "dup"},
+		{Roll, 6, "roll"},
+		{Swap, 7, "swap"},
+		{Pop, 8, "pop"},
+		{Add, 9, "add"},
+		{Sub, 10, "sub"},
+		{Mul, 11, "mult"},
+		{Div, 12, "div"},
+		{Mod, 13, "mod"},
+		{Exp, 14, "exp"},
+		{Neg, 15, "neg"},
+		{Eq, 16, "eq"},
+		{NotEq, 17, "neq"},
+		{Lt, 18, "lt"},
+		{Gt, 19, "gt"},
+		{LtEq, 20, "lte"},
+		{GtEq, 21, "gte"},
+		{ShiftL, 22, "shiftl"},
+		{ShiftR, 23, "shiftr"},
+		{BitwiseAnd, 24, "bitwiseand"},
+		{BitwiseOr, 25, "bitwiseor"},
+		{BitwiseXor, 26, "bitwisexor"},
+		{BitwiseNot, 27, "bitwisenot"},
+		{NoOp, 28, "nop"},
+		{Jmp, 29, "jmp"},
+		{JmpTrue, 30, "jmptrue"},
+		{JmpFalse, 31, "jmpfalse"},
+		{Call, 32, "call"},
+		{CallTrue, 33, "callif"},
+		{CallExt, 34, "callext"},
+		{Ret, 35, "ret"},
+		{Size, 36, "size"},
+		{StoreLoc, 37, "storeloc"},
+		{StoreSt, 38, "storest"},
+		{LoadLoc, 39, "loadloc"},
+		{LoadSt, 40, "loadst"},
+		{Address, 41, "address"},
+		{Issuer, 42, "issuer"},
+		{Balance, 43, "balance"},
+		{Caller, 44, "caller"},
+		{CallVal, 45, "callval"},
+		{CallData, 46, "calldata"},
+		{NewMap, 47, "newmap"},
+		{MapHasKey, 48, "maphaskey"},
+		{MapGetVal, 49, "mapgetval"},
+		{MapSetVal, 50, "mapsetval"},
+		{MapRemove, 51, "mapremove"},
+		{NewArr, 52, "newarr"},
+		{ArrAppend, 53, "arrappend"},
+		{ArrInsert, 54, "arrinsert"},
+		{ArrRemove, 55, "arrremove"},
+		{ArrAt, 56, "arrat"},
+		{ArrLen, 57, "arrlen"},
+		{NewStr, 58, "newstr"},
+		{StoreFld, 59, "storefld"},
+		{LoadFld, 60, "loadfld"},
+		{SHA3, 61, "sha3"},
+		{CheckSig, 62, "checksig"},
+		{ErrHalt, 63, "errhalt"},
+		{Halt, 64, "halt"},
+	}
+
+	for _, expected := range baseline {
+		assert.Equal(t, expected.opCode, int(expected.code), expected.name)
+		assert.Equal(t, OpCodes[expected.code].Name, expected.name)
+	}
+}
+
+func TestOpCodes_LookupOpcode(t *testing.T) {
+	opCode, ok := LookupOpcode("tuck")
+	assert.Assert(t, ok)
+	assert.Equal(t, opCode.Code, byte(Tuck))
+	assert.Equal(t, opCode.Pops, 2)
+	assert.Equal(t, opCode.Pushes, 3)
+
+	opCode, ok = LookupOpcode("popn")
+	assert.Assert(t, ok)
+	assert.Equal(t, opCode.Pops, VariableStackEffect)
+
+	_, ok = LookupOpcode("unknown")
+	assert.Assert(t, !ok)
+}
+
+func TestOpCodes_ArgWidth(t *testing.T) {
+	assert.Equal(t, ArgWidth(BYTE), 1)
+	assert.Equal(t, ArgWidth(LABEL), 2)
+	assert.Equal(t, ArgWidth(ADDR), 32)
+	assert.Equal(t, ArgWidth(BYTES), 0)
+}
@@ -0,0 +1,94 @@
+package vm
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// OpStats counts the executed opcodes, their gas and the pairs of consecutive opcodes. It is safe for concurrent use,
+// so a single accumulator can be shared by the VMs of a block or chain replay, e.g. to tune the gas schedule or to
+// find candidates for superinstructions.
+type OpStats struct {
+	counts [256]uint64
+	gas    [256]uint64
+	pairs  [256 * 256]uint64 // Counts of consecutive opcodes by first opcode * 256 + second opcode
+}
+
+// OpStat is the number of executions of an opcode and the gas used by them.
+type OpStat struct {
+	OpCode byte   `json:"-"`
+	Name   string `json:"opcode"`
+	Count  uint64 `json:"count"`
+	Gas    uint64 `json:"gas"`
+}
+
+// OpPairStat is the number of executions of an opcode directly followed by another opcode in the same execution.
+type OpPairStat struct {
+	First  string `json:"first"`
+	Second string `json:"second"`
+	Count  uint64 `json:"count"`
+}
+
+// NewOpStats creates an empty accumulator, which is enabled by the OpStats field of the config.
+func NewOpStats() *OpStats {
+	return &OpStats{}
+}
+
+// record adds an executed instruction, previous is the opcode of the preceding instruction or -1
+func (s *OpStats) record(previous int, code byte, gas uint64) {
+	atomic.AddUint64(&s.counts[code], 1)
+	atomic.AddUint64(&s.gas[code], gas)
+	if previous >= 0 {
+		atomic.AddUint64(&s.pairs[previous*256+int(code)], 1)
+	}
+}
+
+// OpCodes returns the statistics of the executed opcodes in the order of their codes.
+func (s *OpStats) OpCodes() []OpStat {
+	var stats []OpStat
+	for code := range s.counts {
+		count := atomic.LoadUint64(&s.counts[code])
+		if count == 0 {
+			continue
+		}
+		stats = append(stats, OpStat{
+			OpCode: byte(code),
+			Name:   OpCodes[code].Name,
+			Count:  count,
+			Gas:    atomic.LoadUint64(&s.gas[code]),
+		})
+	}
+	return stats
+}
+
+// Pairs returns the n most frequent pairs of consecutive opcodes, all pairs if n is not positive.
+// Pairs with the same count are ordered by their codes.
+func (s *OpStats) Pairs(n int) []OpPairStat {
+	type pair struct {
+		index int
+		count uint64
+	}
+
+	var pairs []pair
+	for index := range s.pairs {
+		if count := atomic.LoadUint64(&s.pairs[index]); count > 0 {
+			pairs = append(pairs, pair{index, count})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].count > pairs[j].count
+	})
+	if n > 0 && len(pairs) > n {
+		pairs = pairs[:n]
+	}
+
+	stats := make([]OpPairStat, len(pairs))
+	for i, p := range pairs {
+		stats[i] = OpPairStat{
+			First:  OpCodes[p.index/256].Name,
+			Second: OpCodes[p.index%256].Name,
+			Count:  p.count,
+		}
+	}
+	return stats
+}
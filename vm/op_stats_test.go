@@ -0,0 +1,59 @@
+package vm
+
+import (
+	"sync"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestOpStats_CountsAndGas(t *testing.T) {
+	stats := NewOpStats()
+	vm := NewVMWithConfig(NewMockContext([]byte{PushInt, 0, PushInt, 0, Add, Halt}), VMConfig{OpStats: stats})
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+
+	assert.DeepEqual(t, stats.OpCodes(), []OpStat{
+		{OpCode: PushInt, Name: "pushint", Count: 2, Gas: 2},
+		{OpCode: Add, Name: "add", Count: 1, Gas: 5},
+		{OpCode: Halt, Name: "halt", Count: 1, Gas: 0},
+	})
+	assert.DeepEqual(t, stats.Pairs(0), []OpPairStat{
+		{First: "pushint", Second: "pushint", Count: 1},
+		{First: "pushint", Second: "add", Count: 1},
+		{First: "add", Second: "halt", Count: 1},
+	})
+}
+
+func TestOpStats_PairsDoNotSpanExecutions(t *testing.T) {
+	stats := NewOpStats()
+	code := []byte{PushInt, 0, Pop, Halt}
+	vm := NewVMWithConfig(NewMockContext(code), VMConfig{OpStats: stats})
+	assert.Assert(t, vm.Exec(false))
+	vm.Reset(NewMockContext(code))
+	assert.Assert(t, vm.Exec(false))
+
+	assert.DeepEqual(t, stats.Pairs(1), []OpPairStat{{First: "pushint", Second: "pop", Count: 2}})
+	for _, pair := range stats.Pairs(0) {
+		assert.Assert(t, pair.First != "halt")
+	}
+}
+
+func TestOpStats_SharedByConcurrentVMs(t *testing.T) {
+	stats := NewOpStats()
+	code := []byte{PushInt, 0, Pop, Halt}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vm := NewVMWithConfig(NewMockContext(code), VMConfig{OpStats: stats})
+			vm.Exec(false)
+		}()
+	}
+	wg.Wait()
+
+	for _, stat := range stats.OpCodes() {
+		assert.Equal(t, stat.Count, uint64(8), stat.Name)
+	}
+}
@@ -0,0 +1,32 @@
+package vm
+
+// PauseContext is implemented by contexts of contracts, which can be paused in an emergency.
+// Contexts without this interface are never paused.
+type PauseContext interface {
+	// IsPaused returns true, if the contract is paused.
+	IsPaused() bool
+}
+
+// stateChangingOpCodes change the state of the contract or call other contracts, which may change their state.
+// They are rejected while the contract is paused, all other opcodes only read the state or compute.
+var stateChangingOpCodes = map[byte]bool{
+	StoreSt:      true,
+	CallExt:      true,
+	DelegateCode: true,
+
+	CheckAndBumpNonce: true,
+}
+
+// isPaused returns true, if the context pauses the contract
+func (vm *VM) isPaused() bool {
+	pauseContext, ok := vm.context.(PauseContext)
+	return ok && pauseContext.IsPaused()
+}
+
+// checkPaused rejects instructions, which change the state, while the contract is paused
+func (vm *VM) checkPaused(opCode OpCode) error {
+	if vm.paused && stateChangingOpCodes[opCode.Code] {
+		return newError(ErrPaused)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// paused pauses the contract, which declares one contract variable
+func paused(mc *MockContext) {
+	mc.ContractVariables = [][]byte{{0, 5}}
+	mc.Paused = true
+}
+
+func TestPause_Reads(t *testing.T) {
+	mc := newTestContext([]byte{LoadSt, 0, PushInt, 1, 0, 1, Add, Balance, Halt}, paused)
+
+	vm := NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+}
+
+func TestPause_StateChanges(t *testing.T) {
+	tests := []struct {
+		code []byte
+		err  string
+	}{
+		{[]byte{PushInt, 1, 0, 1, StoreSt, 0, Halt}, "storest: contract is paused"},
+		{append(pushAddress(nil, [32]byte{7}), DelegateCode, Halt), "delegatecode: contract is paused"},
+		{append([]byte{CallExt}, make([]byte, 37)...), "callext: contract is paused"},
+	}
+
+	for _, test := range tests {
+		mc := newTestContext(test.code, paused)
+
+		vm := NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+		assert.Assert(t, !vm.Exec(false))
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+		assert.DeepEqual(t, mc.ContractVariables, [][]byte{{0, 5}})
+	}
+}
+
+func TestPause_Transfer(t *testing.T) {
+	mc := newTestContext([]byte{Halt}, paused)
+	mc.Amount = 10
+
+	vm := NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "vm.exec(): contract is paused")
+}
+
+func TestPause_Unpaused(t *testing.T) {
+	mc := newTestContext([]byte{PushInt, 1, 0, 1, StoreSt, 0, Halt}, paused)
+	mc.Paused = false
+	mc.Amount = 10
+
+	vm := NewVMWithConfig(mc, VMConfig{CheckIntegrity: true})
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+}
@@ -0,0 +1,69 @@
+package vm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Profiler aggregates the gas used by the executed instructions per call stack. WriteFolded writes the samples in the
+// folded stack format, which is read by flame graph tools, e.g. flamegraph.pl or speedscope.
+//
+// Frames are labelled by the hash of the called function, if it is declared in the function table of the contract,
+// and by the address of the function otherwise. The outermost frame is "main".
+type Profiler struct {
+	stacks  []string // Stacks in the order of their first sample
+	weights map[string]uint64
+}
+
+// NewProfiler creates an empty profiler, which is enabled by the Profiler field of the config or by SetProfiler.
+func NewProfiler() *Profiler {
+	return &Profiler{weights: map[string]uint64{}}
+}
+
+// SetProfiler sets the profiler, which receives the gas used by every executed instruction. Nil disables profiling.
+func (vm *VM) SetProfiler(profiler *Profiler) {
+	vm.config.Profiler = profiler
+}
+
+// add adds the gas to the samples of the stack
+func (p *Profiler) add(stack string, gas uint64) {
+	if _, ok := p.weights[stack]; !ok {
+		p.stacks = append(p.stacks, stack)
+	}
+	p.weights[stack] += gas
+}
+
+// WriteFolded writes a line per stack, the frames separated by semicolons followed by the used gas, sorted by stack.
+func (p *Profiler) WriteFolded(w io.Writer) error {
+	stacks := append([]string{}, p.stacks...)
+	sort.Strings(stacks)
+
+	for _, stack := range stacks {
+		if _, err := fmt.Fprintf(w, "%v %v\n", stack, p.weights[stack]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// profileStack returns the folded stack of the instruction, the innermost frame being the opcode
+func (vm *VM) profileStack(opCode OpCode) string {
+	frames := []string{"main"}
+	for _, frame := range vm.callStack.values {
+		frames = append(frames, vm.functionLabel(frame.function))
+	}
+	return strings.Join(append(frames, opCode.Name), ";")
+}
+
+// functionLabel returns the label of the function at the address
+func (vm *VM) functionLabel(address int) string {
+	for _, function := range vm.functions {
+		if int(function.Address) == address {
+			return "fn_" + hex.EncodeToString(function.Hash[:])
+		}
+	}
+	return fmt.Sprintf("fn@%04d", address)
+}
@@ -0,0 +1,61 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func profile(t *testing.T, code []byte) string {
+	profiler := NewProfiler()
+	vm := NewVMWithConfig(NewMockContext(code), VMConfig{Profiler: profiler})
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+
+	var folded bytes.Buffer
+	assert.NilError(t, profiler.WriteFolded(&folded))
+	return folded.String()
+}
+
+func TestProfiler_FunctionTableLabels(t *testing.T) {
+	table := NewFunctionTable([]Function{{Hash: FunctionHash("f"), Address: 7}})
+	code := append(table,
+		CallLocals, 0, 7, 0, 0, 0,
+		Halt,
+		PushInt, 0, // Begin of function at address 7
+		Pop,
+		Ret,
+	)
+
+	label := fmt.Sprintf("fn_%x", FunctionHash("f"))
+	expected := fmt.Sprintf("main;calllocals 1\nmain;%[1]v;pop 2\nmain;%[1]v;pushint 1\nmain;%[1]v;ret 1\nmain;halt 0\n", label)
+	assert.Equal(t, profile(t, code), expected)
+}
+
+func TestProfiler_AddressLabels(t *testing.T) {
+	code := []byte{
+		CallLocals, 0, 7, 0, 0, 0,
+		Halt,
+		PushInt, 0, // Begin of function at address 7
+		Pop,
+		Ret,
+	}
+
+	folded := profile(t, code)
+	assert.Assert(t, strings.Contains(folded, "main;fn@0007;pop 2\n"), folded)
+}
+
+func TestProfiler_AccumulatesExecutions(t *testing.T) {
+	code := []byte{PushInt, 0, Pop, Halt}
+	profiler := NewProfiler()
+	vm := NewVMWithConfig(NewMockContext(code), VMConfig{Profiler: profiler})
+	assert.Assert(t, vm.Exec(false))
+	vm.Reset(NewMockContext(code))
+	assert.Assert(t, vm.Exec(false))
+
+	var folded bytes.Buffer
+	assert.NilError(t, profiler.WriteFolded(&folded))
+	assert.Equal(t, folded.String(), "main;halt 0\nmain;pop 4\nmain;pushint 2\n")
+}
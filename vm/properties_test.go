@@ -0,0 +1,176 @@
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// operand is a random signed integer of up to 16 bytes, so the properties cover multi-word values
+type operand struct {
+	value *big.Int
+}
+
+func (operand) Generate(r *rand.Rand, size int) reflect.Value {
+	magnitude := make([]byte, r.Intn(17))
+	r.Read(magnitude)
+
+	value := new(big.Int).SetBytes(magnitude)
+	if r.Intn(2) == 0 {
+		value.Neg(value)
+	}
+	return reflect.ValueOf(operand{value})
+}
+
+// pushOperand encodes the operand as PushInt instruction
+func pushOperand(o operand) []byte {
+	return push(nil, PushInt, SignedByteArrayConversion(*o.value))
+}
+
+// program concatenates instructions and appends Halt
+func program(instructions ...[]byte) []byte {
+	var code []byte
+	for _, instruction := range instructions {
+		code = append(code, instruction...)
+	}
+	return append(code, Halt)
+}
+
+// evalProgram executes the code and returns the top of the stack, which must be a canonically encoded integer
+func evalProgram(t *testing.T, code []byte) *big.Int {
+	mc := NewMockContext(code)
+	mc.Fee = 10000
+	vm := NewVM(mc)
+	vm.SetConfig(VMConfig{CheckIntegrity: true})
+
+	if !vm.Exec(false) {
+		t.Fatalf("Code %v failed with '%v'", code, vm.GetErrorMsg())
+	}
+
+	result, err := vm.PeekResult()
+	if err != nil {
+		t.Fatalf("Code %v left no result: %v", code, err)
+	}
+
+	value, err := SignedBigIntConversion(result, nil)
+	if err != nil {
+		t.Fatalf("Code %v returned %v: %v", code, result, err)
+	}
+	if !bytes.Equal(result, SignedByteArrayConversion(value)) {
+		t.Fatalf("Code %v returned the non-canonical encoding %v", code, result)
+	}
+	return &value
+}
+
+func evalBool(t *testing.T, code []byte) bool {
+	mc := NewMockContext(code)
+	mc.Fee = 10000
+	vm := NewVM(mc)
+	vm.SetConfig(VMConfig{CheckIntegrity: true})
+
+	if !vm.Exec(false) {
+		t.Fatalf("Code %v failed with '%v'", code, vm.GetErrorMsg())
+	}
+
+	result, _ := vm.PeekResult()
+	return ByteArrayToBool(result)
+}
+
+func checkProperty(t *testing.T, property interface{}) {
+	if err := quick.Check(property, &quick.Config{MaxCount: 500, Rand: rand.New(rand.NewSource(1))}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperties_AddCommutative(t *testing.T) {
+	checkProperty(t, func(a operand, b operand) bool {
+		left := evalProgram(t, program(pushOperand(a), pushOperand(b), []byte{Add}))
+		right := evalProgram(t, program(pushOperand(b), pushOperand(a), []byte{Add}))
+		return left.Cmp(right) == 0 && left.Cmp(new(big.Int).Add(a.value, b.value)) == 0
+	})
+}
+
+func TestProperties_SubInverse(t *testing.T) {
+	checkProperty(t, func(a operand, b operand) bool {
+		result := evalProgram(t, program(pushOperand(a), pushOperand(b), []byte{Sub}, pushOperand(b), []byte{Add}))
+		return result.Cmp(a.value) == 0
+	})
+}
+
+func TestProperties_SubSelf(t *testing.T) {
+	checkProperty(t, func(a operand) bool {
+		return evalProgram(t, program(pushOperand(a), []byte{Dup, Sub})).Sign() == 0
+	})
+}
+
+func TestProperties_NegInvolution(t *testing.T) {
+	checkProperty(t, func(a operand) bool {
+		return evalProgram(t, program(pushOperand(a), []byte{Neg, Neg})).Cmp(a.value) == 0
+	})
+}
+
+func TestProperties_DivMod(t *testing.T) {
+	checkProperty(t, func(a operand, b operand) bool {
+		if b.value.Sign() == 0 {
+			return true
+		}
+
+		quotient := evalProgram(t, program(pushOperand(a), pushOperand(b), []byte{Div}))
+		remainder := evalProgram(t, program(pushOperand(a), pushOperand(b), []byte{Mod}))
+
+		// a == (a div b) * b + a mod b and 0 <= a mod b < |b|
+		recombined := new(big.Int).Mul(quotient, b.value)
+		recombined.Add(recombined, remainder)
+		return recombined.Cmp(a.value) == 0 && remainder.Sign() >= 0 && remainder.CmpAbs(b.value) < 0
+	})
+}
+
+func TestProperties_DivModTruncFloor(t *testing.T) {
+	checkProperty(t, func(a operand, b operand) bool {
+		if b.value.Sign() == 0 {
+			return true
+		}
+
+		pairs := []struct {
+			div, mod byte
+			sign     int // Sign of a non-zero remainder
+		}{
+			{DivTrunc, ModTrunc, a.value.Sign()},
+			{DivFloor, ModFloor, b.value.Sign()},
+		}
+		for _, pair := range pairs {
+			quotient := evalProgram(t, program(pushOperand(a), pushOperand(b), []byte{pair.div}))
+			remainder := evalProgram(t, program(pushOperand(a), pushOperand(b), []byte{pair.mod}))
+
+			// a == (a div b) * b + a mod b and |a mod b| < |b|
+			recombined := new(big.Int).Mul(quotient, b.value)
+			recombined.Add(recombined, remainder)
+			if recombined.Cmp(a.value) != 0 || remainder.CmpAbs(b.value) >= 0 {
+				return false
+			}
+			if remainder.Sign() != 0 && remainder.Sign() != pair.sign {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestProperties_ComparisonTotality(t *testing.T) {
+	checkProperty(t, func(a operand, b operand) bool {
+		compare := func(op byte) bool {
+			return evalBool(t, program(pushOperand(a), pushOperand(b), []byte{op}))
+		}
+
+		lt, eq, gt := compare(Lt), compare(Eq), compare(Gt)
+		exactlyOne := (lt && !eq && !gt) || (!lt && eq && !gt) || (!lt && !eq && gt)
+		return exactlyOne &&
+			compare(LtEq) == (lt || eq) &&
+			compare(GtEq) == (gt || eq) &&
+			compare(NotEq) == !eq &&
+			lt == (a.value.Cmp(b.value) < 0)
+	})
+}
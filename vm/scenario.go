@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Scenario is a sequence of transactions calling the same contract, e.g. a counter incremented several times.
+// The changes of every successful transaction are persisted before the next one, so the expected results verify how
+// the storage evolves across the calls. Values are hex encoded like in test vectors.
+type Scenario struct {
+	Name     string         `json:"name"`
+	Code     Bytecode       `json:"code"`
+	PreState []string       `json:"preState,omitempty"` // Contract variables before the first transaction
+	Balance  uint64         `json:"balance,omitempty"`
+	Steps    []ScenarioStep `json:"steps"`
+}
+
+// ScenarioStep is a transaction of a scenario and its expected result.
+type ScenarioStep struct {
+	Name     string           `json:"name,omitempty"` // Index of the step if empty
+	Fee      uint64           `json:"fee"`
+	Amount   uint64           `json:"amount,omitempty"`
+	Sender   string           `json:"sender,omitempty"`
+	CallData string           `json:"callData,omitempty"`
+	Expected TestVectorResult `json:"expected"`
+}
+
+// LoadScenarios reads a JSON array of scenarios.
+func LoadScenarios(r io.Reader) ([]Scenario, error) {
+	var scenarios []Scenario
+	err := json.NewDecoder(r).Decode(&scenarios)
+	return scenarios, err
+}
+
+// Run executes the steps in order and returns an error describing the first mismatch. The context is returned with
+// the state after the last executed step.
+func (s *Scenario) Run() (*MockContext, error) {
+	preState, err := decodeAll(s.PreState)
+	if err != nil {
+		return nil, err
+	}
+
+	mc := NewMockContext(s.Code)
+	mc.ContractVariables = preState
+	mc.Balance = s.Balance
+
+	for i, step := range s.Steps {
+		name := step.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		name = s.Name + "/" + name
+
+		if mc.Data, err = hex.DecodeString(step.CallData); err != nil {
+			return mc, err
+		}
+		mc.From = [32]byte{}
+		if step.Sender != "" {
+			if err := decodeFixed(step.Sender, mc.From[:]); err != nil {
+				return mc, err
+			}
+		}
+		mc.Fee = step.Fee
+		mc.Amount = step.Amount
+
+		vm := NewVM(mc)
+		isSuccess := vm.Exec(false)
+		if err := step.Expected.check(name, &vm, mc, isSuccess); err != nil {
+			return mc, err
+		}
+		if isSuccess {
+			mc.PersistChanges()
+		}
+	}
+	return mc, nil
+}
@@ -0,0 +1,55 @@
+package vm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestScenarios(t *testing.T) {
+	files, err := filepath.Glob("../tests/scenarios/*.json")
+	assert.NilError(t, err)
+	assert.Assert(t, len(files) > 0)
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		assert.NilError(t, err)
+		scenarios, err := LoadScenarios(f)
+		f.Close()
+		assert.NilError(t, err, file)
+
+		for _, scenario := range scenarios {
+			scenario := scenario
+			t.Run(scenario.Name, func(t *testing.T) {
+				_, err := scenario.Run()
+				assert.NilError(t, err)
+			})
+		}
+	}
+}
+
+func TestScenario_Mismatch(t *testing.T) {
+	scenarios, err := LoadScenarios(strings.NewReader(`[{
+		"name": "counter",
+		"code": ["loadst", 0, "calldata", "add", "storest", 0, "halt"],
+		"preState": ["0000"],
+		"steps": [
+			{"fee": 5000, "callData": "020002", "expected": {"success": true, "storage": ["0002"], "gas": 3976}},
+			{"fee": 5000, "callData": "020002", "expected": {"success": true, "storage": ["0002"], "gas": 3976}}
+		]
+	}]`))
+	assert.NilError(t, err)
+
+	mc, err := scenarios[0].Run()
+	assert.Error(t, err, "test vector counter/1: expected storage [0002], but got [0004]")
+	assert.Equal(t, len(mc.CallDataHistory), 1)
+}
+
+func TestScenario_InvalidCallData(t *testing.T) {
+	scenario := Scenario{Name: "invalid", Code: []byte{Halt}, Steps: []ScenarioStep{{CallData: "zz"}}}
+	_, err := scenario.Run()
+	assert.ErrorContains(t, err, "invalid byte")
+}
@@ -0,0 +1,58 @@
+package vm
+
+// Sections are the optional sections, which precede the executable code of a contract. A contract declares them in the
+// order state schema, constructor, metadata and function table.
+type Sections struct {
+	Schema      StateSchema
+	Constructor int // Address of the constructor relative to the code, -1 if the contract has no constructor
+	Metadata    *Metadata
+	Functions   []Function
+	Code        []byte // Executable code following the sections
+}
+
+// ParseSections splits the contract into its sections and the executable code.
+func ParseSections(contract []byte) (sections Sections, err error) {
+	code := contract
+	if sections.Schema, code, err = ParseStateSchema(code); err != nil {
+		return Sections{}, err
+	}
+
+	if sections.Constructor, code, err = ParseConstructorSection(code); err != nil {
+		return Sections{}, err
+	}
+
+	if sections.Metadata, code, err = ParseMetadataSection(code); err != nil {
+		return Sections{}, err
+	}
+
+	if sections.Functions, sections.Code, err = ParseFunctionTable(code); err != nil {
+		return Sections{}, err
+	}
+	return sections, nil
+}
+
+// NewContract serializes the sections followed by the code, it is the inverse of ParseSections. Sections, which are
+// nil, are omitted.
+func NewContract(sections Sections) ([]byte, error) {
+	var contract []byte
+	if sections.Schema != nil {
+		contract = append(contract, NewStateSchema(sections.Schema)...)
+	}
+
+	if sections.Constructor >= 0 {
+		contract = append(contract, NewConstructorSection(uint16(sections.Constructor))...)
+	}
+
+	if sections.Metadata != nil {
+		metadata, err := NewMetadataSection(*sections.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		contract = append(contract, metadata...)
+	}
+
+	if sections.Functions != nil {
+		contract = append(contract, NewFunctionTable(sections.Functions)...)
+	}
+	return append(contract, sections.Code...), nil
+}
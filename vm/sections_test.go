@@ -0,0 +1,38 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSections_Parse(t *testing.T) {
+	metadata, err := NewMetadataSection(Metadata{Name: "token"})
+	assert.NilError(t, err)
+	functions := []Function{{Hash: [4]byte{1, 2, 3, 4}, Address: 1, NrOfLocals: 1}}
+
+	var contract []byte
+	contract = append(contract, NewStateSchema(StateSchema{{Type: TypeInt}})...)
+	contract = append(contract, NewConstructorSection(2)...)
+	contract = append(contract, metadata...)
+	contract = append(contract, NewFunctionTable(functions)...)
+	contract = append(contract, Halt, Ret, Halt)
+
+	sections, err := ParseSections(contract)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sections, Sections{
+		Schema:      StateSchema{{Type: TypeInt}},
+		Constructor: 2,
+		Metadata:    &Metadata{Name: "token"},
+		Functions:   functions,
+		Code:        []byte{Halt, Ret, Halt},
+	})
+
+	// Every section is optional
+	sections, err = ParseSections([]byte{Halt})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sections, Sections{Constructor: -1, Code: []byte{Halt}})
+
+	_, err = ParseSections(append(NewConstructorSection(2), MetadataMarker, 0))
+	assert.Error(t, err, newError(ErrMetadataOutOfBounds).Error())
+}
@@ -0,0 +1,55 @@
+package vm
+
+import (
+	"bytes"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+// SelfCallPolicy decides whether CallExt may call the executing contract itself.
+type SelfCallPolicy byte
+
+// Self-call policies
+const (
+	// SelfCallAllow allows all calls to the executing contract.
+	SelfCallAllow SelfCallPolicy = iota
+	// SelfCallForbid fails all calls to the executing contract.
+	SelfCallForbid
+	// SelfCallRequireFlag only allows calls to the executing contract, which set CallExtSelfFlag.
+	SelfCallRequireFlag
+)
+
+// DefaultSelfCallPolicy is the self-call policy, if not configured otherwise.
+// Intended self calls have to be marked, accidental ones fail.
+const DefaultSelfCallPolicy = SelfCallRequireFlag
+
+// CallExtSelfFlag is set in the argument count of CallExt to mark an intended call of the executing contract.
+const CallExtSelfFlag = 0x80
+
+// SetSelfCallPolicy sets the policy for calls of CallExt to the executing contract.
+func (vm *VM) SetSelfCallPolicy(policy SelfCallPolicy) {
+	vm.selfCallPolicy = policy
+}
+
+// selfAddress returns the 32 byte address of the executing contract, which is the hash of its account address
+func (vm *VM) selfAddress() [32]byte {
+	return protocol.SerializeHashContent(vm.context.GetAddress())
+}
+
+func (vm *VM) isSelf(address []byte) bool {
+	self := vm.selfAddress()
+	return bytes.Equal(address, self[:])
+}
+
+// checkSelfCall applies the self-call policy to a call of the executing contract
+func (vm *VM) checkSelfCall(argsToLoad byte) error {
+	switch vm.selfCallPolicy {
+	case SelfCallForbid:
+		return newError(ErrSelfCall)
+	case SelfCallRequireFlag:
+		if argsToLoad&CallExtSelfFlag == 0 {
+			return newError(ErrSelfCall)
+		}
+	}
+	return nil
+}
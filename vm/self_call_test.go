@@ -0,0 +1,84 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"gotest.tools/assert"
+)
+
+var selfAccount = [64]byte{1, 2, 3}
+
+func callExtCode(address [32]byte, argsToLoad byte) []byte {
+	code := []byte{CallExt}
+	code = append(code, address[:]...)
+	code = append(code, 0, 0, 0, 0, argsToLoad, Halt)
+	return code
+}
+
+func execSelfCall(policy SelfCallPolicy, argsToLoad byte) (*VM, bool) {
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(callExtCode(protocol.SerializeHashContent(selfAccount), argsToLoad))
+	mc.Address = selfAccount
+	mc.Fee = 2000
+	vm.context = mc
+	vm.SetSelfCallPolicy(policy)
+	return &vm, vm.Exec(false)
+}
+
+func TestSelfCall_Allow(t *testing.T) {
+	_, isSuccess := execSelfCall(SelfCallAllow, 0)
+	assert.Assert(t, isSuccess)
+}
+
+func TestSelfCall_Forbid(t *testing.T) {
+	vm, isSuccess := execSelfCall(SelfCallForbid, CallExtSelfFlag)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "callext: call to the executing contract is not allowed")
+}
+
+func TestSelfCall_RequireFlag(t *testing.T) {
+	vm, isSuccess := execSelfCall(SelfCallRequireFlag, 0)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "callext: call to the executing contract is not allowed")
+
+	_, isSuccess = execSelfCall(SelfCallRequireFlag, CallExtSelfFlag)
+	assert.Assert(t, isSuccess)
+}
+
+func TestSelfCall_OtherContract(t *testing.T) {
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(callExtCode([32]byte{9}, 0))
+	mc.Address = selfAccount
+	mc.Fee = 2000
+	vm.context = mc
+	vm.SetSelfCallPolicy(SelfCallForbid)
+	assert.Assert(t, vm.Exec(false))
+}
+
+func TestSelfCall_IsSelf(t *testing.T) {
+	self := protocol.SerializeHashContent(selfAccount)
+	code := []byte{Push, 32}
+	code = append(code, self[:]...)
+	code = append(code, IsSelf, Push, 32)
+	code = append(code, make([]byte, 32)...)
+	code = append(code, IsSelf, Halt)
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Address = selfAccount
+	mc.Fee = 200
+	vm.context = mc
+	assert.Assert(t, vm.Exec(false))
+
+	other, _ := vm.evaluationStack.Pop()
+	isSelf, _ := vm.evaluationStack.Pop()
+	assertBytes(t, other, 0)
+	assertBytes(t, isSelf, 1)
+}
+
+func TestSelfCall_IsSelf_InvalidAddress(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushInt, 1, 0, 2, IsSelf, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "isself: not a valid address")
+}
@@ -0,0 +1,41 @@
+package vm
+
+import "math"
+
+// ExecUnlimited executes the contract code like Exec, but ignores the fee of the context, so the execution never
+// runs out of gas. The gas is still charged as usual and can be read with GasUsed afterwards. It is intended for
+// unit tests of contracts, which should not depend on a guessed fee.
+func (vm *VM) ExecUnlimited() bool {
+	return vm.exec(math.MaxUint64, false)
+}
+
+// GasUsed returns the gas charged by the last execution, including the intrinsic gas.
+func (vm *VM) GasUsed() uint64 {
+	return vm.gasLimit - vm.fee
+}
+
+// RecordedStep is the gas charged by a single executed instruction.
+type RecordedStep struct {
+	PC     int
+	OpCode string
+	Gas    uint64
+}
+
+// StepRecorder is a tracer, which records the gas charged by every executed instruction.
+type StepRecorder struct {
+	Steps []RecordedStep
+}
+
+// CaptureStep records the step.
+func (r *StepRecorder) CaptureStep(step TraceStep) {
+	r.Steps = append(r.Steps, RecordedStep{PC: step.PC, OpCode: step.OpCode, Gas: step.GasBefore - step.GasAfter})
+}
+
+// Gas returns the gas charged by all recorded instructions, which excludes the intrinsic gas.
+func (r *StepRecorder) Gas() uint64 {
+	var gas uint64
+	for _, step := range r.Steps {
+		gas += step.Gas
+	}
+	return gas
+}
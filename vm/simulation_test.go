@@ -0,0 +1,49 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSimulation_ExecUnlimited(t *testing.T) {
+	mc := NewMockContext([]byte{PushInt, 1, 0, 100, PushInt, 1, 0, 2, Exp, Halt})
+	mc.Fee = 1
+	vm := NewVM(mc)
+
+	assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+	assert.Assert(t, vm.GasUsed() > mc.Fee)
+}
+
+func TestSimulation_GasUsedMatchesExec(t *testing.T) {
+	code := []byte{PushInt, 1, 0, 5, PushInt, 1, 0, 6, Add, StoreSt, 0, Halt}
+
+	mc := NewMockContext(code)
+	mc.ContractVariables = [][]byte{{0}}
+	unlimited := NewVM(mc)
+	assert.Assert(t, unlimited.ExecUnlimited(), unlimited.GetErrorMsg())
+
+	mc = NewMockContext(code)
+	mc.ContractVariables = [][]byte{{0}}
+	mc.Fee = 2000
+	limited := NewVM(mc)
+	assert.Assert(t, limited.Exec(false), limited.GetErrorMsg())
+
+	assert.Equal(t, unlimited.GasUsed(), limited.GasUsed())
+	assert.Equal(t, limited.GasUsed(), 2000-limited.GetRemainingFee())
+}
+
+func TestSimulation_StepRecorder(t *testing.T) {
+	recorder := &StepRecorder{}
+	vm := NewVM(NewMockContext([]byte{PushInt, 1, 0, 5, Dup, Add, Halt}))
+	vm.SetTracer(recorder)
+
+	assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+	assert.DeepEqual(t, recorder.Steps, []RecordedStep{
+		{PC: 0, OpCode: "pushint", Gas: 6}, // Including the first word of memory
+		{PC: 4, OpCode: "dup", Gas: 3},
+		{PC: 5, OpCode: "add", Gas: 5},
+		{PC: 6, OpCode: "halt", Gas: 0},
+	})
+	assert.Equal(t, recorder.Gas()+IntrinsicGas(7, 0), vm.GasUsed())
+}
@@ -0,0 +1,85 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SourceLocation is a position in the high-level source code of a contract.
+type SourceLocation struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"col"`
+}
+
+// String formats the location as file:line:col.
+func (l SourceLocation) String() string {
+	return fmt.Sprintf("%v:%v:%v", l.File, l.Line, l.Column)
+}
+
+// SourceMapEntry maps the instructions from the offset up to the offset of the next entry to a source location.
+type SourceMapEntry struct {
+	Offset int `json:"offset"`
+	SourceLocation
+}
+
+// SourceMap links bytecode offsets to the source code, from which the bytecode was compiled.
+// Offsets are relative to the code following the function table, i.e. they are equal to the program counter.
+type SourceMap struct {
+	entries []SourceMapEntry // Sorted by offset
+}
+
+// NewSourceMap creates a source map of the entries, which may be given in any order.
+func NewSourceMap(entries []SourceMapEntry) *SourceMap {
+	sorted := make([]SourceMapEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+	return &SourceMap{entries: sorted}
+}
+
+// LoadSourceMap reads a JSON array of entries, e.g. [{"offset": 0, "file": "token.lazo", "line": 3, "col": 5}].
+func LoadSourceMap(r io.Reader) (*SourceMap, error) {
+	var entries []SourceMapEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return NewSourceMap(entries), nil
+}
+
+// Lookup returns the source location of the instruction at the offset.
+func (m *SourceMap) Lookup(offset int) (SourceLocation, bool) {
+	i := sort.Search(len(m.entries), func(i int) bool {
+		return m.entries[i].Offset > offset
+	})
+
+	if i == 0 {
+		return SourceLocation{}, false
+	}
+	return m.entries[i-1].SourceLocation, true
+}
+
+// SetSourceMap sets the source map, which is used to resolve the source locations of traced steps and errors.
+func (vm *VM) SetSourceMap(sourceMap *SourceMap) {
+	vm.sourceMap = sourceMap
+}
+
+// GetErrorLocation returns the source location of the last executed instruction, which is the failed instruction
+// if the execution failed. It is only available if a source map is set and covers the instruction, and not if the
+// execution failed before its first instruction.
+func (vm *VM) GetErrorLocation() (SourceLocation, bool) {
+	if vm.failure != nil && vm.failure.PC < 0 {
+		return SourceLocation{}, false
+	}
+	return vm.sourceLocation(vm.instructionPC)
+}
+
+func (vm *VM) sourceLocation(pc int) (SourceLocation, bool) {
+	if vm.sourceMap == nil {
+		return SourceLocation{}, false
+	}
+	return vm.sourceMap.Lookup(pc)
+}
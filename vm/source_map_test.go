@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestSourceMap_Lookup(t *testing.T) {
+	sourceMap := NewSourceMap([]SourceMapEntry{
+		{Offset: 8, SourceLocation: SourceLocation{File: "a.lazo", Line: 3, Column: 1}},
+		{Offset: 2, SourceLocation: SourceLocation{File: "a.lazo", Line: 2, Column: 5}},
+	})
+
+	_, ok := sourceMap.Lookup(1)
+	assert.Assert(t, !ok)
+
+	location, ok := sourceMap.Lookup(2)
+	assert.Assert(t, ok)
+	assert.Equal(t, location.String(), "a.lazo:2:5")
+
+	location, _ = sourceMap.Lookup(7)
+	assert.Equal(t, location.String(), "a.lazo:2:5")
+
+	location, _ = sourceMap.Lookup(100)
+	assert.Equal(t, location.String(), "a.lazo:3:1")
+}
+
+func TestSourceMap_LoadSourceMap(t *testing.T) {
+	sourceMap, err := LoadSourceMap(strings.NewReader(`[{"offset": 4, "file": "b.lazo", "line": 7, "col": 2}]`))
+	assert.NilError(t, err)
+
+	location, ok := sourceMap.Lookup(4)
+	assert.Assert(t, ok)
+	assert.Equal(t, location, SourceLocation{File: "b.lazo", Line: 7, Column: 2})
+}
+
+func TestSourceMap_ErrorLocation(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 2,
+		PushInt, 0,
+		Div,
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	vm.context = NewMockContext(code)
+	vm.SetSourceMap(NewSourceMap([]SourceMapEntry{
+		{Offset: 0, SourceLocation: SourceLocation{File: "c.lazo", Line: 1, Column: 9}},
+		{Offset: 6, SourceLocation: SourceLocation{File: "c.lazo", Line: 1, Column: 11}},
+	}))
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "div: division by zero")
+
+	location, ok := vm.GetErrorLocation()
+	assert.Assert(t, ok)
+	assert.Equal(t, location.String(), "c.lazo:1:11")
+}
+
+func TestSourceMap_ErrorLocation_WithoutSourceMap(t *testing.T) {
+	vm, isSuccess := execCode([]byte{Add})
+	assert.Assert(t, !isSuccess)
+
+	_, ok := vm.GetErrorLocation()
+	assert.Assert(t, !ok)
+}
+
+func TestSourceMap_Trace(t *testing.T) {
+	vm := NewTestVM([]byte{})
+	vm.context = NewMockContext([]byte{PushInt, 1, 0, 2, Halt})
+	vm.SetSourceMap(NewSourceMap([]SourceMapEntry{
+		{Offset: 4, SourceLocation: SourceLocation{File: "d.lazo", Line: 4, Column: 1}},
+	}))
+
+	recorder := &lastStep{}
+	vm.SetTracer(recorder)
+	assert.Assert(t, vm.Exec(false))
+	assert.Equal(t, recorder.step.Source, "d.lazo:4:1")
+}
+
+type lastStep struct {
+	step TraceStep
+}
+
+func (l *lastStep) CaptureStep(step TraceStep) {
+	l.step = step
+}
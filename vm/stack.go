@@ -1,8 +1,8 @@
 package vm
 
-import (
-	"errors"
-)
+// stackShrinkCapacity is the capacity, above which the backing array of the stack is reallocated, when less than a
+// quarter of it is in use. So the memory of a deep stack is released, after it has been popped.
+const stackShrinkCapacity = 1024
 
 type Stack struct {
 	Stack       [][]byte
@@ -28,7 +28,7 @@ func (s *Stack) Push(element []byte) error {
 		s.Stack = append(s.Stack, element)
 		return nil
 	} else {
-		return errors.New("Stack out of memory")
+		return newError(ErrStackOutOfMemory)
 	}
 }
 
@@ -36,21 +36,30 @@ func (s *Stack) PopIndexAt(index int) ([]byte, error) {
 	if (*s).GetLength() >= index {
 		element := (*s).Stack[index]
 		s.memoryUsage -= uint32(len(element))
-		s.Stack = append((*s).Stack[:index], (*s).Stack[index+1:]...)
+		copy(s.Stack[index:], s.Stack[index+1:])
+		s.truncate(s.GetLength() - 1)
 		return element, nil
 	} else {
-		return []byte{}, errors.New("index out of bounds")
+		return []byte{}, newError(ErrIndexOutOfBounds)
 	}
 }
 
+// PeekIndexAt returns the element at the given index without removing it from the stack.
+func (s *Stack) PeekIndexAt(index int) ([]byte, error) {
+	if index < 0 || index >= (*s).GetLength() {
+		return []byte{}, newError(ErrIndexOutOfBounds)
+	}
+	return (*s).Stack[index], nil
+}
+
 func (s *Stack) Pop() (element []byte, err error) {
 	if (*s).GetLength() > 0 {
 		element = (*s).Stack[s.GetLength()-1]
 		s.memoryUsage -= uint32(len(element))
-		s.Stack = s.Stack[:s.GetLength()-1]
+		s.truncate(s.GetLength() - 1)
 		return element, nil
 	} else {
-		return []byte{}, errors.New("pop() on empty stack")
+		return []byte{}, newError(ErrPopOnEmptyStack)
 	}
 }
 
@@ -59,7 +68,7 @@ func (s *Stack) PeekBytes() (element []byte, err error) {
 		element = (*s).Stack[s.GetLength()-1]
 		return element, nil
 	} else {
-		return []byte{}, errors.New("peek() on empty Stack")
+		return []byte{}, newError(ErrPeekOnEmptyStack)
 	}
 }
 
@@ -67,3 +76,41 @@ func (s *Stack) PeekBytes() (element []byte, err error) {
 func (s *Stack) hasEnoughMemory(elementSize int) bool {
 	return s.memoryMax >= uint32(elementSize)+s.memoryUsage
 }
+
+// moveToTop moves the element at the index to the top
+func (s *Stack) moveToTop(index int) error {
+	if index < 0 || index >= s.GetLength() {
+		return newError(ErrIndexOutOfBounds)
+	}
+
+	element := s.Stack[index]
+	copy(s.Stack[index:], s.Stack[index+1:])
+	s.Stack[s.GetLength()-1] = element
+	return nil
+}
+
+// remove removes count elements starting at the index
+func (s *Stack) remove(index int, count int) {
+	if count == 0 {
+		return
+	}
+
+	for _, element := range s.Stack[index : index+count] {
+		s.memoryUsage -= uint32(len(element))
+	}
+	copy(s.Stack[index:], s.Stack[index+count:])
+	s.truncate(s.GetLength() - count)
+}
+
+// truncate removes the elements above the length. The removed slots are cleared, so the backing array does not keep
+// the popped elements alive, and the backing array is shrunk, if most of its capacity is unused.
+func (s *Stack) truncate(length int) {
+	for i := length; i < len(s.Stack); i++ {
+		s.Stack[i] = nil
+	}
+	s.Stack = s.Stack[:length]
+
+	if cap(s.Stack) > stackShrinkCapacity && len(s.Stack) < cap(s.Stack)/4 {
+		s.Stack = append(make([][]byte, 0, 2*len(s.Stack)), s.Stack...)
+	}
+}
@@ -176,3 +176,94 @@ func TestStack_MemoryUsage(t *testing.T) {
 		t.Errorf("Expected memory usage to be '%v' but was '%v'", expected, actual)
 	}
 }
+
+func TestStack_PeekIndexAt(t *testing.T) {
+	s := NewStack()
+	s.Push([]byte{1})
+	s.Push([]byte{2})
+
+	element, err := s.PeekIndexAt(0)
+	if err != nil || element[0] != 1 {
+		t.Errorf("Expected element [1] but got %v (%v)", element, err)
+	}
+
+	if s.GetLength() != 2 {
+		t.Errorf("Expected stack size to be 2 but got %v", s.GetLength())
+	}
+
+	_, err = s.PeekIndexAt(2)
+	if err == nil {
+		t.Errorf("Expected index out of bounds error")
+	}
+
+	_, err = s.PeekIndexAt(-1)
+	if err == nil {
+		t.Errorf("Expected index out of bounds error")
+	}
+}
+
+func TestStack_PopClearsSlot(t *testing.T) {
+	s := NewStack()
+	s.Push([]byte{1})
+	s.Push([]byte{2})
+	s.Push([]byte{3})
+
+	s.Pop()
+	s.PopIndexAt(0)
+
+	if backing := s.Stack[:cap(s.Stack)]; backing[1] != nil || backing[2] != nil {
+		t.Errorf("Expected popped slots to be cleared but got %v", backing)
+	}
+	if s.GetLength() != 1 || s.Stack[0][0] != 2 {
+		t.Errorf("Expected stack [[2]] but got %v", s.Stack)
+	}
+}
+
+func TestStack_Shrink(t *testing.T) {
+	s := NewStack()
+	for i := 0; i < 4*stackShrinkCapacity; i++ {
+		s.Push([]byte{1})
+	}
+	grown := cap(s.Stack)
+
+	for s.GetLength() > 10 {
+		s.Pop()
+	}
+
+	if cap(s.Stack) >= grown || cap(s.Stack) > stackShrinkCapacity {
+		t.Errorf("Expected capacity below %v after popping but got %v", stackShrinkCapacity, cap(s.Stack))
+	}
+	if s.GetLength() != 10 || s.memoryUsage != 10 {
+		t.Errorf("Expected 10 elements using 10 bytes but got %v using %v", s.GetLength(), s.memoryUsage)
+	}
+}
+
+// BenchmarkStack_PushPop measures a stack, which repeatedly grows deep and is popped again
+func BenchmarkStack_PushPop(b *testing.B) {
+	element := make([]byte, 1024)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		s := NewStack()
+		for j := 0; j < 4*stackShrinkCapacity; j++ {
+			s.Push(element)
+		}
+		for s.GetLength() > 0 {
+			s.Pop()
+		}
+	}
+}
+
+// BenchmarkStack_Oscillate measures a shallow stack, which must not be reallocated by pushing and popping
+func BenchmarkStack_Oscillate(b *testing.B) {
+	element := make([]byte, 32)
+	s := NewStack()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		s.Push(element)
+		s.Push(element)
+		s.Pop()
+		s.Pop()
+	}
+}
@@ -0,0 +1,160 @@
+package vm
+
+// StateSchemaMarker is the first byte of a contract which starts with a state schema.
+// It is never a valid opcode. The schema precedes the function table, if the contract has both.
+const StateSchemaMarker = 0xFE
+
+// Size of a state schema entry in bytes: type (1), maximum size (2)
+const variableEntrySize = 3
+
+// immutableFlag is set in the type byte of immutable variables
+const immutableFlag = 0x80
+
+// VariableType is the declared type of a contract variable.
+type VariableType byte
+
+// Variable types
+const (
+	// TypeBytes accepts any value.
+	TypeBytes VariableType = iota
+	// TypeInt is a signed integer as pushed by PushInt.
+	TypeInt
+	// TypeUint64 is an 8 byte little endian value as pushed by Balance and CallVal.
+	TypeUint64
+	// TypeBool is a boolean as pushed by PushBool.
+	TypeBool
+	// TypeAddress is a 32 byte address.
+	TypeAddress
+	// TypeArray is an array as created by NewArr.
+	TypeArray
+	// TypeMap is a map as created by NewMap.
+	TypeMap
+)
+
+var variableTypeNames = []string{"bytes", "int", "uint64", "bool", "address", "array", "map"}
+
+func (t VariableType) String() string {
+	if int(t) < len(variableTypeNames) {
+		return variableTypeNames[t]
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the type by its name.
+func (t VariableType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// VariableDeclaration declares the type and the maximum size in bytes of a contract variable.
+// A size of 0 does not limit the size. Immutable variables can only be written by the constructor.
+type VariableDeclaration struct {
+	Type      VariableType `json:"type"`
+	Size      uint16       `json:"size"`
+	Immutable bool         `json:"immutable,omitempty"`
+}
+
+// StateSchema declares the contract variables by index. Variables beyond the schema are not checked.
+type StateSchema []VariableDeclaration
+
+// NewStateSchema serializes the declarations into a state schema, which can be prepended to the contract code.
+func NewStateSchema(schema StateSchema) []byte {
+	section := []byte{StateSchemaMarker}
+	section = append(section, UInt16ToByteArray(uint16(len(schema)))...)
+
+	for _, declaration := range schema {
+		typeByte := byte(declaration.Type)
+		if declaration.Immutable {
+			typeByte |= immutableFlag
+		}
+		section = append(section, typeByte)
+		section = append(section, UInt16ToByteArray(declaration.Size)...)
+	}
+	return section
+}
+
+// ParseStateSchema splits the contract into the state schema and the remaining contract.
+// Contracts without a state schema are returned unchanged.
+func ParseStateSchema(contract []byte) (schema StateSchema, rest []byte, err error) {
+	if len(contract) == 0 || contract[0] != StateSchemaMarker {
+		return nil, contract, nil
+	}
+
+	if len(contract) < 3 {
+		return nil, nil, newError(ErrStateSchemaOutOfBounds)
+	}
+
+	size, _ := ByteArrayToUI16(contract[1:3])
+	restStart := 3 + int(size)*variableEntrySize
+	if len(contract) < restStart {
+		return nil, nil, newError(ErrStateSchemaOutOfBounds)
+	}
+
+	schema = make(StateSchema, size)
+	for i := range schema {
+		entry := contract[3+i*variableEntrySize : 3+(i+1)*variableEntrySize]
+
+		schema[i].Type = VariableType(entry[0] &^ immutableFlag)
+		if int(schema[i].Type) >= len(variableTypeNames) {
+			return nil, nil, newError(ErrUnknownVariableType, entry[0])
+		}
+		schema[i].Immutable = entry[0]&immutableFlag != 0
+		schema[i].Size, _ = ByteArrayToUI16(entry[1:3])
+	}
+
+	return schema, contract[restStart:], nil
+}
+
+// Check returns an error, if the value does not match the declaration of the variable
+func (schema StateSchema) Check(index int, value []byte) error {
+	if index < 0 || index >= len(schema) {
+		return nil
+	}
+	declaration := schema[index]
+
+	if declaration.Size > 0 && len(value) > int(declaration.Size) {
+		return newError(ErrVariableSize, index, declaration.Size)
+	}
+
+	var valid bool
+	switch declaration.Type {
+	case TypeBytes:
+		valid = true
+	case TypeInt:
+		valid = len(value) > 0 && value[0] <= 1
+	case TypeUint64:
+		valid = len(value) == 8
+	case TypeBool:
+		valid = len(value) == 1 && value[0] <= 1
+	case TypeAddress:
+		valid = len(value) == 32
+	case TypeArray:
+		_, err := ArrayFromByteArray(value)
+		valid = err == nil
+	case TypeMap:
+		_, err := MapFromByteArray(value)
+		valid = err == nil
+	}
+
+	if !valid {
+		return newError(ErrVariableType, index, declaration.Type)
+	}
+	return nil
+}
+
+// IsImmutable returns true, if the variable is declared immutable
+func (schema StateSchema) IsImmutable(index int) bool {
+	return index >= 0 && index < len(schema) && schema[index].Immutable
+}
+
+// StateSchema returns the state schema of the contract, so explorers can decode the contract variables.
+// Contracts without a state schema declare no variables. The state schema of a delegating contract is declared by
+// the code of its delegate.
+func (vm *VM) StateSchema() (StateSchema, error) {
+	contract, _, err := vm.loadCode()
+	if err != nil {
+		return nil, err
+	}
+
+	schema, _, err := ParseStateSchema(contract)
+	return schema, err
+}
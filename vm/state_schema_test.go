@@ -0,0 +1,138 @@
+package vm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestStateSchema_NewAndParse(t *testing.T) {
+	expected := StateSchema{{Type: TypeUint64}, {Type: TypeBytes, Size: 10}, {Type: TypeMap}, {Type: TypeAddress, Immutable: true}}
+	contract := append(NewStateSchema(expected), Halt)
+
+	schema, rest, err := ParseStateSchema(contract)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schema, expected)
+	assertBytes(t, rest, Halt)
+}
+
+func TestStateSchema_ParseErrors(t *testing.T) {
+	_, _, err := ParseStateSchema([]byte{StateSchemaMarker, 0, 1, 1})
+	assert.Error(t, err, "state schema out of bounds")
+
+	_, _, err = ParseStateSchema([]byte{StateSchemaMarker, 0, 1, 20, 0, 0})
+	assert.Error(t, err, "unknown variable type 20")
+}
+
+func TestStateSchema_Check(t *testing.T) {
+	schema := StateSchema{
+		{Type: TypeInt},
+		{Type: TypeUint64},
+		{Type: TypeBool},
+		{Type: TypeAddress},
+		{Type: TypeArray},
+		{Type: TypeMap},
+		{Type: TypeBytes, Size: 2},
+	}
+
+	tests := []struct {
+		index int
+		value []byte
+		err   string
+	}{
+		{0, []byte{1, 5}, ""},
+		{0, []byte{2, 5}, "variable 0 must be of type int"},
+		{1, make([]byte, 8), ""},
+		{1, []byte{0, 1}, "variable 1 must be of type uint64"},
+		{2, []byte{1}, ""},
+		{2, []byte{2}, "variable 2 must be of type bool"},
+		{3, make([]byte, 32), ""},
+		{4, NewArray(), ""},
+		{4, CreateMap(), "variable 4 must be of type array"},
+		{5, CreateMap(), ""},
+		{6, []byte{1, 2}, ""},
+		{6, []byte{1, 2, 3}, "variable 6 exceeds 2 bytes"},
+		{7, []byte{1, 2, 3}, ""},
+	}
+
+	for _, test := range tests {
+		err := schema.Check(test.index, test.value)
+		if test.err == "" {
+			assert.NilError(t, err)
+		} else {
+			assert.Error(t, err, test.err)
+		}
+	}
+}
+
+func TestStateSchema_StoreSt(t *testing.T) {
+	code := NewStateSchema(StateSchema{{Type: TypeBool}})
+	code = append(code, NewFunctionTable(nil)...)
+	code = append(code, PushInt, 1, 0, 2, StoreSt, 0, Halt)
+
+	mc := NewMockContext(code)
+	mc.ContractVariables = [][]byte{{0}}
+	mc.Fee = 5000
+	vm := NewVM(mc)
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "storest: variable 0 must be of type bool")
+
+	schema, err := vm.StateSchema()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, schema, StateSchema{{Type: TypeBool}})
+
+	functions, err := vm.Functions()
+	assert.NilError(t, err)
+	assert.Equal(t, len(functions), 0)
+}
+
+func TestStateSchema_JSON(t *testing.T) {
+	data, err := json.Marshal(StateSchema{{Type: TypeAddress, Size: 32}})
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `[{"type":"address","size":32}]`)
+
+	data, err = json.Marshal(StateSchema{{Type: TypeInt, Immutable: true}})
+	assert.NilError(t, err)
+	assert.Equal(t, string(data), `[{"type":"int","size":0,"immutable":true}]`)
+}
+
+// newImmutableContract returns a contract with the immutable variable 0, which is written by the constructor, and
+// whose regular code writes the value pushed before it to variable 0
+func newImmutableContract(value byte) []byte {
+	contract := NewStateSchema(StateSchema{{Type: TypeInt, Immutable: true}, {Type: TypeInt}})
+	contract = append(contract, NewConstructorSection(7)...)
+	return append(contract,
+		PushInt, 1, 0, value, StoreSt, 0, Halt,
+		// Constructor at address 7
+		PushInt, 1, 0, 100, StoreSt, 0, Halt,
+	)
+}
+
+func TestStateSchema_Immutable(t *testing.T) {
+	mc := newTestContext(newImmutableContract(5), withVariables)
+	vm := NewVM(mc)
+	assert.Assert(t, vm.ExecInit(), vm.GetErrorMsg())
+	mc.PersistChanges()
+	assert.DeepEqual(t, mc.ContractVariables[0], []byte{0, 100})
+
+	vm = NewVM(mc)
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "storest: variable 0 is immutable")
+	assert.DeepEqual(t, mc.ContractVariables[0], []byte{0, 100})
+
+	schema, err := vm.StateSchema()
+	assert.NilError(t, err)
+	assert.Assert(t, schema.IsImmutable(0))
+	assert.Assert(t, !schema.IsImmutable(1))
+	assert.Assert(t, !schema.IsImmutable(2))
+}
+
+func TestStateSchema_MutableVariable(t *testing.T) {
+	contract := NewStateSchema(StateSchema{{Type: TypeInt, Immutable: true}, {Type: TypeInt}})
+	contract = append(contract, PushInt, 1, 0, 5, StoreSt, 1, Halt)
+	vm := NewVM(newTestContext(contract, withVariables))
+
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+}
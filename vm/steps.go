@@ -0,0 +1,52 @@
+package vm
+
+// Status is the state of an execution driven by ExecSteps.
+type Status int
+
+// Statuses of an execution
+const (
+	// StatusPaused is an execution, which has instructions left and is resumed by the next call of ExecSteps.
+	StatusPaused Status = iota
+	// StatusDone is an execution, which succeeded.
+	StatusDone
+	// StatusFailed is an execution, which failed. The error message is on top of the evaluation stack.
+	StatusFailed
+)
+
+var statusNames = []string{"paused", "done", "failed"}
+
+func (s Status) String() string {
+	if int(s) < len(statusNames) {
+		return statusNames[s]
+	}
+	return "unknown"
+}
+
+// finish ends the execution and returns its status
+func (vm *VM) finish(isSuccess bool) Status {
+	vm.materialize()
+	vm.transient = nil
+	vm.suspended = false
+	if isSuccess {
+		return StatusDone
+	}
+	vm.recordFailure(vm.instructionPC)
+	return StatusFailed
+}
+
+// ExecSteps executes at most n instructions of the contract code, at least one, and returns whether the execution is
+// done, failed or paused. A paused execution is resumed by the next call, so a scheduler can interleave the executions
+// of many VMs. Otherwise the call starts a new execution like Exec, which requires a Reset after a previous execution.
+func (vm *VM) ExecSteps(n int) Status {
+	if !vm.suspended && !vm.start(vm.context.GetFee()) {
+		vm.recordFailure(-1)
+		return StatusFailed
+	}
+
+	if n < 1 {
+		n = 1
+	}
+	status := vm.run(n, false)
+	vm.suspended = status == StatusPaused
+	return status
+}
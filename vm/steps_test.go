@@ -0,0 +1,70 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+var stepsCode = []byte{
+	PushInt, 1, 0, 1,
+	PushInt, 1, 0, 2,
+	Add,
+	PushInt, 1, 0, 3,
+	Mul,
+	Halt,
+}
+
+func TestSteps_ExecSteps(t *testing.T) {
+	vm := NewVM(NewMockContext(stepsCode))
+
+	assert.Equal(t, vm.ExecSteps(2), StatusPaused)
+	assert.Equal(t, vm.evaluationStack.GetLength(), 2)
+	assert.Equal(t, vm.ExecSteps(2), StatusPaused)
+	assert.Equal(t, vm.ExecSteps(2), StatusDone)
+
+	expected := NewVM(NewMockContext(stepsCode))
+	assert.Assert(t, expected.Exec(false))
+	assert.DeepEqual(t, vm.PeekEvalStack(), expected.PeekEvalStack())
+	assert.Equal(t, vm.GasUsed(), expected.GasUsed())
+}
+
+func TestSteps_Restart(t *testing.T) {
+	vm := NewVM(NewMockContext(stepsCode))
+	assert.Equal(t, vm.ExecSteps(100), StatusDone)
+
+	// A finished execution is not resumed, the next call starts a new execution
+	vm.Reset(NewMockContext(stepsCode))
+	assert.Equal(t, vm.ExecSteps(1), StatusPaused)
+	assert.Equal(t, vm.evaluationStack.GetLength(), 1)
+	assert.Equal(t, vm.ExecSteps(0), StatusPaused)
+	assert.Equal(t, vm.evaluationStack.GetLength(), 2)
+
+	// Reset discards the paused execution
+	vm.Reset(NewMockContext(stepsCode))
+	assert.Equal(t, vm.ExecSteps(1), StatusPaused)
+	assert.Equal(t, vm.evaluationStack.GetLength(), 1)
+}
+
+func TestSteps_Failed(t *testing.T) {
+	vm := NewVM(NewMockContext([]byte{PushInt, 1, 0, 1, PushInt, 1, 0, 0, Div, Halt}))
+	assert.Equal(t, vm.ExecSteps(2), StatusPaused)
+	assert.Equal(t, vm.ExecSteps(2), StatusFailed)
+	assert.Equal(t, vm.GetErrorMsg(), "div: division by zero")
+
+	mc := NewMockContext(stepsCode)
+	mc.Fee = 1
+	vm = NewVM(mc)
+	assert.Equal(t, vm.ExecSteps(1), StatusFailed)
+	assert.Equal(t, vm.GetErrorMsg(), "vm.exec(): out of gas")
+}
+
+func TestSteps_Interleaved(t *testing.T) {
+	first := NewVM(NewMockContext(stepsCode))
+	second := NewVM(NewMockContext([]byte{PushInt, 1, 0, 5, Halt}))
+
+	statuses := []Status{first.ExecSteps(1), second.ExecSteps(1), first.ExecSteps(1), second.ExecSteps(1)}
+	assert.DeepEqual(t, statuses, []Status{StatusPaused, StatusPaused, StatusPaused, StatusDone})
+	assert.Equal(t, first.ExecSteps(10), StatusDone)
+	assert.Equal(t, StatusFailed.String(), "failed")
+}
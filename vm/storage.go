@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"sort"
+)
+
+// BatchContext is implemented by contexts, which write several contract variables at once.
+// Contexts without this interface receive a SetContractVariable call for every written variable.
+type BatchContext interface {
+	// SetContractVariables writes all variables or, if one of them fails, none.
+	SetContractVariables(variables map[int][]byte) error
+}
+
+// storeVariable buffers the value of a contract variable until the execution succeeds
+func (vm *VM) storeVariable(index int, value []byte) error {
+	if err := vm.schema.Check(index, value); err != nil {
+		return err
+	}
+	if vm.schema.IsImmutable(index) && !vm.initializing {
+		return newError(ErrImmutableVariable, index)
+	}
+
+	if _, ok := vm.dirty[index]; !ok {
+		// The index is checked by the context now, so invalid writes fail immediately
+		previous, err := vm.context.GetContractVariable(index)
+		if err != nil {
+			return err
+		}
+		vm.witness.record(WitnessVariable, vm.selfAddress(), index, previous)
+	}
+
+	vm.dirty[index] = append([]byte{}, value...)
+	vm.witness.markWritten(WitnessVariable, vm.selfAddress(), index)
+	return nil
+}
+
+// loadVariable returns the buffered value of a contract variable or reads it from the context
+func (vm *VM) loadVariable(index int) ([]byte, error) {
+	if value, ok := vm.dirty[index]; ok {
+		return append([]byte{}, value...), nil
+	}
+
+	value, err := vm.context.GetContractVariable(index)
+	if err != nil {
+		return nil, err
+	}
+	vm.witness.record(WitnessVariable, vm.selfAddress(), index, value)
+	return value, nil
+}
+
+// commitVariables writes the buffered contract variables to the context, in a single batch if supported
+func (vm *VM) commitVariables() error {
+	return vm.writeVariables(vm.dirty)
+}
+
+// writeVariables writes contract variables to the context, in a single batch if supported. Without batches the
+// variables written before a failed write are not reverted, see commit.
+func (vm *VM) writeVariables(variables map[int][]byte) error {
+	if len(variables) == 0 {
+		return nil
+	}
+
+	if batchContext, ok := vm.context.(BatchContext); ok {
+		return batchContext.SetContractVariables(variables)
+	}
+
+	indexes := make([]int, 0, len(variables))
+	for index := range variables {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	for _, index := range indexes {
+		if err := vm.context.SetContractVariable(index, variables[index]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DirtyVariables returns the sorted indexes of the contract variables written by the last execution.
+func (vm *VM) DirtyVariables() []int {
+	indexes := make([]int, 0, len(vm.dirty))
+	for index := range vm.dirty {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
@@ -0,0 +1,85 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// withVariables declares three contract variables
+func withVariables(mc *MockContext) {
+	mc.ContractVariables = [][]byte{{0}, {0}, {0}}
+}
+
+func TestStorage_BatchCommit(t *testing.T) {
+	mc := newTestContext([]byte{
+		PushInt, 1, 0, 1, StoreSt, 2,
+		PushInt, 1, 0, 2, StoreSt, 0,
+		PushInt, 1, 0, 3, StoreSt, 2,
+		LoadSt, 2,
+		Halt,
+	}, withVariables)
+	vm := NewVM(mc)
+
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.DirtyVariables(), []int{0, 2})
+	assert.Equal(t, mc.Batches, 1)
+
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0, 3)
+
+	mc.PersistChanges()
+	assert.DeepEqual(t, mc.Persisted, []int{0, 2})
+	assert.DeepEqual(t, mc.ContractVariables, [][]byte{{0, 2}, {0}, {0, 3}})
+}
+
+func TestStorage_NoCommitOnFailure(t *testing.T) {
+	mc := newTestContext([]byte{
+		PushInt, 1, 0, 1, StoreSt, 0,
+		Pop,
+	}, withVariables)
+	vm := NewVM(mc)
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, mc.Batches, 0)
+
+	value, _ := mc.GetContractVariable(0)
+	assertBytes(t, value, 0)
+}
+
+func TestStorage_IndexOutOfBounds(t *testing.T) {
+	mc := newTestContext([]byte{PushInt, 1, 0, 1, StoreSt, 3, Halt}, withVariables)
+	vm := NewVM(mc)
+
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "storest: Index out of bounds")
+}
+
+func TestStorage_CommitWithoutBatchContext(t *testing.T) {
+	mc := newTestContext([]byte{
+		PushInt, 1, 0, 1, StoreSt, 1,
+		PushInt, 1, 0, 2, StoreSt, 0,
+		Halt,
+	}, withVariables)
+	vm := NewVM(plainContext{mc})
+
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.Equal(t, mc.Batches, 0)
+
+	mc.PersistChanges()
+	assert.DeepEqual(t, mc.Persisted, []int{0, 1})
+}
+
+func TestMockContext_SetContractVariables(t *testing.T) {
+	mc := newTestContext(nil, withVariables)
+
+	err := mc.SetContractVariables(map[int][]byte{0: {1}, 3: {1}})
+	assert.Error(t, err, "index out of bounds")
+	value, _ := mc.GetContractVariable(0)
+	assertBytes(t, value, 0)
+
+	assert.NilError(t, mc.SetContractVariables(map[int][]byte{0: {1}, 1: {2}}))
+	assert.Equal(t, mc.Batches, 1)
+	value, _ = mc.GetContractVariable(1)
+	assertBytes(t, value, 2)
+}
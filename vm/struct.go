@@ -1,31 +1,63 @@
 package vm
 
-import (
-	"github.com/pkg/errors"
-)
-
 // Struct type represents the composite data type declaration that
 // defines a group of variables.
 type Struct Array
 
 // NewStruct creates a new struct data structure.
 func newStruct(size uint16) Struct {
-	array := NewArray()
+	s := Struct(StructHeader{}.Marshal())
 	for i := uint16(0); i < size; i++ {
-		_ = array.Append([]byte{0})
+		_ = s.toArray().Append([]byte{0})
 	}
-	return Struct(array)
+	return s
 }
 
-func structFromByteArray(arr []byte) (Struct, error) {
+// structFromByteArray decodes a struct. If the contract declares struct layouts, the struct must match a layout with
+// its number of fields, so structs supplied by the caller cannot have missing, additional or oversized fields.
+func structFromByteArray(arr []byte, layouts []StructLayout) (Struct, error) {
 	array, err := ArrayFromByteArray(arr)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(layouts) > 0 {
+		if err := validateStruct(array, layouts); err != nil {
+			return nil, err
+		}
+	}
 	return Struct(array), nil
 }
 
+// validateStruct returns an error, if the array does not match any of the layouts
+func validateStruct(array Array, layouts []StructLayout) error {
+	fields := array.elements()
+	sizes := make([]int, len(fields))
+	for i, field := range fields {
+		sizes[i] = len(field)
+	}
+
+	for _, layout := range layouts {
+		if layout.matches(sizes) {
+			return nil
+		}
+	}
+	return newError(ErrStructLayout, len(sizes))
+}
+
+// matches returns true, if the struct has the fields of the layout and no field exceeds its maximum size
+func (l StructLayout) matches(sizes []int) bool {
+	if len(sizes) != len(l.Fields) {
+		return false
+	}
+	for i, maxSize := range l.Fields {
+		if maxSize > 0 && sizes[i] > int(maxSize) {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *Struct) toArray() *Array {
 	return (*Array)(s)
 }
@@ -45,7 +77,56 @@ func (s *Struct) storeField(index uint16, element []byte) error {
 	}
 
 	if index >= size {
-		return errors.New("index out of bounds")
+		return newError(ErrIndexOutOfBounds)
 	}
 	return array.Insert(index, element)
 }
+
+// structAt returns the struct, which is stored as element of the array at the given index
+func structAt(array *Array, index uint16, layouts []StructLayout) (Struct, error) {
+	size, err := array.GetSize()
+	if err != nil {
+		return nil, err
+	}
+	if index >= size {
+		return nil, newError(ErrArrayIndexOutOfBounds)
+	}
+
+	element, err := array.At(index)
+	if err != nil {
+		return nil, err
+	}
+	return structFromByteArray(element, layouts)
+}
+
+// loadArrayField returns the field of the struct at the given index of the array
+func loadArrayField(array *Array, index uint16, field uint16, layouts []StructLayout) ([]byte, error) {
+	s, err := structAt(array, index, layouts)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := s.toArray().GetSize()
+	if err != nil {
+		return nil, err
+	}
+	if field >= size {
+		return nil, newError(ErrIndexOutOfBounds)
+	}
+	return s.loadField(field)
+}
+
+// storeArrayField sets the field of the struct at the given index of the array. The array is modified in place.
+func storeArrayField(array *Array, index uint16, field uint16, element []byte, layouts []StructLayout) error {
+	s, err := structAt(array, index, layouts)
+	if err != nil {
+		return err
+	}
+
+	// The struct is a slice of the array, which is modified by Insert
+	s = append(Struct{}, s...)
+	if err := s.storeField(field, element); err != nil {
+		return err
+	}
+	return array.Insert(index, s)
+}
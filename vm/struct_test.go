@@ -54,3 +54,81 @@ func TestStruct_StoreFields(t *testing.T) {
 	assert.NilError(t, loadErr)
 	assertBytes(t, fieldValue, element2...)
 }
+
+func TestStruct_ArrayFields(t *testing.T) {
+	first, second := newStruct(2), newStruct(2)
+	assert.NilError(t, second.storeField(1, []byte{5}))
+
+	array := NewArray()
+	assert.NilError(t, array.Append(first))
+	assert.NilError(t, array.Append(second))
+
+	assert.NilError(t, storeArrayField(&array, 0, 1, []byte{7, 7}, nil))
+
+	field, err := loadArrayField(&array, 0, 1, nil)
+	assert.NilError(t, err)
+	assertBytes(t, field, 7, 7)
+
+	field, err = loadArrayField(&array, 1, 1, nil)
+	assert.NilError(t, err)
+	assertBytes(t, field, 5)
+
+	_, err = loadArrayField(&array, 2, 0, nil)
+	assert.Error(t, err, "array index out of bounds")
+}
+
+func TestStruct_Layouts(t *testing.T) {
+	layouts := []StructLayout{{Fields: []uint16{1, 0}}, {Fields: []uint16{2}}}
+
+	valid := [][]byte{
+		{0x02, 0x00, 0x02, 0x00, 0x01, 1, 0x00, 0x03, 1, 2, 3},
+		{0x02, 0x00, 0x01, 0x00, 0x02, 1, 2},
+	}
+	for _, blob := range valid {
+		_, err := structFromByteArray(blob, layouts)
+		assert.NilError(t, err)
+	}
+
+	invalid := []struct {
+		blob []byte
+		err  string
+	}{
+		{[]byte{0x02, 0x00, 0x02, 0x00, 0x02, 1, 2, 0x00, 0x00}, "struct with 2 fields does not match a declared layout"},
+		{[]byte{0x02, 0x00, 0x01, 0x00, 0x03, 1, 2, 3}, "struct with 1 fields does not match a declared layout"},
+		{[]byte{0x02, 0x00, 0x00}, "struct with 0 fields does not match a declared layout"},
+	}
+	for _, test := range invalid {
+		_, err := structFromByteArray(test.blob, layouts)
+		assert.Error(t, err, test.err)
+
+		_, err = structFromByteArray(test.blob, nil)
+		assert.NilError(t, err)
+	}
+}
+
+func TestStruct_LayoutsVM(t *testing.T) {
+	metadata, err := NewMetadataSection(Metadata{Structs: []StructLayout{{Fields: []uint16{1, 0}}}})
+	assert.NilError(t, err)
+
+	tests := []struct {
+		code []byte
+		err  string
+	}{
+		{[]byte{NewStr, 0, 2, PushInt, 1, 0, 7, StoreFld, 0, 1, LoadFld, 0, 1, Halt}, ""},
+		{[]byte{NewStr, 0, 2, PushInt, 1, 0, 7, StoreFld, 0, 0, LoadFld, 0, 1, Halt},
+			"loadfld: struct with 2 fields does not match a declared layout"},
+		{[]byte{NewStr, 0, 3, LoadFld, 0, 0, Halt}, "loadfld: struct with 3 fields does not match a declared layout"},
+		{[]byte{PushInt, 0, NewStr, 0, 1, NewArrFrom, 1, ArrFieldAt, 0, 0, Halt},
+			"arrfieldat: struct with 1 fields does not match a declared layout"},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode(append(append([]byte{}, metadata...), test.code...))
+		if test.err == "" {
+			assert.Assert(t, isSuccess, vm.GetErrorMsg())
+			continue
+		}
+		assert.Assert(t, !isSuccess)
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+	}
+}
@@ -0,0 +1,161 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// Tracer receives every executed instruction of the VM.
+type Tracer interface {
+	CaptureStep(step TraceStep)
+}
+
+// TraceStep describes a single executed instruction.
+// The stack and storage values are only valid during the call of CaptureStep.
+type TraceStep struct {
+	Step      int           `json:"step"`
+	PC        int           `json:"pc"`
+	OpCode    string        `json:"opcode"`
+	GasBefore uint64        `json:"gasBefore"`
+	GasAfter  uint64        `json:"gasAfter"`
+	Stack     [][]byte      `json:"stack"` // Top of stack first
+	Storage   []StorageDiff `json:"storage,omitempty"`
+	Source    string        `json:"source,omitempty"` // Source location, if a source map is set
+
+	// Addresses of the called functions after the instruction, outermost call first
+	CallStack []int `json:"callStack,omitempty"`
+}
+
+// StorageDiff is a contract variable written by an instruction.
+type StorageDiff struct {
+	Index int    `json:"index"`
+	Value []byte `json:"value"`
+}
+
+// JSONTracer writes every step as JSON object on a separate line, which is the replay format of ReplayTrace.
+type JSONTracer struct {
+	encoder   *json.Encoder
+	stackSize int
+	err       error
+}
+
+// NewJSONTracer creates a tracer, which writes the top stackSize elements of the stack for every step.
+func NewJSONTracer(w io.Writer, stackSize int) *JSONTracer {
+	return &JSONTracer{
+		encoder:   json.NewEncoder(w),
+		stackSize: stackSize,
+	}
+}
+
+// CaptureStep writes the step to the writer. After the first write error, all further steps are dropped.
+func (t *JSONTracer) CaptureStep(step TraceStep) {
+	if t.err != nil {
+		return
+	}
+
+	if len(step.Stack) > t.stackSize {
+		step.Stack = step.Stack[:t.stackSize]
+	}
+	t.err = t.encoder.Encode(step)
+}
+
+// Err returns the first error, which occurred while writing the trace.
+func (t *JSONTracer) Err() error {
+	return t.err
+}
+
+// ReplayTrace executes the contract and verifies, that the execution yields the same trace.
+// The VM must not have been executed yet and its context has to provide the same state as the traced execution.
+func (vm *VM) ReplayTrace(trace io.Reader, stackSize int) error {
+	var buffer bytes.Buffer
+	tracer := NewJSONTracer(&buffer, stackSize)
+	vm.SetTracer(tracer)
+	vm.Exec(false)
+
+	if tracer.Err() != nil {
+		return tracer.Err()
+	}
+
+	expected := json.NewDecoder(trace)
+	actual := json.NewDecoder(&buffer)
+	for step := 0; ; step++ {
+		var expectedStep, actualStep TraceStep
+		expectedErr := expected.Decode(&expectedStep)
+		actualErr := actual.Decode(&actualStep)
+
+		if expectedErr == io.EOF && actualErr == io.EOF {
+			return nil
+		}
+
+		if expectedErr != nil && expectedErr != io.EOF {
+			return expectedErr
+		}
+
+		if actualErr != nil && actualErr != io.EOF {
+			return actualErr
+		}
+
+		if expectedErr == io.EOF || actualErr == io.EOF || !reflect.DeepEqual(expectedStep, actualStep) {
+			return fmt.Errorf("trace mismatch at step %v", step)
+		}
+	}
+}
+
+func (vm *VM) beginStep(pc int, opCode OpCode, gasBefore uint64) {
+	if vm.tracer == nil {
+		return
+	}
+
+	vm.step = &TraceStep{
+		Step:      vm.stepCount,
+		PC:        pc,
+		OpCode:    opCode.Name,
+		GasBefore: gasBefore,
+	}
+	if location, ok := vm.sourceLocation(pc); ok {
+		vm.step.Source = location.String()
+	}
+	vm.stepCount++
+}
+
+// endStep completes the current step and passes it to the tracer.
+func (vm *VM) endStep() {
+	if vm.step == nil {
+		return
+	}
+
+	step := vm.step
+	vm.step = nil
+
+	step.GasAfter = vm.fee
+	// The tracer may keep the elements, so containers, which may be modified in place, are copied
+	stack := vm.evaluationStack.Stack
+	step.Stack = make([][]byte, len(stack))
+	for i := range stack {
+		element := stack[len(stack)-1-i]
+		if o := vm.objects.lookup(element); o != nil {
+			element = append([]byte{}, o.data...)
+		}
+		step.Stack[i] = element
+	}
+
+	for _, frame := range vm.callStack.values {
+		step.CallStack = append(step.CallStack, frame.function)
+	}
+
+	vm.tracer.CaptureStep(*step)
+}
+
+func (vm *VM) traceStorage(index int, value []byte) {
+	if vm.step != nil {
+		vm.step.Storage = append(vm.step.Storage, StorageDiff{Index: index, Value: value})
+	}
+}
+
+// SetTracer sets the tracer, which receives every executed instruction.
+func (vm *VM) SetTracer(tracer Tracer) {
+	vm.tracer = tracer
+}
@@ -0,0 +1,140 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func traceCode(t *testing.T, code []byte, stackSize int) []TraceStep {
+	var buffer bytes.Buffer
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.ContractVariables = [][]byte{{0}}
+	mc.Fee = 10000
+	vm.context = mc
+	tracer := NewJSONTracer(&buffer, stackSize)
+	vm.SetTracer(tracer)
+	vm.Exec(false)
+	assert.NilError(t, tracer.Err())
+
+	var steps []TraceStep
+	decoder := json.NewDecoder(&buffer)
+	for decoder.More() {
+		var step TraceStep
+		assert.NilError(t, decoder.Decode(&step))
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func TestTracer_JSONTracer(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 2,
+		PushInt, 1, 0, 3,
+		Add,
+		Halt,
+	}
+
+	steps := traceCode(t, code, 1)
+	assert.Equal(t, len(steps), 4)
+
+	assert.Equal(t, steps[0].Step, 0)
+	assert.Equal(t, steps[0].PC, 0)
+	assert.Equal(t, steps[0].OpCode, "pushint")
+	assert.Equal(t, steps[0].GasBefore, uint64(9999))
+	assert.Equal(t, steps[0].GasAfter, uint64(9993))
+	assert.Equal(t, len(steps[0].Stack), 1)
+	assertBytes(t, steps[0].Stack[0], 0, 2)
+
+	// Only the top of the stack is traced
+	assert.Equal(t, len(steps[1].Stack), 1)
+	assertBytes(t, steps[1].Stack[0], 0, 3)
+
+	assert.Equal(t, steps[2].PC, 8)
+	assert.Equal(t, steps[2].OpCode, "add")
+	assertBytes(t, steps[2].Stack[0], 0, 5)
+
+	assert.Equal(t, steps[3].OpCode, "halt")
+}
+
+func TestTracer_JSONTracer_StorageDiff(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 2,
+		StoreSt, 0,
+		Halt,
+	}
+
+	steps := traceCode(t, code, 1)
+	assert.Equal(t, len(steps), 3)
+	assert.Equal(t, len(steps[0].Storage), 0)
+	assert.Equal(t, len(steps[1].Storage), 1)
+	assert.Equal(t, steps[1].Storage[0].Index, 0)
+	assertBytes(t, steps[1].Storage[0].Value, 0, 2)
+}
+
+func TestTracer_JSONTracer_Error(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 2,
+		Add,
+	}
+
+	steps := traceCode(t, code, 1)
+	assert.Equal(t, len(steps), 2)
+	assert.Equal(t, string(steps[1].Stack[0]), "add: stack underflow at pc=4")
+}
+
+func TestTracer_ReplayTrace(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 2,
+		PushInt, 1, 0, 3,
+		Mul,
+		StoreSt, 0,
+		Halt,
+	}
+
+	var buffer bytes.Buffer
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.ContractVariables = [][]byte{{0}}
+	mc.Fee = 10000
+	vm.context = mc
+	vm.SetTracer(NewJSONTracer(&buffer, 2))
+	vm.Exec(false)
+
+	replay := NewTestVM([]byte{})
+	replay.context = mc
+	assert.NilError(t, replay.ReplayTrace(&buffer, 2))
+}
+
+func TestTracer_ReplayTrace_Mismatch(t *testing.T) {
+	var buffer bytes.Buffer
+	vm := NewTestVM([]byte{})
+	vm.context = NewMockContext([]byte{PushInt, 1, 0, 2, PushInt, 1, 0, 3, Add, Halt})
+	vm.SetTracer(NewJSONTracer(&buffer, 2))
+	vm.Exec(false)
+
+	replay := NewTestVM([]byte{})
+	replay.context = NewMockContext([]byte{PushInt, 1, 0, 2, PushInt, 1, 0, 3, Sub, Halt})
+	err := replay.ReplayTrace(&buffer, 2)
+	assert.Error(t, err, "trace mismatch at step 2")
+}
+
+func TestTracer_ReplayTrace_MissingSteps(t *testing.T) {
+	var buffer bytes.Buffer
+	vm := NewTestVM([]byte{})
+	vm.context = NewMockContext([]byte{PushInt, 1, 0, 2, Halt})
+	vm.SetTracer(NewJSONTracer(&buffer, 2))
+	vm.Exec(false)
+
+	trace := strings.SplitAfter(buffer.String(), "\n")[0]
+
+	replay := NewTestVM([]byte{})
+	replay.context = NewMockContext([]byte{PushInt, 1, 0, 2, Halt})
+	err := replay.ReplayTrace(strings.NewReader(trace), 2)
+	assert.Error(t, err, "trace mismatch at step 1")
+}
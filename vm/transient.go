@@ -0,0 +1,27 @@
+package vm
+
+// storeTransient writes a value to the transient storage, which lives until the end of the execution
+func (vm *VM) storeTransient(key []byte, value []byte) {
+	if vm.transient == nil {
+		vm.transient = make(map[string][]byte)
+	}
+	vm.transient[string(key)] = append([]byte{}, value...)
+}
+
+// loadTransient returns a copy of a value of the transient storage
+func (vm *VM) loadTransient(key []byte) ([]byte, error) {
+	value, ok := vm.transient[string(key)]
+	if !ok {
+		return nil, newError(ErrTransientNotSet, key)
+	}
+	return append([]byte{}, value...), nil
+}
+
+// transientMemoryUsage returns the size of the keys and values of the transient storage in bytes
+func (vm *VM) transientMemoryUsage() int {
+	size := 0
+	for key, value := range vm.transient {
+		size += len(key) + len(value)
+	}
+	return size
+}
@@ -0,0 +1,55 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestVM_Transient_SharedAcrossCalls(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 42, // value
+		PushStr, 3, 'k', 'e', 'y',
+		TStore,
+		CallLocals, 0, 17, 0, 1, 0,
+		Halt,
+		PushStr, 3, 'k', 'e', 'y',
+		TLoad,
+		Ret,
+	}
+
+	vm := NewTestVM(code)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{0, 42}})
+
+	// The transient storage does not survive the execution
+	assert.Assert(t, vm.transient == nil)
+	vm.Reset(NewMockContext([]byte{PushStr, 3, 'k', 'e', 'y', TLoad, Halt}))
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "tload: transient key 6b6579 is not set")
+}
+
+func TestVM_Transient_NotSet(t *testing.T) {
+	vm := NewTestVM([]byte{PushInt, 1, 0, 1, TLoad, Halt})
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "tload: transient key 0001 is not set")
+}
+
+func TestVM_Transient_Overwrite(t *testing.T) {
+	vm := NewTestVM([]byte{
+		PushInt, 1, 0, 1, PushInt, 0, TStore,
+		PushInt, 1, 0, 2, PushInt, 0, TStore,
+		PushInt, 0, TLoad,
+		Halt,
+	})
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{0, 2}})
+}
+
+func TestVM_Transient_LiveMemory(t *testing.T) {
+	code := append(pushBytes(100), PushInt, 0, TStore, Halt)
+	vm := NewVMWithConfig(NewMockContext(code), VMConfig{GasSchedule: GasSchedule{MemoryGasName: {Price: 1}}})
+
+	assert.Assert(t, vm.ExecUnlimited(), vm.GetErrorMsg())
+	assert.Equal(t, vm.PeakMemory(), 101)
+}
@@ -4,8 +4,6 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
-	"errors"
-	"fmt"
 	"math/big"
 )
 
@@ -39,7 +37,7 @@ func BigIntToUInt16(value big.Int) (uint16, error) {
 
 func BigIntToUInt(value big.Int) (uint, error) {
 	if len(value.Bytes()) > 4 {
-		return 0, fmt.Errorf("value cannot be greater than 32bits")
+		return 0, newError(ErrValueExceeds32Bits)
 	}
 	return uint(value.Uint64()), nil
 }
@@ -49,13 +47,16 @@ func ByteArrayToUI16(element []byte) (uint16, error) {
 		return 0, nil
 	}
 	if len(element) != 2 {
-		return 0, fmt.Errorf("value cannot be greater than %v", UINT16_MAX)
+		return 0, newError(ErrValueExceedsUInt16, UINT16_MAX)
 	}
 
 	result := binary.BigEndian.Uint16(element)
 	return result, nil
 }
 
+// StrToBigInt interprets the bytes of the string as unsigned integer.
+//
+// Deprecated: use convert.Encode, which encodes strings and integers as they are pushed onto the stack.
 func StrToBigInt(element string) big.Int {
 	var result big.Int
 	hexEncoded := hex.EncodeToString([]byte(element))
@@ -69,6 +70,9 @@ func ByteArrayToInt(element []byte) int {
 	return int(binary.BigEndian.Uint64(ba))
 }
 
+// BigIntToString returns the bytes of the unsigned integer as string.
+//
+// Deprecated: use convert.Decode with a string target.
 func BigIntToString(element big.Int) string {
 	ba := element.Bytes()
 	return string(ba[:])
@@ -93,7 +97,7 @@ func SignedBigIntConversion(ba []byte, err error) (big.Int, error) {
 		result := big.Int{}
 
 		if ba[0] != 0x01 && ba[0] != 0x00 {
-			return big.Int{}, errors.New("Invalid signing bit")
+			return big.Int{}, newError(ErrInvalidSigningBit)
 		}
 
 		result.SetBytes(ba[1:])
@@ -140,6 +144,10 @@ func BigIntToByteArray(value big.Int) []byte {
 	return result
 }
 
+// BigIntToPushableBytes returns the immediate of PushInt for the integer. Integers beyond uint64 are encoded as
+// negative.
+//
+// Deprecated: use convert.Encode, which encodes the sign of all integers correctly.
 func BigIntToPushableBytes(element big.Int) []byte {
 	baseLength := byte(len(element.Bytes()))
 
@@ -155,3 +163,18 @@ func BigIntToPushableBytes(element big.Int) []byte {
 	baseVal = append(baseVal, element.Bytes()...) // value
 	return baseVal
 }
+
+// toFixedWidth left-pads the bytes with zeros to the width. Leading zeros beyond the width are removed,
+// other bytes are never truncated.
+func toFixedWidth(value []byte, width int) ([]byte, error) {
+	for len(value) > width && value[0] == 0 {
+		value = value[1:]
+	}
+	if len(value) > width {
+		return nil, newError(ErrFixedWidthOverflow, width)
+	}
+
+	fixed := make([]byte, width)
+	copy(fixed[width-len(value):], value)
+	return fixed, nil
+}
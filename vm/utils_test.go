@@ -172,7 +172,7 @@ func TestUtils_BigIntToUInt_Overflow(t *testing.T) {
 	value := big.NewInt(max)
 	_, err := BigIntToUInt(*value)
 
-	assert.Equal(t, err.Error(), "value cannot be greater than 32bits")
+	assert.Equal(t, err.Error(), "value cannot be greater than 32 bits")
 }
 
 // big.Int to []byte
@@ -195,3 +195,43 @@ func TestUtils_BigIntToByteArray_Positive(t *testing.T) {
 	result := BigIntToByteArray(*value)
 	assertBytes(t, result, 0, 1)
 }
+
+func TestUtils_ToFixedWidth(t *testing.T) {
+	fixed, err := toFixedWidth([]byte{1, 2}, 4)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, fixed, []byte{0, 0, 1, 2})
+
+	fixed, err = toFixedWidth([]byte{0, 0, 1, 2}, 2)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, fixed, []byte{1, 2})
+
+	fixed, err = toFixedWidth([]byte{}, 2)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, fixed, []byte{0, 0})
+
+	_, err = toFixedWidth([]byte{0, 1, 2, 3}, 2)
+	assert.Error(t, err, "value does not fit into 2 bytes")
+}
+
+// newTestContext creates a mock context with enough gas for most tests, which is prepared by the setups
+func newTestContext(code []byte, setups ...func(mc *MockContext)) *MockContext {
+	mc := NewMockContext(code)
+	mc.Fee = 5000
+	for _, setup := range setups {
+		setup(mc)
+	}
+	return mc
+}
+
+// push appends the instruction, which pushes the value with the opcode, to the code. Values of PushInt are signed
+// integers as encoded by SignedByteArrayConversion, the length of PushInt excludes their sign byte.
+func push(code []byte, opCode byte, value []byte) []byte {
+	length := len(value)
+	if opCode == PushInt {
+		if length <= 1 {
+			return append(code, PushInt, 0)
+		}
+		length--
+	}
+	return append(append(code, opCode, byte(length)), value...)
+}
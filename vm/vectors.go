@@ -0,0 +1,165 @@
+package vm
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// TestVector describes the execution of a contract and its expected result.
+// Values are hex encoded, so the vectors can be shared with other implementations of the VM.
+type TestVector struct {
+	Name     string           `json:"name"`
+	Code     Bytecode         `json:"code"`
+	Fee      uint64           `json:"fee"`
+	PreState []string         `json:"preState,omitempty"` // Contract variables before the execution
+	CallData string           `json:"callData,omitempty"`
+	Expected TestVectorResult `json:"expected"`
+}
+
+// Bytecode is the notation of contract code in test vectors, which is independent of the opcode numbering.
+// Opcodes are written by name and arguments as numbers, e.g. ["push", 1, 3, "halt"].
+type Bytecode []byte
+
+// UnmarshalJSON resolves the opcode names of the bytecode.
+func (b *Bytecode) UnmarshalJSON(data []byte) error {
+	var tokens []interface{}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return err
+	}
+
+	code := make([]byte, len(tokens))
+	for i, token := range tokens {
+		switch value := token.(type) {
+		case string:
+			opCode, ok := LookupOpcode(value)
+			if !ok {
+				return fmt.Errorf("unknown opcode %v", value)
+			}
+			code[i] = opCode.Code
+		case float64:
+			if value < 0 || value > 255 || value != float64(int(value)) {
+				return fmt.Errorf("invalid byte %v", value)
+			}
+			code[i] = byte(value)
+		default:
+			return fmt.Errorf("invalid byte %v", value)
+		}
+	}
+
+	*b = code
+	return nil
+}
+
+// TestVectorResult is the expected result of a test vector.
+// Stack and storage are only verified, if they are declared.
+type TestVectorResult struct {
+	Success bool     `json:"success"`
+	Error   string   `json:"error,omitempty"`
+	Stack   []string `json:"stack,omitempty"` // Top of stack first
+	Storage []string `json:"storage,omitempty"`
+	Gas     uint64   `json:"gas"` // Remaining fee
+}
+
+// LoadTestVectors reads a JSON array of test vectors.
+func LoadTestVectors(r io.Reader) ([]TestVector, error) {
+	var vectors []TestVector
+	err := json.NewDecoder(r).Decode(&vectors)
+	return vectors, err
+}
+
+// newVM creates a VM with the code and the pre-state of the test vector
+func (v *TestVector) newVM() (VM, *MockContext, error) {
+	callData, err := hex.DecodeString(v.CallData)
+	if err != nil {
+		return VM{}, nil, err
+	}
+
+	mc := NewMockContext(v.Code)
+	mc.Fee = v.Fee
+	mc.Data = callData
+	mc.ContractVariables = make([][]byte, len(v.PreState))
+	for i, variable := range v.PreState {
+		mc.ContractVariables[i], err = hex.DecodeString(variable)
+		if err != nil {
+			return VM{}, nil, err
+		}
+	}
+	return NewVM(mc), mc, nil
+}
+
+// DumpState executes the test vector and returns the state dump of the VM, which helps to diagnose failing vectors.
+func (v *TestVector) DumpState() ([]byte, error) {
+	vm, _, err := v.newVM()
+	if err != nil {
+		return nil, err
+	}
+
+	vm.Exec(false)
+	return vm.DumpState()
+}
+
+// Run executes the test vector and returns an error describing the first mismatch.
+func (v *TestVector) Run() error {
+	vm, mc, err := v.newVM()
+	if err != nil {
+		return err
+	}
+
+	isSuccess := vm.Exec(false)
+	return v.Expected.check(v.Name, &vm, mc, isSuccess)
+}
+
+// check compares the result of the execution with the expected result
+func (r *TestVectorResult) check(name string, vm *VM, mc *MockContext, isSuccess bool) error {
+	if isSuccess != r.Success {
+		return fmt.Errorf("test vector %v: expected %v %v, but got %v", name, "success", r.Success, isSuccess)
+	}
+
+	if !isSuccess && r.Error != "" && vm.GetErrorMsg() != r.Error {
+		return fmt.Errorf("test vector %v: expected %v %v, but got %v", name, "error", r.Error, vm.GetErrorMsg())
+	}
+
+	if r.Stack != nil {
+		stack := vm.evaluationStack.Stack
+		actual := make([]string, len(stack))
+		for i := range stack {
+			actual[i] = hex.EncodeToString(stack[len(stack)-1-i])
+		}
+
+		if !equalStrings(r.Stack, actual) {
+			return fmt.Errorf("test vector %v: expected %v %v, but got %v", name, "stack", r.Stack, actual)
+		}
+	}
+
+	if r.Storage != nil {
+		actual := make([]string, len(mc.ContractVariables))
+		for i := range actual {
+			variable, _ := mc.GetContractVariable(i)
+			actual[i] = hex.EncodeToString(variable)
+		}
+
+		if !equalStrings(r.Storage, actual) {
+			return fmt.Errorf("test vector %v: expected %v %v, but got %v", name, "storage", r.Storage, actual)
+		}
+	}
+
+	if vm.fee != r.Gas {
+		return fmt.Errorf("test vector %v: expected %v %v, but got %v", name, "gas", r.Gas, vm.fee)
+	}
+	return nil
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
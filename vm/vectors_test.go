@@ -0,0 +1,61 @@
+package vm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func loadTestVectors(t *testing.T) []TestVector {
+	files, err := filepath.Glob("../tests/vectors/*.json")
+	assert.NilError(t, err)
+	assert.Assert(t, len(files) > 0)
+
+	var vectors []TestVector
+	for _, file := range files {
+		f, err := os.Open(file)
+		assert.NilError(t, err)
+
+		fileVectors, err := LoadTestVectors(f)
+		f.Close()
+		assert.NilError(t, err, file)
+		vectors = append(vectors, fileVectors...)
+	}
+	return vectors
+}
+
+func TestVectors(t *testing.T) {
+	for _, vector := range loadTestVectors(t) {
+		vector := vector
+		t.Run(vector.Name, func(t *testing.T) {
+			if err := vector.Run(); err != nil {
+				dump, _ := vector.DumpState()
+				t.Fatalf("%v\nstate: %s", err, dump)
+			}
+		})
+	}
+}
+
+func TestVectors_Mismatch(t *testing.T) {
+	vectors, err := LoadTestVectors(strings.NewReader(`[{
+		"name": "addition",
+		"code": ["pushint", 1, 0, 1, "pushint", 1, 0, 2, "add", "halt"],
+		"fee": 50,
+		"expected": {"success": true, "stack": ["0004"], "gas": 42}
+	}]`))
+	assert.NilError(t, err)
+
+	err = vectors[0].Run()
+	assert.Error(t, err, "test vector addition: expected stack [0004], but got [0003]")
+}
+
+func TestVectors_UnknownOpCode(t *testing.T) {
+	_, err := LoadTestVectors(strings.NewReader(`[{"name": "unknown", "code": ["foo"]}]`))
+	assert.Error(t, err, "unknown opcode foo")
+
+	_, err = LoadTestVectors(strings.NewReader(`[{"name": "invalid", "code": [256]}]`))
+	assert.Error(t, err, "invalid byte 256")
+}
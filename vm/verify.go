@@ -0,0 +1,55 @@
+package vm
+
+// checkAddressLength verifies the length of the immediate of PushAddr
+func checkAddressLength(length int) error {
+	if length != 32 && length != 64 {
+		return newError(ErrAddressLength, length)
+	}
+	return nil
+}
+
+// VerifyCode decodes all instructions of a contract including its state schema, constructor, metadata and function
+// table sections. It rejects malformed instructions, e.g. truncated arguments or PushAddr immediates of the wrong
+// length, so they are caught when the contract is deployed and not only when they are executed. The sections are
+// skipped by the execution, so the metadata blob is never decoded as instructions.
+// Tail calls between functions of the function table are checked to return the same number of elements.
+func VerifyCode(contract []byte) error {
+	sections, err := ParseSections(contract)
+	if err != nil {
+		return err
+	}
+
+	instructions, err := Disassemble(sections.Code)
+	if err != nil {
+		return err
+	}
+	return verifyTailCalls(instructions, sections.Functions)
+}
+
+// verifyTailCalls compares the return types of the caller and the callee of every tail call, which is declared in the
+// function table. The callee returns directly to the caller of the replaced function, so both have to return the same
+// number of elements. The function containing a tail call is the declared function with the closest address below it.
+// Tail calls outside of declared functions or to undeclared addresses are only checked, when they are executed.
+func verifyTailCalls(instructions []Instruction, functions []Function) error {
+	for _, instruction := range instructions {
+		if instruction.OpCode.Code != TailCall {
+			continue
+		}
+
+		var caller, callee *Function
+		for i, function := range functions {
+			address := int(function.Address)
+			if address <= instruction.Address && (caller == nil || address > int(caller.Address)) {
+				caller = &functions[i]
+			}
+			if address == ByteArrayToInt(instruction.Args[:2]) {
+				callee = &functions[i]
+			}
+		}
+
+		if caller != nil && callee != nil && caller.NrOfReturnTypes != callee.NrOfReturnTypes {
+			return newError(ErrTailCallReturnTypes, instruction.Address, callee.NrOfReturnTypes, caller.NrOfReturnTypes)
+		}
+	}
+	return nil
+}
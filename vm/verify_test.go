@@ -0,0 +1,65 @@
+package vm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestVerify_PushAddr(t *testing.T) {
+	for _, length := range []int{32, 64} {
+		address := make([]byte, length)
+		address[0] = 7
+		code := append(append([]byte{PushAddr, byte(length)}, address...), Halt)
+		assert.NilError(t, VerifyCode(code))
+
+		for _, engine := range Engines {
+			mc := NewMockContext(code)
+			mc.Fee = 1000
+			vm := NewVMWithConfig(mc, VMConfig{Engine: engine})
+			assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+			tos, _ := vm.evaluationStack.Pop()
+			assert.DeepEqual(t, tos, address)
+		}
+	}
+}
+
+func TestVerify_PushAddrLength(t *testing.T) {
+	// An address with a missing byte, which Push would accept
+	code := append(append([]byte{PushAddr, 31}, make([]byte, 31)...), Halt)
+	assert.Error(t, VerifyCode(code), "address must have 32 or 64 bytes, but has 31")
+
+	for _, engine := range Engines {
+		mc := NewMockContext(code)
+		mc.Fee = 1000
+		vm := NewVMWithConfig(mc, VMConfig{Engine: engine})
+		assert.Assert(t, !vm.Exec(false))
+		assert.Equal(t, vm.GetErrorMsg(), "pushaddr: address must have 32 or 64 bytes, but has 31")
+	}
+}
+
+func TestVerify_Sections(t *testing.T) {
+	f := Function{Hash: FunctionHash("f"), Address: 0, NrOfArgs: 0, NrOfReturnTypes: 0, NrOfLocals: 0}
+	schema := NewStateSchema([]VariableDeclaration{{Type: TypeInt, Size: 8}})
+
+	code := append(append([]byte{}, schema...), NewConstructorSection(1)...)
+	code = append(code, NewFunctionTable([]Function{f})...)
+	assert.NilError(t, VerifyCode(append(append([]byte{}, code...), Halt, Halt)))
+	assert.Error(t, VerifyCode(append(append([]byte{}, code...), Halt, PushInt, 1, 0)), "instruction set out of bounds")
+
+	assert.Error(t, VerifyCode([]byte{StateSchemaMarker, 5}), "state schema out of bounds")
+}
+
+func TestVerify_TailCallReturnTypes(t *testing.T) {
+	body := []byte{
+		TailCall, 0, 6, 0, 1, 0, // f at address 0
+		PushInt, 1, 0, 7, Ret, // g at address 6
+	}
+	f := Function{Hash: FunctionHash("f"), Address: 0, NrOfReturnTypes: 1}
+	g := Function{Hash: FunctionHash("g"), Address: 6, NrOfReturnTypes: 1}
+	assert.NilError(t, VerifyCode(append(NewFunctionTable([]Function{f, g}), body...)))
+
+	g.NrOfReturnTypes = 2
+	assert.Error(t, VerifyCode(append(NewFunctionTable([]Function{g, f}), body...)),
+		"tail call at 0 to a function with 2 return types, but the caller has 1")
+}
@@ -5,8 +5,8 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"encoding/binary"
-	"errors"
 	"fmt"
+	"math"
 	"math/big"
 
 	"golang.org/x/crypto/sha3"
@@ -29,6 +29,12 @@ type Context interface {
 	GetSig1() [64]byte
 }
 
+// CallerContext is implemented by contexts of cross-contract calls, in which the immediate caller is a contract.
+// Contexts without this interface are called by the transaction signer.
+type CallerContext interface {
+	GetCaller() [32]byte
+}
+
 // VM is a stack-based virtual machine and executes the contract code sequentially.
 type VM struct {
 	code            []byte
@@ -36,31 +42,132 @@ type VM struct {
 	fee             uint64
 	evaluationStack *Stack
 	callStack       *CallStack
+	objects         objectTable // Containers referenced by handles on the evaluation stack and in locals
 	context         Context
+	functions       []Function
+	guardActive     bool // Re-entrancy guard
+	maxIntegerSize  int  // Maximum size of integers in bytes, without the sign byte
+	bytecodeVersion byte // Selects the semantics of deprecated opcodes
+	tracer          Tracer
+	step            *TraceStep // Step of the current instruction, if traced
+	stepCount       int
+	sourceMap       *SourceMap
+	instructionPC   int // Address of the current instruction
+	selfCallPolicy  SelfCallPolicy
+	witness         *Witness       // State read by the execution
+	dirty           map[int][]byte // Contract variables written by the execution, committed when it succeeds
+	schema          StateSchema
+	config          VMConfig
+	stackChecksum   *[32]byte // Checksum of the evaluation stack after the previous instruction, if checked
+	callDataLimits  callDataLimits
+	gasLimit        uint64 // Fee available at the start of the execution
+	contract        []byte // Copy of the contract code, so the context cannot modify it during the execution
+	codeChecksum    [32]byte
+	loops           map[int]int // Iterations of the loops by address of the loop header
+	codeAddress     [32]byte    // Account of the executed code
+	delegate        *[32]byte   // Code delegate set by the execution, applied when it succeeds
+	paused          bool        // Rejects instructions changing the state
+	memoryPeak      int         // Maximum live memory in bytes, charged if the gas schedule has a memory entry
+
+	// Instructions by address, if compiled by the engine
+	program []*compiledInstruction
+	// Transient storage of TStore and TLoad, cleared at the end of the execution
+	transient map[string][]byte
+	// Detects handlers, which do not terminate, if enabled by the config
+	watchdog *watchdog
+	// Executes the constructor, set by ExecInit
+	initializing bool
+	// Metadata section of the executed contract, nil if it has none
+	metadata *Metadata
+	// Nonce of the caller bumped by the execution, applied when it succeeds
+	nonce *uint64
+	// Execution paused by ExecSteps, which the next call resumes
+	suspended bool
+	// Opcode of the previous instruction of the execution, -1 before the first one
+	previousOpCode int
+	// Failure of the last execution, nil if it succeeded
+	failure *ExecutionError
+}
+
+// DefaultMaxIntegerSize is the maximum size of integer results in bytes, if not configured otherwise.
+const DefaultMaxIntegerSize = 256
+
+// Limits of the parameters pushed by CallData, if not configured otherwise.
+// The size of a parameter is encoded in one byte, so it cannot exceed 255 bytes anyway.
+const (
+	DefaultMaxCallDataParams    = 32
+	DefaultMaxCallDataParamSize = 255
+)
+
+type callDataLimits struct {
+	maxParams    int
+	maxParamSize int
+}
+
+// Bytecode versions select the semantics of deprecated opcodes.
+const (
+	// BytecodeVersion1 is the initial version, in which Neg negates booleans by flipping the first byte.
+	BytecodeVersion1 byte = iota + 1
+	// BytecodeVersion2 restricts Neg to signed integers, booleans have to be negated with Not.
+	BytecodeVersion2
+	// BytecodeVersion3 pushes Balance and CallVal as signed integers like PushInt instead of 8 byte little endian
+	// values, so they can be used by the arithmetic opcodes directly.
+	BytecodeVersion3
+)
+
+// DefaultBytecodeVersion is the bytecode version, if not configured otherwise.
+const DefaultBytecodeVersion = BytecodeVersion1
+
+// IntegerOverflowError is returned if the result of an arithmetic operation exceeds the maximum integer size.
+type IntegerOverflowError struct {
+	MaxSize int
 }
 
-// NewVM creates a new Bazo virtual machine with the context received from Bazo miner.
+func (e *IntegerOverflowError) Error() string {
+	return newError(ErrIntegerOverflow, e.MaxSize).Error()
+}
+
+// NewVM creates a new Bazo virtual machine with the context received from Bazo miner and the default settings.
 func NewVM(context Context) VM {
-	return VM{
-		code:            []byte{},
-		pc:              0,
-		fee:             0,
-		evaluationStack: NewStack(),
-		callStack:       NewCallStack(),
-		context:         context,
-	}
+	return NewVMWithConfig(context, VMConfig{})
 }
 
-// NewTestVM creates a new Bazo virtual machine with the test contract code.
+// NewTestVM creates a new Bazo virtual machine with the test contract code, which checks its integrity.
 func NewTestVM(byteCode []byte) VM {
-	return VM{
-		code:            []byte{},
-		pc:              0,
-		fee:             0,
-		evaluationStack: NewStack(),
-		callStack:       NewCallStack(),
-		context:         NewMockContext(byteCode),
-	}
+	return NewVMWithConfig(NewMockContext(byteCode), VMConfig{CheckIntegrity: true, Watchdog: TestWatchdog})
+}
+
+// Reset clears the state of the previous execution and sets the context of the next one, so the VM can be reused.
+// The configuration, e.g. the tracer, the limits and the bytecode version, is kept.
+func (vm *VM) Reset(context Context) {
+	vm.code = []byte{}
+	vm.pc = 0
+	vm.fee = 0
+	vm.gasLimit = 0
+	vm.evaluationStack = NewStack()
+	vm.callStack = NewCallStack()
+	vm.context = context
+	vm.functions = nil
+	vm.guardActive = false
+	vm.step = nil
+	vm.stepCount = 0
+	vm.instructionPC = 0
+	vm.failure = nil
+	vm.witness = NewWitness()
+	vm.dirty = nil
+	vm.schema = nil
+	vm.stackChecksum = nil
+	vm.contract = nil
+	vm.loops = nil
+	vm.codeAddress = [32]byte{}
+	vm.delegate = nil
+	vm.nonce = nil
+	vm.suspended = false
+	vm.paused = false
+	vm.memoryPeak = 0
+	vm.program = nil
+	vm.transient = nil
+	vm.objects.clear()
 }
 
 // Private function, that can be activated by Exec call, useful for debugging
@@ -118,7 +225,7 @@ func (vm *VM) trace() {
 	reversedStack := make([][]byte, stack.GetLength())
 	maxIndex := len(stack.Stack) - 1
 	for i := maxIndex; i >= 0; i-- {
-		reversedStack[maxIndex-i] = stack.Stack[i]
+		reversedStack[maxIndex-i] = vm.objects.resolve(stack.Stack[i])
 	}
 
 	fmt.Printf("\t  Stack: %v \n", reversedStack)
@@ -129,1242 +236,2554 @@ func (vm *VM) trace() {
 
 // Exec executes the contract code and stores the result on evaluation stack.
 func (vm *VM) Exec(trace bool) bool {
-	vm.code = vm.context.GetContract()
-	vm.fee = vm.context.GetFee()
+	return vm.exec(vm.context.GetFee(), trace)
+}
+
+// exec executes the contract code with the fee as gas limit
+func (vm *VM) exec(fee uint64, trace bool) bool {
+	if !vm.start(fee) {
+		vm.recordFailure(-1)
+		return false
+	}
+	return vm.run(0, trace) == StatusDone
+}
+
+// start prepares the execution of the contract code with the fee as gas limit. It returns false, if the execution
+// failed before its first instruction.
+func (vm *VM) start(fee uint64) bool {
+	vm.fee = fee
+	vm.gasLimit = fee
+	vm.witness = NewWitness()
+	vm.dirty = make(map[int][]byte)
+	vm.stackChecksum = nil
+	vm.loops = nil
+	vm.delegate = nil
+	vm.nonce = nil
+	vm.memoryPeak = 0
+	vm.transient = nil
+	vm.objects.clear()
+	vm.suspended = false
+	vm.previousOpCode = -1
+	vm.failure = nil
+
+	// A paused contract does not accept coins
+	vm.paused = vm.isPaused()
+	if vm.paused && vm.context.GetAmount() > 0 {
+		vm.pushExecError(newError(ErrPaused))
+		return false
+	}
+
+	contract, codeAddress, err := vm.loadCode()
+	if err != nil {
+		vm.pushExecError(err)
+		return false
+	}
+	vm.contract = append([]byte{}, contract...)
+	vm.codeChecksum = contractChecksum(vm.contract)
+	vm.codeAddress = codeAddress
+	vm.code = vm.contract
+	vm.witness.record(WitnessCode, codeAddress, 0, vm.code)
 
 	if len(vm.code) > 100000 {
-		vm.evaluationStack.Push([]byte("vm.exec(): Instruction set to big"))
+		vm.pushExecError(newError(ErrInstructionSetTooBig))
+		return false
+	}
+
+	intrinsicGas := intrinsicGas(len(vm.code), len(vm.context.GetTransactionData()),
+		vm.gasPrice(CodeGasName, CodeGasFactor), vm.gasPrice(CallDataGasName, CallDataGasFactor))
+	if vm.fee < intrinsicGas {
+		vm.pushExecError(newError(ErrOutOfGas))
+		return false
+	}
+	vm.fee -= intrinsicGas
+
+	sections, err := ParseSections(vm.code)
+	if err != nil {
+		vm.pushExecError(err)
+		return false
+	}
+	vm.schema = sections.Schema
+	vm.metadata = sections.Metadata
+	vm.functions = sections.Functions
+
+	if err := vm.enterCode(sections.Constructor, sections.Code); err != nil {
+		vm.pushExecError(err)
 		return false
 	}
 
-	// Infinite Loop until return called
-	for {
+	vm.program = nil
+	if vm.config.Engine == EngineCompiled {
+		vm.program = vm.compile(vm.code)
+	}
+
+	return true
+}
+
+// run executes the instructions of a started execution, at most limit instructions, if the limit is positive.
+func (vm *VM) run(limit int, trace bool) Status {
+	defer vm.endStep()
+
+	if vm.config.Watchdog > 0 {
+		vm.watchdog = startWatchdog(vm.config.Watchdog)
+		defer func() {
+			vm.watchdog.stop()
+			vm.watchdog = nil
+		}()
+	}
+
+	// Loop until return called or the limit is reached
+	for steps := 0; limit <= 0 || steps < limit; steps++ {
+		vm.endStep()
+
 		if trace {
 			vm.trace()
 		}
 
+		pc, gasBefore := vm.pc, vm.fee
+		vm.instructionPC = pc
+
+		if vm.config.CheckIntegrity {
+			if err := vm.verifyChecksum(); err != nil {
+				vm.pushExecError(err)
+				return vm.finish(false)
+			}
+		}
+
+		if err := vm.checkCancelled(); err != nil {
+			vm.pushExecError(err)
+			return vm.finish(false)
+		}
+
 		// Fetch
-		byteCode, err := vm.fetch("vm.exec()")
+		opCode, run, err := vm.fetchInstruction()
 		if err != nil {
-			vm.evaluationStack.Push([]byte("vm.exec(): " + err.Error()))
-			return false
+			vm.pushExecError(err)
+			return vm.finish(false)
 		}
 
-		// Return false if instruction is not an opCode
-		if len(OpCodes) <= int(byteCode) {
-			vm.evaluationStack.Push([]byte("vm.exec(): Not a valid opCode"))
-			return false
+		vm.beginStep(pc, opCode, gasBefore)
+
+		if err := vm.runHook(vm.config.PreOpHook, pc, opCode); err != nil {
+			vm.pushError(opCode, err)
+			return vm.finish(false)
+		}
+
+		if err := vm.checkPaused(opCode); err != nil {
+			vm.pushError(opCode, err)
+			return vm.finish(false)
+		}
+
+		if err := vm.checkDisabled(opCode); err != nil {
+			vm.pushError(opCode, err)
+			return vm.finish(false)
 		}
 
-		opCode := OpCodes[byteCode]
 		// Subtract gas used for operation
-		if vm.fee < opCode.gasPrice {
-			vm.evaluationStack.Push([]byte("vm.exec(): out of gas"))
-			return false
+		if vm.fee < opCode.GasPrice {
+			vm.pushExecError(newError(ErrOutOfGas))
+			return vm.finish(false)
 		}
-		vm.fee -= opCode.gasPrice
+		vm.fee -= opCode.GasPrice
 
-		// Decode
-		switch opCode.code {
+		if opCode.Pops != VariableStackEffect && vm.evaluationStack.GetLength() < opCode.Pops {
+			vm.pushError(opCode, newError(ErrStackUnderflow, pc))
+			return vm.finish(false)
+		}
 
-		case PushInt:
-			totalBytes, errArg1 := vm.fetch(opCode.Name)
-			if !vm.checkErrors(opCode.Name, errArg1) {
-				return false
-			}
+		// Decode
+		var profileStack string
+		if vm.config.Profiler != nil {
+			profileStack = vm.profileStack(opCode)
+		}
 
-			var err error
-			if totalBytes == 0 {
-				err = vm.evaluationStack.Push([]byte{0})
-			} else {
-				// Amount of bytes pushed (including sign byte)
-				// Maximum amount of bytes that can be pushed is 256
-				byteCount := int(totalBytes) + 1 //
-				bytes, errArg2 := vm.fetchMany(opCode.Name, byteCount)
-
-				if !vm.checkErrors(opCode.Name, errArg2) {
-					return false
-				}
+		vm.watchdog.begin(pc, opCode)
+		done, isSuccess := run(vm, opCode)
+		vm.watchdog.end()
 
-				err = vm.evaluationStack.Push(bytes)
-			}
+		if vm.config.Profiler != nil {
+			vm.config.Profiler.add(profileStack, gasBefore-vm.fee)
+		}
+		if vm.config.OpStats != nil {
+			vm.config.OpStats.record(vm.previousOpCode, opCode.Code, gasBefore-vm.fee)
+			vm.previousOpCode = int(opCode.Code)
+		}
+		if done {
+			return vm.finish(isSuccess)
+		}
 
-			if err != nil {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
-		case PushBool:
-			boolValue, err := vm.fetch(opCode.Name)
+		if err := vm.chargeMemory(); err != nil {
+			vm.pushError(opCode, err)
+			return vm.finish(false)
+		}
 
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
+		if vm.config.CheckIntegrity {
+			if err := vm.checkIntegrity(); err != nil {
+				vm.pushError(opCode, err)
+				return vm.finish(false)
 			}
+			checksum := vm.evaluationStackChecksum()
+			vm.stackChecksum = &checksum
+		}
 
-			if boolValue > 1 {
-				_ = vm.evaluationStack.Push([]byte(
-					fmt.Sprintf("%s: invalid bool value %v", opCode.Name, boolValue)))
-				return false
-			}
+		if err := vm.runHook(vm.config.PostOpHook, pc, opCode); err != nil {
+			vm.pushError(opCode, err)
+			return vm.finish(false)
+		}
+	}
+	return StatusPaused
+}
 
-			err = vm.evaluationStack.Push([]byte{boolValue})
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
-			}
-		case PushChar:
-			charCode, err := vm.fetch(opCode.Name)
+// execute executes a single instruction, whose opcode has been fetched. It returns true and the result of the
+// execution, if the instruction ended the execution.
+func (vm *VM) execute(opCode OpCode) (done bool, isSuccess bool) {
+	var err error
 
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
-			}
+	switch opCode.Code {
 
-			if charCode > 127 {
-				_ = vm.evaluationStack.Push([]byte(
-					fmt.Sprintf("%s: invalid ASCII code %v", opCode.Name, charCode)))
-				return false
-			}
+	case PushInt:
+		totalBytes, errArg1 := vm.fetch(opCode.Name)
+		if !vm.checkErrors(opCode.Name, errArg1) {
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push([]byte{charCode})
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
-			}
-		case PushStr:
-			length, errArg1 := vm.fetch(opCode.Name)
-			bytes, errArg2 := vm.fetchMany(opCode.Name, int(length))
+		var err error
+		if totalBytes == 0 {
+			err = vm.evaluationStack.Push([]byte{0})
+		} else {
+			// Amount of bytes pushed (including sign byte)
+			// Maximum amount of bytes that can be pushed is 256
+			byteCount := int(totalBytes) + 1 //
+			bytes, errArg2 := vm.fetchMany(opCode.Name, byteCount)
 
-			if !vm.checkErrors(opCode.Name, errArg1, errArg2) {
-				return false
+			if !vm.checkErrors(opCode.Name, errArg2) {
+				return true, false
 			}
 
-			for _, charCode := range bytes {
-				if charCode > 127 {
-					_ = vm.evaluationStack.Push([]byte(
-						fmt.Sprintf("%s: invalid ASCII code %v", opCode.Name, charCode)))
-					return false
-				}
+			if err := vm.chargeImmediateGas(opCode, byteCount); err != nil {
+				vm.pushError(opCode, err)
+				return true, false
 			}
 
 			err = vm.evaluationStack.Push(bytes)
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
-			}
-		case Push:
-			length, errArg1 := vm.fetch(opCode.Name)
-			bytes, errArg2 := vm.fetchMany(opCode.Name, int(length))
+		}
 
-			if !vm.checkErrors(opCode.Name, errArg1, errArg2) {
-				return false
-			}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+	case PushBool:
+		boolValue, err := vm.fetch(opCode.Name)
 
-			err = vm.evaluationStack.Push(bytes)
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
-			}
-		case Dup:
-			tos, err := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
-			}
+		if boolValue > 1 {
+			vm.pushError(opCode, newError(ErrInvalidBool, boolValue))
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push([]byte{boolValue})
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+	case PushChar:
+		charCode, err := vm.fetch(opCode.Name)
 
-			err = vm.evaluationStack.Push(tos)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if charCode > 127 {
+			vm.pushError(opCode, newError(ErrInvalidASCII, charCode))
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(tos)
+		err = vm.evaluationStack.Push([]byte{charCode})
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+	case PushStr:
+		length, errArg1 := vm.fetch(opCode.Name)
+		bytes, errArg2 := vm.fetchMany(opCode.Name, int(length))
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if !vm.checkErrors(opCode.Name, errArg1, errArg2) {
+			return true, false
+		}
 
-		case Roll:
-			arg, err := vm.fetch(opCode.Name) // arg shows how many have to be rolled
-			index := vm.evaluationStack.GetLength() - (int(arg) + 2)
+		if err := vm.chargeImmediateGas(opCode, len(bytes)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
+		for _, charCode := range bytes {
+			if charCode > 127 {
+				vm.pushError(opCode, newError(ErrInvalidASCII, charCode))
+				return true, false
 			}
+		}
 
-			if index != -1 {
-				if int(arg) >= vm.evaluationStack.GetLength() {
-					vm.evaluationStack.Push([]byte(opCode.Name + ": index out of bounds"))
-					return false
-				}
-
-				newTos, err := vm.evaluationStack.PopIndexAt(index)
+		err = vm.evaluationStack.Push(bytes)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+	case Push:
+		length, errArg1 := vm.fetch(opCode.Name)
+		bytes, errArg2 := vm.fetchMany(opCode.Name, int(length))
 
-				if err != nil {
-					vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-					return false
-				}
+		if !vm.checkErrors(opCode.Name, errArg1, errArg2) {
+			return true, false
+		}
 
-				err = vm.evaluationStack.Push(newTos)
+		if err := vm.chargeImmediateGas(opCode, len(bytes)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-				if err != nil {
-					vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-					return false
-				}
-			}
-		case Swap:
-			last, err1 := vm.evaluationStack.Pop()
-			secondLast, err2 := vm.evaluationStack.Pop()
-			if !vm.checkErrors(opCode.Name, err1, err2) {
-				return false
-			}
+		err = vm.evaluationStack.Push(bytes)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			err1 = vm.evaluationStack.Push(last)
-			err2 = vm.evaluationStack.Push(secondLast)
-			if !vm.checkErrors(opCode.Name, err1, err2) {
-				return false
-			}
-		case Pop:
-			_, rerr := vm.PopBytes(opCode)
-			if !vm.checkErrors(opCode.Name, rerr) {
-				return false
-			}
+	// PushAddr pushes an address like Push, but only accepts immediates of 32 or 64 bytes. Contracts verified by
+	// VerifyCode cannot fail here, the check covers code, which is executed without verification.
+	case PushAddr:
+		length, errArg1 := vm.fetch(opCode.Name)
+		bytes, errArg2 := vm.fetchMany(opCode.Name, int(length))
+		if !vm.checkErrors(opCode.Name, errArg1, errArg2) {
+			return true, false
+		}
 
-		case Add:
-			isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
-				left.Add(left, right)
-			})
+		if err := checkAddressLength(int(length)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if !isSuccess {
-				return false
-			}
-		case Sub:
-			isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
-				left.Sub(left, right)
-			})
+		if err := vm.chargeImmediateGas(opCode, len(bytes)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if !isSuccess {
-				return false
-			}
-		case Mul:
-			isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
-				left.Mul(left, right)
-			})
+		err = vm.evaluationStack.Push(bytes)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			if !isSuccess {
-				return false
-			}
+	case Dup:
+		tos, err := vm.popValue(opCode)
 
-		case Exp:
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			left, rerr := vm.PopSignedBigInt(opCode)
-			right, lerr := vm.PopSignedBigInt(opCode)
+		err = vm.evaluationStack.Push(tos)
 
-			if !vm.checkErrors(opCode.Name, rerr, lerr) {
-				return false
-			}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if right.Cmp(big.NewInt(0)) == -1 {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": Negative exponents are not allowed."))
-				return false
-			}
+		err = vm.pushCopy(tos)
 
-			// The Exp OpCode is a special case in terms of gas calculation. The calculation of the gasCost is done
-			// during execution. An Exp function such as 2 ** n can be split up into n multiplications of the first
-			// factor -> 2 * 2 * 2 ... (n times). Therefore the gasCosts need to be as high as if the user performed
-			// n multiplications. As the user already paid the opcode price, we reduce the gasCost by this price.
-			gasCost := opCode.gasPrice*uint64(right.Int64()) - opCode.gasPrice
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if int64(vm.fee-gasCost) < 0 {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": Out of gas"))
-				return false
-			}
+	case Roll:
+		arg, err := vm.fetch(opCode.Name) // arg shows how many have to be rolled
+		index := vm.evaluationStack.GetLength() - (int(arg) + 2)
 
-			left.Exp(&left, &right, nil)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			err := vm.evaluationStack.Push(SignedByteArrayConversion(left))
+		// Deprecated: an index one below the bottom of the stack is ignored, strict mode rejects it
+		if index == -1 && !vm.config.Strict {
+			return false, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		// Roll n moves the element below the top n+1 elements to the top, i.e. Roll 0 is equal to Swap
+		if index < 0 {
+			vm.pushError(opCode, newError(ErrIndexOutOfBounds))
+			return true, false
+		}
 
-		case Div:
-			right, rerr := vm.PopSignedBigInt(opCode)
-			left, lerr := vm.PopSignedBigInt(opCode)
+		err = vm.evaluationStack.moveToTop(index)
 
-			if !vm.checkErrors(opCode.Name, rerr, lerr) {
-				return false
-			}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+	case Swap:
+		err := vm.evaluationStack.moveToTop(vm.evaluationStack.GetLength() - 2)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+	case Pop:
+		rerr := vm.discard(opCode)
+		if !vm.checkErrors(opCode.Name, rerr) {
+			return true, false
+		}
 
-			if right.Cmp(big.NewInt(0)) == 0 {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": Division by Zero"))
-				return false
-			}
+	case Pick:
+		// Pick n copies the nth element (counted from the top, starting at 0) to the top, i.e. Pick 0 is equal to Dup
+		arg, err := vm.fetch(opCode.Name)
 
-			left.Div(&left, &right)
-			err := vm.evaluationStack.Push(SignedByteArrayConversion(left))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		element, err := vm.evaluationStack.PeekIndexAt(vm.evaluationStack.GetLength() - 1 - int(arg))
 
-		case Mod:
-			right, rerr := vm.PopSignedBigInt(opCode)
-			left, lerr := vm.PopSignedBigInt(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, rerr, lerr) {
-				return false
-			}
+		err = vm.chargeSizeGas(opCode, len(vm.objects.resolve(element)))
 
-			if right.Cmp(big.NewInt(0)) == 0 {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": Division by Zero"))
-				return false
-			}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			left.Mod(&left, &right)
-			err := vm.evaluationStack.Push(SignedByteArrayConversion(left))
+		err = vm.pushCopy(element)
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-		case Neg:
-			tos, err := vm.PopBytes(opCode)
+	case Tuck:
+		// Tuck copies the top element below the second element: [a b] -> [b a b]
+		last, err1 := vm.popValue(opCode)
+		secondLast, err2 := vm.evaluationStack.Pop()
+		if !vm.checkErrors(opCode.Name, err1, err2) {
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err1 = vm.evaluationStack.Push(last)
+		err2 = vm.evaluationStack.Push(secondLast)
+		err3 := vm.pushCopy(last)
+		if !vm.checkErrors(opCode.Name, err1, err2, err3) {
+			return true, false
+		}
 
-			switch tos[0] {
-			case 1:
-				tos[0] = 0
-			case 0:
-				tos[0] = 1
-			default:
-				err = fmt.Errorf("unable to negate %v", tos[0])
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+	case PopN:
+		arg, err := vm.fetch(opCode.Name) // arg shows how many elements have to be popped
 
-			err = vm.evaluationStack.Push(tos)
-			if err != nil {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
-		case Eq:
-			right, rerr := vm.PopBytes(opCode)
-			left, lerr := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, rerr, lerr) {
-				return false
-			}
+		if int(arg) > vm.evaluationStack.GetLength() {
+			vm.pushError(opCode, newError(ErrIndexOutOfBounds))
+			return true, false
+		}
 
-			result := bytes.Compare(left, right)
-			err := vm.evaluationStack.Push(BoolToByteArray(result == 0))
+		for i := 0; i < int(arg); i++ {
+			err = vm.discard(opCode)
 
 			if err != nil {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
+				vm.pushError(opCode, err)
+				return true, false
 			}
-		case NotEq:
-			right, rerr := vm.PopBytes(opCode)
-			left, lerr := vm.PopBytes(opCode)
+		}
 
-			if !vm.checkErrors(opCode.Name, rerr, lerr) {
-				return false
-			}
+	case DupN:
+		// DupN n duplicates the top n elements in their order: [a b] -> [a b a b]
+		arg, err := vm.fetch(opCode.Name) // arg shows how many elements have to be duplicated
 
-			result := bytes.Compare(left, right)
-			err := vm.evaluationStack.Push(BoolToByteArray(result != 0))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			if err != nil {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
-		case Lt:
-			isSuccess := vm.evaluateRelationalComp(opCode, -1)
-			if !isSuccess {
-				return false
-			}
-		case Gt:
-			isSuccess := vm.evaluateRelationalComp(opCode, 1)
-			if !isSuccess {
-				return false
-			}
-		case LtEq:
-			isSuccess := vm.evaluateRelationalComp(opCode, -1, 0)
-			if !isSuccess {
-				return false
-			}
-		case GtEq:
-			isSuccess := vm.evaluateRelationalComp(opCode, 0, 1)
-			if !isSuccess {
-				return false
-			}
-		case ShiftL:
-			shiftsBigInt, err := vm.PopSignedBigInt(opCode)
-			tos, errStack := vm.PopSignedBigInt(opCode)
+		offset := vm.evaluationStack.GetLength() - int(arg)
+		if offset < 0 {
+			vm.pushError(opCode, newError(ErrIndexOutOfBounds))
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, err, errStack) {
-				return false
-			}
+		for i := 0; i < int(arg); i++ {
+			element, err := vm.evaluationStack.PeekIndexAt(offset + i)
 
-			if shiftsBigInt.Sign() == -1 {
-				vm.pushError(opCode, fmt.Errorf("negative shift operand is not allowed"))
-				return false
+			if err == nil {
+				err = vm.chargeSizeGas(opCode, len(vm.objects.resolve(element)))
 			}
 
-			nrOfShifts, err := BigIntToUInt(shiftsBigInt)
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
+			if err == nil {
+				err = vm.pushCopy(element)
 			}
 
-			tos.Lsh(&tos, nrOfShifts)
-			err = vm.evaluationStack.Push(SignedByteArrayConversion(tos))
-
 			if err != nil {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
+				vm.pushError(opCode, err)
+				return true, false
 			}
+		}
 
-		case ShiftR:
-			shiftsBigInt, err := vm.PopSignedBigInt(opCode)
-			tos, errStack := vm.PopSignedBigInt(opCode)
-
-			if !vm.checkErrors(opCode.Name, err, errStack) {
-				return false
-			}
+	case Add:
+		isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
+			left.Add(left, right)
+		})
 
-			if shiftsBigInt.Sign() == -1 {
-				vm.pushError(opCode, fmt.Errorf("negative shift operand is not allowed"))
-				return false
-			}
+		if !isSuccess {
+			return true, false
+		}
+	case Sub:
+		isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
+			left.Sub(left, right)
+		})
 
-			nrOfShifts, err := BigIntToUInt(shiftsBigInt)
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
-			}
+		if !isSuccess {
+			return true, false
+		}
+	case Mul:
+		right, rerr := vm.PopSignedBigInt(opCode)
+		left, lerr := vm.PopSignedBigInt(opCode)
 
-			tos.Rsh(&tos, nrOfShifts)
-			err = vm.evaluationStack.Push(SignedByteArrayConversion(tos))
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
 
-			if err != nil {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
-		case BitwiseAnd:
-			isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
-				left.And(left, right)
-			})
+		// The costs of a multiplication grow with the product of the operand sizes
+		gasCost := opCode.GasFactor * uint64(wordCount(&left)) * uint64(wordCount(&right))
+		if vm.fee < gasCost {
+			vm.pushError(opCode, newError(ErrOutOfGas))
+			return true, false
+		}
+		vm.fee -= gasCost
 
-			if !isSuccess {
-				return false
-			}
-		case BitwiseOr:
-			isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
-				left.Or(left, right)
-			})
+		left.Mul(&left, &right)
+		if err := vm.checkIntegerSize(&left); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if !isSuccess {
-				return false
-			}
-		case BitwiseXor:
-			isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
-				left.Xor(left, right)
-			})
+		err := vm.evaluationStack.Push(SignedByteArrayConversion(left))
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if !isSuccess {
-				return false
-			}
-		case BitwiseNot:
-			bigInt, err := vm.PopSignedBigInt(opCode)
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
-			}
+	case Exp:
 
-			bigInt.Not(&bigInt)
-			err = vm.evaluationStack.Push(SignedByteArrayConversion(bigInt))
+		left, rerr := vm.PopSignedBigInt(opCode)
+		right, lerr := vm.PopSignedBigInt(opCode)
 
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
-			}
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
 
-		case NoOp:
-			_, err := vm.fetch(opCode.Name)
+		if right.Cmp(big.NewInt(0)) == -1 {
+			vm.pushError(opCode, newError(ErrNegativeExponent))
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
+		// The result has at least (bitLen - 1) * exponent + 1 bits, reject it before it is calculated
+		if left.BitLen() > 1 {
+			maxBits := int64(vm.maxIntegerSize) * 8
+			if !right.IsInt64() || int64(left.BitLen()-1)*right.Int64()+1 > maxBits {
+				vm.pushError(opCode, &IntegerOverflowError{MaxSize: vm.maxIntegerSize})
+				return true, false
 			}
+		}
 
-		case Jmp:
-			nextInstruction, err := vm.fetchMany(opCode.Name, 2)
+		// The Exp OpCode is a special case in terms of gas calculation. The calculation of the gasCost is done
+		// during execution. An Exp function such as 2 ** n can be split up into n multiplications of the first
+		// factor -> 2 * 2 * 2 ... (n times). Therefore the gasCosts need to be as high as if the user performed
+		// n multiplications. As the user already paid the opcode price, we reduce the gasCost by this price.
+		// Exponents of base 0 and ±1 are not limited by the integer size, an exponent beyond int64 cannot be paid.
+		if !right.IsInt64() {
+			vm.pushError(opCode, newError(ErrOutOfGas))
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
+		var gasCost uint64
+		if exponent := uint64(right.Int64()); exponent > 1 {
+			if opCode.GasPrice > 0 && exponent-1 > vm.fee/opCode.GasPrice {
+				vm.pushError(opCode, newError(ErrOutOfGas))
+				return true, false
 			}
+			gasCost = opCode.GasPrice * (exponent - 1)
+		}
 
-			var jumpTo big.Int
-			jumpTo.SetBytes(nextInstruction)
+		if vm.fee < gasCost {
+			vm.pushError(opCode, newError(ErrOutOfGas))
+			return true, false
+		}
+		vm.fee -= gasCost
 
-			vm.pc = int(jumpTo.Int64())
+		result, err := vm.exp(&left, &right)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+		if err := vm.checkIntegerSize(result); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-		case JmpTrue:
-			nextInstruction, errArg := vm.fetchMany(opCode.Name, 2)
-			right, errStack := vm.PopBytes(opCode)
-			if !vm.checkErrors(opCode.Name, errArg, errStack) {
-				return false
-			}
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(*result))
 
-			if ByteArrayToBool(right) {
-				vm.pc = ByteArrayToInt(nextInstruction)
-			}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-		case JmpFalse:
-			nextInstruction, errArg := vm.fetchMany(opCode.Name, 2)
-			right, errStack := vm.PopBytes(opCode)
-			if !vm.checkErrors(opCode.Name, errArg, errStack) {
-				return false
-			}
+	case Div:
+		right, rerr := vm.PopSignedBigInt(opCode)
+		left, lerr := vm.PopSignedBigInt(opCode)
 
-			if !ByteArrayToBool(right) {
-				vm.pc = ByteArrayToInt(nextInstruction)
-			}
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
 
-		case Call:
-			returnAddressBytes, errArg1 := vm.fetchMany(opCode.Name, 2) // Shows where to jump after executing
-			argsToLoad, errArg2 := vm.fetch(opCode.Name)                // Shows how many elements have to be popped from evaluationStack
-			nrOfReturnTypesByte, errArg3 := vm.fetch(opCode.Name)
+		if right.Cmp(big.NewInt(0)) == 0 {
+			vm.pushError(opCode, newError(ErrDivisionByZero))
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, errArg1, errArg2, errArg3) {
-				return false
-			}
+		left.Div(&left, &right)
+		err := vm.evaluationStack.Push(SignedByteArrayConversion(left))
 
-			var returnAddress big.Int
-			returnAddress.SetBytes(returnAddressBytes)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if int(returnAddress.Int64()) == 0 || int(returnAddress.Int64()) > len(vm.code) {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": ReturnAddress out of bounds"))
-				return false
-			}
+	case Mod:
+		right, rerr := vm.PopSignedBigInt(opCode)
+		left, lerr := vm.PopSignedBigInt(opCode)
 
-			nrOfReturnTypes := int(nrOfReturnTypesByte)
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
 
-			if nrOfReturnTypes < 0 {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": Number of return types cannot be negative"))
-				return false
-			}
+		if right.Cmp(big.NewInt(0)) == 0 {
+			vm.pushError(opCode, newError(ErrDivisionByZero))
+			return true, false
+		}
 
-			frame := &Frame{
-				returnAddress:   vm.pc,
-				variables:       make(map[int][]byte),
-				nrOfReturnTypes: nrOfReturnTypes,
-			}
+		left.Mod(&left, &right)
+		err := vm.evaluationStack.Push(SignedByteArrayConversion(left))
 
-			for i := int(argsToLoad) - 1; i >= 0; i-- {
-				frame.variables[i], err = vm.PopBytes(opCode)
-				if err != nil {
-					_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-					return false
-				}
-			}
-			frame.evalStackOffset = len(vm.evaluationStack.Stack)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			vm.callStack.Push(frame)
-			vm.pc = int(returnAddress.Int64())
+	case DivTrunc, ModTrunc, DivFloor, ModFloor:
+		right, rerr := vm.PopSignedBigInt(opCode)
+		left, lerr := vm.PopSignedBigInt(opCode)
 
-		case CallTrue:
-			returnAddressBytes, errArg1 := vm.fetchMany(opCode.Name, 2) // Shows where to jump after executing
-			argsToLoad, errArg2 := vm.fetch(opCode.Name)                // Shows how many elements have to be popped from evaluationStack
-			nrOfReturnTypesByte, errArg3 := vm.fetch(opCode.Name)
-			right, errStack := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
+
+		if right.Sign() == 0 {
+			vm.pushError(opCode, newError(ErrDivisionByZero))
+			return true, false
+		}
+
+		result := divide(opCode.Code, &left, &right)
+		err := vm.evaluationStack.Push(SignedByteArrayConversion(*result))
+
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, errArg1, errArg2, errArg3, errStack) {
-				return false
+	case Min:
+		isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
+			if right.Cmp(left) < 0 {
+				left.Set(right)
 			}
+		})
 
-			if ByteArrayToBool(right) {
-				var returnAddress big.Int
-				returnAddress.SetBytes(returnAddressBytes)
+		if !isSuccess {
+			return true, false
+		}
 
-				if int(returnAddress.Int64()) == 0 || int(returnAddress.Int64()) > len(vm.code) {
-					_ = vm.evaluationStack.Push([]byte(opCode.Name + ": ReturnAddress out of bounds"))
-					return false
-				}
+	case Max:
+		isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
+			if right.Cmp(left) > 0 {
+				left.Set(right)
+			}
+		})
 
-				nrOfReturnTypes := int(nrOfReturnTypesByte)
+		if !isSuccess {
+			return true, false
+		}
 
-				if nrOfReturnTypes < 0 {
-					_ = vm.evaluationStack.Push([]byte(opCode.Name + ": Number of return types cannot be negative"))
-					return false
-				}
+	case Abs, Sign:
+		bigInt, err := vm.PopSignedBigInt(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-				frame := &Frame{
-					returnAddress:   vm.pc,
-					variables:       make(map[int][]byte),
-					nrOfReturnTypes: nrOfReturnTypes,
-				}
+		if opCode.Code == Abs {
+			bigInt.Abs(&bigInt)
+		} else {
+			// Sign pushes -1, 0 or 1
+			bigInt.SetInt64(int64(bigInt.Sign()))
+		}
 
-				for i := int(argsToLoad) - 1; i >= 0; i-- {
-					frame.variables[i], err = vm.PopBytes(opCode)
-					if err != nil {
-						_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-						return false
-					}
-				}
-				frame.evalStackOffset = len(vm.evaluationStack.Stack)
-				vm.callStack.Push(frame)
-				vm.pc = int(returnAddress.Int64())
-			}
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(bigInt))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// Sqrt calculates the integer square root, i.e. the result is rounded down
+	case Sqrt:
+		bigInt, err := vm.PopSignedBigInt(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-		case CallExt:
-			transactionAddress, errArg1 := vm.fetchMany(opCode.Name, 32) // Addresses are 32 bytes (var name: transactionAddress)
-			functionHash, errArg2 := vm.fetchMany(opCode.Name, 4)        // Function hash identifies function in external smart contract, first 4 byte of SHA3 hash (var name: functionHash)
-			argsToLoad, errArg3 := vm.fetch(opCode.Name)                 // Shows how many arguments to pop from stack and pass to external function (var name: argsToLoad)
+		if bigInt.Sign() == -1 {
+			vm.pushError(opCode, newError(ErrNegativeSqrt))
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, errArg1, errArg2, errArg3) {
-				return false
-			}
+		// The square root is approximated with multiplications of the size of the operand
+		gasCost := opCode.GasFactor * uint64(wordCount(&bigInt)) * uint64(wordCount(&bigInt))
+		if vm.fee < gasCost {
+			vm.pushError(opCode, newError(ErrOutOfGas))
+			return true, false
+		}
+		vm.fee -= gasCost
 
-			fmt.Sprint("CALLEXT", transactionAddress, functionHash, argsToLoad)
-			//TODO: Invoke new transaction with function hash and arguments, waiting for integration in bazo blockchain to finish
+		bigInt.Sqrt(&bigInt)
 
-		case Ret:
-			callstackTos, err := vm.callStack.Peek()
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(bigInt))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// Log2 calculates the binary logarithm rounded down, i.e. the index of the highest set bit
+	case Log2:
+		bigInt, err := vm.PopSignedBigInt(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		if bigInt.Sign() != 1 {
+			vm.pushError(opCode, newError(ErrNonPositiveLog))
+			return true, false
+		}
+
+		result := big.NewInt(int64(bigInt.BitLen() - 1))
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(*result))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
+	case Neg:
+		if vm.bytecodeVersion >= BytecodeVersion2 {
+			bigInt, err := vm.PopSignedBigInt(opCode)
 			if !vm.checkErrors(opCode.Name, err) {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
+				return true, false
 			}
 
-			if (vm.evaluationStack.GetLength() - callstackTos.evalStackOffset) != callstackTos.nrOfReturnTypes {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": Number of returned elements does not match."))
-				return false
+			bigInt.Neg(&bigInt)
+			err = vm.evaluationStack.Push(SignedByteArrayConversion(bigInt))
+			if !vm.checkErrors(opCode.Name, err) {
+				return true, false
 			}
+			break
+		}
 
-			vm.callStack.Pop()
-			vm.pc = callstackTos.returnAddress
+		// Deprecated: boolean negation, use Not instead
+		if vm.config.Strict {
+			vm.pushError(opCode, newError(ErrDeprecatedBehavior, "boolean negation with neg"))
+			return true, false
+		}
 
-		case Size:
-			element, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		tos, err := vm.PopBytes(opCode)
 
-			size := UInt64ToByteArray(uint64(len(element)))
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(size)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		switch tos[0] {
+		case 1:
+			tos[0] = 0
+		case 0:
+			tos[0] = 1
+		default:
+			vm.pushError(opCode, newError(ErrUnableToNegate, tos[0]))
+			return true, false
+		}
 
-		case StoreSt:
-			index, errArgs := vm.fetch(opCode.Name)
-			value, errStack := vm.PopBytes(opCode)
-			if !vm.checkErrors(opCode.Name, errArgs, errStack) {
-				return false
-			}
+		err = vm.evaluationStack.Push(tos)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+	case Not:
+		value, err := vm.popBool(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			err = vm.context.SetContractVariable(int(index), value)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.evaluationStack.Push(BoolToByteArray(!value))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-		case StoreLoc:
-			address, errArgs := vm.fetch(opCode.Name)
-			right, errStack := vm.PopBytes(opCode)
+	case And, Or, Xor:
+		right, rerr := vm.popBool(opCode)
+		left, lerr := vm.popBool(opCode)
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, errArgs, errStack) {
-				return false
-			}
+		var result bool
+		switch opCode.Code {
+		case And:
+			result = left && right
+		case Or:
+			result = left || right
+		case Xor:
+			result = left != right
+		}
 
-			callstackTos, err := vm.callStack.Peek()
+		err := vm.evaluationStack.Push(BoolToByteArray(result))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+	case Eq:
+		right, rerr := vm.PopBytes(opCode)
+		left, lerr := vm.PopBytes(opCode)
 
-			callstackTos.variables[int(address)] = right
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
 
-		case LoadSt:
-			index, err := vm.fetch(opCode.Name)
-			if !vm.checkErrors(opCode.Name, err) {
-				return false
-			}
+		result := bytes.Compare(left, right)
+		err := vm.evaluationStack.Push(BoolToByteArray(result == 0))
 
-			value, err := vm.context.GetContractVariable(int(index))
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+	case NotEq:
+		right, rerr := vm.PopBytes(opCode)
+		left, lerr := vm.PopBytes(opCode)
 
-			err = vm.evaluationStack.Push(value)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
 
-		case LoadLoc:
-			address, errArg := vm.fetch(opCode.Name)
-			callstackTos, errCallStack := vm.callStack.Peek()
+		result := bytes.Compare(left, right)
+		err := vm.evaluationStack.Push(BoolToByteArray(result != 0))
 
-			if !vm.checkErrors(opCode.Name, errArg, errCallStack) {
-				return false
-			}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+	case Lt:
+		isSuccess := vm.evaluateRelationalComp(opCode, -1)
+		if !isSuccess {
+			return true, false
+		}
+	case Gt:
+		isSuccess := vm.evaluateRelationalComp(opCode, 1)
+		if !isSuccess {
+			return true, false
+		}
+	case LtEq:
+		isSuccess := vm.evaluateRelationalComp(opCode, -1, 0)
+		if !isSuccess {
+			return true, false
+		}
+	case GtEq:
+		isSuccess := vm.evaluateRelationalComp(opCode, 0, 1)
+		if !isSuccess {
+			return true, false
+		}
+	// Byte strings are compared as unsigned big-endian magnitudes, e.g. hashes and addresses. Leading zeros are
+	// ignored, so operands of different lengths are compared by their values and not lexicographically.
+	case ULt, UGt, ULtEq, UGtEq:
+		right, rerr := vm.PopBytes(opCode)
+		left, lerr := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
 
-			val := callstackTos.variables[int(address)]
+		var leftInt, rightInt big.Int
+		result := leftInt.SetBytes(left).Cmp(rightInt.SetBytes(right))
+
+		var compResult bool
+		switch opCode.Code {
+		case ULt:
+			compResult = result < 0
+		case UGt:
+			compResult = result > 0
+		case ULtEq:
+			compResult = result <= 0
+		case UGtEq:
+			compResult = result >= 0
+		}
 
-			err := vm.evaluationStack.Push(val)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err := vm.evaluationStack.Push(BoolToByteArray(compResult))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-		case Address:
-			address := vm.context.GetAddress()
-			err := vm.evaluationStack.Push(address[:])
+	// Byte strings are compared lexicographically by their unsigned byte values
+	case StrLt, StrGt, StrCmp:
+		right, rerr := vm.PopBytes(opCode)
+		left, lerr := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		result := bytes.Compare(left, right)
 
-		case Issuer:
-			issuer := vm.context.GetIssuer()
-			err := vm.evaluationStack.Push(issuer[:])
+		var err error
+		switch opCode.Code {
+		case StrLt:
+			err = vm.evaluationStack.Push(BoolToByteArray(result == -1))
+		case StrGt:
+			err = vm.evaluationStack.Push(BoolToByteArray(result == 1))
+		case StrCmp:
+			err = vm.evaluationStack.Push(SignedByteArrayConversion(*big.NewInt(int64(result))))
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-		case Balance:
-			balance := make([]byte, 8)
-			binary.LittleEndian.PutUint64(balance, vm.context.GetBalance())
+	case ShiftL:
+		shiftsBigInt, err := vm.PopSignedBigInt(opCode)
+		tos, errStack := vm.PopSignedBigInt(opCode)
 
-			err := vm.evaluationStack.Push(balance)
+		if !vm.checkErrors(opCode.Name, err, errStack) {
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if shiftsBigInt.Sign() == -1 {
+			vm.pushError(opCode, newError(ErrNegativeShift))
+			return true, false
+		}
 
-		case Caller:
-			caller := vm.context.GetSender()
-			err := vm.evaluationStack.Push(caller[:])
+		nrOfShifts, err := BigIntToUInt(shiftsBigInt)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		// Reject the result before it is calculated, the number of shifts can be very large
+		if tos.Sign() != 0 && uint64(tos.BitLen())+uint64(nrOfShifts) > uint64(vm.maxIntegerSize)*8 {
+			vm.pushError(opCode, &IntegerOverflowError{MaxSize: vm.maxIntegerSize})
+			return true, false
+		}
 
-		case CallVal:
-			value := make([]byte, 8)
-			binary.LittleEndian.PutUint64(value, vm.context.GetAmount())
+		tos.Lsh(&tos, nrOfShifts)
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(tos))
 
-			err := vm.evaluationStack.Push(value[:])
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+	case ShiftR:
+		shiftsBigInt, err := vm.PopSignedBigInt(opCode)
+		tos, errStack := vm.PopSignedBigInt(opCode)
 
-		case CallData:
-			td := vm.context.GetTransactionData()
-			for i := 0; i < len(td); i++ {
-				length := int(td[i]) // Length of parameters
+		if !vm.checkErrors(opCode.Name, err, errStack) {
+			return true, false
+		}
 
-				// Check if Length of TransactionData - the already read data is greater then or equal to the given
-				// length parameter
-				if len(td)-i-1 < length {
-					vm.evaluationStack.Push([]byte(opCode.Name + ": Index out of bounds"))
-					return false
-				}
+		if shiftsBigInt.Sign() == -1 {
+			vm.pushError(opCode, newError(ErrNegativeShift))
+			return true, false
+		}
 
-				err := vm.evaluationStack.Push(td[i+1 : i+length+1])
+		nrOfShifts, err := BigIntToUInt(shiftsBigInt)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-				if err != nil {
-					vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-					return false
-				}
+		tos.Rsh(&tos, nrOfShifts)
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(tos))
 
-				i += int(td[i]) // Increase to next parameter length
-			}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+	case BitwiseAnd:
+		isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
+			left.And(left, right)
+		})
 
-		case NewMap:
-			m := CreateMap()
+		if !isSuccess {
+			return true, false
+		}
+	case BitwiseOr:
+		isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
+			left.Or(left, right)
+		})
 
-			err = vm.evaluationStack.Push(m)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if !isSuccess {
+			return true, false
+		}
+	case BitwiseXor:
+		isSuccess := vm.evaluateBigIntOperation(opCode, func(left *big.Int, right *big.Int) {
+			left.Xor(left, right)
+		})
 
-		case MapHasKey:
-			mba, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if !isSuccess {
+			return true, false
+		}
+	case BitwiseNot:
+		bigInt, err := vm.PopSignedBigInt(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			m, err := MapFromByteArray(mba)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		bigInt.Not(&bigInt)
+		if err := vm.checkIntegerSize(&bigInt); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			k, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(bigInt))
 
-			result, err := m.MapContainsKey(k)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			vm.evaluationStack.Push(BoolToByteArray(result))
+	// IntToBytes converts a non-negative integer into its big-endian bytes without sign byte, zero is converted to [0]
+	case IntToBytes:
+		bigInt, err := vm.PopSignedBigInt(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-		case MapGetVal:
-			mapAsByteArray, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if bigInt.Sign() == -1 {
+			vm.pushError(opCode, newError(ErrNegativeConversion))
+			return true, false
+		}
 
-			k, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		bytes := bigInt.Bytes()
+		if len(bytes) == 0 {
+			bytes = []byte{0}
+		}
 
-			m, err := MapFromByteArray(mapAsByteArray)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.evaluationStack.Push(bytes)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			v, err := m.GetVal(k)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+	// BytesToInt interprets the bytes as unsigned big-endian integer
+	case BytesToInt:
+		bigInt, err := vm.PopUnsignedBigInt(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(v)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if err := vm.checkIntegerSize(&bigInt); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-		case MapSetVal:
-			mapAsByteArray, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(bigInt))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			m, err := MapFromByteArray(mapAsByteArray)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+	// BytesToAddress and BytesToPubKey left-pad the bytes with zeros to 32 and 64 bytes respectively,
+	// e.g. to use a hash or an integer as address. Leading zeros are removed, if the value is longer.
+	case BytesToAddress, BytesToPubKey:
+		value, err := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			k, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		width := 32
+		if opCode.Code == BytesToPubKey {
+			width = 64
+		}
 
-			v, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		fixed, err := toFixedWidth(value, width)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			hasKey, err := m.MapContainsKey(k)
-			if err != nil {
-				vm.pushError(opCode, err)
-				return false
-			}
+		err = vm.evaluationStack.Push(fixed)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			if hasKey {
-				err = m.SetVal(k, v)
-			} else {
-				err = m.Append(k, v)
-			}
+	// HexEncode and Base64Encode convert bytes into lowercase hex and padded standard base64 text, HexDecode and
+	// Base64Decode parse it. Like the popped value, the result is charged with the gas factor per started 64 bytes.
+	case HexEncode, HexDecode, Base64Encode, Base64Decode:
+		value, err := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		result, err := transcode(opCode.Code, value)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(m)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if err := vm.chargeSizeGas(opCode, len(result)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-		case MapRemove:
-			mapAsByteArray, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.evaluationStack.Push(result)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			k, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+	// BoolToInt converts false to 0 and true to 1
+	case BoolToInt:
+		value, err := vm.popBool(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			m, err := MapFromByteArray(mapAsByteArray)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		var result int64
+		if value {
+			result = 1
+		}
 
-			err = m.Remove(k)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(*big.NewInt(result)))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(m)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
+	// CharToInt converts an ASCII character to its character code
+	case CharToInt:
+		char, err := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		if len(char) != 1 || char[0] > 127 {
+			vm.pushError(opCode, newError(ErrInvalidASCII, char))
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(*big.NewInt(int64(char[0]))))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// Uint64ToInt converts an 8 byte little endian value, as pushed by Balance and CallVal, to an integer
+	case Uint64ToInt:
+		value, err := vm.popUint64(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		bigInt := new(big.Int).SetUint64(value)
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(*bigInt))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// IntToUint64 converts an integer to an 8 byte little endian value
+	case IntToUint64:
+		bigInt, err := vm.PopSignedBigInt(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		if bigInt.Sign() == -1 {
+			vm.pushError(opCode, newError(ErrNegativeConversion))
+			return true, false
+		}
+		if !bigInt.IsUint64() {
+			vm.pushError(opCode, newError(ErrUint64Overflow))
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(uint64Bytes(bigInt.Uint64()))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// Add64 adds two 8 byte little endian values and fails on overflow
+	case Add64:
+		right, rerr := vm.popUint64(opCode)
+		left, lerr := vm.popUint64(opCode)
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
+
+		if left > math.MaxUint64-right {
+			vm.pushError(opCode, newError(ErrUint64Overflow))
+			return true, false
+		}
+
+		err := vm.evaluationStack.Push(uint64Bytes(left + right))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// Sub64 subtracts two 8 byte little endian values and fails, if the result is negative
+	case Sub64:
+		right, rerr := vm.popUint64(opCode)
+		left, lerr := vm.popUint64(opCode)
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
+
+		if left < right {
+			vm.pushError(opCode, newError(ErrUint64Overflow))
+			return true, false
+		}
+
+		err := vm.evaluationStack.Push(uint64Bytes(left - right))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// Cmp64 compares two 8 byte little endian values and pushes -1, 0 or 1 like StrCmp
+	case Cmp64:
+		right, rerr := vm.popUint64(opCode)
+		left, lerr := vm.popUint64(opCode)
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
+
+		var result int64
+		if left < right {
+			result = -1
+		} else if left > right {
+			result = 1
+		}
+
+		err := vm.evaluationStack.Push(SignedByteArrayConversion(*big.NewInt(result)))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// AddAmount adds two coin amounts and fails on overflow instead of truncating the sum
+	case AddAmount:
+		right, rerr := vm.popAmount(opCode)
+		left, lerr := vm.popAmount(opCode)
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
+
+		if left > math.MaxUint64-right {
+			vm.pushError(opCode, newError(ErrAmountOverflow))
+			return true, false
+		}
+
+		err := vm.evaluationStack.Push(vm.uint64Value(uint64Bytes(left + right)))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// SubAmount subtracts two coin amounts and fails, if the result is negative
+	case SubAmount:
+		right, rerr := vm.popAmount(opCode)
+		left, lerr := vm.popAmount(opCode)
+		if !vm.checkErrors(opCode.Name, rerr, lerr) {
+			return true, false
+		}
+
+		if left < right {
+			vm.pushError(opCode, newError(ErrAmountUnderflow))
+			return true, false
+		}
+
+		err := vm.evaluationStack.Push(vm.uint64Value(uint64Bytes(left - right)))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// AddDuration and SubDuration add or subtract block heights and durations and fail on overflow or underflow
+	case AddDuration, SubDuration:
+		if err := vm.timeArithmetic(opCode); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	// BlocksToSeconds and SecondsToBlocks convert a duration with the block interval of the chain
+	case BlocksToSeconds, SecondsToBlocks:
+		if err := vm.convertDuration(opCode); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case NoOp:
+		_, err := vm.fetch(opCode.Name)
+
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case Jmp:
+		nextInstruction, err := vm.fetchMany(opCode.Name, 2)
+
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		var jumpTo big.Int
+		jumpTo.SetBytes(nextInstruction)
+
+		if err := vm.jump(int(jumpTo.Int64())); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case JmpTrue:
+		nextInstruction, errArg := vm.fetchMany(opCode.Name, 2)
+		right, errStack := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, errArg, errStack) {
+			return true, false
+		}
+
+		if ByteArrayToBool(right) {
+			if err := vm.jump(ByteArrayToInt(nextInstruction)); err != nil {
+				vm.pushError(opCode, err)
+				return true, false
 			}
+		}
 
-		case NewArr:
-			length, err := vm.PopUnsignedBigInt(opCode)
+	case JmpFalse:
+		nextInstruction, errArg := vm.fetchMany(opCode.Name, 2)
+		right, errStack := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, errArg, errStack) {
+			return true, false
+		}
 
-			if err != nil {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
+		if !ByteArrayToBool(right) {
+			if err := vm.jump(ByteArrayToInt(nextInstruction)); err != nil {
+				vm.pushError(opCode, err)
+				return true, false
 			}
+		}
+
+	case Call, CallTrue, CallLocals, CallTrueLocals:
+		if !vm.call(opCode) {
+			return true, false
+		}
+
+	case TailCall:
+		returnAddressBytes, errArg1 := vm.fetchMany(opCode.Name, 2) // Shows where to jump after replacing the frame
+		argsToLoad, errArg2 := vm.fetch(opCode.Name)                // Shows how many elements have to be popped from evaluationStack
+		nrOfReturnTypesByte, errArg3 := vm.fetch(opCode.Name)
+		nrOfLocalsByte, errArg4 := vm.fetch(opCode.Name) // Shows how many local variables (including arguments) the function declares
+
+		if !vm.checkErrors(opCode.Name, errArg1, errArg2, errArg3, errArg4) {
+			return true, false
+		}
+
+		callstackTos, err := vm.callStack.Peek()
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		returnAddress := ByteArrayToInt(returnAddressBytes)
+
+		if returnAddress == 0 || returnAddress > len(vm.code) {
+			vm.pushError(opCode, newError(ErrReturnAddressOutOfBounds))
+			return true, false
+		}
+
+		// The callee returns directly to the caller of the current function,
+		// therefore both have to return the same number of elements.
+		if int(nrOfReturnTypesByte) != callstackTos.nrOfReturnTypes {
+			vm.pushError(opCode, newError(ErrReturnTypesMismatch))
+			return true, false
+		}
+
+		// Arguments passed by reference would stay in the frame, which is replaced
+		if argsToLoad&CallByReference != 0 {
+			vm.pushError(opCode, newError(ErrTailCallByReference))
+			return true, false
+		}
+
+		if nrOfLocalsByte < argsToLoad {
+			vm.pushError(opCode, newError(ErrLessLocalsThanArguments))
+			return true, false
+		}
+
+		variables := make([][]byte, nrOfLocalsByte)
+		for i := int(argsToLoad) - 1; i >= 0; i-- {
+			variables[i], err = vm.popValue(opCode)
+			if err != nil {
+				vm.pushError(opCode, err)
+				return true, false
+			}
+		}
+
+		// Elements left on the evaluation stack of the current frame would be returned by the callee
+		if vm.evaluationStack.GetLength() != callstackTos.evalStackOffset {
+			vm.pushError(opCode, newError(ErrFrameNotEmpty))
+			return true, false
+		}
+
+		// Reuse the current frame instead of pushing a new one, so the call stack does not grow. Arguments passed to
+		// the current function by reference stay on the stack until the callee returns.
+		vm.releaseLocals(callstackTos)
+		callstackTos.variables = variables
+		for i := range callstackTos.references {
+			callstackTos.references[i] = false
+		}
+		callstackTos.function, callstackTos.nrOfArgs = returnAddress, int(argsToLoad)
+		vm.pc = returnAddress
+
+	case CallFn:
+		functionHash, errArg1 := vm.fetchMany(opCode.Name, 4) // Function hash identifies function in the function table, first 4 byte of SHA3 hash
+		argsToLoad, errArg2 := vm.fetch(opCode.Name)          // Shows how many elements have to be popped from evaluationStack
+
+		if !vm.checkErrors(opCode.Name, errArg1, errArg2) {
+			return true, false
+		}
+
+		function, err := findFunction(vm.functions, functionHash)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		if argsToLoad != function.NrOfArgs {
+			vm.pushError(opCode, newError(ErrArgumentsMismatch))
+			return true, false
+		}
+
+		if int(function.Address) == 0 || int(function.Address) > len(vm.code) {
+			vm.pushError(opCode, newError(ErrReturnAddressOutOfBounds))
+			return true, false
+		}
+
+		if function.NrOfLocals < function.NrOfArgs {
+			vm.pushError(opCode, newError(ErrLessLocalsThanArguments))
+			return true, false
+		}
+
+		frame := &Frame{
+			returnAddress:   vm.pc,
+			variables:       make([][]byte, function.NrOfLocals),
+			nrOfReturnTypes: int(function.NrOfReturnTypes),
+			function:        int(function.Address),
+			callAddress:     vm.instructionPC,
+			nrOfArgs:        int(argsToLoad),
+		}
+
+		for i := int(argsToLoad) - 1; i >= 0; i-- {
+			frame.variables[i], err = vm.popValue(opCode)
+			if err != nil {
+				vm.pushError(opCode, err)
+				return true, false
+			}
+		}
+		frame.evalStackOffset = len(vm.evaluationStack.Stack)
+
+		vm.callStack.Push(frame)
+		vm.pc = int(function.Address)
+
+	case CallExt:
+		transactionAddress, errArg1 := vm.fetchMany(opCode.Name, 32) // Addresses are 32 bytes (var name: transactionAddress)
+		functionHash, errArg2 := vm.fetchMany(opCode.Name, 4)        // Function hash identifies function in external smart contract, first 4 byte of SHA3 hash (var name: functionHash)
+		argsToLoad, errArg3 := vm.fetch(opCode.Name)                 // Shows how many arguments to pop from stack and pass to external function (var name: argsToLoad)
+
+		if !vm.checkErrors(opCode.Name, errArg1, errArg2, errArg3) {
+			return true, false
+		}
+
+		if vm.isSelf(transactionAddress) {
+			if err := vm.checkSelfCall(argsToLoad); err != nil {
+				vm.pushError(opCode, err)
+				return true, false
+			}
+		}
+		argsToLoad &^= CallExtSelfFlag
+
+		_ = fmt.Sprint("CALLEXT", transactionAddress, functionHash, argsToLoad)
+		//TODO: Invoke new transaction with function hash and arguments, waiting for integration in bazo blockchain to finish
+		// If the guard is active and the same contract is invoked, ActivateGuard() has to be called on the new VM.
+		// The context of the new VM has to implement CallerContext and return the address of this contract.
+
+	case CallBuiltin:
+		index, err := vm.fetch(opCode.Name)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		if int(index) >= len(Builtins) {
+			vm.pushError(opCode, newError(ErrUnknownBuiltin, index))
+			return true, false
+		}
+
+		result, err := vm.callBuiltin(opCode, Builtins[index])
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(*result))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	case EnterGuard:
+		if vm.guardActive {
+			vm.pushError(opCode, newError(ErrReentrantCall))
+			return true, false
+		}
+		vm.guardActive = true
+
+	case ExitGuard:
+		if !vm.guardActive {
+			vm.pushError(opCode, newError(ErrGuardNotActive))
+			return true, false
+		}
+		vm.guardActive = false
+
+	case Ret:
+		callstackTos, err := vm.callStack.Peek()
+
+		if !vm.checkErrors(opCode.Name, err) {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		if (vm.evaluationStack.GetLength() - callstackTos.evalStackOffset) != callstackTos.nrOfReturnTypes {
+			vm.pushError(opCode, newError(ErrReturnedElementsMismatch))
+			return true, false
+		}
+
+		vm.callStack.Pop()
+		vm.releaseLocals(callstackTos)
+		references := callstackTos.evalStackOffset - len(callstackTos.references)
+		for _, element := range vm.evaluationStack.Stack[references:callstackTos.evalStackOffset] {
+			vm.objects.release(element)
+		}
+		vm.evaluationStack.remove(references, len(callstackTos.references))
+		vm.pc = callstackTos.returnAddress
+
+	// CallDepth pushes the number of frames on the call stack, i.e. 0 outside of any function call
+	case CallDepth:
+		depth := big.NewInt(int64(vm.CallDepth()))
+
+		err := vm.evaluationStack.Push(SignedByteArrayConversion(*depth))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// ReturnAddressOf n pushes the return address of the frame at depth n, i.e. ReturnAddressOf 0 is the
+	// address, to which the current function returns
+	case ReturnAddressOf:
+		depth, err := vm.fetch(opCode.Name)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		frame, err := vm.callStack.PeekAt(int(depth))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		address := big.NewInt(int64(frame.returnAddress))
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(*address))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	case Size:
+		element, err := vm.PopBytes(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		size := UInt64ToByteArray(uint64(len(element)))
+
+		err = vm.evaluationStack.Push(size)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case StoreSt:
+		index, errArgs := vm.fetch(opCode.Name)
+		value, errStack := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, errArgs, errStack) {
+			return true, false
+		}
+
+		err = vm.storeVariable(int(index), value)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+		vm.traceStorage(int(index), value)
+
+	case StoreLoc:
+		address, errArgs := vm.fetch(opCode.Name)
+		right, errStack := vm.popValue(opCode)
+
+		if !vm.checkErrors(opCode.Name, errArgs, errStack) {
+			return true, false
+		}
+
+		callstackTos, err := vm.callStack.Peek()
+
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		previous, err := callstackTos.setVariable(int(address), right)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+		vm.objects.release(previous)
+
+	case LoadSt:
+		index, err := vm.fetch(opCode.Name)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		value, err := vm.loadVariable(int(index))
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(value)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case TStore:
+		key, errKey := vm.PopBytes(opCode)
+		value, errValue := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, errKey, errValue) {
+			return true, false
+		}
+
+		vm.storeTransient(key, value)
+
+	case TLoad:
+		key, err := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		value, err := vm.loadTransient(key)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(value)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case LoadLoc:
+		address, errArg := vm.fetch(opCode.Name)
+		callstackTos, errCallStack := vm.callStack.Peek()
+
+		if !vm.checkErrors(opCode.Name, errArg, errCallStack) {
+			return true, false
+		}
+
+		val, err := callstackTos.getVariable(int(address))
+
+		// Deprecated: an uninitialized local is loaded as empty value, strict mode rejects it
+		if err != nil && callstackTos.isDeclared(int(address)) && !vm.config.Strict {
+			val, err = []byte{}, nil
+		}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = vm.pushCopy(val)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case Address:
+		address := vm.context.GetAddress()
+		err := vm.evaluationStack.Push(address[:])
+
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case Issuer:
+		issuer := vm.context.GetIssuer()
+		err := vm.evaluationStack.Push(issuer[:])
+
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case Balance:
+		balance := uint64Bytes(vm.context.GetBalance())
+		vm.witness.record(WitnessBalance, vm.selfAddress(), 0, balance)
+
+		err := vm.evaluationStack.Push(vm.uint64Value(balance))
+
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	// BalanceOf pops a 64 byte address and pushes the balance of the account, which is 0 for unknown accounts
+	case BalanceOf:
+		balance, err := vm.popBalanceOf(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(vm.uint64Value(balance))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// Caller pushes the address of the immediate caller, which is a contract after cross-contract calls
+	case Caller:
+		caller := vm.caller()
+		err := vm.evaluationStack.Push(caller[:])
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	// IsSelf pops a 32 byte address and pushes true, if it is the address of the executing contract
+	case IsSelf:
+		address, err := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		if len(address) != 32 {
+			vm.pushError(opCode, newError(ErrInvalidAddress))
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(BoolToByteArray(vm.isSelf(address)))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// CodeSize pushes the size of the code of the executing contract
+	case CodeSize:
+		size := big.NewInt(int64(len(vm.contract)))
+
+		err := vm.evaluationStack.Push(SignedByteArrayConversion(*size))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// MetaField pushes the field of the metadata section selected by the argument, see MetadataName, or empty bytes,
+	// if the contract has no metadata
+	case MetaField:
+		index, err := vm.fetch(opCode.Name)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		field, err := vm.metadataField(index)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(field)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// CodeHash pushes the SHA3 hash of the code of the executing contract
+	case CodeHash:
+		code := vm.contract
+		if err := vm.chargeSizeGas(opCode, len(code)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err := vm.evaluationStack.Push(codeHash(code, true))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// ExtCodeSize pops a 32 byte address and pushes the size of the code of the account, 0 if it does not exist
+	case ExtCodeSize:
+		code, _, err := vm.popAccountCode(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		size := big.NewInt(int64(len(code)))
+		err = vm.evaluationStack.Push(SignedByteArrayConversion(*size))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// ExtCodeHash pops a 32 byte address and pushes the SHA3 hash of the code of the account
+	case ExtCodeHash:
+		code, exists, err := vm.popAccountCode(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		if err := vm.chargeSizeGas(opCode, len(code)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(codeHash(code, exists))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// ExtLoadSt pops an index and a 32 byte address and pushes the contract variable of the account. Other accounts
+	// are not cached, so every read is priced like a cold storage access and by the size of the value.
+	case ExtLoadSt:
+		value, err := vm.popExtVariable(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		if err := vm.chargeSizeGas(opCode, len(value)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(value)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// AccountExists pops a 32 byte address and pushes true, if the account exists
+	case AccountExists:
+		account, isSelf, accountContext, err := vm.popAccount(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		exists := isSelf || accountContext.AccountExists(account)
+		if !isSelf {
+			vm.witness.record(WitnessAccount, account, 0, BoolToByteArray(exists))
+		}
+		err = vm.evaluationStack.Push(BoolToByteArray(exists))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// IsContract pops a 32 byte address and pushes true, if the account exists and has contract code
+	case IsContract:
+		code, exists, err := vm.popAccountCode(opCode)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(BoolToByteArray(exists && len(code) > 0))
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+	// DelegateCode pops a 32 byte address and delegates the code of future executions to the account.
+	// Delegating to the contract itself removes the delegation.
+	case DelegateCode:
+		if err := vm.delegateCode(opCode); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	// RequireIssuer fails the execution, if the immediate caller is not the issuer of the contract
+	case RequireIssuer:
+		if vm.caller() != vm.context.GetIssuer() {
+			vm.pushError(opCode, newError(ErrNotIssuer))
+			return true, false
+		}
+
+	// CheckAndBumpNonce pops the expected nonce of the caller and fails the execution, if it is not the current nonce.
+	// The nonce is incremented, when the execution succeeds, so every nonce is accepted only once.
+	case CheckAndBumpNonce:
+		if err := vm.checkAndBumpNonce(opCode); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	// RequireBefore pops a deadline and fails the execution, if the block time is past the deadline
+	case RequireBefore:
+		if err := vm.requireBefore(opCode); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	// Origin pushes the address of the transaction signer
+	case Origin:
+		origin := vm.context.GetSender()
+		err := vm.evaluationStack.Push(origin[:])
+
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case CallVal:
+		value := uint64Bytes(vm.context.GetAmount())
+
+		err := vm.evaluationStack.Push(vm.uint64Value(value))
+
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	// CallData pushes the parameters of the transaction data, each prefixed by its length in bytes.
+	// The whole data is validated before the first parameter is pushed.
+	case CallData:
+		params, err := vm.callDataParams(vm.context.GetTransactionData())
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		gasCost := CallDataParamGas * uint64(len(params))
+		if vm.fee < gasCost {
+			vm.pushError(opCode, newError(ErrOutOfGas))
+			return true, false
+		}
+		vm.fee -= gasCost
+
+		for _, param := range params {
+			err := vm.evaluationStack.Push(param)
+			if !vm.checkErrors(opCode.Name, err) {
+				return true, false
+			}
+		}
+
+	case NewMap:
+		m := CreateMap()
+
+		err = vm.pushContainer(m)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	// NewMapFrom n pops n entries, each pushed as value and key, and pushes a map of them
+	case NewMapFrom:
+		n, err := vm.fetch(opCode.Name)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		if err := vm.newMapFrom(opCode, int(n)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case MapHasKey:
+		mba, err := vm.popContainerView(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		m, err := MapFromByteArray(mba)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		k, err := vm.PopBytes(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		result, err := m.MapContainsKey(k)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		vm.evaluationStack.Push(BoolToByteArray(result))
+
+	case MapGetVal:
+		mapAsByteArray, err := vm.popContainerView(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		k, err := vm.PopBytes(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		m, err := MapFromByteArray(mapAsByteArray)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		v, err := m.GetVal(k)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = vm.evaluationStack.Push(append([]byte{}, v...))
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case MapSetVal:
+		mapAsByteArray, err := vm.popContainer(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		m, err := MapFromByteArray(mapAsByteArray)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		k, err := vm.PopBytes(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		v, err := vm.PopBytes(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		hasKey, err := m.MapContainsKey(k)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		if hasKey {
+			err = m.SetVal(k, v)
+		} else {
+			err = m.Append(k, v)
+		}
+
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = vm.chargeSizeGas(opCode, len(m))
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = vm.pushContainer(m)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case MapRemove:
+		mapAsByteArray, err := vm.popContainer(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		k, err := vm.PopBytes(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		m, err := MapFromByteArray(mapAsByteArray)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = m.Remove(k)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = vm.pushContainer(m)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	// MapMerge pops two maps and pushes their union, the values of the map on top win for keys in both maps
+	case MapMerge:
+		if err := vm.mapMerge(opCode); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	// MapSetMany n pops a map and n entries, each pushed as value and key, and sets them in the map
+	case MapSetMany:
+		n, err := vm.fetch(opCode.Name)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		if err := vm.mapSetMany(opCode, int(n)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case NewArr:
+		length, err := vm.PopUnsignedBigInt(opCode)
+
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		if !length.IsUint64() || length.Uint64() > uint64(UINT16_MAX) {
+			vm.pushError(opCode, newError(ErrArraySizeOverflow))
+			return true, false
+		}
+
+		// Every element is initialized with one byte and its size of two bytes
+		err = vm.chargeSizeGas(opCode, 3+3*int(length.Uint64()))
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		a := NewArray()
+
+		for i := big.NewInt(0); i.Cmp(&length) == -1; i.Add(i, big.NewInt(1)) {
+			err := a.Append([]byte{0})
+			if err != nil {
+				vm.pushError(opCode, err)
+				return true, false
+			}
+		}
+
+		err = vm.pushContainer(a)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+	// NewArrFrom n pops n elements and pushes an array of them in the order, in which they were pushed
+	case NewArrFrom:
+		n, err := vm.fetch(opCode.Name)
+		if !vm.checkErrors(opCode.Name, err) {
+			return true, false
+		}
+
+		if err := vm.newArrayFrom(opCode, int(n)); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case ArrAppend:
+		a, aerr := vm.popContainer(opCode)
+		v, verr := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, verr, aerr) {
+			return true, false
+		}
+
+		arr, err := ArrayFromByteArray(a)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		err = arr.Append(v)
+		if err != nil {
+			vm.pushError(opCode, newError(ErrInvalidArgumentSize))
+			return true, false
+		}
+
+		err = vm.pushContainer(arr)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+	case ArrInsert:
+		a, err := vm.popContainer(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		i, err := vm.PopUnsignedBigInt(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		element, err := vm.PopBytes(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		arr, err := ArrayFromByteArray(a)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		index, err := BigIntToUInt16(i)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+
+		size, err := arr.GetSize()
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			a := NewArray()
+		if index >= size {
+			vm.pushError(opCode, newError(ErrIndexOutOfBounds))
+			return true, false
+		}
 
-			for i := big.NewInt(0); i.Cmp(&length) == -1; i.Add(i, big.NewInt(1)) {
-				err := a.Append([]byte{0})
-				if err != nil {
-					_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-					return false
-				}
-			}
+		err = arr.Insert(index, element)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(a)
-			if err != nil {
-				_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
-		case ArrAppend:
-			a, aerr := vm.PopBytes(opCode)
-			v, verr := vm.PopBytes(opCode)
-			if !vm.checkErrors(opCode.Name, verr, aerr) {
-				return false
-			}
+		err = vm.chargeSizeGas(opCode, len(arr))
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			arr, err := ArrayFromByteArray(a)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.pushContainer(arr)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			err = arr.Append(v)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": Invalid argument size of ARRAPPEND"))
-				return false
-			}
+	case ArrRemove:
+		a, err := vm.popContainer(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(arr)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		i, err := vm.PopUnsignedBigInt(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-		case ArrInsert:
-			a, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		index, err := BigIntToUInt16(i)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			i, err := vm.PopUnsignedBigInt(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		arr, err := ArrayFromByteArray(a)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			element, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = arr.Remove(index)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			arr, err := ArrayFromByteArray(a)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.pushContainer(arr)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			index, err := BigIntToUInt16(i)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+	case ArrAt:
+		a, err := vm.popContainerView(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			size, err := arr.GetSize()
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		i, err := vm.PopUnsignedBigInt(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			if index >= size {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": Index out of bounds"))
-				return false
-			}
+		index, err := BigIntToUInt16(i)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			err = arr.Insert(index, element)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		arr, err := ArrayFromByteArray(a)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(arr)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		element, err := arr.At(index)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-		case ArrRemove:
-			a, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.evaluationStack.Push(append([]byte{}, element...))
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+	case ArrLen:
+		a, err := vm.popContainerView(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			i, err := vm.PopUnsignedBigInt(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		arr, err := ArrayFromByteArray(a)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			index, err := BigIntToUInt16(i)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		length, err := arr.GetSize()
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+		lengthBigInt := UInt16ToBigInt(length)
+		lengthBytes := BigIntToByteArray(lengthBigInt)
 
-			arr, err := ArrayFromByteArray(a)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.evaluationStack.Push(lengthBytes)
 
-			err = arr.Remove(index)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(arr)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+	// ArrFill pops an array and a value and sets every element of the array to the value
+	case ArrFill:
+		if err := vm.arrFill(opCode); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-		case ArrAt:
-			a, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+	// ArrResize pops an array and a size and truncates the array or appends elements initialized like NewArr
+	case ArrResize:
+		if err := vm.arrResize(opCode); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			i, err := vm.PopUnsignedBigInt(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+	case NewStr:
+		sizeBytes, err := vm.fetchMany(opCode.Name, 2)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			index, err := BigIntToUInt16(i)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		size, err := ByteArrayToUI16(sizeBytes)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			arr, err := ArrayFromByteArray(a)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		str := newStruct(size)
+		err = vm.pushContainer(str)
+		if err != nil {
+			return true, false
+		}
+	case StoreFld:
+		indexBytes, indexErr := vm.fetchMany(opCode.Name, 2)
+		element, elementErr := vm.PopBytes(opCode)
+		structBytes, structErr := vm.popContainer(opCode)
 
-			element, err := arr.At(index)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		if !vm.checkErrors(opCode.Name, structErr, indexErr, elementErr) {
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(element)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
-		case ArrLen:
-			a, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		str, structErr := structFromByteArray(structBytes, vm.structLayouts())
+		index, indexErr := ByteArrayToUI16(indexBytes)
+		if !vm.checkErrors(opCode.Name, structErr, indexErr) {
+			return true, false
+		}
 
-			arr, err := ArrayFromByteArray(a)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err := str.storeField(index, element)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+		err = vm.pushContainer(str)
+		if err != nil {
+			return true, false
+		}
+	case LoadFld:
+		indexBytes, indexErr := vm.fetchMany(opCode.Name, 2)
+		structBytes, structErr := vm.popContainerView(opCode)
 
-			length, err := arr.GetSize()
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
-			lengthBigInt := UInt16ToBigInt(length)
-			lengthBytes := BigIntToByteArray(lengthBigInt)
+		if !vm.checkErrors(opCode.Name, structErr, indexErr) {
+			return true, false
+		}
 
-			err = vm.evaluationStack.Push(lengthBytes)
+		str, structErr := structFromByteArray(structBytes, vm.structLayouts())
+		index, indexErr := ByteArrayToUI16(indexBytes)
+		if !vm.checkErrors(opCode.Name, structErr, indexErr) {
+			return true, false
+		}
 
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
-		case NewStr:
-			sizeBytes, err := vm.fetchMany(opCode.Name, 2)
-			if err != nil {
-				vm.pushError(opCode, err)
-				return false
-			}
+		element, err := str.loadField(index)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+		err = vm.evaluationStack.Push(append([]byte{}, element...))
+		if err != nil {
+			return true, false
+		}
 
-			size, err := ByteArrayToUI16(sizeBytes)
-			if err != nil {
-				vm.pushError(opCode, err)
-				return false
-			}
+	// ArrFieldAt pops an array of structs and an index and pushes the field of the struct at the index
+	case ArrFieldAt:
+		fieldBytes, fieldErr := vm.fetchMany(opCode.Name, 2)
+		arrayBytes, arrayErr := vm.popContainerView(opCode)
+		i, indexErr := vm.PopUnsignedBigInt(opCode)
+		if !vm.checkErrors(opCode.Name, fieldErr, arrayErr, indexErr) {
+			return true, false
+		}
 
-			str := newStruct(size)
-			err = vm.evaluationStack.Push(str)
-			if err != nil {
-				return false
-			}
-		case StoreFld:
-			indexBytes, indexErr := vm.fetchMany(opCode.Name, 2)
-			element, elementErr := vm.PopBytes(opCode)
-			structBytes, structErr := vm.PopBytes(opCode)
+		arr, arrayErr := ArrayFromByteArray(arrayBytes)
+		index, indexErr := BigIntToUInt16(i)
+		field, fieldErr := ByteArrayToUI16(fieldBytes)
+		if !vm.checkErrors(opCode.Name, arrayErr, indexErr, fieldErr) {
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, structErr, indexErr, elementErr) {
-				return false
-			}
+		element, err := loadArrayField(&arr, index, field, vm.structLayouts())
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			str, structErr := structFromByteArray(structBytes)
-			index, indexErr := ByteArrayToUI16(indexBytes)
-			if !vm.checkErrors(opCode.Name, structErr, indexErr) {
-				return false
-			}
+		err = vm.evaluationStack.Push(append([]byte{}, element...))
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			err := str.storeField(index, element)
-			if err != nil {
-				vm.pushError(opCode, err)
-				return false
-			}
-			err = vm.evaluationStack.Push(str)
-			if err != nil {
-				return false
-			}
-		case LoadFld:
-			indexBytes, indexErr := vm.fetchMany(opCode.Name, 2)
-			structBytes, structErr := vm.PopBytes(opCode)
+	// ArrFieldStore pops an array of structs, an index and a value and sets the field of the struct at the index
+	case ArrFieldStore:
+		fieldBytes, fieldErr := vm.fetchMany(opCode.Name, 2)
+		arrayBytes, arrayErr := vm.popContainer(opCode)
+		i, indexErr := vm.PopUnsignedBigInt(opCode)
+		element, elementErr := vm.PopBytes(opCode)
+		if !vm.checkErrors(opCode.Name, fieldErr, arrayErr, indexErr, elementErr) {
+			return true, false
+		}
 
-			if !vm.checkErrors(opCode.Name, structErr, indexErr) {
-				return false
-			}
+		arr, arrayErr := ArrayFromByteArray(arrayBytes)
+		index, indexErr := BigIntToUInt16(i)
+		field, fieldErr := ByteArrayToUI16(fieldBytes)
+		if !vm.checkErrors(opCode.Name, arrayErr, indexErr, fieldErr) {
+			return true, false
+		}
 
-			str, structErr := structFromByteArray(structBytes)
-			index, indexErr := ByteArrayToUI16(indexBytes)
-			if !vm.checkErrors(opCode.Name, structErr, indexErr) {
-				return false
-			}
+		err := storeArrayField(&arr, index, field, element, vm.structLayouts())
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			element, err := str.loadField(index)
-			if err != nil {
-				vm.pushError(opCode, err)
-				return false
-			}
-			err = vm.evaluationStack.Push(element)
-			if err != nil {
-				return false
-			}
-		case SHA3:
-			right, err := vm.PopBytes(opCode)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.pushContainer(arr)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
+	case SHA3:
+		right, err := vm.PopBytes(opCode)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-			hasher := sha3.New256()
-			hasher.Write(right)
-			hash := hasher.Sum(nil)
+		hasher := sha3.New256()
+		hasher.Write(right)
+		hash := hasher.Sum(nil)
 
-			err = vm.evaluationStack.Push(hash)
-			if err != nil {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
-				return false
-			}
+		err = vm.evaluationStack.Push(hash)
+		if err != nil {
+			vm.pushError(opCode, err)
+			return true, false
+		}
 
-		case CheckSig:
-			publicKeySig, errArg1 := vm.PopBytes(opCode)
-			hash, errArg2 := vm.PopBytes(opCode)
+	case CheckSig:
+		publicKeySig, errArg1 := vm.PopBytes(opCode)
+		hash, errArg2 := vm.PopBytes(opCode)
 
-			if !vm.checkErrors(opCode.Name, errArg1, errArg2) {
-				return false
-			}
+		if !vm.checkErrors(opCode.Name, errArg1, errArg2) {
+			return true, false
+		}
 
-			if len(publicKeySig) != 64 {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": Not a valid address"))
-				return false
-			}
+		if len(publicKeySig) != 64 {
+			vm.pushError(opCode, newError(ErrInvalidAddress))
+			return true, false
+		}
 
-			if len(hash) != 32 {
-				vm.evaluationStack.Push([]byte(opCode.Name + ": Not a valid hash"))
-				return false
-			}
+		if len(hash) != 32 {
+			vm.pushError(opCode, newError(ErrInvalidHash))
+			return true, false
+		}
 
-			pubKey1Sig1, pubKey2Sig1 := new(big.Int), new(big.Int)
-			r, s := new(big.Int), new(big.Int)
+		pubKey1Sig1, pubKey2Sig1 := new(big.Int), new(big.Int)
+		r, s := new(big.Int), new(big.Int)
 
-			pubKey1Sig1.SetBytes(publicKeySig[:32])
-			pubKey2Sig1.SetBytes(publicKeySig[32:])
+		pubKey1Sig1.SetBytes(publicKeySig[:32])
+		pubKey2Sig1.SetBytes(publicKeySig[32:])
 
-			sig1 := vm.context.GetSig1()
-			r.SetBytes(sig1[:32])
-			s.SetBytes(sig1[32:])
+		sig1 := vm.context.GetSig1()
+		r.SetBytes(sig1[:32])
+		s.SetBytes(sig1[32:])
 
-			pubKey := ecdsa.PublicKey{elliptic.P256(), pubKey1Sig1, pubKey2Sig1}
+		pubKey := ecdsa.PublicKey{Curve: elliptic.P256(), X: pubKey1Sig1, Y: pubKey2Sig1}
 
-			result := ecdsa.Verify(&pubKey, hash, r, s)
-			vm.evaluationStack.Push(BoolToByteArray(result))
+		result := ecdsa.Verify(&pubKey, hash, r, s)
+		vm.evaluationStack.Push(BoolToByteArray(result))
 
-		case ErrHalt:
-			return false
+	case ErrHalt:
+		return true, false
 
-		case Halt:
-			return true
+	case Halt:
+		if err := vm.commit(); err != nil {
+			vm.pushError(opCode, err)
+			return true, false
 		}
+		return true, true
 	}
+	return false, false
 }
 
 func (vm *VM) fetch(errorLocation string) (element byte, err error) {
@@ -1373,16 +2792,17 @@ func (vm *VM) fetch(errorLocation string) (element byte, err error) {
 		vm.pc++
 		return vm.code[tempPc], nil
 	}
-	return 0, errors.New("Instruction set out of bounds")
+	return 0, newError(ErrInstructionSetOutOfBounds)
 }
 
+// fetchMany returns a copy of the next bytes of the code, so instructions modifying their operands cannot modify the code
 func (vm *VM) fetchMany(errorLocation string, argument int) (elements []byte, err error) {
 	tempPc := vm.pc
 	if len(vm.code)-tempPc > argument {
 		vm.pc += argument
-		return vm.code[tempPc : tempPc+argument], nil
+		return append([]byte{}, vm.code[tempPc:tempPc+argument]...), nil
 	}
-	return []byte{}, errors.New("Instruction set out of bounds")
+	return []byte{}, newError(ErrInstructionSetOutOfBounds)
 }
 
 func (vm *VM) checkErrors(errorLocation string, errors ...error) bool {
@@ -1399,40 +2819,136 @@ func (vm *VM) pushError(opCode OpCode, err error) {
 	_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
 }
 
-// PopBytes pops bytes from the evaluation stack.
+func (vm *VM) pushExecError(err error) {
+	_ = vm.evaluationStack.Push([]byte("vm.exec(): " + err.Error()))
+}
+
+// chargeSizeGas charges the gas factor of the opcode for every started 64 bytes of the given size.
+func (vm *VM) chargeSizeGas(opCode OpCode, size int) error {
+	gasCost := opCode.GasFactor * uint64((size+64-1)/64)
+	if vm.fee < gasCost {
+		return newError(ErrOutOfGas)
+	}
+
+	vm.fee -= gasCost
+	return nil
+}
+
+// chargeImmediateGas charges the gas factor per byte of the immediate argument of a push opcode, so large constants
+// are priced by their size
+func (vm *VM) chargeImmediateGas(opCode OpCode, size int) error {
+	gasCost := opCode.GasFactor * uint64(size)
+	if vm.fee < gasCost {
+		return newError(ErrOutOfGas)
+	}
+
+	vm.fee -= gasCost
+	return nil
+}
+
+// PopBytes pops bytes from the evaluation stack. A container is taken from the object table.
 func (vm *VM) PopBytes(opCode OpCode) (elements []byte, err error) {
-	bytes, err := vm.evaluationStack.Pop()
+	element, err := vm.popValue(opCode)
 	if err != nil {
 		return nil, err
 	}
 
-	elementSize := (len(bytes) + 64 - 1) / 64
+	bytes, _ := vm.objects.take(element)
+	return bytes, nil
+}
+
+// chargePopGas charges the gas factor for every started 64 bytes of a popped element
+func (vm *VM) chargePopGas(opCode OpCode, size int) error {
+	elementSize := (size + 64 - 1) / 64
 
-	gasCost := opCode.gasFactor * uint64(elementSize)
-	if int64(vm.fee-gasCost) < 0 {
-		return nil, errors.New("Out of gas")
+	gasCost := opCode.GasFactor * uint64(elementSize)
+	if vm.fee < gasCost {
+		return newError(ErrOutOfGas)
 	}
 
 	vm.fee -= gasCost
+	return nil
+}
 
-	return bytes, nil
+// popBool pops a boolean from the evaluation stack, which has to be exactly one byte of value 0 or 1.
+func (vm *VM) popBool(opCode OpCode) (bool, error) {
+	bytes, err := vm.PopBytes(opCode)
+	if err != nil {
+		return false, err
+	}
+
+	if len(bytes) != 1 || bytes[0] > 1 {
+		return false, newError(ErrInvalidBool, bytes)
+	}
+	return ByteArrayToBool(bytes), nil
 }
 
-// PopSignedBigInt pops bytes from evaluation stack and convert it to a big integer with sign.
-func (vm *VM) PopSignedBigInt(opCode OpCode) (bigInt big.Int, err error) {
-	bytes, err := vm.evaluationStack.Pop()
+// callDataParams splits the transaction data into its parameters and checks them against the limits
+func (vm *VM) callDataParams(td []byte) ([][]byte, error) {
+	var params [][]byte
+	for i := 0; i < len(td); {
+		length := int(td[i])
+		if length == 0 {
+			return nil, newError(ErrEmptyCallDataParam, len(params))
+		}
+		if length > vm.callDataLimits.maxParamSize {
+			return nil, newError(ErrCallDataParamSize, len(params), vm.callDataLimits.maxParamSize)
+		}
+		if len(params) == vm.callDataLimits.maxParams {
+			return nil, newError(ErrTooManyCallDataParams, vm.callDataLimits.maxParams)
+		}
+		if len(td)-i-1 < length {
+			return nil, newError(ErrIndexOutOfBounds)
+		}
+
+		params = append(params, td[i+1:i+length+1])
+		i += length + 1
+	}
+	return params, nil
+}
+
+// popUint64 pops an 8 byte little endian value, as pushed by Balance and CallVal
+func (vm *VM) popUint64(opCode OpCode) (uint64, error) {
+	bytes, err := vm.PopBytes(opCode)
 	if err != nil {
-		return *big.NewInt(0), err
+		return 0, err
+	}
+
+	if len(bytes) != 8 {
+		return 0, newError(ErrInvalidArgumentSize)
 	}
+	return binary.LittleEndian.Uint64(bytes), nil
+}
 
-	elementSize := (len(bytes) + 64 - 1) / 64
+// popAmount pops a coin amount in the representation of the bytecode version, see uint64Value
+func (vm *VM) popAmount(opCode OpCode) (uint64, error) {
+	if vm.bytecodeVersion < BytecodeVersion3 {
+		return vm.popUint64(opCode)
+	}
 
-	gasCost := opCode.gasFactor * uint64(elementSize)
-	if int64(vm.fee-gasCost) < 0 {
-		return *big.NewInt(0), errors.New("Out of gas")
+	bigInt, err := vm.PopSignedBigInt(opCode)
+	if err != nil {
+		return 0, err
 	}
 
-	vm.fee -= gasCost
+	if bigInt.Sign() < 0 || !bigInt.IsUint64() {
+		return 0, newError(ErrInvalidAmount)
+	}
+	return bigInt.Uint64(), nil
+}
+
+func uint64Bytes(value uint64) []byte {
+	bytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(bytes, value)
+	return bytes
+}
+
+// PopSignedBigInt pops bytes from evaluation stack and convert it to a big integer with sign.
+func (vm *VM) PopSignedBigInt(opCode OpCode) (bigInt big.Int, err error) {
+	bytes, err := vm.PopBytes(opCode)
+	if err != nil {
+		return *big.NewInt(0), err
+	}
 
 	result, err := SignedBigIntConversion(bytes, err)
 	return result, err
@@ -1440,27 +2956,68 @@ func (vm *VM) PopSignedBigInt(opCode OpCode) (bigInt big.Int, err error) {
 
 // PopUnsignedBigInt pops bytes from evaluation stack and convert it to an unsigned big integer.
 func (vm *VM) PopUnsignedBigInt(opCode OpCode) (bigInt big.Int, err error) {
-	bytes, err := vm.evaluationStack.Pop()
+	bytes, err := vm.PopBytes(opCode)
 	if err != nil {
 		return *big.NewInt(0), err
 	}
 
-	elementSize := (len(bytes) + 64 - 1) / 64
+	result, err := UnsignedBigIntConversion(bytes, err)
+	return result, err
+}
 
-	gasCost := opCode.gasFactor * uint64(elementSize)
-	if int64(vm.fee-gasCost) < 0 {
-		return *big.NewInt(0), errors.New("Out of gas")
+// Functions returns the functions declared in the function table of the contract.
+// Contracts without a function table declare no functions.
+func (vm *VM) Functions() ([]Function, error) {
+	contract, _, err := vm.loadCode()
+	if err != nil {
+		return nil, err
 	}
 
-	vm.fee -= gasCost
+	sections, err := ParseSections(contract)
+	return sections.Functions, err
+}
 
-	result, err := UnsignedBigIntConversion(bytes, err)
-	return result, err
+// IsGuardActive returns true if the contract has entered the re-entrancy guard and not exited it yet.
+func (vm *VM) IsGuardActive() bool {
+	return vm.guardActive
+}
+
+// ActivateGuard activates the re-entrancy guard before the execution starts.
+// It has to be called if the contract is re-entered while the guard of the calling execution is active,
+// so that EnterGuard fails deterministically.
+func (vm *VM) ActivateGuard() {
+	vm.guardActive = true
+}
+
+// uint64Value encodes an 8 byte little endian value of the context as the bytecode version requires
+func (vm *VM) uint64Value(value []byte) []byte {
+	if vm.bytecodeVersion < BytecodeVersion3 {
+		return value
+	}
+
+	bigInt := new(big.Int).SetUint64(binary.LittleEndian.Uint64(value))
+	return SignedByteArrayConversion(*bigInt)
+}
+
+// SetCallDataLimits sets the maximum number and the maximum size in bytes of the parameters pushed by CallData.
+func (vm *VM) SetCallDataLimits(maxParams int, maxParamSize int) {
+	vm.callDataLimits = callDataLimits{maxParams, maxParamSize}
+}
+
+// SetBytecodeVersion sets the bytecode version, which selects the semantics of deprecated opcodes.
+func (vm *VM) SetBytecodeVersion(version byte) {
+	vm.bytecodeVersion = version
+}
+
+// SetMaxIntegerSize sets the maximum size in bytes of integer results of arithmetic operations.
+func (vm *VM) SetMaxIntegerSize(size int) {
+	vm.maxIntegerSize = size
 }
 
 // PeekResult returns the element on top of the stack
 func (vm *VM) PeekResult() (element []byte, err error) {
-	return vm.evaluationStack.PeekBytes()
+	element, err = vm.evaluationStack.PeekBytes()
+	return vm.objects.resolve(element), err
 }
 
 // PeekEvalStack returns a copy of the complete evaluation stack
@@ -1469,19 +3026,58 @@ func (vm *VM) PeekEvalStack() [][]byte {
 	copiedStack := make([][]byte, len(evalStack))
 
 	for i := range evalStack {
-		copiedStack[i] = make([]byte, len(evalStack[i]))
-		copy(copiedStack[i], evalStack[i])
+		element := vm.objects.resolve(evalStack[i])
+		copiedStack[i] = make([]byte, len(element))
+		copy(copiedStack[i], element)
 	}
 	return copiedStack
 }
 
+// GetRemainingFee returns the fee, which has not been used by the execution.
+func (vm *VM) GetRemainingFee() uint64 {
+	return vm.fee
+}
+
 // GetErrorMsg peeks bytes from evaluation stack and returns the error message.
 func (vm *VM) GetErrorMsg() string {
 	tos, err := vm.evaluationStack.PeekBytes()
 	if err != nil {
 		return "Peek on empty Stack"
 	}
-	return string(tos)
+	return string(vm.objects.resolve(tos))
+}
+
+// exp calculates base ** exponent by square and multiply, the execution can be cancelled after every bit of the
+// exponent
+func (vm *VM) exp(base *big.Int, exponent *big.Int) (*big.Int, error) {
+	result := big.NewInt(1)
+	for i := exponent.BitLen() - 1; i >= 0; i-- {
+		if err := vm.checkCancelled(); err != nil {
+			return nil, err
+		}
+
+		result.Mul(result, result)
+		if exponent.Bit(i) == 1 {
+			result.Mul(result, base)
+		}
+	}
+	return result, nil
+}
+
+func (vm *VM) checkIntegerSize(value *big.Int) error {
+	if (value.BitLen()+7)/8 > vm.maxIntegerSize {
+		return &IntegerOverflowError{MaxSize: vm.maxIntegerSize}
+	}
+	return nil
+}
+
+// wordCount returns the number of started 64 byte words of the integer, but at least 1
+func wordCount(value *big.Int) int {
+	size := (value.BitLen() + 7) / 8
+	if size == 0 {
+		return 1
+	}
+	return (size + 64 - 1) / 64
 }
 
 type bigIntAction func(left *big.Int, right *big.Int)
@@ -1495,10 +3091,15 @@ func (vm *VM) evaluateBigIntOperation(opCode OpCode, exec bigIntAction) bool {
 	}
 
 	exec(&left, &right)
+	if err := vm.checkIntegerSize(&left); err != nil {
+		vm.pushError(opCode, err)
+		return false
+	}
+
 	err := vm.evaluationStack.Push(SignedByteArrayConversion(left))
 
 	if err != nil {
-		_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
+		vm.pushError(opCode, err)
 		return false
 	}
 	return true
@@ -1534,7 +3135,7 @@ func (vm *VM) evaluateRelationalComp(opCode OpCode, expectedResult ...int) bool
 
 	err := vm.evaluationStack.Push(BoolToByteArray(compResult))
 	if err != nil {
-		_ = vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
+		vm.pushError(opCode, err)
 		return false
 	}
 	return true
@@ -86,7 +86,7 @@ func TestVM_Exec_PushInt_OutOfBounds(t *testing.T) {
 	}
 
 	actual := string(tos)
-	expected := "pushint: Instruction set out of bounds"
+	expected := "pushint: instruction set out of bounds"
 
 	if actual != expected {
 		t.Errorf("Expected '%v' to be returned but got '%v'", expected, actual)
@@ -223,14 +223,14 @@ func TestVM_Exec_Push_InvalidLength(t *testing.T) {
 	assert.Assert(t, !isSuccess)
 
 	tos, _ := vm.evaluationStack.Pop()
-	assert.Equal(t, string(tos), "push: Instruction set out of bounds")
+	assert.Equal(t, string(tos), "push: instruction set out of bounds")
 }
 
-func TestVM_Exec_Addition(t *testing.T) {
+func TestVM_Exec_Exponent(t *testing.T) {
 	code := []byte{
-		PushInt, 1, 0, 125,
-		PushInt, 2, 0, 168, 22,
-		Add,
+		PushInt, 1, 0, 2,
+		PushInt, 1, 0, 5,
+		Exp,
 		Halt,
 	}
 
@@ -241,7 +241,7 @@ func TestVM_Exec_Addition(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := 43155
+	expected := 25
 	actual := ByteArrayToInt(tos)
 
 	if expected != actual {
@@ -249,11 +249,11 @@ func TestVM_Exec_Addition(t *testing.T) {
 	}
 }
 
-func TestVM_Exec_Subtraction(t *testing.T) {
+func TestVM_Exec_Big_Exponent(t *testing.T) {
 	code := []byte{
-		PushInt, 1, 0, 6,
-		PushInt, 1, 0, 3,
-		Sub,
+		PushInt, 1, 0, 8,
+		PushInt, 1, 0, 5,
+		Exp,
 		Halt,
 	}
 
@@ -264,7 +264,7 @@ func TestVM_Exec_Subtraction(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := 3
+	expected := 390625
 	actual := ByteArrayToInt(tos)
 
 	if expected != actual {
@@ -272,11 +272,11 @@ func TestVM_Exec_Subtraction(t *testing.T) {
 	}
 }
 
-func TestVM_Exec_SubtractionWithNegativeResults(t *testing.T) {
+func TestVM_Exec_Zero_Exponent(t *testing.T) {
 	code := []byte{
-		PushInt, 1, 0, 3,
-		PushInt, 1, 0, 6,
-		Sub,
+		PushInt, 1, 0, 0,
+		PushInt, 1, 0, 5,
+		Exp,
 		Halt,
 	}
 
@@ -287,108 +287,79 @@ func TestVM_Exec_SubtractionWithNegativeResults(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := -3
-	actual := ByteArrayToInt(tos[1:])
-
-	if tos[0] == 0x01 {
-		actual = actual * -1
-	}
+	expected := 1
+	actual := ByteArrayToInt(tos)
 
 	if expected != actual {
 		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
 	}
 }
 
-func TestVM_Exec_Multiplication(t *testing.T) {
+func TestVM_Exec_Exponent_Overflow(t *testing.T) {
 	code := []byte{
-		PushInt, 1, 0, 5,
+		PushInt, 2, 0, 0x08, 0x00, // 2048
 		PushInt, 1, 0, 2,
-		Mul,
+		Exp,
 		Halt,
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
-
-	tos, _ := vm.evaluationStack.Pop()
-
-	expected := 10
-	actual := ByteArrayToInt(tos)
-
-	if expected != actual {
-		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
-	}
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "exp: integer overflow, result exceeds 256 bytes")
 }
 
-func TestVM_Exec_Exponent(t *testing.T) {
+func TestVM_Exec_Multiplication_Overflow(t *testing.T) {
 	code := []byte{
-		PushInt, 1, 0, 2,
-		PushInt, 1, 0, 5,
-		Exp,
+		PushInt, 2, 0, 1, 0,
+		PushInt, 2, 0, 1, 0,
+		Mul,
 		Halt,
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
-
-	tos, _ := vm.evaluationStack.Pop()
-
-	expected := 25
-	actual := ByteArrayToInt(tos)
+	vm := NewTestVM(code)
+	vm.SetMaxIntegerSize(2)
+	isSuccess := vm.Exec(false)
 
-	if expected != actual {
-		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
-	}
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "mult: integer overflow, result exceeds 2 bytes")
 }
 
-func TestVM_Exec_Big_Exponent(t *testing.T) {
+func TestVM_Exec_Addition_Overflow(t *testing.T) {
 	code := []byte{
-		PushInt, 1, 0, 8,
-		PushInt, 1, 0, 5,
-		Exp,
+		PushInt, 1, 0, 255,
+		PushInt, 1, 0, 1,
+		Add,
 		Halt,
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
-
-	tos, _ := vm.evaluationStack.Pop()
-
-	expected := 390625
-	actual := ByteArrayToInt(tos)
+	vm := NewTestVM(code)
+	vm.SetMaxIntegerSize(1)
+	isSuccess := vm.Exec(false)
 
-	if expected != actual {
-		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
-	}
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "add: integer overflow, result exceeds 1 bytes")
 }
 
-func TestVM_Exec_Zero_Exponent(t *testing.T) {
+func TestVM_Exec_Multiplication_GasByOperandSize(t *testing.T) {
 	code := []byte{
-		PushInt, 1, 0, 0,
-		PushInt, 1, 0, 5,
-		Exp,
+		PushInt, 64, 0, 1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8,
+		1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8,
+		PushInt, 65, 0, 1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8,
+		1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8, 9,
+		Mul,
 		Halt,
 	}
 
-	vm := NewTestVM([]byte{})
+	vm := NewTestVM(code)
 	mc := NewMockContext(code)
+	mc.Fee = 300
 	vm.context = mc
-	vm.Exec(false)
-
-	tos, _ := vm.evaluationStack.Pop()
-
-	expected := 1
-	actual := ByteArrayToInt(tos)
+	isSuccess := vm.Exec(false)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
 
-	if expected != actual {
-		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
-	}
+	// 3 intrinsic gas, 2 pushes with 65 and 66 immediate bytes, mult price, pop gas for 65 and 66 bytes,
+	// 2 * 1 * 2 words for the product and 3 words of memory for the operands
+	assert.Equal(t, vm.fee, uint64(300-3-2-65-66-1-4-4-4-3*DefaultMemoryGasPrice))
 }
 
 func TestVM_Exec_Multiple_Exponent(t *testing.T) {
@@ -432,7 +403,7 @@ func TestVM_Exec_Negative_Exponent(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := "exp: Negative exponents are not allowed."
+	expected := "exp: negative exponents are not allowed"
 	actual := string(tos)
 
 	if expected != actual {
@@ -455,7 +426,7 @@ func TestVM_Exec_Exponent_Out_of_Gas(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := "exp: Out of gas"
+	expected := "exp: out of gas"
 	actual := string(tos)
 
 	if expected != actual {
@@ -463,26 +434,30 @@ func TestVM_Exec_Exponent_Out_of_Gas(t *testing.T) {
 	}
 }
 
-func TestVM_Exec_Modulo(t *testing.T) {
-	code := []byte{
-		PushInt, 1, 0, 5,
-		PushInt, 1, 0, 2,
-		Mod,
-		Halt,
+func TestVM_Exec_Exponent_Gas(t *testing.T) {
+	remainingFee := func(exponent byte) uint64 {
+		vm, isSuccess := execCode([]byte{PushInt, 1, 0, exponent, PushInt, 1, 0, 2, Exp, Halt})
+		assert.Assert(t, isSuccess, vm.GetErrorMsg())
+		return vm.GetRemainingFee()
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
-
-	tos, _ := vm.evaluationStack.Pop()
+	// Every multiplication after the first one is charged with the price of the opcode
+	assert.Equal(t, remainingFee(1)-remainingFee(5), 4*OpCodes[Exp].GasPrice)
+	assert.Equal(t, remainingFee(0), remainingFee(1))
+}
 
-	expected := 1
-	actual := ByteArrayToInt(tos)
+func TestVM_Exec_Exponent_BeyondInt64(t *testing.T) {
+	for _, base := range []byte{0, 1} {
+		code := []byte{
+			PushInt, 9, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0,
+			PushInt, 1, 0, base,
+			Exp,
+			Halt,
+		}
 
-	if expected != actual {
-		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
+		vm, isSuccess := execCode(code)
+		assert.Assert(t, !isSuccess)
+		assert.Equal(t, vm.GetErrorMsg(), "exp: out of gas")
 	}
 }
 
@@ -550,195 +525,618 @@ func TestVM_Exec_Negate_Error(t *testing.T) {
 	assert.Equal(t, string(tos), "neg: unable to negate 3")
 }
 
-func TestVM_Exec_Division(t *testing.T) {
-	code := []byte{
-		PushInt, 1, 0, 6,
-		PushInt, 1, 0, 2,
-		Div,
-		Halt,
+func TestVM_Exec_Negate_BytecodeVersion2(t *testing.T) {
+	tests := []struct {
+		code     []byte
+		expected []byte
+	}{
+		{[]byte{PushInt, 1, 0, 5, Neg, Halt}, []byte{1, 5}},
+		{[]byte{PushInt, 1, 1, 5, Neg, Halt}, []byte{0, 5}},
+		{[]byte{PushInt, 0, Neg, Halt}, []byte{0}},
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
+	for _, test := range tests {
+		vm := NewTestVM([]byte{})
+		vm.context = NewMockContext(test.code)
+		vm.SetBytecodeVersion(BytecodeVersion2)
 
-	tos, _ := vm.evaluationStack.Pop()
-
-	expected := 3
-	actual := ByteArrayToInt(tos)
+		isSuccess := vm.Exec(false)
+		assert.Assert(t, isSuccess)
 
-	if expected != actual {
-		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.expected...)
 	}
 }
 
-func TestVM_Exec_DivisionByZero(t *testing.T) {
-	code := []byte{
-		PushInt, 1, 0, 6,
-		PushInt, 1, 0, 0,
-		Div,
-		Halt,
+func TestVM_Exec_Not(t *testing.T) {
+	tests := []struct {
+		value    byte
+		expected byte
+	}{
+		{0, 1},
+		{1, 0},
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
-
-	result, err := vm.evaluationStack.Pop()
-	if err != nil {
-		t.Errorf("%v", err)
-	}
+	for _, test := range tests {
+		vm, isSuccess := execCode([]byte{PushBool, test.value, Not, Halt})
+		assert.Assert(t, isSuccess)
 
-	expected := "div: Division by Zero"
-	actual := string(result)
-	if actual != expected {
-		t.Errorf("Expected tos to be '%v' error message but was '%v'", expected, actual)
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.expected)
 	}
 }
 
-func TestVM_Exec_Eq(t *testing.T) {
-	code := []byte{
-		Push, 3, 1, 0, 6,
-		Push, 3, 1, 0, 6,
-		Eq,
-		Halt,
-	}
-
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
-
-	tos, err := vm.evaluationStack.Pop()
-	if err != nil {
-		t.Errorf("%v", err)
-	}
-
-	if !ByteArrayToBool(tos) {
-		t.Errorf("Actual value is %v, should be 1 after comparing 6 with 6", tos[0])
+func TestVM_Exec_BooleanOperations(t *testing.T) {
+	tests := []struct {
+		opCode   byte
+		left     byte
+		right    byte
+		expected byte
+	}{
+		{And, 0, 0, 0},
+		{And, 0, 1, 0},
+		{And, 1, 0, 0},
+		{And, 1, 1, 1},
+		{Or, 0, 0, 0},
+		{Or, 0, 1, 1},
+		{Or, 1, 0, 1},
+		{Or, 1, 1, 1},
+		{Xor, 0, 0, 0},
+		{Xor, 0, 1, 1},
+		{Xor, 1, 0, 1},
+		{Xor, 1, 1, 0},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode([]byte{PushBool, test.left, PushBool, test.right, test.opCode, Halt})
+		assert.Assert(t, isSuccess)
+
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.expected)
 	}
 }
 
-func TestVM_Exec_Neq(t *testing.T) {
-	code := []byte{
-		Push, 3, 1, 0, 6,
-		Push, 3, 1, 0, 5,
-		NotEq,
-		Halt,
-	}
-
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
+func TestVM_Exec_BooleanOperations_InvalidBool(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushBool, 1, Push, 1, 2, And, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "and: invalid bool value [2]")
 
-	tos, err := vm.evaluationStack.Pop()
-	if err != nil {
-		t.Errorf("%v", err)
-	}
+	vm, isSuccess = execCode([]byte{PushInt, 1, 0, 1, Not, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "not: invalid bool value [0 1]")
+}
 
-	if !ByteArrayToBool(tos) {
-		t.Errorf("Actual value is %v, should be 1 after comparing 6 with 5 to not be equal", tos[0])
+func TestVM_Exec_StringComparison(t *testing.T) {
+	tests := []struct {
+		left  string
+		right string
+		lt    byte
+		gt    byte
+		cmp   []byte
+	}{
+		{"abc", "abd", 1, 0, []byte{1, 1}},
+		{"abd", "abc", 0, 1, []byte{0, 1}},
+		{"abc", "abc", 0, 0, []byte{0}},
+		{"ab", "abc", 1, 0, []byte{1, 1}},
+		{"b", "abc", 0, 1, []byte{0, 1}},
+		{"", "a", 1, 0, []byte{1, 1}},
+		{"Z", "a", 1, 0, []byte{1, 1}},
+	}
+
+	for _, test := range tests {
+		push := []byte{PushStr, byte(len(test.left))}
+		push = append(push, test.left...)
+		push = append(push, PushStr, byte(len(test.right)))
+		push = append(push, test.right...)
+
+		vm, isSuccess := execCode(append(push, StrLt, Halt))
+		assert.Assert(t, isSuccess)
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.lt)
+
+		vm, isSuccess = execCode(append(push, StrGt, Halt))
+		assert.Assert(t, isSuccess)
+		tos, _ = vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.gt)
+
+		vm, isSuccess = execCode(append(push, StrCmp, Halt))
+		assert.Assert(t, isSuccess)
+		tos, _ = vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.cmp...)
 	}
 }
 
-func TestVM_Exec_Lt(t *testing.T) {
-	code := []byte{
-		PushInt, 1, 0, 4,
-		PushInt, 1, 0, 6,
-		Lt,
-		Halt,
+func TestVM_Exec_UnsignedComparison(t *testing.T) {
+	tests := []struct {
+		left     []byte
+		right    []byte
+		expected [4]byte // ult, ugt, ulte, ugte
+	}{
+		{[]byte{0x01}, []byte{0xff}, [4]byte{1, 0, 1, 0}},
+		{[]byte{0xff, 0x00}, []byte{0x01, 0xff}, [4]byte{0, 1, 0, 1}},
+		{[]byte{0x00, 0x00, 0x05}, []byte{0x05}, [4]byte{0, 0, 1, 1}},
+		{[]byte{0x00, 0xff}, []byte{0x01, 0x00}, [4]byte{1, 0, 1, 0}},
+		{[]byte{}, []byte{0x00}, [4]byte{0, 0, 1, 1}},
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
-
-	tos, err := vm.evaluationStack.Pop()
-
-	if err != nil {
-		t.Errorf("%v", err)
-	}
+	for _, test := range tests {
+		push := append([]byte{Push, byte(len(test.left))}, test.left...)
+		push = append(push, Push, byte(len(test.right)))
+		push = append(push, test.right...)
 
-	if !ByteArrayToBool(tos) {
-		t.Errorf("Actual value is %v, should be 1 after evaluating 4 < 6", tos[0])
+		for i, opCode := range []byte{ULt, UGt, ULtEq, UGtEq} {
+			vm, isSuccess := execCode(append(append([]byte{}, push...), opCode, Halt))
+			assert.Assert(t, isSuccess, vm.GetErrorMsg())
+			tos, _ := vm.evaluationStack.Pop()
+			assertBytes(t, tos, test.expected[i])
+		}
 	}
 }
 
-func TestVM_Exec_LtChar(t *testing.T) {
-	code := []byte{
-		PushChar, 0,
-		PushChar, 70,
-		Lt,
-		Halt,
-	}
+// Lt interprets the first byte as sign, ULt compares the whole magnitude
+func TestVM_Exec_UnsignedComparison_Hashes(t *testing.T) {
+	push := []byte{Push, 2, 0x01, 0x02, Push, 2, 0x00, 0x03}
 
-	vm, isSuccess := execCode(code)
+	vm, isSuccess := execCode(append(append([]byte{}, push...), Lt, Halt))
 	assert.Assert(t, isSuccess)
-
 	tos, _ := vm.evaluationStack.Pop()
 	assertBytes(t, tos, 1)
+
+	vm, isSuccess = execCode(append(append([]byte{}, push...), ULt, Halt))
+	assert.Assert(t, isSuccess)
+	tos, _ = vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0)
 }
 
-func TestVM_Exec_LtChar_Negative(t *testing.T) {
-	code := []byte{
-		PushChar, 70,
-		PushChar, 0,
-		Lt,
-		Halt,
-	}
+func TestVM_Exec_BytesToAddress(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushInt, 1, 0, 5, BytesToAddress, Halt})
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+	tos, _ := vm.evaluationStack.Pop()
+	assert.Equal(t, len(tos), 32)
+	assert.Equal(t, tos[31], byte(5))
 
-	vm, isSuccess := execCode(code)
-	assert.Assert(t, isSuccess)
+	vm, isSuccess = execCode([]byte{PushStr, 3, 'a', 'b', 'c', SHA3, BytesToAddress, Halt})
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+	hash, _ := vm.evaluationStack.Pop()
+	assert.Equal(t, len(hash), 32)
+
+	vm, isSuccess = execCode([]byte{PushStr, 3, 'a', 'b', 'c', SHA3, BytesToAddress, IsSelf, Halt})
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+}
 
+func TestVM_Exec_BytesToPubKey(t *testing.T) {
+	vm, isSuccess := execCode([]byte{Push, 2, 1, 2, BytesToPubKey, Halt})
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
 	tos, _ := vm.evaluationStack.Pop()
-	assertBytes(t, tos, 0)
+	assert.Equal(t, len(tos), 64)
+	assertBytes(t, tos[62:], 1, 2)
 }
 
-func TestVM_Exec_Gt(t *testing.T) {
-	code := []byte{
-		PushInt, 1, 0, 6,
-		PushInt, 1, 0, 4,
-		Gt,
-		Halt,
+func TestVM_Exec_BytesToAddress_Truncation(t *testing.T) {
+	code := append([]byte{Push, 33, 1}, make([]byte, 32)...)
+	vm, isSuccess := execCode(append(code, BytesToAddress, Halt))
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "bytestoaddress: value does not fit into 32 bytes")
+}
+
+func TestVM_Exec_IntToBytes(t *testing.T) {
+	tests := []struct {
+		code     []byte
+		expected []byte
+	}{
+		{[]byte{PushInt, 0, IntToBytes, Halt}, []byte{0}},
+		{[]byte{PushInt, 1, 0, 5, IntToBytes, Halt}, []byte{5}},
+		{[]byte{PushInt, 2, 0, 1, 0, IntToBytes, Halt}, []byte{1, 0}},
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
+	for _, test := range tests {
+		vm, isSuccess := execCode(test.code)
+		assert.Assert(t, isSuccess)
 
-	tos, err := vm.evaluationStack.Pop()
-	if err != nil {
-		t.Errorf("%v", err)
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.expected...)
 	}
+}
 
-	if !ByteArrayToBool(tos) {
-		t.Errorf("Actual value is %v, should be 1 after evaluating 6 > 4", tos[0])
-	}
+func TestVM_Exec_IntToBytes_Negative(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushInt, 1, 1, 5, IntToBytes, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "inttobytes: negative integers cannot be converted to bytes")
 }
 
-func TestVM_Exec_GtChar(t *testing.T) {
-	code := []byte{
-		PushChar, 70,
-		PushChar, 0,
-		Gt,
-		Halt,
+func TestVM_Exec_BytesToInt(t *testing.T) {
+	tests := []struct {
+		code     []byte
+		expected []byte
+	}{
+		{[]byte{Push, 1, 0, BytesToInt, Halt}, []byte{0}},
+		{[]byte{Push, 1, 255, BytesToInt, Halt}, []byte{0, 255}},
+		{[]byte{Push, 3, 0, 1, 0, BytesToInt, Halt}, []byte{0, 1, 0}},
+		{[]byte{PushInt, 2, 0, 1, 0, IntToBytes, BytesToInt, Halt}, []byte{0, 1, 0}},
 	}
 
-	vm, isSuccess := execCode(code)
-	assert.Assert(t, isSuccess)
+	for _, test := range tests {
+		vm, isSuccess := execCode(test.code)
+		assert.Assert(t, isSuccess)
 
-	tos, _ := vm.evaluationStack.Pop()
-	assertBytes(t, tos, 1)
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.expected...)
+	}
 }
 
-func TestVM_Exec_GtChar_Negative(t *testing.T) {
-	code := []byte{
-		PushChar, 0,
+func TestVM_Exec_BoolToInt(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushBool, 1, BoolToInt, Halt})
+	assert.Assert(t, isSuccess)
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0, 1)
+
+	vm, isSuccess = execCode([]byte{PushBool, 0, BoolToInt, Halt})
+	assert.Assert(t, isSuccess)
+	tos, _ = vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0)
+
+	vm, isSuccess = execCode([]byte{PushInt, 1, 0, 1, BoolToInt, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "booltoint: invalid bool value [0 1]")
+}
+
+func TestVM_Exec_CharToInt(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushChar, 'a', CharToInt, Halt})
+	assert.Assert(t, isSuccess)
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0, 97)
+
+	vm, isSuccess = execCode([]byte{PushStr, 2, 'a', 'b', CharToInt, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "chartoint: invalid ASCII code [97 98]")
+}
+
+func TestVM_Exec_Uint64ToInt(t *testing.T) {
+	vm, isSuccess := execCode([]byte{Push, 8, 0, 1, 0, 0, 0, 0, 0, 0, Uint64ToInt, Halt})
+	assert.Assert(t, isSuccess)
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0, 1, 0)
+
+	vm, isSuccess = execCode([]byte{Push, 8, 255, 255, 255, 255, 255, 255, 255, 255, Uint64ToInt, Halt})
+	assert.Assert(t, isSuccess)
+	tos, _ = vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0, 255, 255, 255, 255, 255, 255, 255, 255)
+
+	vm, isSuccess = execCode([]byte{Push, 2, 1, 0, Uint64ToInt, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "uint64toint: invalid argument size")
+}
+
+func TestVM_Exec_IntToUint64(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushInt, 2, 0, 1, 0, IntToUint64, Halt})
+	assert.Assert(t, isSuccess)
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0, 1, 0, 0, 0, 0, 0, 0)
+
+	vm, isSuccess = execCode([]byte{PushInt, 1, 1, 1, IntToUint64, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "inttouint64: negative integers cannot be converted to bytes")
+
+	vm, isSuccess = execCode([]byte{PushInt, 9, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, IntToUint64, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "inttouint64: integer does not fit into uint64")
+}
+
+func TestVM_Exec_Uint64Arithmetic(t *testing.T) {
+	max := []byte{Push, 8, 255, 255, 255, 255, 255, 255, 255, 255}
+	one := []byte{Push, 8, 1, 0, 0, 0, 0, 0, 0, 0}
+	two := []byte{Push, 8, 2, 0, 0, 0, 0, 0, 0, 0}
+
+	tests := []struct {
+		left     []byte
+		right    []byte
+		opCode   byte
+		expected []byte
+		err      string
+	}{
+		{one, two, Add64, []byte{3, 0, 0, 0, 0, 0, 0, 0}, ""},
+		{max, one, Add64, nil, "add64: integer does not fit into uint64"},
+		{two, one, Sub64, []byte{1, 0, 0, 0, 0, 0, 0, 0}, ""},
+		{one, two, Sub64, nil, "sub64: integer does not fit into uint64"},
+		{one, two, Cmp64, []byte{1, 1}, ""},
+		{two, two, Cmp64, []byte{0}, ""},
+		{max, two, Cmp64, []byte{0, 1}, ""},
+		{[]byte{PushInt, 1, 0, 1}, two, Add64, nil, "add64: invalid argument size"},
+	}
+
+	for _, test := range tests {
+		code := append(append(append([]byte{}, test.left...), test.right...), test.opCode, Halt)
+		vm, isSuccess := execCode(code)
+		if test.err != "" {
+			assert.Assert(t, !isSuccess)
+			assert.Equal(t, vm.GetErrorMsg(), test.err)
+			continue
+		}
+
+		assert.Assert(t, isSuccess, vm.GetErrorMsg())
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.expected...)
+	}
+}
+
+func TestVM_Exec_Add64BalanceAndCallVal(t *testing.T) {
+	mc := NewMockContext([]byte{Balance, CallVal, Add64, Halt})
+	mc.Balance = 300
+	mc.Amount = 2
+	mc.Fee = 100
+	vm := NewVM(mc)
+
+	assert.Assert(t, vm.Exec(false))
+	tos, _ := vm.evaluationStack.Pop()
+	assert.Equal(t, binary.LittleEndian.Uint64(tos), uint64(302))
+}
+
+func TestVM_Exec_AmountArithmetic(t *testing.T) {
+	max := []byte{Push, 8, 255, 255, 255, 255, 255, 255, 255, 255}
+	one := []byte{Push, 8, 1, 0, 0, 0, 0, 0, 0, 0}
+	two := []byte{Push, 8, 2, 0, 0, 0, 0, 0, 0, 0}
+
+	tests := []struct {
+		left     []byte
+		right    []byte
+		opCode   byte
+		expected []byte
+		err      string
+	}{
+		{one, two, AddAmount, []byte{3, 0, 0, 0, 0, 0, 0, 0}, ""},
+		{max, one, AddAmount, nil, "addamount: amount overflow"},
+		{two, one, SubAmount, []byte{1, 0, 0, 0, 0, 0, 0, 0}, ""},
+		{one, two, SubAmount, nil, "subamount: amount underflow"},
+		{max, max, SubAmount, []byte{0, 0, 0, 0, 0, 0, 0, 0}, ""},
+		{[]byte{PushInt, 1, 0, 1}, two, AddAmount, nil, "addamount: invalid argument size"},
+	}
+
+	for _, test := range tests {
+		code := append(append(append([]byte{}, test.left...), test.right...), test.opCode, Halt)
+		vm, isSuccess := execCode(code)
+		if test.err != "" {
+			assert.Assert(t, !isSuccess)
+			assert.Equal(t, vm.GetErrorMsg(), test.err)
+			continue
+		}
+
+		assert.Assert(t, isSuccess, vm.GetErrorMsg())
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.expected...)
+	}
+}
+
+func TestVM_Exec_CanonicalAmountArithmetic(t *testing.T) {
+	max := []byte{PushInt, 8, 0, 255, 255, 255, 255, 255, 255, 255, 255}
+	one := []byte{PushInt, 1, 0, 1}
+
+	tests := []struct {
+		left     []byte
+		right    []byte
+		opCode   byte
+		expected *big.Int
+		err      string
+	}{
+		{[]byte{Balance}, []byte{CallVal}, AddAmount, big.NewInt(302), ""},
+		{[]byte{Balance}, []byte{CallVal}, SubAmount, big.NewInt(298), ""},
+		{[]byte{CallVal}, []byte{Balance}, SubAmount, nil, "subamount: amount underflow"},
+		{max, one, AddAmount, nil, "addamount: amount overflow"},
+		{[]byte{PushInt, 1, 1, 1}, one, AddAmount, nil, "addamount: amount must be between 0 and 2^64-1"},
+		{[]byte{PushInt, 9, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}, one, SubAmount, nil,
+			"subamount: amount must be between 0 and 2^64-1"},
+	}
+
+	for _, test := range tests {
+		mc := NewMockContext(append(append(append([]byte{}, test.left...), test.right...), test.opCode, Halt))
+		mc.Balance = 300
+		mc.Amount = 2
+		mc.Fee = 100
+		vm := NewVM(mc)
+		vm.SetBytecodeVersion(BytecodeVersion3)
+
+		isSuccess := vm.Exec(false)
+		if test.err != "" {
+			assert.Assert(t, !isSuccess)
+			assert.Equal(t, vm.GetErrorMsg(), test.err)
+			continue
+		}
+
+		assert.Assert(t, isSuccess, vm.GetErrorMsg())
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, SignedByteArrayConversion(*test.expected)...)
+	}
+}
+
+func TestVM_Exec_CanonicalBalanceAndCallVal(t *testing.T) {
+	mc := NewMockContext([]byte{Balance, CallVal, Add, Halt})
+	mc.Balance = 300
+	mc.Amount = 2
+	mc.Fee = 100
+	vm := NewVM(mc)
+	vm.SetBytecodeVersion(BytecodeVersion3)
+
+	assert.Assert(t, vm.Exec(false))
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0, 1, 46)
+}
+
+func TestVM_Exec_MinMax(t *testing.T) {
+	tests := []struct {
+		left  []byte
+		right []byte
+		min   []byte
+		max   []byte
+	}{
+		{[]byte{PushInt, 1, 0, 5}, []byte{PushInt, 1, 0, 7}, []byte{0, 5}, []byte{0, 7}},
+		{[]byte{PushInt, 1, 0, 7}, []byte{PushInt, 1, 0, 5}, []byte{0, 5}, []byte{0, 7}},
+		{[]byte{PushInt, 1, 1, 5}, []byte{PushInt, 1, 0, 3}, []byte{1, 5}, []byte{0, 3}},
+		{[]byte{PushInt, 1, 1, 5}, []byte{PushInt, 1, 1, 7}, []byte{1, 7}, []byte{1, 5}},
+		{[]byte{PushInt, 0}, []byte{PushInt, 0}, []byte{0}, []byte{0}},
+	}
+
+	for _, test := range tests {
+		code := append(append([]byte{}, test.left...), test.right...)
+
+		vm, isSuccess := execCode(append(code, Min, Halt))
+		assert.Assert(t, isSuccess)
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.min...)
+
+		vm, isSuccess = execCode(append(code, Max, Halt))
+		assert.Assert(t, isSuccess)
+		tos, _ = vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.max...)
+	}
+}
+
+func TestVM_Exec_AbsSign(t *testing.T) {
+	tests := []struct {
+		value []byte
+		abs   []byte
+		sign  []byte
+	}{
+		{[]byte{PushInt, 1, 0, 5}, []byte{0, 5}, []byte{0, 1}},
+		{[]byte{PushInt, 1, 1, 5}, []byte{0, 5}, []byte{1, 1}},
+		{[]byte{PushInt, 0}, []byte{0}, []byte{0}},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode(append(append([]byte{}, test.value...), Abs, Halt))
+		assert.Assert(t, isSuccess)
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.abs...)
+
+		vm, isSuccess = execCode(append(append([]byte{}, test.value...), Sign, Halt))
+		assert.Assert(t, isSuccess)
+		tos, _ = vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.sign...)
+	}
+}
+
+func TestVM_Exec_Sqrt(t *testing.T) {
+	tests := []struct {
+		value    []byte
+		expected []byte
+	}{
+		{[]byte{PushInt, 0}, []byte{0}},
+		{[]byte{PushInt, 1, 0, 1}, []byte{0, 1}},
+		{[]byte{PushInt, 1, 0, 16}, []byte{0, 4}},
+		{[]byte{PushInt, 1, 0, 17}, []byte{0, 4}},
+		{[]byte{PushInt, 2, 0, 39, 16}, []byte{0, 100}},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode(append(append([]byte{}, test.value...), Sqrt, Halt))
+		assert.Assert(t, isSuccess)
+
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.expected...)
+	}
+}
+
+func TestVM_Exec_Sqrt_Negative(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushInt, 1, 1, 4, Sqrt, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "sqrt: square root of negative integers is not allowed")
+}
+
+func TestVM_Exec_Sqrt_GasByOperandSize(t *testing.T) {
+	code := []byte{PushInt, 65, 0}
+	for i := 0; i < 65; i++ {
+		code = append(code, 1)
+	}
+	code = append(code, Sqrt, Halt)
+
+	vm := NewTestVM(code)
+	mc := NewMockContext(code)
+	mc.Fee = 200
+	vm.context = mc
+	isSuccess := vm.Exec(false)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+	// 2 intrinsic gas, push with 66 immediate bytes, sqrt price, pop gas for 66 bytes, 2 * 2 words for the square root
+	// and 2 words of memory for the operand
+	assert.Equal(t, vm.fee, uint64(200-2-1-66-1-4-8-2*DefaultMemoryGasPrice))
+}
+
+func TestVM_Exec_Log2(t *testing.T) {
+	tests := []struct {
+		value    []byte
+		expected []byte
+	}{
+		{[]byte{PushInt, 1, 0, 1}, []byte{0}},
+		{[]byte{PushInt, 1, 0, 2}, []byte{0, 1}},
+		{[]byte{PushInt, 1, 0, 255}, []byte{0, 7}},
+		{[]byte{PushInt, 2, 0, 1, 0}, []byte{0, 8}},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode(append(append([]byte{}, test.value...), Log2, Halt))
+		assert.Assert(t, isSuccess)
+
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, test.expected...)
+	}
+}
+
+func TestVM_Exec_Log2_NonPositive(t *testing.T) {
+	vm, isSuccess := execCode([]byte{PushInt, 0, Log2, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "log2: logarithm of non-positive integers is not allowed")
+
+	vm, isSuccess = execCode([]byte{PushInt, 1, 1, 4, Log2, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "log2: logarithm of non-positive integers is not allowed")
+}
+
+func TestVM_Exec_LtChar(t *testing.T) {
+	code := []byte{
+		PushChar, 0,
+		PushChar, 70,
+		Lt,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 1)
+}
+
+func TestVM_Exec_LtChar_Negative(t *testing.T) {
+	code := []byte{
+		PushChar, 70,
+		PushChar, 0,
+		Lt,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0)
+}
+
+func TestVM_Exec_GtChar(t *testing.T) {
+	code := []byte{
+		PushChar, 70,
+		PushChar, 0,
+		Gt,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 1)
+}
+
+func TestVM_Exec_GtChar_Negative(t *testing.T) {
+	code := []byte{
+		PushChar, 0,
 		PushChar, 70,
 		Gt,
 		Halt,
@@ -904,20 +1302,30 @@ func TestVM_Exec_ShiftL_Max(t *testing.T) {
 		Halt,
 	}
 
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "shiftl: integer overflow, result exceeds 256 bytes")
+}
+
+func TestVM_Exec_ShiftL_MaxIntegerSize(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 1,
+		PushInt, 2, 0, 0x07, 0xff, // 2047
+		ShiftL,
+		Halt,
+	}
+
 	vm, isSuccess := execCode(code)
 	tos, _ := vm.evaluationStack.Pop()
 	assert.Assert(t, isSuccess, string(tos))
 
 	bigShift := big.NewInt(1)
-	bigShift.Lsh(bigShift, uint(4294967295))
+	bigShift.Lsh(bigShift, uint(2047))
 	expected := bigShift.Bytes() // without sign byte
 
 	actual := tos[1:] // remove sign byte, because it is 0
-	assert.Equal(t, len(actual), len(expected))
-
-	// DO NOT compare 536870913 bytes in a for loop. It will take extremely long
-	result := bytes.Compare(actual, expected)
-	assert.Equal(t, result, 0)
+	assert.Equal(t, len(actual), DefaultMaxIntegerSize)
+	assert.Equal(t, bytes.Compare(actual, expected), 0)
 }
 
 func TestVM_Exec_ShiftR(t *testing.T) {
@@ -1103,15 +1511,18 @@ func TestVM_Exec_Jmpfalse_Negative(t *testing.T) {
 	}
 }
 
-func TestVM_Exec_Jmp(t *testing.T) {
+func TestVM_Exec_Call(t *testing.T) {
 	code := []byte{
-		Push, 1, 3,
-		Jmp, 0, 14,
-		Push, 1, 4,
-		Add,
-		Push, 1, 15,
-		Add, // Jump here
+		PushInt, 1, 0, 10,
+		PushInt, 1, 0, 8,
+		Call, 0, 14, 2, 1,
 		Halt,
+		NoOp,
+		NoOp,
+		LoadLoc, 0, // Begin of called function at address 14
+		LoadLoc, 1,
+		Sub,
+		Ret,
 	}
 
 	vm := NewTestVM([]byte{})
@@ -1121,23 +1532,29 @@ func TestVM_Exec_Jmp(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := 3
+	expected := 2
 	actual := ByteArrayToInt(tos)
 
 	if expected != actual {
 		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
 	}
+
+	expected = 0
+	actual = vm.callStack.GetLength()
+	if expected != actual {
+		t.Errorf("After calling and returning, callStack lenght should be %v, but was %v", expected, actual)
+	}
 }
 
-func TestVM_Exec_Call(t *testing.T) {
+func TestVM_Exec_CallLocals(t *testing.T) {
 	code := []byte{
 		PushInt, 1, 0, 10,
 		PushInt, 1, 0, 8,
-		Call, 0, 14, 2, 1,
+		CallLocals, 0, 15, 2, 1, 2,
 		Halt,
 		NoOp,
 		NoOp,
-		LoadLoc, 0, // Begin of called function at address 14
+		LoadLoc, 0, // Begin of called function at address 15
 		LoadLoc, 1,
 		Sub,
 		Ret,
@@ -1164,6 +1581,44 @@ func TestVM_Exec_Call(t *testing.T) {
 	}
 }
 
+func TestVM_Exec_CallDepth(t *testing.T) {
+	code := []byte{
+		CallLocals, 0, 8, 0, 3, 0,
+		Halt,
+		NoOp,
+		CallLocals, 0, 16, 0, 3, 0, // Begin of function at address 8
+		Ret,
+		NoOp,
+		CallDepth, // Begin of nested function at address 16
+		ReturnAddressOf, 0,
+		ReturnAddressOf, 1,
+		Ret,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+
+	stack := vm.PeekEvalStack()
+	assert.Equal(t, len(stack), 3)
+	assertBytes(t, stack[0], 0, 2)
+	assertBytes(t, stack[1], 0, 14)
+	assertBytes(t, stack[2], 0, 6)
+}
+
+func TestVM_Exec_CallDepth_TopLevel(t *testing.T) {
+	vm, isSuccess := execCode([]byte{CallDepth, Halt})
+	assert.Assert(t, isSuccess)
+
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0)
+}
+
+func TestVM_Exec_ReturnAddressOf_OutOfBounds(t *testing.T) {
+	vm, isSuccess := execCode([]byte{ReturnAddressOf, 0, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "returnaddressof: index out of bounds")
+}
+
 func TestVM_Exec_CallRetEval(t *testing.T) {
 	code := []byte{
 		PushInt, 1, 0, 5,
@@ -1197,6 +1652,39 @@ func TestVM_Exec_CallRetEval(t *testing.T) {
 	}
 }
 
+func TestVM_Exec_CallLocals_RetEval(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 5,
+		PushInt, 1, 0, 10,
+		PushInt, 1, 0, 8,
+		CallLocals, 0, 20, 2, 1, 2,
+		Add,
+		Halt,
+		LoadLoc, 0, // Begin of called function at address 20
+		LoadLoc, 1,
+		Sub,
+		Ret,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+
+	tos, _ := vm.evaluationStack.Pop()
+
+	expected := 7
+	actual := ByteArrayToInt(tos)
+
+	if expected != actual {
+		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
+	}
+
+	expected = 0
+	actual = vm.callStack.GetLength()
+	if expected != actual {
+		t.Errorf("After calling and returning, callStack length should be %v, but was %v", expected, actual)
+	}
+}
+
 func TestVM_Exec_Callif_true(t *testing.T) {
 	code := []byte{
 		PushInt, 1, 0, 10,
@@ -1235,18 +1723,18 @@ func TestVM_Exec_Callif_true(t *testing.T) {
 	}
 }
 
-func TestVM_Exec_Callif_false(t *testing.T) {
+func TestVM_Exec_CallTrueLocals_true(t *testing.T) {
 	code := []byte{
 		PushInt, 1, 0, 10,
 		PushInt, 1, 0, 8,
 		PushInt, 1, 0, 10,
-		PushInt, 1, 0, 2,
+		PushInt, 1, 0, 10,
 		Eq,
-		CallTrue, 0, 26, 2, 1,
+		CallTrueLocals, 0, 26, 2, 1, 2,
 		Halt,
 		NoOp,
 		NoOp,
-		LoadLoc, 0, // Begin of called function at address 21
+		LoadLoc, 0, // Begin of called function at address 26
 		LoadLoc, 1,
 		Sub,
 		Ret,
@@ -1259,7 +1747,7 @@ func TestVM_Exec_Callif_false(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := 8
+	expected := 2
 	actual := ByteArrayToInt(tos)
 
 	if expected != actual {
@@ -1269,18 +1757,223 @@ func TestVM_Exec_Callif_false(t *testing.T) {
 	expected = 0
 	actual = vm.callStack.GetLength()
 	if expected != actual {
-		t.Errorf("After skipping callif, callStack lenght should be '%v', but was '%v'", expected, actual)
+		t.Errorf("After calling and returning, callStack lenght should be %v, but was %v", expected, actual)
 	}
 }
 
-func TestVM_Exec_TosSize(t *testing.T) {
+func TestVM_Exec_Callif_false(t *testing.T) {
 	code := []byte{
-		PushInt, 2, 10, 4, 5,
-		Size,
-		Halt,
-	}
-
-	vm := NewTestVM([]byte{})
+		PushInt, 1, 0, 10,
+		PushInt, 1, 0, 8,
+		PushInt, 1, 0, 10,
+		PushInt, 1, 0, 2,
+		Eq,
+		CallTrue, 0, 26, 2, 1,
+		Halt,
+		NoOp,
+		NoOp,
+		LoadLoc, 0, // Begin of called function at address 21
+		LoadLoc, 1,
+		Sub,
+		Ret,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	vm.context = mc
+	vm.Exec(false)
+
+	tos, _ := vm.evaluationStack.Pop()
+
+	expected := 8
+	actual := ByteArrayToInt(tos)
+
+	if expected != actual {
+		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
+	}
+
+	expected = 0
+	actual = vm.callStack.GetLength()
+	if expected != actual {
+		t.Errorf("After skipping callif, callStack lenght should be '%v', but was '%v'", expected, actual)
+	}
+}
+
+func TestVM_Exec_CallTrueLocals_false(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 10,
+		PushInt, 1, 0, 8,
+		PushInt, 1, 0, 10,
+		PushInt, 1, 0, 2,
+		Eq,
+		CallTrueLocals, 0, 27, 2, 1, 2,
+		Halt,
+		NoOp,
+		NoOp,
+		LoadLoc, 0, // Begin of called function at address 27
+		LoadLoc, 1,
+		Sub,
+		Ret,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	vm.context = mc
+	vm.Exec(false)
+
+	tos, _ := vm.evaluationStack.Pop()
+
+	expected := 8
+	actual := ByteArrayToInt(tos)
+
+	if expected != actual {
+		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
+	}
+
+	expected = 0
+	actual = vm.callStack.GetLength()
+	if expected != actual {
+		t.Errorf("After skipping callif, callStack lenght should be '%v', but was '%v'", expected, actual)
+	}
+}
+
+func TestVM_Exec_TailCall(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 10,
+		CallLocals, 0, 11, 1, 1, 1,
+		Halt,
+		LoadLoc, 0, // Begin of called function at address 11
+		PushInt, 0,
+		Eq,
+		JmpFalse, 0, 22,
+		LoadLoc, 0,
+		Ret,
+		LoadLoc, 0, // Recursion at address 22
+		PushInt, 1, 0, 1,
+		Sub,
+		TailCall, 0, 11, 1, 1, 1,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Fee = 1000
+	vm.context = mc
+	isSuccess := vm.Exec(false)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0)
+
+	expected := 0
+	actual := vm.callStack.GetLength()
+	if expected != actual {
+		t.Errorf("After tail calling and returning, callStack length should be %v, but was %v", expected, actual)
+	}
+}
+
+func TestVM_Exec_TailCall_OutsideFunction(t *testing.T) {
+	code := []byte{
+		TailCall, 0, 7, 0, 0, 0,
+		Halt,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "tailcall: peek() on empty call stack")
+}
+
+func TestVM_Exec_TailCall_ReturnTypesMismatch(t *testing.T) {
+	code := []byte{
+		CallLocals, 0, 7, 0, 1, 0,
+		Halt,
+		TailCall, 0, 13, 0, 2, 0, // Begin of called function at address 7
+		Ret,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "tailcall: number of return types does not match the current frame")
+}
+
+func TestVM_Exec_TailCall_NonEmptyFrame(t *testing.T) {
+	code := []byte{
+		CallLocals, 0, 7, 0, 1, 0,
+		Halt,
+		PushInt, 1, 0, 1, // Begin of called function at address 7
+		TailCall, 0, 17, 0, 1, 0,
+		Ret,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "tailcall: evaluation stack of the current frame is not empty")
+}
+
+func TestVM_Exec_CallFn(t *testing.T) {
+	hash := FunctionHash("sub")
+	code := append(NewFunctionTable([]Function{
+		{Hash: hash, Address: 15, NrOfArgs: 2, NrOfReturnTypes: 1, NrOfLocals: 2},
+	}), []byte{
+		PushInt, 1, 0, 10,
+		PushInt, 1, 0, 8,
+		CallFn, hash[0], hash[1], hash[2], hash[3], 2,
+		Halt,
+		LoadLoc, 0, // Begin of called function at address 15
+		LoadLoc, 1,
+		Sub,
+		Ret,
+	}...)
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 0, 2)
+	assert.Equal(t, vm.callStack.GetLength(), 0)
+}
+
+func TestVM_Exec_CallFn_ArgumentsMismatch(t *testing.T) {
+	hash := FunctionHash("sub")
+	code := append(NewFunctionTable([]Function{
+		{Hash: hash, Address: 8, NrOfArgs: 2, NrOfReturnTypes: 1, NrOfLocals: 2},
+	}), CallFn, hash[0], hash[1], hash[2], hash[3], 1, Halt, Ret)
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "callfn: number of arguments does not match the function declaration")
+}
+
+func TestVM_Exec_CallFn_NotFound(t *testing.T) {
+	code := []byte{
+		CallFn, 1, 2, 3, 4, 0,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "callfn: function not found")
+}
+
+func TestVM_Functions(t *testing.T) {
+	expected := []Function{
+		{Hash: FunctionHash("main"), Address: 1, NrOfArgs: 0, NrOfReturnTypes: 0, NrOfLocals: 0},
+	}
+	vm := NewTestVM(append(NewFunctionTable(expected), Halt, Ret))
+
+	functions, err := vm.Functions()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, functions, expected)
+}
+
+func TestVM_Exec_TosSize(t *testing.T) {
+	code := []byte{
+		PushInt, 2, 10, 4, 5,
+		Size,
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
 	vm.context = mc
 	vm.Exec(false)
@@ -1312,14 +2005,51 @@ func TestVM_Exec_CallExt(t *testing.T) {
 	vm.Exec(false)
 }
 
+func TestVM_Exec_Guard(t *testing.T) {
+	code := []byte{
+		EnterGuard,
+		PushInt, 1, 0, 1,
+		ExitGuard,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+	assert.Assert(t, !vm.IsGuardActive())
+}
+
+func TestVM_Exec_Guard_ReEntrant(t *testing.T) {
+	code := []byte{
+		EnterGuard,
+		Halt,
+	}
+
+	vm := NewTestVM(code)
+	vm.ActivateGuard()
+	isSuccess := vm.Exec(false)
+
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "enterguard: re-entrant call while guard is active")
+}
+
+func TestVM_Exec_Guard_ExitWithoutEnter(t *testing.T) {
+	code := []byte{
+		ExitGuard,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "exitguard: guard is not active")
+}
+
 func TestVM_Exec_StoreLoc(t *testing.T) {
 	code := []byte{
 		PushInt, 1, 0, 1, // local variable x = 1
 		PushInt, 1, 0, 2, // local variable y = 2
-		Call, 0, 14, 2, // Call function with 2 variables (x & y)
+		CallLocals, 0, 15, 2, 0, 2, // Call function with 2 variables (x & y)
 		Halt,
-		NoOp,
-		PushInt, 1, 0, 4, // Function starts here at byte 14
+		PushInt, 1, 0, 4, // Function starts here at byte 15
 		StoreLoc, 0, // Override local variable x with 4
 		PushInt, 1, 0, 5,
 		StoreLoc, 1, // override local variable y with 5
@@ -1337,45 +2067,80 @@ func TestVM_Exec_StoreLoc(t *testing.T) {
 	assertBytes(t, callstackTos.variables[1], 0, 5)
 }
 
-func TestVM_Exec_LoadSt(t *testing.T) {
+func TestVM_Exec_StoreLoc_OutOfBounds(t *testing.T) {
 	code := []byte{
-		LoadSt, 1,
-		LoadSt, 0,
-		LoadSt, 2,
+		CallLocals, 0, 7, 0, 0, 1,
 		Halt,
+		PushInt, 1, 0, 4, // Function starts here at byte 7
+		StoreLoc, 1,
+		Ret,
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	mc.ContractVariables = [][]byte{[]byte("Hi There!!"), {26}, {0}}
-	vm.context = mc
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "storeloc: local variable index 1 out of bounds, 1 locals declared")
+}
 
-	vm.Exec(false)
+func TestVM_Exec_StoreLoc_UndeclaredLocals(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 4,
+		Call, 0, 10, 1, 1, // Call does not declare the locals
+		Halt,
+		LoadLoc, 0, // Function starts here at byte 10
+		StoreLoc, 5,
+		LoadLoc, 5,
+		Ret,
+	}
 
-	expected := []byte{0}
-	actual, _ := vm.evaluationStack.Pop()
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+	assert.DeepEqual(t, vm.PeekEvalStack(), [][]byte{{0, 4}})
 
-	if !bytes.Equal(expected, actual) {
-		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
-	}
+	strict := NewVMWithConfig(NewMockContext([]byte{Call, 0, 6, 0, 1, Halt, LoadLoc, 200, Ret}), VMConfig{Strict: true})
+	assert.Assert(t, !strict.Exec(false))
+	assert.Equal(t, strict.GetErrorMsg(), "loadloc: local variable 200 is not initialized")
+}
 
-	result, err := vm.evaluationStack.Pop()
-	if err != nil {
-		t.Errorf("%v", err)
+func TestVM_Exec_LoadLoc_OutOfBounds(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 4,
+		CallLocals, 0, 11, 1, 1, 1,
+		Halt,
+		LoadLoc, 3, // Function starts here at byte 11
+		Ret,
 	}
 
-	expectedString := "Hi There!!"
-	actualString := string(result)
-	if expectedString != actualString {
-		t.Errorf("The String on the Stack should be '%v' but was %v", expectedString, actualString)
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "loadloc: local variable index 3 out of bounds, 1 locals declared")
+}
+
+func TestVM_Exec_LoadLoc_NotInitialized(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 4,
+		CallLocals, 0, 11, 1, 1, 2,
+		Halt,
+		LoadLoc, 1, // Function starts here at byte 11
+		Ret,
 	}
 
-	expected = []byte{26}
-	actual, _ = vm.evaluationStack.Pop()
+	vm := NewVMWithConfig(NewMockContext(code), VMConfig{Strict: true})
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "loadloc: local variable 1 is not initialized")
+}
 
-	if !bytes.Equal(expected, actual) {
-		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
+func TestVM_Exec_CallLocals_LessLocalsThanArguments(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 4,
+		PushInt, 1, 0, 5,
+		CallLocals, 0, 15, 2, 0, 1,
+		Halt,
+		Ret,
 	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "calllocals: number of locals cannot be less than number of arguments")
 }
 
 func TestVM_Exec_StoreSt(t *testing.T) {
@@ -1512,131 +2277,387 @@ func TestVM_Exec_Caller(t *testing.T) {
 	}
 }
 
-func TestVM_Exec_Callval(t *testing.T) {
+func TestVM_Exec_Caller_CrossContract(t *testing.T) {
+	code := []byte{
+		Caller,
+		Origin,
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.From = [32]byte{1, 2, 3}
+	mc.Caller = &[32]byte{4, 5, 6}
+	vm.context = mc
+
+	assert.Assert(t, vm.Exec(false))
+	origin, _ := vm.evaluationStack.Pop()
+	caller, _ := vm.evaluationStack.Pop()
+	assert.DeepEqual(t, origin, mc.From[:])
+	assert.DeepEqual(t, caller, mc.Caller[:])
+}
+
+func TestVM_Exec_RequireIssuer(t *testing.T) {
+	issuer := [32]byte{1, 2, 3}
+	tests := []struct {
+		from   [32]byte
+		caller *[32]byte
+		err    string
+	}{
+		{from: issuer},
+		{from: [32]byte{4, 5, 6}, err: "requireissuer: caller is not the issuer"},
+		// The issuer signed the transaction, but another contract calls
+		{from: issuer, caller: &[32]byte{4, 5, 6}, err: "requireissuer: caller is not the issuer"},
+		{from: [32]byte{4, 5, 6}, caller: &issuer},
+	}
+
+	for _, test := range tests {
+		vm := NewTestVM([]byte{})
+		mc := NewMockContext([]byte{RequireIssuer, PushInt, 1, 0, 1, Halt})
+		mc.Issuer = issuer
+		mc.From = test.from
+		mc.Caller = test.caller
+		vm.context = mc
+
+		isSuccess := vm.Exec(false)
+		if test.err == "" {
+			assert.Assert(t, isSuccess, vm.GetErrorMsg())
+		} else {
+			assert.Assert(t, !isSuccess)
+			assert.Equal(t, vm.GetErrorMsg(), test.err)
+		}
+	}
+}
+
+func TestVM_Exec_Origin(t *testing.T) {
+	code := []byte{
+		Caller,
+		Origin,
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.From = [32]byte{1, 2, 3}
+	vm.context = mc
+
+	// Without cross-contract call, the transaction signer is the immediate caller
+	assert.Assert(t, vm.Exec(false))
+	origin, _ := vm.evaluationStack.Pop()
+	caller, _ := vm.evaluationStack.Pop()
+	assert.DeepEqual(t, origin, mc.From[:])
+	assert.DeepEqual(t, caller, mc.From[:])
+}
+
+func TestVM_Exec_Callval(t *testing.T) {
+	code := []byte{
+		CallVal,
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Amount = uint64(100)
+	vm.context = mc
+
+	vm.Exec(false)
+	tos, _ := vm.evaluationStack.Pop()
+
+	if len(tos) != 8 {
+		t.Errorf("Expected TOS size to be 8, but got %v", len(tos))
+	}
+
+	result := binary.LittleEndian.Uint64(tos)
+
+	if result != 100 {
+		t.Errorf("Expected value to be 100, but got %v", result)
+	}
+}
+
+func TestVM_Exec_Calldata(t *testing.T) {
+	code := []byte{
+		CallData,
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Fee = 50
+
+	td := []byte{
+		1, 0x02,
+		1, 0x05,
+		4, 0x10, 0x12, 0x4, 0x12, // Function hash
+	}
+	mc.Data = td
+
+	vm.context = mc
+	vm.Exec(false)
+
+	functionHash, _ := vm.evaluationStack.Pop()
+
+	if !bytes.Equal(functionHash, td[5:]) {
+		t.Errorf("expected '%# x' but got '%# x'", td[5:], functionHash)
+	}
+
+	arg1, _ := vm.evaluationStack.Pop()
+	if !bytes.Equal(arg1, td[3:4]) {
+		t.Errorf("expected '%# x' but got '%# x'", td[3:4], arg1)
+	}
+
+	arg2, _ := vm.evaluationStack.Pop()
+	if !bytes.Equal(arg2, td[1:2]) {
+		t.Errorf("expected '%# x' but got '%# x'", td[1:2], arg2)
+	}
+}
+
+func TestVM_Exec_Calldata_Gas(t *testing.T) {
+	mc := NewMockContext([]byte{CallData, Halt})
+	mc.Data = []byte{1, 0x02, 1, 0x05, 1, 0x07}
+	mc.Fee = 50
+	vm := NewVM(mc)
+
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+	assert.Equal(t, vm.GetRemainingFee(), 50-IntrinsicGas(2, 6)-1-3*CallDataParamGas-DefaultMemoryGasPrice)
+}
+
+func TestVM_Exec_Calldata_Invalid(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  string
+	}{
+		{[]byte{1, 0x02, 0}, "calldata: call data parameter 1 is empty"},
+		{[]byte{1, 0x02, 2, 0x05}, "calldata: index out of bounds"},
+		{[]byte{1, 0x02, 3, 0x05, 0x06, 0x07}, "calldata: call data parameter 1 exceeds 2 bytes"},
+		{[]byte{1, 0x01, 1, 0x02, 1, 0x03, 1, 0x04}, "calldata: call data has more than 3 parameters"},
+	}
+
+	for _, test := range tests {
+		mc := NewMockContext([]byte{CallData, Halt})
+		mc.Data = test.data
+		vm := NewVM(mc)
+		vm.SetCallDataLimits(3, 2)
+
+		assert.Assert(t, !vm.Exec(false))
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+		assert.Equal(t, len(vm.PeekEvalStack()), 1)
+	}
+}
+
+func TestVM_Exec_Sha3(t *testing.T) {
+	code := []byte{
+		Push, 1, 3,
+		SHA3,
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	vm.context = mc
+	vm.Exec(false)
+
+	actual, _ := vm.evaluationStack.Pop()
+	expected := []byte{227, 237, 86, 189, 8, 109, 137, 88, 72, 58, 18, 115, 79, 160, 174, 127, 92, 139, 177, 96, 239, 144, 146, 198, 126, 130, 237, 155, 25, 228, 199, 178}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("Expected value to be \n '%v', \n but was \n '%v' \n after jumping to halt", expected, actual)
+	}
+}
+
+func TestVM_Exec_Roll(t *testing.T) {
+	code := []byte{
+		Push, 1, 3,
+		Push, 1, 4,
+		Push, 1, 5,
+		Push, 1, 6,
+		Push, 1, 7,
+		Roll, 2,
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	vm.context = mc
+	vm.Exec(false)
+
+	tos, _ := vm.evaluationStack.Pop()
+
+	expected := 4
+	actual := ByteArrayToInt(tos)
+	if actual != expected {
+		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
+	}
+}
+
+func TestVM_Exec_Roll_Zero(t *testing.T) {
+	code := []byte{
+		Push, 1, 3,
+		Push, 1, 4,
+		Roll, 0,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+	assert.Equal(t, vm.evaluationStack.GetLength(), 2)
+
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 3)
+	tos, _ = vm.evaluationStack.Pop()
+	assertBytes(t, tos, 4)
+}
+
+func TestVM_Exec_Roll_IndexOutOfBounds(t *testing.T) {
+	code := []byte{
+		Push, 1, 3,
+		Push, 1, 4,
+		Roll, 1,
+		Halt,
+	}
+
+	vm := NewVMWithConfig(NewMockContext(code), VMConfig{Strict: true})
+	assert.Assert(t, !vm.Exec(false))
+	assert.Equal(t, vm.GetErrorMsg(), "roll: index out of bounds")
+}
+
+func TestVM_Exec_Pick(t *testing.T) {
 	code := []byte{
-		CallVal,
+		Push, 1, 3,
+		Push, 1, 4,
+		Push, 1, 5,
+		Pick, 2,
 		Halt,
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	mc.Amount = uint64(100)
-	vm.context = mc
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+	assert.Equal(t, vm.evaluationStack.GetLength(), 4)
 
-	vm.Exec(false)
 	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 3)
+	tos, _ = vm.evaluationStack.Pop()
+	assertBytes(t, tos, 5)
+}
 
-	if len(tos) != 8 {
-		t.Errorf("Expected TOS size to be 8, but got %v", len(tos))
+func TestVM_Exec_Pick_Zero(t *testing.T) {
+	code := []byte{
+		Push, 1, 3,
+		Pick, 0,
+		Halt,
 	}
 
-	result := binary.LittleEndian.Uint64(tos)
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+	assert.Equal(t, vm.evaluationStack.GetLength(), 2)
 
-	if result != 100 {
-		t.Errorf("Expected value to be 100, but got %v", result)
-	}
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 3)
+	tos, _ = vm.evaluationStack.Pop()
+	assertBytes(t, tos, 3)
 }
 
-func TestVM_Exec_Calldata(t *testing.T) {
+func TestVM_Exec_Pick_IndexOutOfBounds(t *testing.T) {
 	code := []byte{
-		CallData,
+		Push, 1, 3,
+		Push, 1, 4,
+		Pick, 2,
 		Halt,
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	mc.Fee = 50
-
-	td := []byte{
-		1, 0x02,
-		1, 0x05,
-		4, 0x10, 0x12, 0x4, 0x12, // Function hash
-	}
-	mc.Data = td
-
-	vm.context = mc
-	vm.Exec(false)
-
-	functionHash, _ := vm.evaluationStack.Pop()
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "pick: index out of bounds")
+}
 
-	if !bytes.Equal(functionHash, td[5:]) {
-		t.Errorf("expected '%# x' but got '%# x'", td[5:], functionHash)
+func TestVM_Exec_Tuck(t *testing.T) {
+	code := []byte{
+		Push, 1, 3,
+		Push, 1, 4,
+		Tuck,
+		Halt,
 	}
 
-	arg1, _ := vm.evaluationStack.Pop()
-	if !bytes.Equal(arg1, td[3:4]) {
-		t.Errorf("expected '%# x' but got '%# x'", td[3:4], arg1)
-	}
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+	assert.Equal(t, vm.evaluationStack.GetLength(), 3)
 
-	arg2, _ := vm.evaluationStack.Pop()
-	if !bytes.Equal(arg2, td[1:2]) {
-		t.Errorf("expected '%# x' but got '%# x'", td[1:2], arg2)
-	}
+	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 4)
+	tos, _ = vm.evaluationStack.Pop()
+	assertBytes(t, tos, 3)
+	tos, _ = vm.evaluationStack.Pop()
+	assertBytes(t, tos, 4)
 }
 
-func TestVM_Exec_Sha3(t *testing.T) {
+func TestVM_Exec_Tuck_EmptyStack(t *testing.T) {
 	code := []byte{
 		Push, 1, 3,
-		SHA3,
+		Tuck,
 		Halt,
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
-
-	actual, _ := vm.evaluationStack.Pop()
-	expected := []byte{227, 237, 86, 189, 8, 109, 137, 88, 72, 58, 18, 115, 79, 160, 174, 127, 92, 139, 177, 96, 239, 144, 146, 198, 126, 130, 237, 155, 25, 228, 199, 178}
-	if !bytes.Equal(actual, expected) {
-		t.Errorf("Expected value to be \n '%v', \n but was \n '%v' \n after jumping to halt", expected, actual)
-	}
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "tuck: stack underflow at pc=3")
 }
 
-func TestVM_Exec_Roll(t *testing.T) {
+func TestVM_Exec_PopN(t *testing.T) {
 	code := []byte{
 		Push, 1, 3,
 		Push, 1, 4,
 		Push, 1, 5,
-		Push, 1, 6,
-		Push, 1, 7,
-		Roll, 2,
+		PopN, 2,
 		Halt,
 	}
 
-	vm := NewTestVM([]byte{})
-	mc := NewMockContext(code)
-	vm.context = mc
-	vm.Exec(false)
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess)
+	assert.Equal(t, vm.evaluationStack.GetLength(), 1)
 
 	tos, _ := vm.evaluationStack.Pop()
+	assertBytes(t, tos, 3)
+}
 
-	expected := 4
-	actual := ByteArrayToInt(tos)
-	if actual != expected {
-		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
+func TestVM_Exec_PopN_IndexOutOfBounds(t *testing.T) {
+	code := []byte{
+		Push, 1, 3,
+		PopN, 2,
+		Halt,
 	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "popn: index out of bounds")
 }
 
-func TestVM_Exec_Swap(t *testing.T) {
+func TestVM_Exec_DupN(t *testing.T) {
 	code := []byte{
-		Push, 1, 1,
-		Push, 1, 2,
 		Push, 1, 3,
-		Swap,
+		Push, 1, 4,
+		Push, 1, 5,
+		DupN, 2,
 		Halt,
 	}
 
 	vm, isSuccess := execCode(code)
 	assert.Assert(t, isSuccess)
+	assert.Equal(t, vm.evaluationStack.GetLength(), 5)
 
-	last, err := vm.evaluationStack.Pop()
-	assert.NilError(t, err)
-	secondLast, err := vm.evaluationStack.Pop()
-	assert.NilError(t, err)
+	for _, expected := range []byte{5, 4, 5, 4, 3} {
+		tos, _ := vm.evaluationStack.Pop()
+		assertBytes(t, tos, expected)
+	}
+}
 
-	assertBytes(t, last, 2)
-	assertBytes(t, secondLast, 3)
+func TestVM_Exec_DupN_IndexOutOfBounds(t *testing.T) {
+	code := []byte{
+		Push, 1, 3,
+		DupN, 2,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "dupn: index out of bounds")
 }
 
 func TestVM_Exec_SwapError(t *testing.T) {
@@ -1651,7 +2672,7 @@ func TestVM_Exec_SwapError(t *testing.T) {
 
 	errMsg, err := vm.evaluationStack.Pop()
 	assert.NilError(t, err)
-	assert.Equal(t, string(errMsg), "swap: pop() on empty stack")
+	assert.Equal(t, string(errMsg), "swap: stack underflow at pc=3")
 }
 
 func TestVM_Exec_NewMap(t *testing.T) {
@@ -1703,6 +2724,7 @@ func TestVM_Exec_MapHasKey_true(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
+	mc.Fee = 100
 	vm.context = mc
 
 	exec := vm.Exec(false)
@@ -1749,6 +2771,7 @@ func TestVM_Exec_MapHasKey_false(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
+	mc.Fee = 100
 	vm.context = mc
 
 	exec := vm.Exec(false)
@@ -1815,6 +2838,27 @@ func TestVM_Exec_MapSetVal(t *testing.T) {
 
 }
 
+func TestVM_Exec_MapSetVal_GasBySize(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 72, 105,
+		Push, 1, 0x03,
+		NewMap,
+		MapSetVal,
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Fee = 100
+	vm.context = mc
+	isSuccess := vm.Exec(false)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+	// 1 intrinsic gas, 2 pushes with 2 and 1 immediate bytes, newmap, mapsetval: 1 + 6 (pops) + 2 (10 bytes),
+	// 1 word of memory
+	assert.Equal(t, vm.fee, uint64(100-1-2-3-1-9-DefaultMemoryGasPrice))
+}
+
 func TestVM_Exec_MapGetVAL(t *testing.T) {
 	code := []byte{
 		Push, 1, 0x01, //The key for MAPGETVAL
@@ -2019,6 +3063,71 @@ func TestVM_Exec_NewArrWithoutInitialization(t *testing.T) {
 	}
 }
 
+func TestVM_Exec_NewArr_GasBySize(t *testing.T) {
+	tests := []struct {
+		length       byte
+		containerGas uint64
+		memoryWords  uint64
+	}{
+		{1, 2, 1},
+		{20, 2, 1},
+		{21, 4, 2},
+		{100, 10, 5},
+	}
+
+	for _, test := range tests {
+		code := []byte{
+			PushInt, 1, 0, test.length,
+			NewArr,
+			Halt,
+		}
+
+		vm := NewTestVM([]byte{})
+		mc := NewMockContext(code)
+		mc.Fee = 100
+		vm.context = mc
+		isSuccess := vm.Exec(false)
+		assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+		// 1 intrinsic gas, push and newarr price, 2 immediate bytes, 2 gas for popping the length
+		assert.Equal(t, vm.fee, 100-7-test.containerGas-test.memoryWords*DefaultMemoryGasPrice)
+	}
+}
+
+func TestVM_Exec_NewArr_SizeOverflow(t *testing.T) {
+	code := []byte{
+		PushInt, 3, 0, 1, 0, 0, // 65536
+		NewArr,
+		Halt,
+	}
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "newarr: array size overflow")
+}
+
+func TestVM_Exec_ArrInsert_GasBySize(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 5,
+		PushInt, 0,
+		PushInt, 1, 0, 30,
+		NewArr,
+		ArrInsert,
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Fee = 100
+	vm.context = mc
+	isSuccess := vm.Exec(false)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+	// 1 intrinsic gas, 3 pushes with 4 immediate bytes, newarr: 1 + 2 (pop) + 4 (93 bytes),
+	// arrinsert: 1 + 8 (pops) + 4 (94 bytes), 2 words of memory
+	assert.Equal(t, vm.fee, uint64(100-1-3-4-7-13-2*DefaultMemoryGasPrice))
+}
+
 func TestVM_Exec_ArrAppend(t *testing.T) {
 	code := []byte{
 		Push, 2, 0xFF, 0x00,
@@ -2205,7 +3314,7 @@ func TestVM_Exec_NewStr(t *testing.T) {
 	arrBytes, err := vm.evaluationStack.Pop()
 	assert.NilError(t, err)
 
-	str, structErr := structFromByteArray(arrBytes)
+	str, structErr := structFromByteArray(arrBytes, nil)
 	assert.NilError(t, structErr)
 	assert.Assert(t, str != nil)
 
@@ -2229,7 +3338,7 @@ func TestVM_Exec_StoreFld(t *testing.T) {
 	structBytes, err := vm.evaluationStack.Pop()
 	assert.NilError(t, err)
 
-	str, err := structFromByteArray(structBytes)
+	str, err := structFromByteArray(structBytes, nil)
 	assert.NilError(t, err)
 	assert.Assert(t, str != nil)
 
@@ -2263,9 +3372,87 @@ func TestVM_Exec_LoadFld(t *testing.T) {
 	assertBytes(t, element, 0, 4)
 }
 
+var arrayOfStructs = []byte{
+	NewStr, 0, 2,
+	PushInt, 1, 0, 4,
+	StoreFld, 0, 1,
+
+	NewStr, 0, 2,
+	PushInt, 1, 0, 8,
+	StoreFld, 0, 1,
+
+	NewArrFrom, 2,
+}
+
+func TestVM_Exec_ArrFieldAt(t *testing.T) {
+	code := append([]byte{}, arrayOfStructs...)
+	code = append(code,
+		PushInt, 1, 0, 1,
+		Swap,
+		ArrFieldAt, 0, 1, // Load field 1 of the struct at index 1
+		Halt,
+	)
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+	element, err := vm.evaluationStack.Pop()
+	assert.NilError(t, err)
+	assertBytes(t, element, 0, 8)
+}
+
+func TestVM_Exec_ArrFieldStore(t *testing.T) {
+	code := []byte{PushInt, 1, 0, 9, PushInt, 0}
+	code = append(code, arrayOfStructs...)
+	code = append(code,
+		ArrFieldStore, 0, 0, // Store field 0 of the struct at index 0
+		Dup,
+		PushInt, 0,
+		Swap,
+		ArrFieldAt, 0, 0,
+		Swap,
+		PushInt, 0,
+		Swap,
+		ArrFieldAt, 0, 1,
+		Halt,
+	)
+
+	vm, isSuccess := execCode(code)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+
+	assert.Equal(t, len(vm.evaluationStack.Stack), 2)
+	field1, _ := vm.evaluationStack.Pop()
+	field0, _ := vm.evaluationStack.Pop()
+	assertBytes(t, field0, 0, 9)
+	assertBytes(t, field1, 0, 4)
+}
+
+func TestVM_Exec_ArrFieldErrors(t *testing.T) {
+	tests := []struct {
+		operands []byte
+		code     []byte
+		err      string
+	}{
+		{nil, []byte{PushInt, 1, 0, 2, Swap, ArrFieldAt, 0, 0, Halt}, "arrfieldat: array index out of bounds"},
+		{nil, []byte{PushInt, 0, Swap, ArrFieldAt, 0, 2, Halt}, "arrfieldat: index out of bounds"},
+		{[]byte{Push, 1, 1, PushInt, 0}, []byte{ArrFieldStore, 0, 2, Halt}, "arrfieldstore: index out of bounds"},
+	}
+
+	for _, test := range tests {
+		code := append(append(append([]byte{}, test.operands...), arrayOfStructs...), test.code...)
+		vm, isSuccess := execCode(code)
+		assert.Assert(t, !isSuccess)
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+	}
+
+	vm, isSuccess := execCode([]byte{PushInt, 0, Push, 1, 7, NewArrFrom, 1, ArrFieldAt, 0, 0, Halt})
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "arrfieldat: not a valid array")
+}
+
 func TestVM_Exec_NonValidOpCode(t *testing.T) {
 	code := []byte{
-		89,
+		200,
 	}
 
 	vm := NewTestVM([]byte{})
@@ -2275,7 +3462,7 @@ func TestVM_Exec_NonValidOpCode(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := "vm.exec(): Not a valid opCode"
+	expected := "vm.exec(): not a valid opcode"
 	actual := string(tos)
 	if actual != expected {
 		t.Errorf("Expected error message to be '%v' but was '%v'", expected, actual)
@@ -2295,7 +3482,7 @@ func TestVM_Exec_ArgumentsExceedInstructionSet(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := "push: Instruction set out of bounds"
+	expected := "push: instruction set out of bounds"
 	actual := string(tos)
 	if actual != expected {
 		t.Errorf("Expected error message to be '%v' but was '%v'", expected, actual)
@@ -2317,16 +3504,34 @@ func TestVM_Exec_PopOnEmptyStack(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := "sub: Invalid signing bit"
+	expected := "sub: stack underflow at pc=4"
 	actual := string(tos)
 	if actual != expected {
 		t.Errorf("Expected error message to be '%v' but was '%v'", expected, actual)
 	}
 }
 
+func TestVM_Exec_StackUnderflow(t *testing.T) {
+	tests := []struct {
+		code []byte
+		err  string
+	}{
+		{[]byte{PushInt, 0, MapSetVal, Halt}, "mapsetval: stack underflow at pc=2"},
+		{[]byte{PushInt, 0, PushInt, 0, ArrInsert, Halt}, "arrinsert: stack underflow at pc=4"},
+		{[]byte{JmpTrue, 0, 0, Halt}, "jmptrue: stack underflow at pc=0"},
+	}
+
+	for _, test := range tests {
+		vm, isSuccess := execCode(test.code)
+		assert.Assert(t, !isSuccess)
+		assert.Equal(t, vm.GetErrorMsg(), test.err)
+	}
+}
+
 func TestVM_Exec_FuzzReproduction_InstructionSetOutOfBounds(t *testing.T) {
 	code := []byte{
 		Push, 1, 20,
+		Push, 1, 21,
 		Roll, 0,
 	}
 
@@ -2337,7 +3542,7 @@ func TestVM_Exec_FuzzReproduction_InstructionSetOutOfBounds(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := "vm.exec(): Instruction set out of bounds"
+	expected := "vm.exec(): instruction set out of bounds"
 	actual := string(tos)
 	if actual != expected {
 		t.Errorf("Expected error message to be '%v' but was '%v'", expected, actual)
@@ -2357,7 +3562,7 @@ func TestVM_Exec_FuzzReproduction_InstructionSetOutOfBounds2(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := "callext: Instruction set out of bounds"
+	expected := "callext: instruction set out of bounds"
 	actual := string(tos)
 	if actual != expected {
 		t.Errorf("Expected error message to be '%v' but was '%v'", expected, actual)
@@ -2501,7 +3706,7 @@ func TestVM_Exec_GithubIssue13(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := "arrat: pop() on empty stack"
+	expected := "arrat: stack underflow at pc=1"
 	actual := string(tos)
 	if actual != expected {
 		t.Errorf("Expected error message to be '%v' but was '%v'", expected, actual)
@@ -2550,7 +3755,7 @@ func TestVM_Exec_FuzzReproduction_ContextOpCode2(t *testing.T) {
 
 func TestVM_Exec_FuzzReproduction_EdgecaseLastOpcodePlusOne(t *testing.T) {
 	code := []byte{
-		Halt + 1,
+		byte(len(OpCodes)),
 	}
 
 	vm := NewTestVM([]byte{})
@@ -2560,7 +3765,7 @@ func TestVM_Exec_FuzzReproduction_EdgecaseLastOpcodePlusOne(t *testing.T) {
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := "vm.exec(): Not a valid opCode"
+	expected := "vm.exec(): not a valid opcode"
 	actual := string(tos)
 	if actual != expected {
 		t.Errorf("Expected error message to be '%v' but was '%v'", expected, actual)
@@ -2577,7 +3782,7 @@ func TestVM_PopBytes(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 11
+	mc.Fee = 19 // Includes 1 intrinsic gas for the code, 4 gas for the immediate bytes and 3 gas for the memory
 	vm.context = mc
 
 	vm.Exec(false)
@@ -2636,7 +3841,7 @@ func TestVM_GasCalculation(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 11
+	mc.Fee = 86 // Includes 2 intrinsic gas for the code, 67 gas for the immediate bytes and 6 gas for the memory
 	vm.context = mc
 
 	vm.Exec(false)
@@ -2649,6 +3854,53 @@ func TestVM_GasCalculation(t *testing.T) {
 	}
 }
 
+func TestVM_Exec_IntrinsicGas(t *testing.T) {
+	code := []byte{
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Data = make([]byte, 130)
+	mc.Fee = 4
+	vm.context = mc
+
+	isSuccess := vm.Exec(false)
+	assert.Assert(t, isSuccess, vm.GetErrorMsg())
+	assert.Equal(t, vm.fee, uint64(0))
+}
+
+func TestVM_Exec_IntrinsicGas_OutOfGas(t *testing.T) {
+	code := []byte{
+		Halt,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Data = make([]byte, 130)
+	mc.Fee = 3
+	vm.context = mc
+
+	isSuccess := vm.Exec(false)
+	assert.Assert(t, !isSuccess)
+	assert.Equal(t, vm.GetErrorMsg(), "vm.exec(): out of gas")
+}
+
+func TestVM_Exec_IntrinsicGas_Schedule(t *testing.T) {
+	schedule := GasSchedule{CodeGasName: {Price: 5}, CallDataGasName: {Price: 2}}
+	for _, fee := range []uint64{11, 10} {
+		mc := NewMockContext([]byte{Halt})
+		mc.Data = make([]byte, 130)
+		mc.Fee = fee
+		vm := NewVMWithConfig(mc, VMConfig{GasSchedule: schedule})
+
+		// 1 chunk of code and 3 chunks of transaction data
+		isSuccess := vm.Exec(false)
+		assert.Equal(t, isSuccess, fee == 11, vm.GetErrorMsg())
+		assert.Equal(t, vm.GasUsed(), intrinsicGas(1, 130, 5, 2)*uint64(fee/11))
+	}
+}
+
 func TestVM_PopBytesOutOfGas(t *testing.T) {
 	code := []byte{
 		PushInt, 1, 0, 8,
@@ -2659,14 +3911,14 @@ func TestVM_PopBytesOutOfGas(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 3
+	mc.Fee = 11 // Includes 1 intrinsic gas for the code, 4 gas for the immediate bytes and 3 gas for the memory
 	vm.context = mc
 
 	vm.Exec(false)
 
 	tos, _ := vm.evaluationStack.Pop()
 
-	expected := "add: Out of gas"
+	expected := "add: out of gas"
 	actual := string(tos)
 	if actual != expected {
 		t.Errorf("Expected ToS to be '%v' but was '%v'", expected, actual)
@@ -2726,6 +3978,7 @@ func BenchmarkVM_Exec_ModularExponentiation_ContractImplementation(b *testing.B)
 
 	for _, bm := range benchmarks {
 		b.Run(bm.name, func(b *testing.B) {
+			vm := NewTestVM([]byte{})
 			for n := 0; n < b.N; n++ {
 				base.SetBytes(protocol.RandomBytesWithLength(bm.bLen))
 				exponent.SetBytes(protocol.RandomBytesWithLength(1))
@@ -2733,18 +3986,15 @@ func BenchmarkVM_Exec_ModularExponentiation_ContractImplementation(b *testing.B)
 
 				contract := modularExpContract(base, exponent, modulus)
 
-				vm := NewTestVM([]byte{})
 				mc := NewMockContext(contract)
 				mc.Fee = 1000000000000
-				vm.context = mc
+				vm.Reset(mc)
 
 				if vm.Exec(false) != true {
 					tos, err := vm.evaluationStack.Pop()
 					fmt.Println(string(tos), err)
 					b.Fail()
 				}
-				vm.pc = 0
-				mc.Fee = 10000000000000
 			}
 
 			b.ReportAllocs()
@@ -2767,9 +4017,9 @@ func modularExpGo(base big.Int, exponent big.Int, modulus big.Int) *big.Int {
 }
 
 func modularExpContract(base big.Int, exponent big.Int, modulus big.Int) []byte {
-	baseVal := BigIntToPushableBytes(base)
-	exponentVal := BigIntToPushableBytes(exponent)
-	modulusVal := BigIntToPushableBytes(modulus)
+	baseVal := push(nil, PushInt, SignedByteArrayConversion(base))[1:]
+	exponentVal := push(nil, PushInt, SignedByteArrayConversion(exponent))[1:]
+	modulusVal := push(nil, PushInt, SignedByteArrayConversion(modulus))[1:]
 
 	addressBeforeExp := UInt16ToByteArray(uint16(39) + uint16(len(baseVal)) + uint16(len(modulusVal)))
 	addressAfterExp := UInt16ToByteArray(uint16(66) + uint16(len(baseVal)) + uint16(len(modulusVal)) + uint16(len(exponentVal)))
@@ -2810,7 +4060,7 @@ func modularExpContract(base big.Int, exponent big.Int, modulus big.Int) []byte
 		Roll, 4, //Stack: [[0 11 75] [0 4] [0 13] [0 0] [0 11 75] [0 4] [0 1]]
 		Roll, 5, //Stack: [[0 1] [0 11 75] [0 4] [0 13] [0 0] [0 11 75] [0 4]]
 		// Order: counter, modulus, base, exp, i, modulus, base
-		Call,
+		CallLocals,
 	}...)
 	contract = append(contract, byte(addressAfterExp[1]))
 	contract = append(contract, byte(addressAfterExp[0]))
@@ -2918,12 +4168,12 @@ func TestVm_Exec_ModularExponentiation_ContractImplementation(t *testing.T) {
 
 		// Address 44
 		// Order: counter, modulus, base, exp, i, modulus, base
-		Call, 0, 73, 3, 1,
+		CallLocals, 0, 74, 3, 1, 3,
 		// PUT in order
 		Roll, 1,
 		Roll, 1,
 
-		// Address 53
+		// Address 54
 		// Order: exp, i - counter, modulus, base,
 		Dup,
 		Roll, 1,
@@ -2938,7 +4188,7 @@ func TestVm_Exec_ModularExponentiation_ContractImplementation(t *testing.T) {
 		// LOOP END
 		Halt,
 
-		// Address 73
+		// Address 74
 		// FUNCTION Order: c, modulus, base,
 		LoadLoc, 2,
 		LoadLoc, 0,
@@ -2968,11 +4218,11 @@ func TestMultipleReturnValues(t *testing.T) {
 	code := []byte{
 		PushInt, 1, 0, 1,
 		PushInt, 1, 0, 2,
-		Call, 0, 14, 2, 2,
+		CallLocals, 0, 15, 2, 2, 2,
 		Halt,
 		NoOp,
 		NoOp,
-		LoadLoc, 0, // Begin of called function at address 14
+		LoadLoc, 0, // Begin of called function at address 15
 		LoadLoc, 1,
 		Ret,
 	}
@@ -3006,11 +4256,11 @@ func TestMultipleReturnValuesDifferentTypes(t *testing.T) {
 	code := []byte{
 		PushInt, 1, 0, 1,
 		PushBool, 0,
-		Call, 0, 14, 2, 2,
+		CallLocals, 0, 15, 2, 2, 2,
 		Halt,
 		NoOp,
 		NoOp,
-		LoadLoc, 0, // Begin of called function at address 14
+		LoadLoc, 0, // Begin of called function at address 15
 		LoadLoc, 1,
 		Ret,
 	}
@@ -3146,14 +4396,46 @@ func TestPeekEvalStack(t *testing.T) {
 	assertBytes(t, evalStack[2], 1, 2, 3, 4)
 }
 
+func TestVM_Reset(t *testing.T) {
+	mc := NewMockContext([]byte{PushInt, 1, 0, 2, EnterGuard, PushInt, 1, 0, 3, Halt})
+	vm := NewVM(mc)
+	vm.SetMaxIntegerSize(8)
+	assert.Assert(t, vm.Exec(false))
+	assert.Assert(t, vm.IsGuardActive())
+
+	vm.Reset(NewMockContext([]byte{PushInt, 1, 0, 4, Halt}))
+	assert.Equal(t, len(vm.PeekEvalStack()), 0)
+	assert.Assert(t, !vm.IsGuardActive())
+	assert.Equal(t, vm.GetRemainingFee(), uint64(0))
+	assert.Equal(t, vm.maxIntegerSize, 8)
+
+	assert.Assert(t, vm.Exec(false))
+	evalStack := vm.PeekEvalStack()
+	assert.Equal(t, len(evalStack), 1)
+	assertBytes(t, evalStack[0], 0, 4)
+	assert.Equal(t, vm.GetRemainingFee(), uint64(43))
+}
+
+func TestGetRemainingFee(t *testing.T) {
+	code := []byte{
+		PushInt, 1, 0, 2,
+		Halt,
+	}
+
+	vm := NewVM(NewMockContext(code))
+	assert.Assert(t, vm.Exec(false))
+	assert.Equal(t, vm.GetRemainingFee(), uint64(50-1-1-2-DefaultMemoryGasPrice))
+}
+
 // Helper functions
 // ----------------
 
+// execCode executes the code without a gas limit
 func execCode(code []byte) (*VM, bool) {
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
 	vm.context = mc
-	isSuccess := vm.Exec(false)
+	isSuccess := vm.ExecUnlimited()
 
 	return &vm, isSuccess
 }
@@ -0,0 +1,92 @@
+package vm
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// TestWatchdog is the watchdog timeout of NewTestVM
+const TestWatchdog = 10 * time.Second
+
+// watchdogExpired is invoked once a handler exceeds the timeout
+var watchdogExpired = func(message string) { panic(message) }
+
+// watchdog detects instructions, whose handler does not terminate, e.g. because of an infinite loop in native code.
+// Loops in the bytecode are not detected, they end when the gas is used up.
+type watchdog struct {
+	timeout time.Duration
+
+	mutex   sync.Mutex
+	started time.Time // Start of the current instruction, zero between instructions
+	pc      int
+	opCode  string
+	done    chan struct{}
+}
+
+// startWatchdog starts a goroutine checking the duration of the instructions, it runs until stop is called
+func startWatchdog(timeout time.Duration) *watchdog {
+	w := &watchdog{
+		timeout: timeout,
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *watchdog) run() {
+	ticker := time.NewTicker(w.timeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case now := <-ticker.C:
+			if message, ok := w.check(now); ok {
+				watchdogExpired(message)
+				return
+			}
+		}
+	}
+}
+
+// check returns the failure message, if the current instruction runs longer than the timeout
+func (w *watchdog) check(now time.Time) (string, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.started.IsZero() || now.Sub(w.started) <= w.timeout {
+		return "", false
+	}
+
+	// The stacks of all goroutines show where the handler is stuck
+	stacks := make([]byte, 1<<16)
+	stacks = stacks[:runtime.Stack(stacks, true)]
+	return fmt.Sprintf("watchdog: %v at pc=%v runs longer than %v\n%s", w.opCode, w.pc, w.timeout, stacks), true
+}
+
+func (w *watchdog) begin(pc int, opCode OpCode) {
+	if w == nil {
+		return
+	}
+	w.mutex.Lock()
+	w.started, w.pc, w.opCode = now(), pc, opCode.Name
+	w.mutex.Unlock()
+}
+
+func (w *watchdog) end() {
+	if w == nil {
+		return
+	}
+	w.mutex.Lock()
+	w.started = time.Time{}
+	w.mutex.Unlock()
+}
+
+func (w *watchdog) stop() {
+	if w != nil {
+		close(w.done)
+	}
+}
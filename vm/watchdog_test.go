@@ -0,0 +1,63 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+func TestWatchdog_Check(t *testing.T) {
+	w := &watchdog{timeout: time.Second}
+	now := time.Now()
+
+	_, expired := w.check(now)
+	assert.Assert(t, !expired)
+
+	w.begin(3, OpCodes[Add])
+	_, expired = w.check(now)
+	assert.Assert(t, !expired)
+
+	message, expired := w.check(now.Add(2 * time.Second))
+	assert.Assert(t, expired)
+	assert.Assert(t, strings.HasPrefix(message, "watchdog: add at pc=3 runs longer than 1s\n"), message)
+
+	w.end()
+	_, expired = w.check(now.Add(2 * time.Second))
+	assert.Assert(t, !expired)
+}
+
+// slowBalanceContext simulates a handler, which does not terminate in time
+type slowBalanceContext struct {
+	*MockContext
+}
+
+func (c slowBalanceContext) GetBalance() uint64 {
+	time.Sleep(200 * time.Millisecond)
+	return 0
+}
+
+func TestWatchdog_DetectsSlowHandler(t *testing.T) {
+	messages := make(chan string, 1)
+	expired := watchdogExpired
+	watchdogExpired = func(message string) { messages <- message }
+	defer func() { watchdogExpired = expired }()
+
+	vm := NewVMWithConfig(slowBalanceContext{NewMockContext([]byte{PushInt, 0, Balance, Halt})},
+		VMConfig{Watchdog: 20 * time.Millisecond})
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+
+	select {
+	case message := <-messages:
+		assert.Assert(t, strings.HasPrefix(message, "watchdog: balance at pc=2 runs longer than 20ms\n"), message)
+	default:
+		t.Fatal("the watchdog did not detect the slow handler")
+	}
+	assert.Assert(t, vm.watchdog == nil)
+}
+
+func TestWatchdog_FastHandlers(t *testing.T) {
+	vm := NewTestVM([]byte{PushInt, 1, 0, 1, PushInt, 1, 0, 2, Add, Halt})
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+}
@@ -0,0 +1,155 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// WitnessKind identifies the kind of state, which was read from the context.
+type WitnessKind byte
+
+// Witness kinds, in the order of the entries of a witness
+const (
+	// WitnessCode is the code of an account, which is empty for accounts without contract.
+	WitnessCode WitnessKind = iota + 1
+	// WitnessVariable is a contract variable of the executing contract or of an account read by ExtLoadSt.
+	WitnessVariable
+	// WitnessBalance is the balance of an account as 8 byte little endian value.
+	WitnessBalance
+	// WitnessAccount is the existence of an account as boolean.
+	WitnessAccount
+	// WitnessNonce is the nonce of a caller of the executing contract as 8 byte little endian value.
+	WitnessNonce
+)
+
+// WitnessEntry is a value read from the context. Account is the 32 byte address of the account, Index is the index
+// of contract variables and 0 otherwise.
+type WitnessEntry struct {
+	Kind    WitnessKind `json:"kind"`
+	Account [32]byte    `json:"account"`
+	Index   int         `json:"index"`
+	Value   []byte      `json:"value"`
+}
+
+// Encode returns the canonical encoding of the entry, which is used as leaf of the Merkle tree:
+// kind (1 byte), account (32 bytes), index (2 bytes), length of the value (4 bytes) and value, big endian.
+func (e WitnessEntry) Encode() []byte {
+	encoded := make([]byte, 39, 39+len(e.Value))
+	encoded[0] = byte(e.Kind)
+	copy(encoded[1:33], e.Account[:])
+	binary.BigEndian.PutUint16(encoded[33:35], uint16(e.Index))
+	binary.BigEndian.PutUint32(encoded[35:39], uint32(len(e.Value)))
+	return append(encoded, e.Value...)
+}
+
+// Hash returns the leaf hash of the entry
+func (e WitnessEntry) Hash() [32]byte {
+	return hashNode(0x00, e.Encode())
+}
+
+type witnessKey struct {
+	kind    WitnessKind
+	account [32]byte
+	index   int
+}
+
+// Witness collects the state, which an execution read from the context. Every value is recorded with its first read
+// before any write of the execution, so the witness contains the state before the execution.
+// Stateless clients can verify the entries against the state root and re-execute the contract with them.
+type Witness struct {
+	entries map[witnessKey]WitnessEntry
+	written map[witnessKey]bool
+}
+
+// NewWitness creates an empty witness.
+func NewWitness() *Witness {
+	return &Witness{
+		entries: make(map[witnessKey]WitnessEntry),
+		written: make(map[witnessKey]bool),
+	}
+}
+
+// record adds the value, unless it was already read or overwritten by the execution
+func (w *Witness) record(kind WitnessKind, account [32]byte, index int, value []byte) {
+	key := witnessKey{kind, account, index}
+	if _, ok := w.entries[key]; ok || w.written[key] {
+		return
+	}
+
+	w.entries[key] = WitnessEntry{
+		Kind:    kind,
+		Account: account,
+		Index:   index,
+		Value:   append([]byte{}, value...),
+	}
+}
+
+// markWritten excludes later reads of the value, as they do not read the state before the execution
+func (w *Witness) markWritten(kind WitnessKind, account [32]byte, index int) {
+	w.written[witnessKey{kind, account, index}] = true
+}
+
+// Entries returns the entries sorted by kind, account and index.
+func (w *Witness) Entries() []WitnessEntry {
+	entries := make([]WitnessEntry, 0, len(w.entries))
+	for _, entry := range w.entries {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if c := bytes.Compare(a.Account[:], b.Account[:]); c != 0 {
+			return c < 0
+		}
+		return a.Index < b.Index
+	})
+	return entries
+}
+
+// Root returns the root of the Merkle tree over the sorted entries. Leaves and inner nodes are hashed with SHA3 and
+// distinct prefixes, an odd node is promoted to the next level. The root of an empty witness consists of zeros.
+func (w *Witness) Root() [32]byte {
+	entries := w.Entries()
+	if len(entries) == 0 {
+		return [32]byte{}
+	}
+
+	level := make([][32]byte, len(entries))
+	for i, entry := range entries {
+		level[i] = entry.Hash()
+	}
+
+	for len(level) > 1 {
+		var next [][32]byte
+		for i := 0; i+1 < len(level); i += 2 {
+			next = append(next, hashNode(0x01, level[i][:], level[i+1][:]))
+		}
+		if len(level)%2 == 1 {
+			next = append(next, level[len(level)-1])
+		}
+		level = next
+	}
+	return level[0]
+}
+
+func hashNode(prefix byte, data ...[]byte) [32]byte {
+	var hash [32]byte
+	hasher := sha3.New256()
+	hasher.Write([]byte{prefix})
+	for _, d := range data {
+		hasher.Write(d)
+	}
+	copy(hash[:], hasher.Sum(nil))
+	return hash
+}
+
+// GetWitness returns the state read by the last execution.
+func (vm *VM) GetWitness() *Witness {
+	return vm.witness
+}
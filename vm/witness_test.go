@@ -0,0 +1,112 @@
+package vm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"gotest.tools/assert"
+)
+
+func TestWitness_Reads(t *testing.T) {
+	other := [32]byte{7}
+	otherCode := []byte{Halt}
+	missing := [32]byte{8}
+
+	code := []byte{
+		LoadSt, 1,
+		LoadSt, 1,
+		PushInt, 1, 0, 5,
+		StoreSt, 0,
+		LoadSt, 0,
+		Balance,
+	}
+	code = pushAddress(code, other)
+	code = append(code, ExtCodeSize)
+	code = pushAddress(code, missing)
+	code = append(code, AccountExists, Halt)
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.Address = selfAccount
+	mc.Balance = 3
+	mc.ContractVariables = [][]byte{{1}, {2}}
+	mc.Accounts = map[[32]byte][]byte{other: otherCode}
+	mc.Fee = 2000
+	vm.context = mc
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+
+	self := protocol.SerializeHashContent(selfAccount)
+	expected := []WitnessEntry{
+		{Kind: WitnessCode, Account: other, Value: otherCode},
+		{Kind: WitnessCode, Account: self, Value: code},
+		{Kind: WitnessVariable, Account: self, Index: 0, Value: []byte{1}},
+		{Kind: WitnessVariable, Account: self, Index: 1, Value: []byte{2}},
+		{Kind: WitnessBalance, Account: self, Value: []byte{3, 0, 0, 0, 0, 0, 0, 0}},
+		{Kind: WitnessAccount, Account: other, Value: []byte{1}},
+		{Kind: WitnessAccount, Account: missing, Value: []byte{0}},
+	}
+	if bytes.Compare(self[:], other[:]) < 0 {
+		expected[0], expected[1] = expected[1], expected[0]
+	}
+	assert.DeepEqual(t, vm.GetWitness().Entries(), expected)
+}
+
+func TestWitness_StoreOnly(t *testing.T) {
+	// The value read to validate the index is part of the state, on which the execution depends
+	code := []byte{PushInt, 1, 0, 5, StoreSt, 1, PushInt, 1, 0, 6, StoreSt, 1, Halt}
+	mc := newTestContext(code, withVariables)
+	mc.Address = selfAccount
+	mc.ContractVariables[1] = []byte{3}
+	vm := NewVM(mc)
+	assert.Assert(t, vm.Exec(false), vm.GetErrorMsg())
+
+	self := protocol.SerializeHashContent(selfAccount)
+	assert.DeepEqual(t, vm.GetWitness().Entries(), []WitnessEntry{
+		{Kind: WitnessCode, Account: self, Value: code},
+		{Kind: WitnessVariable, Account: self, Index: 1, Value: []byte{3}},
+	})
+}
+
+func TestWitness_BeforeExec(t *testing.T) {
+	vm := NewTestVM([]byte{Halt})
+	assert.Equal(t, len(vm.GetWitness().Entries()), 0)
+}
+
+func TestWitness_EntryEncode(t *testing.T) {
+	entry := WitnessEntry{Kind: WitnessVariable, Account: [32]byte{9}, Index: 258, Value: []byte{4, 5}}
+	encoded := entry.Encode()
+
+	assert.Equal(t, len(encoded), 41)
+	assert.Equal(t, encoded[0], byte(WitnessVariable))
+	assert.Equal(t, encoded[1], byte(9))
+	assertBytes(t, encoded[33:], 1, 2, 0, 0, 0, 2, 4, 5)
+}
+
+func TestWitness_Root(t *testing.T) {
+	witness := NewWitness()
+	assert.Equal(t, witness.Root(), [32]byte{})
+
+	a := WitnessEntry{Kind: WitnessCode, Account: [32]byte{1}, Value: []byte{}}
+	b := WitnessEntry{Kind: WitnessVariable, Account: [32]byte{1}, Value: []byte{1}}
+	c := WitnessEntry{Kind: WitnessBalance, Account: [32]byte{1}, Value: []byte{2}}
+
+	witness.record(a.Kind, a.Account, a.Index, a.Value)
+	assert.Equal(t, witness.Root(), a.Hash())
+
+	witness.record(c.Kind, c.Account, c.Index, c.Value)
+	witness.record(b.Kind, b.Account, b.Index, b.Value)
+	ab := hashNode(0x01, hashSlice(a.Hash()), hashSlice(b.Hash()))
+	assert.Equal(t, witness.Root(), hashNode(0x01, ab[:], hashSlice(c.Hash())))
+}
+
+func TestWitness_IgnoresReadsAfterWrite(t *testing.T) {
+	witness := NewWitness()
+	witness.markWritten(WitnessVariable, [32]byte{}, 0)
+	witness.record(WitnessVariable, [32]byte{}, 0, []byte{1})
+	assert.Equal(t, len(witness.Entries()), 0)
+}
+
+func hashSlice(hash [32]byte) []byte {
+	return hash[:]
+}
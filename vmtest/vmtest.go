@@ -0,0 +1,84 @@
+// Package vmtest compares the results of contract executions with golden files, so tests do not have to assert the
+// stack, storage and gas one by one.
+//
+// The golden files are stored in testdata/<name>.golden.json relative to the package under test. Running the tests
+// with -update writes the current results to the golden files instead of comparing them.
+package vmtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+var update = flag.Bool("update", false, "write the results of vmtest.Run to the golden files")
+
+// Snapshot is the result of an execution as stored in a golden file. The VM has no event logs, the final state of
+// the execution is recorded instead.
+type Snapshot struct {
+	Success bool         `json:"success"`
+	Error   string       `json:"error,omitempty"`
+	State   vm.StateDump `json:"state"`
+}
+
+// Run executes the code and compares the result with the golden file of the name. The context is prepared by setup,
+// which may be nil. The context is returned for further assertions, e.g. of the persisted contract variables.
+func Run(t *testing.T, name string, code []byte, setup func(mc *vm.MockContext)) *vm.MockContext {
+	t.Helper()
+
+	mc := vm.NewMockContext(code)
+	if setup != nil {
+		setup(mc)
+	}
+
+	machine := vm.NewVM(mc)
+	snapshot := Snapshot{Success: machine.Exec(false)}
+	if !snapshot.Success {
+		snapshot.Error = machine.GetErrorMsg()
+	}
+	snapshot.State = machine.State()
+
+	actual, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual = append(actual, '\n')
+
+	Compare(t, name, actual)
+	return mc
+}
+
+// Compare compares the data with the golden file of the name, or writes it to the golden file with -update.
+func Compare(t *testing.T, name string, actual []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden.json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file %v does not exist, run the test with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("result differs from golden file %v, run the test with -update to accept it\nexpected:\n%s\nactual:\n%s",
+			path, expected, actual)
+	}
+}
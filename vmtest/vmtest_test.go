@@ -0,0 +1,29 @@
+package vmtest
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+func TestRun_Success(t *testing.T) {
+	code := []byte{
+		vm.PushInt, 1, 0, 7,
+		vm.PushInt, 1, 0, 5,
+		vm.Add,
+		vm.Dup,
+		vm.StoreSt, 1,
+		vm.Halt,
+	}
+
+	mc := Run(t, "success", code, func(mc *vm.MockContext) {
+		mc.Fee = 5000
+		mc.ContractVariables = [][]byte{{0}, {0}}
+	})
+	assert.Equal(t, mc.Batches, 1)
+}
+
+func TestRun_Failure(t *testing.T) {
+	Run(t, "failure", []byte{vm.PushInt, 1, 0, 1, vm.PushInt, 0, vm.Div, vm.Halt}, nil)
+}
@@ -0,0 +1,255 @@
+package wasm
+
+import (
+	"fmt"
+)
+
+// Opcodes of the supported instructions
+const (
+	opUnreachable = 0x00
+	opNop         = 0x01
+	opBlock       = 0x02
+	opLoop        = 0x03
+	opIf          = 0x04
+	opElse        = 0x05
+	opEnd         = 0x0b
+	opBr          = 0x0c
+	opBrIf        = 0x0d
+	opReturn      = 0x0f
+	opCall        = 0x10
+	opDrop        = 0x1a
+	opSelect      = 0x1b
+	opLocalGet    = 0x20
+	opLocalSet    = 0x21
+	opLocalTee    = 0x22
+	opGlobalGet   = 0x23
+	opGlobalSet   = 0x24
+	opI32Load     = 0x28
+	opI64Load     = 0x29
+	opI32Load8S   = 0x2c
+	opI64Load32U  = 0x35
+	opI32Store    = 0x36
+	opI64Store    = 0x37
+	opI32Store8   = 0x3a
+	opI64Store32  = 0x3e
+	opMemorySize  = 0x3f
+	opMemoryGrow  = 0x40
+	opI32Const    = 0x41
+	opI64Const    = 0x42
+	opI32Eqz      = 0x45
+	opI64GeU      = 0x5a
+	opI32Clz      = 0x67
+	opI64Rotr     = 0x8a
+	opI32WrapI64  = 0xa7
+	opI64ExtendS  = 0xac
+	opI64ExtendU  = 0xad
+
+	// opGas charges the gas of the basic block starting after it, it is injected by the compiler
+	opGas = 0xff
+)
+
+// instruction is a decoded instruction of a function body
+type instruction struct {
+	op     byte
+	imm    uint64 // Constant, index, memory offset, gas costs or number of block results
+	target int    // Index of the matching end of blocks, ifs and elses
+	elseAt int    // Index of the else of an if, -1 if it has none
+}
+
+// compiledFunction is a function with decoded instructions and injected gas metering
+type compiledFunction struct {
+	funcType FuncType
+	locals   []ValueType
+	code     []instruction
+}
+
+// compileFunction decodes the body of a function and injects the gas metering. Every basic block, i.e. the
+// instructions up to and including the next control instruction, is preceded by an opGas instruction, which charges
+// the instruction price for all instructions of the block. An end always starts a basic block, because ifs without
+// else branch jump to it.
+func compileFunction(module *Module, function Function, instructionPrice uint64) (*compiledFunction, error) {
+	funcType := module.Types[function.Type]
+	compiled := &compiledFunction{funcType: funcType, locals: function.Locals}
+
+	instructions, err := decodeBody(module, funcType, function)
+	if err != nil {
+		return nil, err
+	}
+
+	code := make([]instruction, 0, len(instructions)*5/4)
+	gas, leader := 0, true
+	for _, ins := range instructions {
+		if leader || ins.op == opEnd {
+			code = append(code, instruction{op: opGas})
+			gas = len(code) - 1
+			leader = false
+		}
+		code = append(code, ins)
+		code[gas].imm += instructionPrice
+		leader = isControl(ins.op)
+	}
+
+	// Link the blocks with their ends
+	var open []int
+	for i := range code {
+		switch code[i].op {
+		case opBlock, opLoop, opIf:
+			code[i].elseAt = -1
+			open = append(open, i)
+		case opElse:
+			code[open[len(open)-1]].elseAt = i
+			open = append(open, i)
+		case opEnd:
+			if len(open) == 0 {
+				continue
+			}
+			opener := open[len(open)-1]
+			open = open[:len(open)-1]
+			code[opener].target = i
+			if code[opener].op == opElse {
+				opener = open[len(open)-1]
+				open = open[:len(open)-1]
+				code[opener].target = i
+			}
+		}
+	}
+
+	compiled.code = code
+	return compiled, nil
+}
+
+// decodeBody decodes the instructions and verifies their immediate arguments and the nesting of the blocks
+func decodeBody(module *Module, funcType FuncType, function Function) ([]instruction, error) {
+	r := &reader{data: function.Body}
+	nrOfLocals := len(funcType.Params) + len(function.Locals)
+	nrOfFunctions := len(module.Imports) + len(module.Functions)
+
+	var instructions []instruction
+	var blocks []byte // Opcodes of the open blocks
+	ended := false
+	for r.offset < len(r.data) {
+		offset := r.offset
+		op, _ := r.byte()
+		ins := instruction{op: op}
+
+		var err error
+		switch {
+		case op == opBlock || op == opLoop || op == opIf:
+			ins.imm, err = r.blockType()
+			blocks = append(blocks, op)
+		case op == opElse:
+			if len(blocks) == 0 || blocks[len(blocks)-1] != opIf {
+				err = fmt.Errorf("else without if")
+			} else {
+				blocks[len(blocks)-1] = opElse
+			}
+		case op == opEnd:
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			} else if r.offset != len(r.data) {
+				err = fmt.Errorf("instructions after the end of the function")
+			} else {
+				ended = true
+			}
+		case op == opBr || op == opBrIf:
+			var depth uint32
+			depth, err = r.u32()
+			if err == nil && int(depth) > len(blocks) {
+				err = fmt.Errorf("branch depth %v exceeds the nesting", depth)
+			}
+			ins.imm = uint64(depth)
+		case op == opCall:
+			ins.imm, err = r.index(nrOfFunctions, "function")
+		case op >= opLocalGet && op <= opLocalTee:
+			ins.imm, err = r.index(nrOfLocals, "local")
+		case op == opGlobalGet || op == opGlobalSet:
+			ins.imm, err = r.index(len(module.Globals), "global")
+			if err == nil && op == opGlobalSet && !module.Globals[ins.imm].Mutable {
+				err = fmt.Errorf("global %v is immutable", ins.imm)
+			}
+		case isMemoryAccess(op):
+			if module.Memory == nil {
+				err = fmt.Errorf("memory access without memory")
+				break
+			}
+			var memoryOffset uint32
+			if _, err = r.u32(); err == nil {
+				memoryOffset, err = r.u32()
+			}
+			ins.imm = uint64(memoryOffset)
+		case op == opMemorySize || op == opMemoryGrow:
+			if module.Memory == nil {
+				err = fmt.Errorf("memory access without memory")
+				break
+			}
+			var reserved byte
+			if reserved, err = r.byte(); err == nil && reserved != 0 {
+				err = fmt.Errorf("invalid memory index %v", reserved)
+			}
+		case op == opI32Const:
+			var value int32
+			value, err = r.s32()
+			ins.imm = uint64(uint32(value))
+		case op == opI64Const:
+			var value int64
+			value, err = r.s64()
+			ins.imm = uint64(value)
+		case op == opUnreachable, op == opNop, op == opReturn, op == opDrop, op == opSelect,
+			op >= opI32Eqz && op <= opI64GeU, op >= opI32Clz && op <= opI64Rotr,
+			op == opI32WrapI64, op == opI64ExtendS, op == opI64ExtendU:
+		default:
+			err = fmt.Errorf("unsupported instruction 0x%x", op)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("byte %v: %v", offset, err)
+		}
+		instructions = append(instructions, ins)
+	}
+
+	if !ended {
+		return nil, fmt.Errorf("function without end")
+	}
+	return instructions, nil
+}
+
+// blockType decodes the type of a block and returns its number of results
+func (r *reader) blockType() (uint64, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch ValueType(b) {
+	case 0x40:
+		return 0, nil
+	case I32, I64:
+		return 1, nil
+	}
+	return 0, fmt.Errorf("unsupported block type 0x%x", b)
+}
+
+// index decodes the index of a function, local or global and verifies it
+func (r *reader) index(count int, kind string) (uint64, error) {
+	index, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+	if int(index) >= count {
+		return 0, fmt.Errorf("unknown %v %v", kind, index)
+	}
+	return uint64(index), nil
+}
+
+func isMemoryAccess(op byte) bool {
+	return op == opI32Load || op == opI64Load || op >= opI32Load8S && op <= opI64Store32 &&
+		op != 0x38 && op != 0x39
+}
+
+// isControl returns true for the instructions, which end a basic block
+func isControl(op byte) bool {
+	switch op {
+	case opUnreachable, opBlock, opLoop, opIf, opElse, opEnd, opBr, opBrIf, opReturn, opCall:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package wasm
+
+import (
+	"fmt"
+)
+
+// HostModule is the name of the module, from which contracts import the host functions.
+//
+// The host functions give access to the context of the execution. Pointers and lengths are i32 values:
+//
+//	storage_read(index, ptr, max i32) i32  copies up to max bytes of the contract variable to ptr, returns its size
+//	storage_write(index, ptr, len i32)     writes the contract variable, committed if the execution succeeds
+//	call_data_size() i32                   returns the size of the transaction data
+//	call_data_copy(ptr i32)                copies the transaction data to ptr
+//	sender(ptr i32)                        copies the 32 byte address of the sender to ptr
+//	issuer(ptr i32)                        copies the 32 byte address of the issuer of the contract to ptr
+//	address(ptr i32)                       copies the 64 byte address of the contract to ptr
+//	balance() i64                          returns the balance of the contract
+//	amount() i64                           returns the amount of the transaction
+//	gas_left() i64                         returns the remaining gas
+//	return_data(ptr, len i32)              sets the result of the execution
+//	revert(ptr, len i32)                   fails the execution with the message
+const HostModule = "bazo"
+
+type hostFunction struct {
+	funcType FuncType
+	call     func(m *VM, args []uint64) ([]uint64, error)
+}
+
+var hostFunctions = map[string]hostFunction{
+	"storage_read":   {FuncType{[]ValueType{I32, I32, I32}, []ValueType{I32}}, storageRead},
+	"storage_write":  {FuncType{[]ValueType{I32, I32, I32}, nil}, storageWrite},
+	"call_data_size": {FuncType{nil, []ValueType{I32}}, callDataSize},
+	"call_data_copy": {FuncType{[]ValueType{I32}, nil}, callDataCopy},
+	"sender":         {FuncType{[]ValueType{I32}, nil}, sender},
+	"issuer":         {FuncType{[]ValueType{I32}, nil}, issuer},
+	"address":        {FuncType{[]ValueType{I32}, nil}, address},
+	"balance":        {FuncType{nil, []ValueType{I64}}, balance},
+	"amount":         {FuncType{nil, []ValueType{I64}}, amount},
+	"gas_left":       {FuncType{nil, []ValueType{I64}}, gasLeft},
+	"return_data":    {FuncType{[]ValueType{I32, I32}, nil}, returnData},
+	"revert":         {FuncType{[]ValueType{I32, I32}, nil}, revert},
+}
+
+func (t FuncType) equals(other FuncType) bool {
+	return equalTypes(t.Params, other.Params) && equalTypes(t.Results, other.Results)
+}
+
+func equalTypes(a []ValueType, b []ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// read returns the bytes of the memory at ptr and charges their copy
+func (m *VM) read(ptr uint64, length uint64) ([]byte, error) {
+	address, err := m.memoryAddress(ptr, 0, uint64(uint32(length)))
+	if err != nil {
+		return nil, err
+	}
+	if err := m.chargeCopy(len(m.memory[address : address+uint64(uint32(length))])); err != nil {
+		return nil, err
+	}
+	return append([]byte{}, m.memory[address:address+uint64(uint32(length))]...), nil
+}
+
+// write copies the data to the memory at ptr and charges the copy
+func (m *VM) write(ptr uint64, data []byte) error {
+	address, err := m.memoryAddress(ptr, 0, uint64(len(data)))
+	if err != nil {
+		return err
+	}
+	if err := m.chargeCopy(len(data)); err != nil {
+		return err
+	}
+	copy(m.memory[address:], data)
+	return nil
+}
+
+func (m *VM) chargeCopy(size int) error {
+	return m.chargeMultiple(m.config.CopyPrice, uint64((size+63)/64))
+}
+
+func storageRead(m *VM, args []uint64) ([]uint64, error) {
+	index := int(uint32(args[0]))
+	variable, ok := m.dirty[index]
+	if !ok {
+		var err error
+		if variable, err = m.context.GetContractVariable(index); err != nil {
+			return nil, err
+		}
+	}
+
+	size := uint64(len(variable))
+	if max := uint64(uint32(args[2])); size > max {
+		variable = variable[:max]
+	}
+	if err := m.write(args[1], variable); err != nil {
+		return nil, err
+	}
+	return []uint64{size}, nil
+}
+
+func storageWrite(m *VM, args []uint64) ([]uint64, error) {
+	index := int(uint32(args[0]))
+	if _, err := m.context.GetContractVariable(index); err != nil {
+		return nil, err
+	}
+
+	value, err := m.read(args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+	m.dirty[index] = value
+	return nil, nil
+}
+
+func callDataSize(m *VM, args []uint64) ([]uint64, error) {
+	return []uint64{uint64(len(m.context.GetTransactionData()))}, nil
+}
+
+func callDataCopy(m *VM, args []uint64) ([]uint64, error) {
+	return nil, m.write(args[0], m.context.GetTransactionData())
+}
+
+func sender(m *VM, args []uint64) ([]uint64, error) {
+	sender := m.context.GetSender()
+	return nil, m.write(args[0], sender[:])
+}
+
+func issuer(m *VM, args []uint64) ([]uint64, error) {
+	issuer := m.context.GetIssuer()
+	return nil, m.write(args[0], issuer[:])
+}
+
+func address(m *VM, args []uint64) ([]uint64, error) {
+	address := m.context.GetAddress()
+	return nil, m.write(args[0], address[:])
+}
+
+func balance(m *VM, args []uint64) ([]uint64, error) {
+	return []uint64{m.context.GetBalance()}, nil
+}
+
+func amount(m *VM, args []uint64) ([]uint64, error) {
+	return []uint64{m.context.GetAmount()}, nil
+}
+
+func gasLeft(m *VM, args []uint64) ([]uint64, error) {
+	return []uint64{m.fee}, nil
+}
+
+func returnData(m *VM, args []uint64) ([]uint64, error) {
+	data, err := m.read(args[0], args[1])
+	m.result = data
+	return nil, err
+}
+
+func revert(m *VM, args []uint64) ([]uint64, error) {
+	message, err := m.read(args[0], args[1])
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%s", message)
+}
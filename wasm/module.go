@@ -0,0 +1,511 @@
+// Package wasm executes WebAssembly contracts with the context and the gas model of the Bazo VM.
+//
+// A contract is a WebAssembly module exporting the function "main" without parameters and results. It accesses the
+// context with the functions imported from the module "bazo", see HostFunctions. Gas is charged per basic block
+// before the block is executed, so the costs of a block do not depend on where it fails.
+//
+// The supported subset of WebAssembly 1.0 is limited to integers: the value types i32 and i64, one memory, globals
+// and structured control flow without br_table. Modules using floats, tables or a start function are rejected.
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Magic is the prefix of every WebAssembly module, followed by the version.
+var Magic = []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+
+// IsModule returns true, if the code is a WebAssembly module rather than Bazo bytecode.
+func IsModule(code []byte) bool {
+	return bytes.HasPrefix(code, Magic)
+}
+
+// ValueType is the type of a WebAssembly value.
+type ValueType byte
+
+// Supported value types
+const (
+	I32 ValueType = 0x7f
+	I64 ValueType = 0x7e
+)
+
+// FuncType is the signature of a function.
+type FuncType struct {
+	Params  []ValueType
+	Results []ValueType
+}
+
+// Import is a function imported by the module.
+type Import struct {
+	Module string
+	Name   string
+	Type   uint32 // Index of the signature
+}
+
+// Global is a global variable with its initial value.
+type Global struct {
+	Type    ValueType
+	Mutable bool
+	Init    uint64
+}
+
+// Function is a function defined by the module.
+type Function struct {
+	Type   uint32      // Index of the signature
+	Locals []ValueType // Local variables without the parameters
+	Body   []byte      // Instructions including the final end
+}
+
+// Data initializes the memory at the offset.
+type Data struct {
+	Offset uint32
+	Init   []byte
+}
+
+// Module is a decoded WebAssembly module. Functions are indexed by imports first, then by defined functions.
+type Module struct {
+	Types     []FuncType
+	Imports   []Import
+	Functions []Function
+	Memory    *uint32 // Initial number of pages, if the module declares a memory
+	Globals   []Global
+	Exports   map[string]uint32 // Function indexes by name
+	Data      []Data
+}
+
+// Section ids
+const (
+	sectionCustom   = 0
+	sectionType     = 1
+	sectionImport   = 2
+	sectionFunction = 3
+	sectionMemory   = 5
+	sectionGlobal   = 6
+	sectionExport   = 7
+	sectionCode     = 10
+	sectionData     = 11
+)
+
+// DecodeModule decodes the binary format of a WebAssembly module.
+func DecodeModule(code []byte) (*Module, error) {
+	if !IsModule(code) {
+		return nil, fmt.Errorf("not a WebAssembly module")
+	}
+
+	module := &Module{Exports: make(map[string]uint32)}
+	var functionTypes []uint32
+	r := &reader{data: code, offset: len(Magic)}
+
+	for r.offset < len(r.data) {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		content, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+
+		section := &reader{data: content}
+		switch id {
+		case sectionCustom:
+			continue
+		case sectionType:
+			err = section.vector(func() error {
+				return module.decodeType(section)
+			})
+		case sectionImport:
+			err = section.vector(func() error {
+				return module.decodeImport(section)
+			})
+		case sectionFunction:
+			err = section.vector(func() error {
+				index, err := section.u32()
+				functionTypes = append(functionTypes, index)
+				return err
+			})
+		case sectionMemory:
+			err = section.vector(func() error {
+				return module.decodeMemory(section)
+			})
+		case sectionGlobal:
+			err = section.vector(func() error {
+				return module.decodeGlobal(section)
+			})
+		case sectionExport:
+			err = section.vector(func() error {
+				return module.decodeExport(section)
+			})
+		case sectionCode:
+			err = section.vector(func() error {
+				return module.decodeCode(section)
+			})
+		case sectionData:
+			err = section.vector(func() error {
+				return module.decodeData(section)
+			})
+		default:
+			return nil, fmt.Errorf("unsupported section %v", id)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("section %v: %v", id, err)
+		}
+		if section.offset != len(section.data) {
+			return nil, fmt.Errorf("section %v: unexpected trailing bytes", id)
+		}
+	}
+
+	if len(functionTypes) != len(module.Functions) {
+		return nil, fmt.Errorf("%v functions declared but %v defined", len(functionTypes), len(module.Functions))
+	}
+	for i, typeIndex := range functionTypes {
+		if int(typeIndex) >= len(module.Types) {
+			return nil, fmt.Errorf("function %v has unknown type %v", i, typeIndex)
+		}
+		module.Functions[i].Type = typeIndex
+	}
+
+	for name, index := range module.Exports {
+		if int(index) >= len(module.Imports)+len(module.Functions) {
+			return nil, fmt.Errorf("export %v of unknown function %v", name, index)
+		}
+	}
+	return module, nil
+}
+
+func (m *Module) decodeType(r *reader) error {
+	form, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if form != 0x60 {
+		return fmt.Errorf("invalid function type 0x%x", form)
+	}
+
+	var funcType FuncType
+	if funcType.Params, err = r.valueTypes(); err != nil {
+		return err
+	}
+	if funcType.Results, err = r.valueTypes(); err != nil {
+		return err
+	}
+	if len(funcType.Results) > 1 {
+		return fmt.Errorf("multiple results are not supported")
+	}
+	m.Types = append(m.Types, funcType)
+	return nil
+}
+
+func (m *Module) decodeImport(r *reader) error {
+	module, err := r.name()
+	if err != nil {
+		return err
+	}
+	name, err := r.name()
+	if err != nil {
+		return err
+	}
+	kind, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if kind != 0x00 {
+		return fmt.Errorf("import %v.%v: only functions can be imported", module, name)
+	}
+	if len(m.Functions) > 0 {
+		return fmt.Errorf("import %v.%v after function definitions", module, name)
+	}
+
+	typeIndex, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if int(typeIndex) >= len(m.Types) {
+		return fmt.Errorf("import %v.%v has unknown type %v", module, name, typeIndex)
+	}
+	m.Imports = append(m.Imports, Import{Module: module, Name: name, Type: typeIndex})
+	return nil
+}
+
+func (m *Module) decodeMemory(r *reader) error {
+	if m.Memory != nil {
+		return fmt.Errorf("multiple memories are not supported")
+	}
+
+	flags, err := r.byte()
+	if err != nil {
+		return err
+	}
+	pages, err := r.u32()
+	if err != nil {
+		return err
+	}
+	// The maximum of the module is ignored, the configuration limits the memory
+	if flags == 0x01 {
+		if _, err := r.u32(); err != nil {
+			return err
+		}
+	} else if flags != 0x00 {
+		return fmt.Errorf("invalid memory limits 0x%x", flags)
+	}
+	m.Memory = &pages
+	return nil
+}
+
+func (m *Module) decodeGlobal(r *reader) error {
+	valueType, err := r.valueType()
+	if err != nil {
+		return err
+	}
+	mutable, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if mutable > 1 {
+		return fmt.Errorf("invalid mutability %v", mutable)
+	}
+
+	init, err := r.constant(valueType)
+	if err != nil {
+		return err
+	}
+	m.Globals = append(m.Globals, Global{Type: valueType, Mutable: mutable == 1, Init: init})
+	return nil
+}
+
+func (m *Module) decodeExport(r *reader) error {
+	name, err := r.name()
+	if err != nil {
+		return err
+	}
+	kind, err := r.byte()
+	if err != nil {
+		return err
+	}
+	index, err := r.u32()
+	if err != nil {
+		return err
+	}
+
+	// Only functions are callable from outside, other exports are ignored
+	if kind == 0x00 {
+		m.Exports[name] = index
+	}
+	return nil
+}
+
+func (m *Module) decodeCode(r *reader) error {
+	body, err := r.bytes()
+	if err != nil {
+		return err
+	}
+
+	code := &reader{data: body}
+	var function Function
+	err = code.vector(func() error {
+		count, err := code.u32()
+		if err != nil {
+			return err
+		}
+		valueType, err := code.valueType()
+		if err != nil {
+			return err
+		}
+		if len(function.Locals)+int(count) > maxLocals {
+			return fmt.Errorf("more than %v locals", maxLocals)
+		}
+		for i := uint32(0); i < count; i++ {
+			function.Locals = append(function.Locals, valueType)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	function.Body = body[code.offset:]
+	m.Functions = append(m.Functions, function)
+	return nil
+}
+
+func (m *Module) decodeData(r *reader) error {
+	memory, err := r.u32()
+	if err != nil {
+		return err
+	}
+	if memory != 0 {
+		return fmt.Errorf("unknown memory %v", memory)
+	}
+
+	offset, err := r.constant(I32)
+	if err != nil {
+		return err
+	}
+	init, err := r.bytes()
+	if err != nil {
+		return err
+	}
+	m.Data = append(m.Data, Data{Offset: uint32(offset), Init: init})
+	return nil
+}
+
+// maxLocals is the maximum number of local variables of a function without the parameters
+const maxLocals = 1024
+
+// reader decodes the primitive values of the binary format
+type reader struct {
+	data   []byte
+	offset int
+}
+
+func (r *reader) byte() (byte, error) {
+	if r.offset >= len(r.data) {
+		return 0, fmt.Errorf("unexpected end at byte %v", r.offset)
+	}
+	r.offset++
+	return r.data[r.offset-1], nil
+}
+
+// bytes reads a byte vector, prefixed with its length
+func (r *reader) bytes() ([]byte, error) {
+	length, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	if int(length) > len(r.data)-r.offset {
+		return nil, fmt.Errorf("length %v at byte %v exceeds the data", length, r.offset)
+	}
+	r.offset += int(length)
+	return r.data[r.offset-int(length) : r.offset], nil
+}
+
+func (r *reader) name() (string, error) {
+	name, err := r.bytes()
+	return string(name), err
+}
+
+// vector decodes every element of a vector with the function
+func (r *reader) vector(decode func() error) error {
+	count, err := r.u32()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		if err := decode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *reader) valueType() (ValueType, error) {
+	b, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+	switch ValueType(b) {
+	case I32, I64:
+		return ValueType(b), nil
+	}
+	return 0, fmt.Errorf("unsupported value type 0x%x", b)
+}
+
+func (r *reader) valueTypes() ([]ValueType, error) {
+	var types []ValueType
+	err := r.vector(func() error {
+		valueType, err := r.valueType()
+		types = append(types, valueType)
+		return err
+	})
+	return types, err
+}
+
+// constant decodes a constant expression of the type, i.e. a const instruction followed by end
+func (r *reader) constant(valueType ValueType) (uint64, error) {
+	op, err := r.byte()
+	if err != nil {
+		return 0, err
+	}
+
+	var value uint64
+	switch {
+	case valueType == I32 && op == opI32Const:
+		v, err := r.s32()
+		if err != nil {
+			return 0, err
+		}
+		value = uint64(uint32(v))
+	case valueType == I64 && op == opI64Const:
+		v, err := r.s64()
+		if err != nil {
+			return 0, err
+		}
+		value = uint64(v)
+	default:
+		return 0, fmt.Errorf("unsupported constant expression 0x%x", op)
+	}
+
+	if end, err := r.byte(); err != nil || end != opEnd {
+		return 0, fmt.Errorf("constant expression without end")
+	}
+	return value, nil
+}
+
+// u32 decodes an unsigned LEB128 integer of up to 32 bits
+func (r *reader) u32() (uint32, error) {
+	var result uint64
+	for shift := uint(0); shift < 35; shift += 7 {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			if result > 0xffffffff {
+				return 0, fmt.Errorf("integer at byte %v exceeds 32 bits", r.offset)
+			}
+			return uint32(result), nil
+		}
+	}
+	return 0, fmt.Errorf("integer at byte %v exceeds 32 bits", r.offset)
+}
+
+// s32 decodes a signed LEB128 integer of up to 32 bits
+func (r *reader) s32() (int32, error) {
+	value, err := r.signed(32)
+	return int32(value), err
+}
+
+// s64 decodes a signed LEB128 integer of up to 64 bits
+func (r *reader) s64() (int64, error) {
+	return r.signed(64)
+}
+
+func (r *reader) signed(bits uint) (int64, error) {
+	var result int64
+	var shift uint
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		result |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				result |= -1 << shift
+			}
+			break
+		}
+		if shift >= bits+7 {
+			return 0, fmt.Errorf("integer at byte %v exceeds %v bits", r.offset, bits)
+		}
+	}
+
+	if bits == 32 && (result < -1<<31 || result > 1<<31-1) {
+		return 0, fmt.Errorf("integer at byte %v exceeds 32 bits", r.offset)
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package wasm
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+func TestDecodeModule(t *testing.T) {
+	b := &builder{memory: true}
+	b.importHost("balance", nil, []ValueType{I64})
+	b.globals = append(b.globals, concat([]byte{byte(I32), 1}, i32(-5), []byte{opEnd}))
+	b.data = append(b.data, concat([]byte{0}, i32(16), []byte{opEnd}, name("bazo")))
+	code := mainModule(b, []ValueType{I32}, i32(1), []byte{opDrop})
+
+	module, err := DecodeModule(code)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, module.Imports, []Import{{Module: HostModule, Name: "balance", Type: 0}})
+	assert.DeepEqual(t, module.Types, []FuncType{{Results: []ValueType{I64}}, {}})
+	assert.Equal(t, len(module.Functions), 1)
+	assert.DeepEqual(t, module.Functions[0].Locals, []ValueType{I32})
+	assert.Equal(t, module.Functions[0].Type, uint32(1))
+	assert.Equal(t, *module.Memory, uint32(1))
+	assert.DeepEqual(t, module.Globals, []Global{{Type: I32, Mutable: true, Init: 0xfffffffb}})
+	assert.DeepEqual(t, module.Exports, map[string]uint32{EntryPoint: 1})
+	assert.DeepEqual(t, module.Data, []Data{{Offset: 16, Init: []byte("bazo")}})
+}
+
+func TestDecodeModule_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		code []byte
+		err  string
+	}{
+		{"no module", []byte{0, 1, 2}, "not a WebAssembly module"},
+		{"truncated section", concat(Magic, []byte{sectionType, 5, 0}), "length 5 at byte 10 exceeds the data"},
+		{"unsupported section", concat(Magic, []byte{4, 0}), "unsupported section 4"},
+		{"float type", concat(Magic, []byte{sectionType, 4, 1, 0x60, 0, 1}), "section 1: unexpected end at byte 4"},
+		{"float result", concat(Magic, []byte{sectionType, 5, 1, 0x60, 0, 1, 0x7d}), "section 1: unsupported value type 0x7d"},
+		{"trailing bytes", concat(Magic, []byte{sectionType, 2, 0, 0}), "section 1: unexpected trailing bytes"},
+		{"missing code", concat(Magic, []byte{sectionType, 4, 1, 0x60, 0, 0, sectionFunction, 2, 1, 0}), "1 functions declared but 0 defined"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeModule(test.code)
+			assert.Error(t, err, test.err)
+		})
+	}
+}
+
+func TestReader_Integers(t *testing.T) {
+	for _, value := range []int64{0, 1, -1, 63, 64, -64, -65, 1 << 31, -1 << 63, 1<<63 - 1} {
+		r := &reader{data: s64(value)}
+		decoded, err := r.s64()
+		assert.NilError(t, err)
+		assert.Equal(t, decoded, value)
+	}
+
+	r := &reader{data: s64(1 << 31)}
+	_, err := r.s32()
+	assert.ErrorContains(t, err, "exceeds 32 bits")
+
+	r = &reader{data: []byte{0xff, 0xff, 0xff, 0xff, 0x7f}}
+	_, err = r.u32()
+	assert.ErrorContains(t, err, "exceeds 32 bits")
+}
@@ -0,0 +1,781 @@
+package wasm
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+)
+
+// PageSize is the size of a page of the memory in bytes.
+const PageSize = 65536
+
+// EntryPoint is the name of the exported function, which is executed.
+const EntryPoint = "main"
+
+// Config contains the limits and the gas prices of the execution. Zero values select the defaults.
+type Config struct {
+	InstructionPrice uint64 // Gas per instruction, charged per basic block, 1 if 0
+	HostCallPrice    uint64 // Gas per call of a host function, 100 if 0
+	CopyPrice        uint64 // Gas per 64 bytes copied by a host function, 1 if 0
+	PagePrice        uint64 // Gas per page of memory, including the initial pages, 1000 if 0
+	MaxPages         uint32 // Maximum number of memory pages, 16 if 0
+	MaxCallDepth     int    // Maximum depth of nested calls, 256 if 0
+	MaxStackHeight   int    // Maximum number of values on the value stack, 65536 if 0
+}
+
+func (c Config) withDefaults() Config {
+	c.InstructionPrice = orDefault(c.InstructionPrice, 1)
+	c.HostCallPrice = orDefault(c.HostCallPrice, 100)
+	c.CopyPrice = orDefault(c.CopyPrice, 1)
+	c.PagePrice = orDefault(c.PagePrice, 1000)
+	c.MaxPages = uint32(orDefault(uint64(c.MaxPages), 16))
+	c.MaxCallDepth = int(orDefault(uint64(c.MaxCallDepth), 256))
+	c.MaxStackHeight = int(orDefault(uint64(c.MaxStackHeight), 65536))
+	return c
+}
+
+func orDefault(value uint64, defaultValue uint64) uint64 {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// VM executes a WebAssembly contract with the context of the Bazo VM.
+// Contract variables written by the contract are committed to the context, if the execution succeeds.
+type VM struct {
+	context  vm.Context
+	config   Config
+	fee      uint64
+	gasLimit uint64
+	err      error
+	result   []byte
+
+	module    *Module
+	functions []*compiledFunction // Defined functions, without the imports
+	imports   []hostFunction
+	globals   []uint64
+	memory    []byte
+	dirty     map[int][]byte
+
+	stack  []uint64
+	labels []label
+	frames []frame
+}
+
+// label is the target of a branch
+type label struct {
+	arity    int  // Number of values passed by a branch
+	height   int  // Height of the value stack, when the block was entered
+	cont     int  // Instruction continuing after a branch, -1 for the function
+	isLoop   bool // Branches to a loop continue the loop, so the loop stays entered
+	endArity int  // Number of results of the block
+}
+
+type frame struct {
+	function  *compiledFunction
+	locals    []uint64
+	pc        int
+	labelBase int
+}
+
+// NewVM creates a VM, which executes the contract of the context.
+func NewVM(context vm.Context, config Config) *VM {
+	return &VM{context: context, config: config.withDefaults()}
+}
+
+// Exec executes the entry point of the contract and returns true, if it succeeded.
+// The fee of the context is the gas limit of the execution.
+func (m *VM) Exec() bool {
+	m.fee = m.context.GetFee()
+	m.gasLimit = m.fee
+	m.result = nil
+	m.dirty = make(map[int][]byte)
+	m.stack, m.labels, m.frames = nil, nil, nil
+
+	m.err = m.exec()
+	if m.err == nil {
+		m.err = m.commitVariables()
+	}
+	return m.err == nil
+}
+
+// GetErrorMsg returns the error of the last execution or an empty string, if it succeeded.
+func (m *VM) GetErrorMsg() string {
+	if m.err == nil {
+		return ""
+	}
+	return m.err.Error()
+}
+
+// Result returns the data returned by the contract with the host function return_data.
+func (m *VM) Result() []byte {
+	return m.result
+}
+
+// GasUsed returns the gas charged by the last execution, including the intrinsic gas.
+func (m *VM) GasUsed() uint64 {
+	return m.gasLimit - m.fee
+}
+
+func (m *VM) exec() error {
+	code := m.context.GetContract()
+	if err := m.charge(vm.IntrinsicGas(len(code), len(m.context.GetTransactionData()))); err != nil {
+		return err
+	}
+
+	if err := m.instantiate(code); err != nil {
+		return err
+	}
+
+	entryPoint, ok := m.module.Exports[EntryPoint]
+	if !ok {
+		return fmt.Errorf("no exported function %v", EntryPoint)
+	}
+	if int(entryPoint) < len(m.imports) {
+		return fmt.Errorf("entry point is a host function")
+	}
+	funcType := m.module.Types[m.module.Functions[int(entryPoint)-len(m.imports)].Type]
+	if len(funcType.Params) > 0 || len(funcType.Results) > 0 {
+		return fmt.Errorf("entry point must not have parameters or results")
+	}
+
+	return m.run(int(entryPoint))
+}
+
+// instantiate decodes and compiles the module, resolves its imports and initializes the memory and the globals
+func (m *VM) instantiate(code []byte) error {
+	module, err := DecodeModule(code)
+	if err != nil {
+		return err
+	}
+	m.module = module
+
+	m.imports = make([]hostFunction, len(module.Imports))
+	for i, imp := range module.Imports {
+		host, ok := hostFunctions[imp.Name]
+		if imp.Module != HostModule || !ok {
+			return fmt.Errorf("unknown import %v.%v", imp.Module, imp.Name)
+		}
+		if !host.funcType.equals(module.Types[imp.Type]) {
+			return fmt.Errorf("import %v.%v has a wrong signature", imp.Module, imp.Name)
+		}
+		m.imports[i] = host
+	}
+
+	m.functions = make([]*compiledFunction, len(module.Functions))
+	for i, function := range module.Functions {
+		if m.functions[i], err = compileFunction(module, function, m.config.InstructionPrice); err != nil {
+			return fmt.Errorf("function %v: %v", len(module.Imports)+i, err)
+		}
+	}
+
+	m.globals = make([]uint64, len(module.Globals))
+	for i, global := range module.Globals {
+		m.globals[i] = global.Init
+	}
+
+	m.memory = nil
+	if module.Memory != nil {
+		if err := m.grow(*module.Memory); err != nil {
+			return err
+		}
+	}
+	for _, data := range module.Data {
+		if uint64(data.Offset)+uint64(len(data.Init)) > uint64(len(m.memory)) {
+			return fmt.Errorf("data segment at %v exceeds the memory", data.Offset)
+		}
+		copy(m.memory[data.Offset:], data.Init)
+	}
+	return nil
+}
+
+// grow adds pages to the memory and charges them
+func (m *VM) grow(pages uint32) error {
+	current := uint32(len(m.memory) / PageSize)
+	if uint64(current)+uint64(pages) > uint64(m.config.MaxPages) {
+		return fmt.Errorf("memory exceeds %v pages", m.config.MaxPages)
+	}
+	if err := m.chargeMultiple(m.config.PagePrice, uint64(pages)); err != nil {
+		return err
+	}
+	m.memory = append(m.memory, make([]byte, int(pages)*PageSize)...)
+	return nil
+}
+
+func (m *VM) charge(gas uint64) error {
+	if m.fee < gas {
+		return fmt.Errorf("out of gas")
+	}
+	m.fee -= gas
+	return nil
+}
+
+func (m *VM) chargeMultiple(price uint64, count uint64) error {
+	if count > 0 && price > math.MaxUint64/count {
+		return m.charge(math.MaxUint64)
+	}
+	return m.charge(price * count)
+}
+
+func (m *VM) commitVariables() error {
+	if len(m.dirty) == 0 {
+		return nil
+	}
+
+	if batchContext, ok := m.context.(vm.BatchContext); ok {
+		return batchContext.SetContractVariables(m.dirty)
+	}
+
+	indexes := make([]int, 0, len(m.dirty))
+	for index := range m.dirty {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		if err := m.context.SetContractVariable(index, m.dirty[index]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *VM) push(value uint64) error {
+	if len(m.stack) >= m.config.MaxStackHeight {
+		return fmt.Errorf("value stack exceeds %v values", m.config.MaxStackHeight)
+	}
+	m.stack = append(m.stack, value)
+	return nil
+}
+
+func (m *VM) pop() (uint64, error) {
+	if len(m.stack) == 0 || len(m.stack) <= m.labels[len(m.labels)-1].height {
+		return 0, fmt.Errorf("value stack underflow")
+	}
+	value := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return value, nil
+}
+
+// call enters a defined function or calls a host function
+func (m *VM) call(index int) error {
+	if index < len(m.imports) {
+		host := m.imports[index]
+		args := make([]uint64, len(host.funcType.Params))
+		for i := len(args) - 1; i >= 0; i-- {
+			value, err := m.pop()
+			if err != nil {
+				return err
+			}
+			args[i] = value
+		}
+
+		if err := m.charge(m.config.HostCallPrice); err != nil {
+			return err
+		}
+		results, err := host.call(m, args)
+		if err != nil {
+			return fmt.Errorf("%v: %v", m.module.Imports[index].Name, err)
+		}
+		for _, result := range results {
+			if err := m.push(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(m.frames) >= m.config.MaxCallDepth {
+		return fmt.Errorf("call depth exceeds %v", m.config.MaxCallDepth)
+	}
+
+	function := m.functions[index-len(m.imports)]
+	locals := make([]uint64, len(function.funcType.Params)+len(function.locals))
+	for i := len(function.funcType.Params) - 1; i >= 0; i-- {
+		value, err := m.pop()
+		if err != nil {
+			return err
+		}
+		locals[i] = value
+	}
+
+	m.frames = append(m.frames, frame{function: function, locals: locals, labelBase: len(m.labels)})
+	arity := len(function.funcType.Results)
+	m.labels = append(m.labels, label{arity: arity, height: len(m.stack), cont: -1, endArity: arity})
+	return nil
+}
+
+// branch continues after the label at the depth and keeps its values on the stack. It returns true, if the branch
+// leaves the function.
+func (m *VM) branch(depth int) (bool, error) {
+	target := m.labels[len(m.labels)-1-depth]
+	if len(m.stack)-target.arity < target.height {
+		return false, fmt.Errorf("value stack underflow")
+	}
+	m.stack = append(m.stack[:target.height], m.stack[len(m.stack)-target.arity:]...)
+
+	if target.cont == -1 {
+		return true, m.ret()
+	}
+
+	f := &m.frames[len(m.frames)-1]
+	if target.isLoop {
+		m.labels = m.labels[:len(m.labels)-depth]
+	} else {
+		m.labels = m.labels[:len(m.labels)-1-depth]
+	}
+	f.pc = target.cont
+	return false, nil
+}
+
+// ret leaves the current function, its results are already on top of its stack
+func (m *VM) ret() error {
+	f := m.frames[len(m.frames)-1]
+	function := m.labels[f.labelBase]
+	if len(m.stack)-function.endArity < function.height {
+		return fmt.Errorf("value stack underflow")
+	}
+	m.stack = append(m.stack[:function.height], m.stack[len(m.stack)-function.endArity:]...)
+	m.labels = m.labels[:f.labelBase]
+	m.frames = m.frames[:len(m.frames)-1]
+	return nil
+}
+
+// memoryAddress returns the address of an access of the size, if it is within the memory
+func (m *VM) memoryAddress(base uint64, offset uint64, size uint64) (uint64, error) {
+	address := uint64(uint32(base)) + offset
+	if address+size > uint64(len(m.memory)) {
+		return 0, fmt.Errorf("memory access at %v out of bounds", address)
+	}
+	return address, nil
+}
+
+// run executes the function until it returns
+func (m *VM) run(index int) error {
+	m.labels = append(m.labels, label{height: 0, cont: -1})
+	if err := m.call(index); err != nil {
+		return err
+	}
+
+	for len(m.frames) > 0 {
+		f := &m.frames[len(m.frames)-1]
+		ins := &f.function.code[f.pc]
+		f.pc++
+
+		if err := m.step(f, ins); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// step executes a single instruction
+func (m *VM) step(f *frame, ins *instruction) error {
+	switch ins.op {
+	case opGas:
+		return m.charge(ins.imm)
+	case opUnreachable:
+		return fmt.Errorf("unreachable executed")
+	case opNop:
+		return nil
+
+	case opBlock:
+		arity := int(ins.imm)
+		m.labels = append(m.labels, label{arity: arity, height: len(m.stack), cont: ins.target + 1, endArity: arity})
+	case opLoop:
+		m.labels = append(m.labels, label{height: len(m.stack), cont: f.pc, isLoop: true, endArity: int(ins.imm)})
+	case opIf:
+		condition, err := m.pop()
+		if err != nil {
+			return err
+		}
+		arity := int(ins.imm)
+		m.labels = append(m.labels, label{arity: arity, height: len(m.stack), cont: ins.target + 1, endArity: arity})
+		if uint32(condition) == 0 {
+			if ins.elseAt >= 0 {
+				f.pc = ins.elseAt + 1
+			} else {
+				f.pc = ins.target - 1
+			}
+		}
+	case opElse:
+		f.pc = ins.target - 1
+	case opEnd:
+		if len(m.labels)-1 == f.labelBase {
+			return m.ret()
+		}
+		block := m.labels[len(m.labels)-1]
+		if len(m.stack)-block.endArity < block.height {
+			return fmt.Errorf("value stack underflow")
+		}
+		m.labels = m.labels[:len(m.labels)-1]
+	case opBr:
+		_, err := m.branch(int(ins.imm))
+		return err
+	case opBrIf:
+		condition, err := m.pop()
+		if err != nil {
+			return err
+		}
+		if uint32(condition) != 0 {
+			_, err = m.branch(int(ins.imm))
+		}
+		return err
+	case opReturn:
+		_, err := m.branch(len(m.labels) - 1 - f.labelBase)
+		return err
+	case opCall:
+		return m.call(int(ins.imm))
+
+	case opDrop:
+		_, err := m.pop()
+		return err
+	case opSelect:
+		condition, err := m.pop()
+		if err != nil {
+			return err
+		}
+		second, err := m.pop()
+		if err != nil {
+			return err
+		}
+		first, err := m.pop()
+		if err != nil {
+			return err
+		}
+		if uint32(condition) == 0 {
+			first = second
+		}
+		return m.push(first)
+
+	case opLocalGet:
+		return m.push(f.locals[ins.imm])
+	case opLocalSet, opLocalTee:
+		value, err := m.pop()
+		if err != nil {
+			return err
+		}
+		f.locals[ins.imm] = value
+		if ins.op == opLocalTee {
+			return m.push(value)
+		}
+	case opGlobalGet:
+		return m.push(m.globals[ins.imm])
+	case opGlobalSet:
+		value, err := m.pop()
+		if err != nil {
+			return err
+		}
+		m.globals[ins.imm] = m.wrap(m.module.Globals[ins.imm].Type, value)
+
+	case opMemorySize:
+		return m.push(uint64(len(m.memory) / PageSize))
+	case opMemoryGrow:
+		pages, err := m.pop()
+		if err != nil {
+			return err
+		}
+		previous := uint64(len(m.memory) / PageSize)
+		// Growing beyond the maximum fails with -1, but the contract continues
+		if previous+uint64(uint32(pages)) > uint64(m.config.MaxPages) {
+			return m.push(math.MaxUint32)
+		}
+		if err := m.grow(uint32(pages)); err != nil {
+			return err
+		}
+		return m.push(previous)
+
+	case opI32Const, opI64Const:
+		return m.push(ins.imm)
+
+	case opI32WrapI64:
+		value, err := m.pop()
+		if err != nil {
+			return err
+		}
+		return m.push(uint64(uint32(value)))
+	case opI64ExtendS:
+		value, err := m.pop()
+		if err != nil {
+			return err
+		}
+		return m.push(uint64(int64(int32(value))))
+	case opI64ExtendU:
+		value, err := m.pop()
+		if err != nil {
+			return err
+		}
+		return m.push(uint64(uint32(value)))
+
+	default:
+		if isMemoryAccess(ins.op) {
+			return m.access(ins)
+		}
+		return m.numeric(ins.op)
+	}
+	return nil
+}
+
+// wrap truncates the value to the size of the type
+func (m *VM) wrap(valueType ValueType, value uint64) uint64 {
+	if valueType == I32 {
+		return uint64(uint32(value))
+	}
+	return value
+}
+
+// memoryAccess is the size in bytes of a load or store, whether a load is sign extended and its result is an i32
+type memoryAccess struct {
+	size   uint64
+	signed bool
+	is32   bool
+}
+
+var memoryAccesses = map[byte]memoryAccess{
+	0x28: {4, false, true}, 0x29: {8, false, false},
+	0x2c: {1, true, true}, 0x2d: {1, false, true}, 0x2e: {2, true, true}, 0x2f: {2, false, true},
+	0x30: {1, true, false}, 0x31: {1, false, false}, 0x32: {2, true, false}, 0x33: {2, false, false},
+	0x34: {4, true, false}, 0x35: {4, false, false},
+	0x36: {4, false, true}, 0x37: {8, false, false}, 0x3a: {1, false, true}, 0x3b: {2, false, true},
+	0x3c: {1, false, false}, 0x3d: {2, false, false}, 0x3e: {4, false, false},
+}
+
+// access executes the loads and stores
+func (m *VM) access(ins *instruction) error {
+	a := memoryAccesses[ins.op]
+
+	if ins.op >= opI32Store {
+		value, err := m.pop()
+		if err != nil {
+			return err
+		}
+		base, err := m.pop()
+		if err != nil {
+			return err
+		}
+		address, err := m.memoryAddress(base, ins.imm, a.size)
+		if err != nil {
+			return err
+		}
+		for i := uint64(0); i < a.size; i++ {
+			m.memory[address+i] = byte(value >> (8 * i))
+		}
+		return nil
+	}
+
+	base, err := m.pop()
+	if err != nil {
+		return err
+	}
+	address, err := m.memoryAddress(base, ins.imm, a.size)
+	if err != nil {
+		return err
+	}
+
+	var value uint64
+	for i := uint64(0); i < a.size; i++ {
+		value |= uint64(m.memory[address+i]) << (8 * i)
+	}
+	if a.signed {
+		shift := 64 - 8*a.size
+		value = uint64(int64(value<<shift) >> shift)
+	}
+	if a.is32 {
+		value = uint64(uint32(value))
+	}
+	return m.push(value)
+}
+
+// numeric executes the comparisons and the arithmetic instructions
+func (m *VM) numeric(op byte) error {
+	is32 := op <= 0x4f || op >= 0x67 && op <= 0x78
+	unary := op == 0x45 || op == 0x50 || op >= 0x67 && op <= 0x69 || op >= 0x79 && op <= 0x7b
+
+	right, err := m.pop()
+	if err != nil {
+		return err
+	}
+	var left uint64
+	if !unary {
+		if left, err = m.pop(); err != nil {
+			return err
+		}
+	}
+
+	var result uint64
+	if is32 {
+		result, err = numeric32(op, uint32(left), uint32(right))
+	} else {
+		result, err = numeric64(op, left, right)
+	}
+	if err != nil {
+		return err
+	}
+	return m.push(result)
+}
+
+func boolValue(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func numeric32(op byte, l uint32, r uint32) (uint64, error) {
+	switch op {
+	case 0x45:
+		return boolValue(r == 0), nil
+	case 0x46:
+		return boolValue(l == r), nil
+	case 0x47:
+		return boolValue(l != r), nil
+	case 0x48:
+		return boolValue(int32(l) < int32(r)), nil
+	case 0x49:
+		return boolValue(l < r), nil
+	case 0x4a:
+		return boolValue(int32(l) > int32(r)), nil
+	case 0x4b:
+		return boolValue(l > r), nil
+	case 0x4c:
+		return boolValue(int32(l) <= int32(r)), nil
+	case 0x4d:
+		return boolValue(l <= r), nil
+	case 0x4e:
+		return boolValue(int32(l) >= int32(r)), nil
+	case 0x4f:
+		return boolValue(l >= r), nil
+	case 0x67:
+		return uint64(bits.LeadingZeros32(r)), nil
+	case 0x68:
+		return uint64(bits.TrailingZeros32(r)), nil
+	case 0x69:
+		return uint64(bits.OnesCount32(r)), nil
+	case 0x6a:
+		return uint64(l + r), nil
+	case 0x6b:
+		return uint64(l - r), nil
+	case 0x6c:
+		return uint64(l * r), nil
+	case 0x6d, 0x6f:
+		if r == 0 {
+			return 0, fmt.Errorf("integer divide by zero")
+		}
+		if op == 0x6f {
+			if int32(r) == -1 {
+				return 0, nil
+			}
+			return uint64(uint32(int32(l) % int32(r))), nil
+		}
+		if int32(l) == math.MinInt32 && int32(r) == -1 {
+			return 0, fmt.Errorf("integer overflow")
+		}
+		return uint64(uint32(int32(l) / int32(r))), nil
+	case 0x6e, 0x70:
+		if r == 0 {
+			return 0, fmt.Errorf("integer divide by zero")
+		}
+		if op == 0x70 {
+			return uint64(l % r), nil
+		}
+		return uint64(l / r), nil
+	case 0x71:
+		return uint64(l & r), nil
+	case 0x72:
+		return uint64(l | r), nil
+	case 0x73:
+		return uint64(l ^ r), nil
+	case 0x74:
+		return uint64(l << (r % 32)), nil
+	case 0x75:
+		return uint64(uint32(int32(l) >> (r % 32))), nil
+	case 0x76:
+		return uint64(l >> (r % 32)), nil
+	case 0x77:
+		return uint64(bits.RotateLeft32(l, int(r%32))), nil
+	case 0x78:
+		return uint64(bits.RotateLeft32(l, -int(r%32))), nil
+	}
+	return 0, fmt.Errorf("unsupported instruction 0x%x", op)
+}
+
+func numeric64(op byte, l uint64, r uint64) (uint64, error) {
+	switch op {
+	case 0x50:
+		return boolValue(r == 0), nil
+	case 0x51:
+		return boolValue(l == r), nil
+	case 0x52:
+		return boolValue(l != r), nil
+	case 0x53:
+		return boolValue(int64(l) < int64(r)), nil
+	case 0x54:
+		return boolValue(l < r), nil
+	case 0x55:
+		return boolValue(int64(l) > int64(r)), nil
+	case 0x56:
+		return boolValue(l > r), nil
+	case 0x57:
+		return boolValue(int64(l) <= int64(r)), nil
+	case 0x58:
+		return boolValue(l <= r), nil
+	case 0x59:
+		return boolValue(int64(l) >= int64(r)), nil
+	case 0x5a:
+		return boolValue(l >= r), nil
+	case 0x79:
+		return uint64(bits.LeadingZeros64(r)), nil
+	case 0x7a:
+		return uint64(bits.TrailingZeros64(r)), nil
+	case 0x7b:
+		return uint64(bits.OnesCount64(r)), nil
+	case 0x7c:
+		return l + r, nil
+	case 0x7d:
+		return l - r, nil
+	case 0x7e:
+		return l * r, nil
+	case 0x7f, 0x81:
+		if r == 0 {
+			return 0, fmt.Errorf("integer divide by zero")
+		}
+		if op == 0x81 {
+			if int64(r) == -1 {
+				return 0, nil
+			}
+			return uint64(int64(l) % int64(r)), nil
+		}
+		if int64(l) == math.MinInt64 && int64(r) == -1 {
+			return 0, fmt.Errorf("integer overflow")
+		}
+		return uint64(int64(l) / int64(r)), nil
+	case 0x80, 0x82:
+		if r == 0 {
+			return 0, fmt.Errorf("integer divide by zero")
+		}
+		if op == 0x82 {
+			return l % r, nil
+		}
+		return l / r, nil
+	case 0x83:
+		return l & r, nil
+	case 0x84:
+		return l | r, nil
+	case 0x85:
+		return l ^ r, nil
+	case 0x86:
+		return l << (r % 64), nil
+	case 0x87:
+		return uint64(int64(l) >> (r % 64)), nil
+	case 0x88:
+		return l >> (r % 64), nil
+	case 0x89:
+		return bits.RotateLeft64(l, int(r%64)), nil
+	case 0x8a:
+		return bits.RotateLeft64(l, -int(r%64)), nil
+	}
+	return 0, fmt.Errorf("unsupported instruction 0x%x", op)
+}
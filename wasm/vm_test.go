@@ -0,0 +1,289 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-vm/vm"
+	"gotest.tools/assert"
+)
+
+// builder assembles modules in the binary format for the tests
+type builder struct {
+	types     [][]byte
+	imports   [][]byte
+	functions [][]byte
+	bodies    [][]byte
+	globals   [][]byte
+	exports   [][]byte
+	data      [][]byte
+	memory    bool
+}
+
+func u32(value uint32) []byte {
+	var result []byte
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if value == 0 {
+			return append(result, b)
+		}
+		result = append(result, b|0x80)
+	}
+}
+
+func s64(value int64) []byte {
+	var result []byte
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if value == 0 && b&0x40 == 0 || value == -1 && b&0x40 != 0 {
+			return append(result, b)
+		}
+		result = append(result, b|0x80)
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	var result []byte
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	return result
+}
+
+func vector(items [][]byte) []byte {
+	return concat(u32(uint32(len(items))), concat(items...))
+}
+
+func name(value string) []byte {
+	return concat(u32(uint32(len(value))), []byte(value))
+}
+
+func valueTypes(types []ValueType) []byte {
+	result := u32(uint32(len(types)))
+	for _, valueType := range types {
+		result = append(result, byte(valueType))
+	}
+	return result
+}
+
+func i32(value int32) []byte {
+	return concat([]byte{opI32Const}, s64(int64(value)))
+}
+
+func i64(value int64) []byte {
+	return concat([]byte{opI64Const}, s64(value))
+}
+
+func (b *builder) funcType(params []ValueType, results []ValueType) []byte {
+	b.types = append(b.types, concat([]byte{0x60}, valueTypes(params), valueTypes(results)))
+	return u32(uint32(len(b.types) - 1))
+}
+
+// importHost imports a host function and returns its function index
+func (b *builder) importHost(function string, params []ValueType, results []ValueType) byte {
+	typeIndex := b.funcType(params, results)
+	b.imports = append(b.imports, concat(name(HostModule), name(function), []byte{0x00}, typeIndex))
+	return byte(len(b.imports) - 1)
+}
+
+// function defines a function with the locals and the body without the final end
+func (b *builder) function(params []ValueType, results []ValueType, locals []ValueType, body ...[]byte) byte {
+	b.functions = append(b.functions, b.funcType(params, results))
+
+	var declarations [][]byte
+	for _, local := range locals {
+		declarations = append(declarations, []byte{1, byte(local)})
+	}
+	code := concat(vector(declarations), concat(body...), []byte{opEnd})
+	b.bodies = append(b.bodies, concat(u32(uint32(len(code))), code))
+	return byte(len(b.imports) + len(b.functions) - 1)
+}
+
+func (b *builder) export(function string, index byte) {
+	b.exports = append(b.exports, concat(name(function), []byte{0x00, index}))
+}
+
+func (b *builder) bytes() []byte {
+	section := func(id byte, items [][]byte) []byte {
+		if len(items) == 0 {
+			return nil
+		}
+		content := vector(items)
+		return concat([]byte{id}, u32(uint32(len(content))), content)
+	}
+
+	var memory [][]byte
+	if b.memory {
+		memory = [][]byte{{0x00, 1}}
+	}
+	return concat(Magic,
+		section(sectionType, b.types),
+		section(sectionImport, b.imports),
+		section(sectionFunction, b.functions),
+		section(sectionMemory, memory),
+		section(sectionGlobal, b.globals),
+		section(sectionExport, b.exports),
+		section(sectionCode, b.bodies),
+		section(sectionData, b.data),
+	)
+}
+
+// mainModule builds a module, whose entry point consists of the body
+func mainModule(b *builder, locals []ValueType, body ...[]byte) []byte {
+	b.export(EntryPoint, b.function(nil, nil, locals, body...))
+	return b.bytes()
+}
+
+func execModule(t *testing.T, code []byte, fee uint64) (*VM, *vm.MockContext) {
+	mc := vm.NewMockContext(code)
+	mc.Fee = fee
+	mc.ContractVariables = make([][]byte, 2)
+	machine := NewVM(mc, Config{})
+	machine.Exec()
+	return machine, mc
+}
+
+func TestIsModule(t *testing.T) {
+	assert.Assert(t, IsModule(Magic))
+	assert.Assert(t, !IsModule([]byte{vm.PushInt, 1, 0, 1}))
+}
+
+// factorial stores n! into the memory at 0 and returns it
+func factorial(b *builder, n int64) []byte {
+	store := b.importHost("storage_write", []ValueType{I32, I32, I32}, nil)
+	ret := b.importHost("return_data", []ValueType{I32, I32}, nil)
+	b.memory = true
+
+	return mainModule(b, []ValueType{I64, I64},
+		i64(n), []byte{opLocalSet, 0},
+		i64(1), []byte{opLocalSet, 1},
+		[]byte{opBlock, 0x40, opLoop, 0x40},
+		[]byte{opLocalGet, 0, 0x50, opBrIf, 1},                     // leave the loop if n is 0
+		[]byte{opLocalGet, 1, opLocalGet, 0, 0x7e, opLocalSet, 1},  // result *= n
+		[]byte{opLocalGet, 0}, i64(1), []byte{0x7d, opLocalSet, 0}, // n -= 1
+		[]byte{opBr, 0, opEnd, opEnd},
+		i32(0), []byte{opLocalGet, 1, opI64Store, 3, 0},
+		i32(0), i32(0), i32(8), []byte{opCall, store},
+		i32(0), i32(8), []byte{opCall, ret},
+	)
+}
+
+func TestVM_Exec(t *testing.T) {
+	machine, mc := execModule(t, factorial(&builder{}, 5), 100000)
+	assert.Assert(t, machine.GetErrorMsg() == "", machine.GetErrorMsg())
+
+	expected := []byte{120, 0, 0, 0, 0, 0, 0, 0}
+	assert.DeepEqual(t, machine.Result(), expected)
+	variable, err := mc.GetContractVariable(0)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, variable, expected)
+}
+
+func TestVM_Exec_GasPerBasicBlock(t *testing.T) {
+	small, _ := execModule(t, factorial(&builder{}, 5), 100000)
+	large, _ := execModule(t, factorial(&builder{}, 10), 100000)
+
+	// Every iteration executes the basic blocks of the loop, each instruction costs 1
+	iteration := (large.GasUsed() - small.GasUsed()) / 5
+	assert.Equal(t, large.GasUsed()-small.GasUsed(), 5*iteration)
+	assert.Equal(t, iteration, uint64(12))
+}
+
+func TestVM_Exec_OutOfGas(t *testing.T) {
+	machine, mc := execModule(t, factorial(&builder{}, 1000000), 100000)
+	assert.Equal(t, machine.GetErrorMsg(), "out of gas")
+
+	// Variables are not committed if the execution fails
+	variable, err := mc.GetContractVariable(0)
+	assert.NilError(t, err)
+	assert.Equal(t, len(variable), 0)
+}
+
+func TestVM_Exec_Traps(t *testing.T) {
+	tests := []struct {
+		name string
+		body [][]byte
+		err  string
+	}{
+		{"unreachable", [][]byte{{opUnreachable}}, "unreachable executed"},
+		{"division by zero", [][]byte{i32(1), i32(0), {0x6d, opDrop}}, "integer divide by zero"},
+		{"overflow", [][]byte{i32(-1 << 31), i32(-1), {0x6d, opDrop}}, "integer overflow"},
+		{"stack underflow", [][]byte{{opDrop}}, "value stack underflow"},
+		{"memory out of bounds", [][]byte{i32(PageSize - 2), {opI32Load, 2, 0, opDrop}}, "memory access at 65534 out of bounds"},
+		{"grow beyond maximum", [][]byte{i32(100), {opMemoryGrow, 0}, i32(-1), {0x46, opBrIf, 0, opUnreachable}}, ""},
+		{"revert", [][]byte{i32(0), i32(4), {opCall, 0}}, "revert: \x00\x00\x00\x00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := &builder{memory: true}
+			b.importHost("revert", []ValueType{I32, I32}, nil)
+			machine, _ := execModule(t, mainModule(b, nil, test.body...), 100000)
+			assert.Equal(t, machine.GetErrorMsg(), test.err)
+		})
+	}
+}
+
+func TestVM_Exec_Recursion(t *testing.T) {
+	b := &builder{}
+	// The function calls itself until the call depth is exceeded
+	b.function(nil, nil, nil, []byte{opCall, 0})
+	b.export(EntryPoint, 0)
+
+	machine, _ := execModule(t, b.bytes(), 1000000)
+	assert.Equal(t, machine.GetErrorMsg(), "call depth exceeds 256")
+}
+
+func TestVM_Exec_FunctionCall(t *testing.T) {
+	b := &builder{}
+	ret := b.importHost("return_data", []ValueType{I32, I32}, nil)
+	b.memory = true
+	// add returns the sum of its parameters with an explicit return inside a block
+	add := b.function([]ValueType{I32, I32}, []ValueType{I32}, nil,
+		[]byte{opBlock, byte(I32), opLocalGet, 0, opLocalGet, 1, 0x6a, opReturn, opEnd})
+	code := mainModule(b, nil,
+		i32(0), i32(2), i32(40), []byte{opCall, add, opI32Store, 2, 0},
+		i32(0), i32(4), []byte{opCall, ret},
+	)
+
+	machine, _ := execModule(t, code, 100000)
+	assert.Equal(t, machine.GetErrorMsg(), "")
+	assert.DeepEqual(t, machine.Result(), []byte{42, 0, 0, 0})
+}
+
+func TestVM_Exec_IfElse(t *testing.T) {
+	for _, condition := range []int32{0, 1} {
+		b := &builder{}
+		ret := b.importHost("return_data", []ValueType{I32, I32}, nil)
+		b.memory = true
+		code := mainModule(b, nil,
+			i32(0), i32(condition), []byte{opIf, byte(I32)}, i32(7), []byte{opElse}, i32(9), []byte{opEnd},
+			[]byte{opI32Store8, 0, 0},
+			i32(0), i32(1), []byte{opCall, ret},
+		)
+
+		machine, _ := execModule(t, code, 100000)
+		assert.Equal(t, machine.GetErrorMsg(), "")
+		assert.DeepEqual(t, machine.Result(), []byte{byte(9 - 2*condition)})
+	}
+}
+
+func TestVM_Exec_InvalidModules(t *testing.T) {
+	b := &builder{}
+	b.importHost("unknown", nil, nil)
+	machine, _ := execModule(t, mainModule(b, nil), 1000)
+	assert.Equal(t, machine.GetErrorMsg(), "unknown import bazo.unknown")
+
+	b = &builder{}
+	machine, _ = execModule(t, mainModule(b, nil, []byte{0x43, 0, 0, 0, 0}), 1000)
+	assert.Equal(t, machine.GetErrorMsg(), "function 0: byte 0: unsupported instruction 0x43")
+
+	b = &builder{}
+	b.function(nil, nil, nil)
+	machine, _ = execModule(t, b.bytes(), 1000)
+	assert.Equal(t, machine.GetErrorMsg(), "no exported function main")
+
+	machine, _ = execModule(t, Magic[:6], 1000)
+	assert.Equal(t, machine.GetErrorMsg(), "not a WebAssembly module")
+}